@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/banzaicloud/kafka-operator/internal/alertmanager"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -30,13 +31,14 @@ const (
 
 // AController implements Runnable
 type AController struct {
-	Client client.Client
+	Client   client.Client
+	Recorder record.EventRecorder
 }
 
 // SetAlertManagerWithManager creates a new Alertmanager Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func SetAlertManagerWithManager(mgr manager.Manager) error {
-	return mgr.Add(AController{Client: mgr.GetClient()})
+	return mgr.Add(AController{Client: mgr.GetClient(), Recorder: mgr.GetEventRecorderFor("alertmanager")})
 }
 
 // Start initiates the alertmanager controller
@@ -45,6 +47,6 @@ func (c AController) Start(<-chan struct{}) error {
 	log := logf.Log.WithName("alertmanager")
 
 	ln, _ := net.Listen("tcp", receiverAddr)
-	httpServer := &http.Server{Handler: alertmanager.NewApp(log, c.Client)}
+	httpServer := &http.Server{Handler: alertmanager.NewApp(log, c.Client, c.Recorder)}
 	return httpServer.Serve(ln)
 }
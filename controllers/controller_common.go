@@ -22,6 +22,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
 	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -51,6 +52,22 @@ func reconciled() (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
+// isReconcilePaused returns true if the operator has to leave the users and topics of
+// the cluster untouched. Deleting the cluster lifts the pause so its children can go.
+func isReconcilePaused(cluster *v1beta1.KafkaCluster) bool {
+	return cluster.Spec.Paused && !k8sutil.IsMarkedForDeletion(cluster.ObjectMeta)
+}
+
+// requeueWhilePaused checks back later on a user/topic CR of a paused cluster, as
+// the cluster itself is not watched by their controllers
+func requeueWhilePaused(logger logr.Logger) (ctrl.Result, error) {
+	logger.Info("Reconciliation of the referenced cluster is paused")
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: time.Minute,
+	}, nil
+}
+
 // getClusterRefNamespace returns the expected namespace for a kafka cluster
 // referenced by a user/topic CR. It takes the namespace of the CR as the first
 // argument and the reference itself as the second.
@@ -62,6 +79,18 @@ func getClusterRefNamespace(ns string, ref v1alpha1.ClusterReference) string {
 	return clusterNamespace
 }
 
+// getDeletionPolicy returns the deletion policy of a user/topic CR, falling back
+// to the default of the referenced cluster and then to Delete.
+func getDeletionPolicy(policy v1alpha1.DeletionPolicy, cluster *v1beta1.KafkaCluster) v1alpha1.DeletionPolicy {
+	if policy != "" {
+		return policy
+	}
+	if cluster != nil && cluster.Spec.DefaultDeletionPolicy != "" {
+		return v1alpha1.DeletionPolicy(cluster.Spec.DefaultDeletionPolicy)
+	}
+	return v1alpha1.DeletionPolicyDelete
+}
+
 // clusterLabelString returns the label value for a cluster reference
 func clusterLabelString(cluster *v1beta1.KafkaCluster) string {
 	return fmt.Sprintf("%s.%s", cluster.Name, cluster.Namespace)
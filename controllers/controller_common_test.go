@@ -15,6 +15,7 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -25,12 +26,41 @@ import (
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
 	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
 var log = logf.Log.WithName("controller_testing")
 
+// setCruiseControlScaler makes the reconcilers talk to the given scaler instead of Cruise Control,
+// the returned func restores the default one
+func setCruiseControlScaler(scaler scale.CruiseControlScaler) func() {
+	newCruiseControlScaler = func(namespace, kubernetesClusterDomain, endpoint, clusterName string, taskSpec v1beta1.CruiseControlTaskSpec) scale.CruiseControlScaler {
+		return scaler
+	}
+	return func() { newCruiseControlScaler = scale.NewCruiseControlScaler }
+}
+
+// reconcileAndGet reconciles the object with the given key and reads it back into obj
+func reconcileAndGet(t *testing.T, r reconcile.Reconciler, c client.Client, key types.NamespacedName, obj runtime.Object) ctrl.Result {
+	t.Helper()
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if err := c.Get(context.TODO(), key, obj); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	return result
+}
+
 func TestRequeueWithError(t *testing.T) {
 	_, err := requeueWithError(log, "test", errors.New("test error"))
 	if err == nil {
@@ -62,6 +92,40 @@ func TestGetClusterRefNamespace(t *testing.T) {
 	}
 }
 
+func TestGetDeletionPolicy(t *testing.T) {
+	if policy := getDeletionPolicy("", nil); policy != v1alpha1.DeletionPolicyDelete {
+		t.Error("Expected to get 'Delete', got:", policy)
+	}
+	cluster := &v1beta1.KafkaCluster{}
+	cluster.Spec.DefaultDeletionPolicy = v1beta1.DeletionPolicyRetain
+	if policy := getDeletionPolicy("", cluster); policy != v1alpha1.DeletionPolicyRetain {
+		t.Error("Expected to get 'Retain', got:", policy)
+	}
+	if policy := getDeletionPolicy(v1alpha1.DeletionPolicyOrphan, cluster); policy != v1alpha1.DeletionPolicyOrphan {
+		t.Error("Expected to get 'Orphan', got:", policy)
+	}
+}
+
+func TestIsReconcilePaused(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{}
+	cluster.Spec.PausedSubsystems = []v1beta1.PausableSubsystem{v1beta1.SubsystemRollingUpgrade}
+	if isReconcilePaused(cluster) {
+		t.Error("Expected topics and users to be reconciled while only a subsystem is paused")
+	}
+	cluster.Spec.Paused = true
+	if !isReconcilePaused(cluster) {
+		t.Error("Expected topics and users of a paused cluster to be left alone")
+	}
+	now := metav1.Now()
+	cluster.SetDeletionTimestamp(&now)
+	if isReconcilePaused(cluster) {
+		t.Error("Expected topics and users of a deleted cluster to be reconciled")
+	}
+	if res, err := requeueWhilePaused(log); err != nil || res.RequeueAfter == 0 {
+		t.Error("Expected a delayed requeue, got:", res, err)
+	}
+}
+
 func TestClusterLabelString(t *testing.T) {
 	cluster := &v1beta1.KafkaCluster{}
 	cluster.Name = "test-cluster"
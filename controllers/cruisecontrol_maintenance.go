@@ -0,0 +1,355 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+	ccutils "github.com/banzaicloud/kafka-operator/pkg/util/cruisecontrol"
+)
+
+const (
+	// maxMaintenanceRuns is the number of maintenance runs kept in the status of the cluster
+	maxMaintenanceRuns = 10
+	// maintenanceCheckInterval is how often the imbalance of the cluster is checked while a maintenance window is open
+	maintenanceCheckInterval = 5 * time.Minute
+)
+
+// Reasons of the events recorded on the cluster about the maintenance tasks
+const (
+	maintenanceTaskStartedReason  = "MaintenanceTaskStarted"
+	maintenanceTaskFinishedReason = "MaintenanceTaskFinished"
+	maintenanceTaskFailedReason   = "MaintenanceTaskFailed"
+)
+
+// reconcileMaintenance follows the running maintenance task, or starts the next task needed in the open maintenance
+// windows unless the brokers have Cruise Control tasks of their own or are being restarted. It requeues the cluster for the next check.
+func (r *CruiseControlTaskReconciler) reconcileMaintenance(kafkaCluster *v1beta1.KafkaCluster, brokersBusy bool, log logr.Logger) (ctrl.Result, error) {
+	status := *kafkaCluster.Status.Maintenance.DeepCopy()
+	if run := runningMaintenanceRun(status.Runs); run != nil {
+		return ctrl.Result{RequeueAfter: maintenanceCheckInterval}, r.checkMaintenanceRun(kafkaCluster, status, run, log)
+	}
+
+	windows := kafkaCluster.Spec.CruiseControlConfig.MaintenanceWindows
+	if len(windows) == 0 {
+		return reconciled()
+	}
+
+	now := time.Now()
+	requeueAfter := time.Duration(math.MaxInt64)
+	for _, window := range windows {
+		open, windowStart, next, err := ccutils.MaintenanceWindowAt(window, now)
+		if err != nil {
+			log.Error(err, "invalid maintenance window schedule", "window", window.Name)
+			continue
+		}
+		if !open {
+			requeueAfter = minDuration(requeueAfter, next.Sub(now))
+			continue
+		}
+		requeueAfter = minDuration(requeueAfter, minDuration(maintenanceCheckInterval, next.Sub(now)))
+		if brokersBusy {
+			log.Info("Maintenance window is open, waiting for the Cruise Control tasks of the brokers", "window", window.Name)
+			continue
+		}
+		if reason := brokersRestarting(kafkaCluster); reason != "" {
+			log.Info("Maintenance window is open, waiting for "+reason, "window", window.Name)
+			continue
+		}
+		started, err := r.startMaintenanceTask(kafkaCluster, status, window, windowStart, log)
+		if err != nil || started {
+			return ctrl.Result{RequeueAfter: maintenanceCheckInterval}, err
+		}
+	}
+	if requeueAfter == time.Duration(math.MaxInt64) {
+		return reconciled()
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// startMaintenanceTask starts the first task of the window that did not run in its open occurrence yet and is needed
+// by the load of the brokers
+func (r *CruiseControlTaskReconciler) startMaintenanceTask(kafkaCluster *v1beta1.KafkaCluster, status v1beta1.MaintenanceStatus,
+	window v1beta1.MaintenanceWindow, windowStart time.Time, log logr.Logger) (bool, error) {
+	var tasks []v1beta1.MaintenanceTask
+	for _, task := range window.Tasks {
+		if !maintenanceTaskRan(status.Runs, window.Name, windowStart, task) {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		return false, nil
+	}
+
+	operation, err := r.unfinishedOperation(kafkaCluster)
+	if err != nil {
+		return false, errors.WrapIf(err, "could not list cruise control operations")
+	}
+	if operation != "" {
+		log.Info("Maintenance window is open, waiting for the CruiseControlOperation", "window", window.Name, "operation", operation)
+		return false, nil
+	}
+
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
+	loads, err := cc.GetBrokerLoads()
+	if err != nil {
+		return false, errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "could not get the load of the brokers")
+	}
+
+	for _, task := range tasks {
+		var reason, taskId string
+		switch task {
+		case v1beta1.MaintenanceTaskRebalance:
+			if reason = diskImbalance(loads, window.Thresholds.GetDiskUsageDeviationPercent()); reason != "" {
+				taskId, err = cc.RebalanceCluster()
+			}
+		case v1beta1.MaintenanceTaskRebalanceDisks:
+			var logDirs map[string][]string
+			if logDirs, reason = volumeImbalance(loads, window.Thresholds.GetVolumeUsageDeviationPercent()); reason != "" {
+				taskId, _, err = cc.RebalanceDisks(logDirs)
+			}
+		case v1beta1.MaintenanceTaskPreferredLeaderElection:
+			if reason = leaderImbalance(loads, window.Thresholds.GetLeaderCountDeviationPercent()); reason != "" {
+				taskId, err = cc.RunPreferedLeaderElectionInCluster()
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		if err != nil {
+			return false, errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "could not start maintenance task", "task", task)
+		}
+
+		status.Runs = append(status.Runs, v1beta1.MaintenanceRun{
+			Window:              window.Name,
+			WindowStart:         metav1.NewTime(windowStart),
+			Task:                task,
+			Reason:              reason,
+			CruiseControlTaskId: taskId,
+			State:               v1beta1.MaintenanceRunRunning,
+			StartTime:           metav1.Now(),
+		})
+		if len(status.Runs) > maxMaintenanceRuns {
+			status.Runs = status.Runs[len(status.Runs)-maxMaintenanceRuns:]
+		}
+		if err := k8sutil.UpdateCRStatus(r.Client, kafkaCluster, status, log); err != nil {
+			return false, errors.WrapIfWithDetails(err, "could not update maintenance status", "task", task)
+		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeNormal, maintenanceTaskStartedReason,
+			"Cruise Control task %s started %s in maintenance window %s: %s", taskId, task, window.Name, reason)
+		return true, nil
+	}
+
+	log.V(1).Info("Maintenance window is open, the cluster is balanced", "window", window.Name)
+	return false, nil
+}
+
+// checkMaintenanceRun records the result of the running maintenance task once it finished
+func (r *CruiseControlTaskReconciler) checkMaintenanceRun(kafkaCluster *v1beta1.KafkaCluster, status v1beta1.MaintenanceStatus,
+	run *v1beta1.MaintenanceRun, log logr.Logger) error {
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
+	taskState, err := cc.GetCCTaskState(run.CruiseControlTaskId)
+	if err != nil {
+		log.Info("Cruise control communication error checking maintenance task", "taskId", run.CruiseControlTaskId)
+		return errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "cc communication error")
+	}
+
+	eventType, reason := corev1.EventTypeNormal, maintenanceTaskFinishedReason
+	switch taskState {
+	case v1beta1.CruiseControlTaskCompleted:
+		run.State = v1beta1.MaintenanceRunCompleted
+	case v1beta1.CruiseControlTaskCompletedWithError, v1beta1.CruiseControlTaskNotFound:
+		run.State = v1beta1.MaintenanceRunFailed
+		eventType, reason = corev1.EventTypeWarning, maintenanceTaskFailedReason
+	default:
+		log.Info("Maintenance task is still running", "taskId", run.CruiseControlTaskId, "task", run.Task)
+		return errorfactory.New(errorfactory.CruiseControlTaskRunning{}, errors.New("maintenance task is still running"),
+			fmt.Sprintf("cc task id: %s", run.CruiseControlTaskId))
+	}
+	finishTime := metav1.Now()
+	run.FinishTime = &finishTime
+
+	if err := k8sutil.UpdateCRStatus(r.Client, kafkaCluster, status, log); err != nil {
+		return errors.WrapIfWithDetails(err, "could not update maintenance status", "task", run.Task)
+	}
+	r.Recorder.Eventf(kafkaCluster, eventType, reason,
+		"Cruise Control task %s running %s in maintenance window %s finished with status %s", run.CruiseControlTaskId, run.Task, run.Window, taskState)
+	return nil
+}
+
+// unfinishedOperation returns the name of a CruiseControlOperation of the cluster that did not finish yet
+func (r *CruiseControlTaskReconciler) unfinishedOperation(kafkaCluster *v1beta1.KafkaCluster) (string, error) {
+	operations := &v1alpha1.CruiseControlOperationList{}
+	if err := r.Client.List(context.TODO(), operations); err != nil {
+		return "", err
+	}
+	for _, operation := range operations.Items {
+		if operation.Spec.ClusterRef.Name == kafkaCluster.Name &&
+			getClusterRefNamespace(operation.Namespace, operation.Spec.ClusterRef) == kafkaCluster.Namespace &&
+			!operation.IsFinished() {
+			return operation.Namespace + "/" + operation.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// brokersRestarting returns what a maintenance task has to wait for while the brokers are restarted: a rolling
+// upgrade, or the leadership moved away from a broker for its restart
+func brokersRestarting(kafkaCluster *v1beta1.KafkaCluster) string {
+	if kafkaCluster.Status.State == v1beta1.KafkaClusterRollingUpgrading {
+		return "the rolling upgrade"
+	}
+	brokerIds := make([]string, 0, len(kafkaCluster.Status.BrokersState))
+	for brokerId := range kafkaCluster.Status.BrokersState {
+		brokerIds = append(brokerIds, brokerId)
+	}
+	sort.Strings(brokerIds)
+	for _, brokerId := range brokerIds {
+		state := kafkaCluster.Status.BrokersState[brokerId]
+		if state.ConfigurationState == v1beta1.ConfigOutOfSync {
+			return fmt.Sprintf("the restart of broker %s", brokerId)
+		}
+		if state.LeadershipState.CruiseControlTaskId != "" || len(state.LeadershipState.DemotedPartitions) > 0 {
+			return fmt.Sprintf("the leadership of broker %s to be restored", brokerId)
+		}
+	}
+	return ""
+}
+
+// runningMaintenanceRun returns the maintenance run whose task is running in Cruise Control
+func runningMaintenanceRun(runs []v1beta1.MaintenanceRun) *v1beta1.MaintenanceRun {
+	for i := range runs {
+		if runs[i].State == v1beta1.MaintenanceRunRunning {
+			return &runs[i]
+		}
+	}
+	return nil
+}
+
+// maintenanceTaskRan returns true if the task was started in the given occurrence of the window
+func maintenanceTaskRan(runs []v1beta1.MaintenanceRun, window string, windowStart time.Time, task v1beta1.MaintenanceTask) bool {
+	for _, run := range runs {
+		if run.Window == window && run.WindowStart.Time.Equal(windowStart) && run.Task == task {
+			return true
+		}
+	}
+	return false
+}
+
+// diskImbalance returns why the disk usage of the brokers needs a rebalance, or an empty string if it does not
+func diskImbalance(loads []scale.BrokerLoad, threshold float64) string {
+	if len(loads) < 2 {
+		return ""
+	}
+	var total float64
+	for _, load := range loads {
+		total += load.DiskPct
+	}
+	average := total / float64(len(loads))
+
+	var worst scale.BrokerLoad
+	var worstDeviation float64
+	for _, load := range loads {
+		if deviation := math.Abs(load.DiskPct - average); deviation > worstDeviation {
+			worst, worstDeviation = load, deviation
+		}
+	}
+	if worstDeviation <= threshold {
+		return ""
+	}
+	return fmt.Sprintf("disk usage of broker %s is %.1f%%, %.1f points from the average of %.1f%%",
+		worst.BrokerID, worst.DiskPct, worstDeviation, average)
+}
+
+// volumeImbalance returns the log dirs of the brokers whose volumes need a disk rebalance, and why they need it
+func volumeImbalance(loads []scale.BrokerLoad, threshold float64) (map[string][]string, string) {
+	logDirs := make(map[string][]string)
+	var brokerIds []string
+	for _, load := range loads {
+		if len(load.LogDirDiskPct) < 2 {
+			continue
+		}
+		lowest, highest := math.MaxFloat64, 0.0
+		for _, diskPct := range load.LogDirDiskPct {
+			lowest, highest = math.Min(lowest, diskPct), math.Max(highest, diskPct)
+		}
+		if highest-lowest <= threshold {
+			continue
+		}
+		for logDir := range load.LogDirDiskPct {
+			logDirs[load.BrokerID] = append(logDirs[load.BrokerID], logDir)
+		}
+		sort.Strings(logDirs[load.BrokerID])
+		brokerIds = append(brokerIds, load.BrokerID)
+	}
+	if len(brokerIds) == 0 {
+		return nil, ""
+	}
+	sort.Strings(brokerIds)
+	return logDirs, fmt.Sprintf("disk usage of the volumes of broker(s) %s differs by more than %.0f points",
+		strings.Join(brokerIds, ","), threshold)
+}
+
+// leaderImbalance returns why the leadership of the partitions needs a preferred leader election, or an empty
+// string if it does not
+func leaderImbalance(loads []scale.BrokerLoad, threshold float64) string {
+	if len(loads) < 2 {
+		return ""
+	}
+	total := 0
+	for _, load := range loads {
+		total += load.Leaders
+	}
+	if total == 0 {
+		return ""
+	}
+	average := float64(total) / float64(len(loads))
+
+	var worst scale.BrokerLoad
+	var worstDeviation float64
+	for _, load := range loads {
+		if deviation := math.Abs(float64(load.Leaders)-average) / average * 100; deviation > worstDeviation {
+			worst, worstDeviation = load, deviation
+		}
+	}
+	if worstDeviation <= threshold {
+		return ""
+	}
+	return fmt.Sprintf("broker %s leads %d partitions, %.0f%% from the average of %.1f",
+		worst.BrokerID, worst.Leaders, worstDeviation, average)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
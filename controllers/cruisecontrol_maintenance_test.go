@@ -0,0 +1,241 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+)
+
+type fakeMaintenanceScaler struct {
+	scale.CruiseControlScaler
+	loads     []scale.BrokerLoad
+	taskState v1beta1.CruiseControlUserTaskState
+	started   []string
+}
+
+func (f *fakeMaintenanceScaler) GetBrokerLoads() ([]scale.BrokerLoad, error) {
+	return f.loads, nil
+}
+
+func (f *fakeMaintenanceScaler) RebalanceCluster() (string, error) {
+	f.started = append(f.started, "rebalance")
+	return "rebalance-task", nil
+}
+
+func (f *fakeMaintenanceScaler) RunPreferedLeaderElectionInCluster() (string, error) {
+	f.started = append(f.started, "leader-election")
+	return "leader-election-task", nil
+}
+
+func (f *fakeMaintenanceScaler) UpScaleCluster(brokerIds []string) (string, string, error) {
+	f.started = append(f.started, "upscale")
+	return "upscale-task", "", nil
+}
+
+func (f *fakeMaintenanceScaler) GetCCTaskState(uTaskId string) (v1beta1.CruiseControlUserTaskState, error) {
+	return f.taskState, nil
+}
+
+func TestImbalance(t *testing.T) {
+	loads := []scale.BrokerLoad{
+		{BrokerID: "0", DiskPct: 40, Leaders: 100, LogDirDiskPct: map[string]float64{"/kafka-logs/kafka": 38, "/kafka-logs2/kafka": 42}},
+		{BrokerID: "1", DiskPct: 45, Leaders: 105, LogDirDiskPct: map[string]float64{"/kafka-logs/kafka": 70, "/kafka-logs2/kafka": 20}},
+		{BrokerID: "2", DiskPct: 65, Leaders: 60},
+	}
+
+	if reason := diskImbalance(loads, 10); reason != "disk usage of broker 2 is 65.0%, 15.0 points from the average of 50.0%" {
+		t.Error("Expected broker 2 to imbalance the disk usage, got:", reason)
+	}
+	if reason := diskImbalance(loads, 20); reason != "" {
+		t.Error("Expected the disk usage to be balanced within 20 points, got:", reason)
+	}
+
+	logDirs, reason := volumeImbalance(loads, 10)
+	if !reflect.DeepEqual(logDirs, map[string][]string{"1": {"/kafka-logs/kafka", "/kafka-logs2/kafka"}}) || reason == "" {
+		t.Error("Expected the volumes of broker 1 to be rebalanced, got:", logDirs, reason)
+	}
+
+	if reason := leaderImbalance(loads, 10); reason != "broker 2 leads 60 partitions, 32% from the average of 88.3" {
+		t.Error("Expected broker 2 to imbalance the leadership, got:", reason)
+	}
+	if reason := leaderImbalance(loads, 40); reason != "" {
+		t.Error("Expected the leadership to be balanced within 40%, got:", reason)
+	}
+}
+
+func TestReconcileMaintenance(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			CruiseControlConfig: v1beta1.CruiseControlConfig{
+				MaintenanceWindows: []v1beta1.MaintenanceWindow{{
+					Name:            "daily",
+					Schedule:        "0 0 * * *",
+					DurationMinutes: 24 * 60,
+					Tasks:           []v1beta1.MaintenanceTask{v1beta1.MaintenanceTaskRebalance, v1beta1.MaintenanceTaskPreferredLeaderElection},
+				}},
+			},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(s, cluster)
+
+	scaler := &fakeMaintenanceScaler{
+		loads: []scale.BrokerLoad{
+			{BrokerID: "0", DiskPct: 30, Leaders: 100},
+			{BrokerID: "1", DiskPct: 70, Leaders: 100},
+		},
+		taskState: v1beta1.CruiseControlTaskInExecution,
+	}
+	defer setCruiseControlScaler(scaler)()
+
+	r := &CruiseControlTaskReconciler{
+		Client:   c,
+		Scheme:   s,
+		Log:      logf.NullLogger{},
+		Recorder: record.NewFakeRecorder(10),
+	}
+	key := types.NamespacedName{Name: "kafka", Namespace: "kafka"}
+	reconcileCluster := func() []v1beta1.MaintenanceRun {
+		cluster := &v1beta1.KafkaCluster{}
+		if result := reconcileAndGet(t, r, c, key, cluster); result.RequeueAfter == 0 {
+			t.Error("Expected the cluster to be requeued for the next check")
+		}
+		return cluster.Status.Maintenance.Runs
+	}
+
+	runs := reconcileCluster()
+	if len(runs) != 1 || runs[0].Task != v1beta1.MaintenanceTaskRebalance || runs[0].State != v1beta1.MaintenanceRunRunning ||
+		runs[0].CruiseControlTaskId != "rebalance-task" || runs[0].Reason == "" {
+		t.Error("Expected a running rebalance, got:", runs)
+	}
+	if runs = reconcileCluster(); len(runs) != 1 || runs[0].State != v1beta1.MaintenanceRunRunning {
+		t.Error("Expected the rebalance to keep running, got:", runs)
+	}
+
+	scaler.taskState = v1beta1.CruiseControlTaskCompleted
+	if runs = reconcileCluster(); len(runs) != 1 || runs[0].State != v1beta1.MaintenanceRunCompleted || runs[0].FinishTime == nil {
+		t.Error("Expected the rebalance to be completed, got:", runs)
+	}
+
+	// the rebalance runs once per window, and the leadership is balanced
+	if runs = reconcileCluster(); len(runs) != 1 || !reflect.DeepEqual(scaler.started, []string{"rebalance"}) {
+		t.Error("Expected no other maintenance task, got:", runs, scaler.started)
+	}
+
+	scaler.loads[0].Leaders = 20
+	if err := c.Create(context.TODO(), newMockCruiseControlOperation("rebalance", v1alpha1.CruiseControlOperationRebalance, cluster.CreationTimestamp.Time)); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if runs = reconcileCluster(); len(runs) != 1 {
+		t.Error("Expected the maintenance to wait for the CruiseControlOperation, got:", runs)
+	}
+	if err := c.Delete(context.TODO(), &v1alpha1.CruiseControlOperation{ObjectMeta: metav1.ObjectMeta{Name: "rebalance", Namespace: "kafka"}}); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if runs = reconcileCluster(); len(runs) != 2 || runs[1].Task != v1beta1.MaintenanceTaskPreferredLeaderElection {
+		t.Error("Expected a preferred leader election, got:", runs)
+	}
+}
+
+func TestMaintenanceHoldsBrokerTasks(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleRequired}},
+			},
+			Maintenance: v1beta1.MaintenanceStatus{Runs: []v1beta1.MaintenanceRun{{
+				Window: "daily", Task: v1beta1.MaintenanceTaskRebalance, State: v1beta1.MaintenanceRunRunning, CruiseControlTaskId: "rebalance-task",
+			}}},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(s, cluster)
+
+	scaler := &fakeMaintenanceScaler{taskState: v1beta1.CruiseControlTaskInExecution}
+	defer setCruiseControlScaler(scaler)()
+
+	r := &CruiseControlTaskReconciler{
+		Client:   c,
+		Scheme:   s,
+		Log:      logf.NullLogger{},
+		Recorder: record.NewFakeRecorder(10),
+	}
+	key := types.NamespacedName{Name: "kafka", Namespace: "kafka"}
+	reconcileCluster := func() v1beta1.CruiseControlState {
+		cluster := &v1beta1.KafkaCluster{}
+		reconcileAndGet(t, r, c, key, cluster)
+		return cluster.Status.BrokersState["0"].GracefulActionState.CruiseControlState
+	}
+
+	if state := reconcileCluster(); state != v1beta1.GracefulUpscaleRequired || len(scaler.started) > 0 {
+		t.Error("Expected the upscale to wait for the maintenance task, got:", state, scaler.started)
+	}
+
+	// the upscale starts once the finished maintenance task is recorded
+	scaler.taskState = v1beta1.CruiseControlTaskCompleted
+	reconcileCluster()
+	if state := reconcileCluster(); state != v1beta1.GracefulUpscaleRunning || !reflect.DeepEqual(scaler.started, []string{"upscale"}) {
+		t.Error("Expected the upscale to start after the maintenance task, got:", state, scaler.started)
+	}
+}
+
+func TestBrokersRestarting(t *testing.T) {
+	testCases := map[string]v1beta1.KafkaClusterStatus{
+		"rolling upgrade": {State: v1beta1.KafkaClusterRollingUpgrading},
+		"config out of sync": {BrokersState: map[string]v1beta1.BrokerState{
+			"0": {ConfigurationState: v1beta1.ConfigOutOfSync},
+		}},
+		"demoted broker": {BrokersState: map[string]v1beta1.BrokerState{
+			"0": {ConfigurationState: v1beta1.ConfigInSync, LeadershipState: v1beta1.LeadershipState{CruiseControlTaskId: "demote-task"}},
+		}},
+		"leadership not restored": {BrokersState: map[string]v1beta1.BrokerState{
+			"0": {ConfigurationState: v1beta1.ConfigInSync, LeadershipState: v1beta1.LeadershipState{DemotedPartitions: map[string][]int32{"test": {0}}}},
+		}},
+	}
+	for name, status := range testCases {
+		if reason := brokersRestarting(&v1beta1.KafkaCluster{Status: status}); reason == "" {
+			t.Error("Expected the maintenance to wait due to", name)
+		}
+	}
+
+	status := v1beta1.KafkaClusterStatus{
+		State:        v1beta1.KafkaClusterRunning,
+		BrokersState: map[string]v1beta1.BrokerState{"0": {ConfigurationState: v1beta1.ConfigInSync}},
+	}
+	if reason := brokersRestarting(&v1beta1.KafkaCluster{Status: status}); reason != "" {
+		t.Error("Expected settled brokers, got:", reason)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+)
+
+// newCruiseControlScaler points to the function for retrieving cruise control clients,
+// use as var so it can be overwritten from unit tests
+var newCruiseControlScaler = scale.NewCruiseControlScaler
+
+const (
+	// ccOperationQueueInterval is how often a queued operation checks whether it can start
+	ccOperationQueueInterval = 15 * time.Second
+	// ccOperationRefreshInterval is how often the task of a running operation is checked
+	ccOperationRefreshInterval = 20 * time.Second
+)
+
+// Reasons of the events recorded on the CruiseControlOperations
+const (
+	ccOperationStartedReason  = "CruiseControlOperationStarted"
+	ccOperationFinishedReason = "CruiseControlOperationFinished"
+	ccOperationFailedReason   = "CruiseControlOperationFailed"
+)
+
+// SetupCruiseControlOperationWithManager registers cruise control operation controller with manager
+func SetupCruiseControlOperationWithManager(mgr ctrl.Manager) error {
+	// Create a new controller
+	r := &CruiseControlOperationReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CruiseControlOperation"),
+		Recorder: mgr.GetEventRecorderFor("cruisecontroloperation-controller"),
+	}
+
+	c, err := controller.New("cruisecontroloperation", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CruiseControlOperation
+	err = c.Watch(&source.Kind{Type: &v1alpha1.CruiseControlOperation{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that CruiseControlOperationReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &CruiseControlOperationReconciler{}
+
+// CruiseControlOperationReconciler reconciles a CruiseControlOperation object
+type CruiseControlOperationReconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=cruisecontroloperations,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=cruisecontroloperations/status,verbs=get;update;patch
+
+// Reconcile starts the operation once the earlier operations of the cluster finished, then follows its
+// Cruise Control task until it finishes
+func (r *CruiseControlOperationReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := r.Log.WithValues("cruisecontroloperation", request.NamespacedName, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CruiseControlOperation")
+	var err error
+
+	// Get a context for the request
+	ctx := context.Background()
+
+	// Fetch the CruiseControlOperation instance
+	instance := &v1alpha1.CruiseControlOperation{}
+	if err = r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconciled()
+		}
+		// Error reading the object - requeue the request.
+		return requeueWithError(reqLogger, err.Error(), err)
+	}
+
+	// Finished operations are kept as a record, they are never started again
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) || instance.IsFinished() {
+		return reconciled()
+	}
+
+	// Get the referenced kafkacluster
+	clusterNamespace := getClusterRefNamespace(instance.Namespace, instance.Spec.ClusterRef)
+	var cluster *v1beta1.KafkaCluster
+	if cluster, err = k8sutil.LookupKafkaCluster(r.Client, instance.Spec.ClusterRef.Name, clusterNamespace); err != nil {
+		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
+	}
+
+	// ensure kafkaCluster label
+	if instance, err = r.ensureClusterLabel(ctx, cluster, instance); err != nil {
+		return requeueWithError(reqLogger, "failed to ensure kafkacluster label on cruise control operation", err)
+	}
+
+	status := *instance.Status.DeepCopy()
+	result := ctrl.Result{Requeue: true, RequeueAfter: ccOperationRefreshInterval}
+	if status.State == v1alpha1.CruiseControlOperationRunning {
+		r.checkOperationTask(reqLogger, cluster, instance, &status)
+	} else {
+		status.State = v1alpha1.CruiseControlOperationQueued
+		if reason, err := r.waitingFor(ctx, cluster, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to check the cruise control operation queue", err)
+		} else if reason != "" {
+			reqLogger.Info("Cruise control operation is queued", "reason", reason)
+			status.Message = reason
+			result.RequeueAfter = ccOperationQueueInterval
+		} else {
+			r.startOperation(reqLogger, cluster, instance, &status)
+		}
+	}
+
+	if !reflect.DeepEqual(status, instance.Status) {
+		instance.Status = status
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to update cruisecontroloperation status", err)
+		}
+	}
+
+	if instance.IsFinished() {
+		return reconciled()
+	}
+	return result, nil
+}
+
+// waitingFor returns why the operation can not start yet, or an empty string if it can
+func (r *CruiseControlOperationReconciler) waitingFor(ctx context.Context, cluster *v1beta1.KafkaCluster, operation *v1alpha1.CruiseControlOperation) (string, error) {
+	if isReconcilePaused(cluster) || cluster.Spec.IsPaused(v1beta1.SubsystemCruiseControlTask) {
+		return "Cruise Control tasks of the cluster are paused", nil
+	}
+
+	for brokerId, brokerState := range cluster.Status.BrokersState {
+		if brokerState.GracefulActionState.CruiseControlState.IsRunningState() {
+			return fmt.Sprintf("waiting for the Cruise Control task of broker %s", brokerId), nil
+		}
+		for _, volumeState := range brokerState.GracefulActionState.VolumeStates {
+			if volumeState.CruiseControlVolumeState == v1beta1.GracefulDiskRebalanceRunning {
+				return fmt.Sprintf("waiting for the disk rebalance of broker %s", brokerId), nil
+			}
+		}
+	}
+
+	if run := runningMaintenanceRun(cluster.Status.Maintenance.Runs); run != nil {
+		return fmt.Sprintf("waiting for the %s task of maintenance window %s", run.Task, run.Window), nil
+	}
+
+	// the operations are listed regardless of their label, the ones not labeled yet may have been created earlier
+	operations := &v1alpha1.CruiseControlOperationList{}
+	if err := r.Client.List(ctx, operations); err != nil {
+		return "", err
+	}
+	var clusterOperations []v1alpha1.CruiseControlOperation
+	for _, op := range operations.Items {
+		if op.Spec.ClusterRef.Name == cluster.Name && getClusterRefNamespace(op.Namespace, op.Spec.ClusterRef) == cluster.Namespace {
+			clusterOperations = append(clusterOperations, op)
+		}
+	}
+	if previous := previousOperation(clusterOperations, operation); previous != nil {
+		return fmt.Sprintf("waiting for the operation %s/%s", previous.Namespace, previous.Name), nil
+	}
+	return "", nil
+}
+
+// previousOperation returns the operation that has to finish before the given one can start: a running one,
+// or the first queued one, in the order the operations were created
+func previousOperation(operations []v1alpha1.CruiseControlOperation, operation *v1alpha1.CruiseControlOperation) *v1alpha1.CruiseControlOperation {
+	var queued []v1alpha1.CruiseControlOperation
+	for _, op := range operations {
+		if op.Namespace == operation.Namespace && op.Name == operation.Name {
+			continue
+		}
+		if k8sutil.IsMarkedForDeletion(op.ObjectMeta) || op.IsFinished() {
+			continue
+		}
+		if op.Status.State == v1alpha1.CruiseControlOperationRunning {
+			return &op
+		}
+		queued = append(queued, op)
+	}
+
+	sort.Slice(queued, func(i, j int) bool {
+		return createdBefore(&queued[i], &queued[j])
+	})
+	if len(queued) > 0 && createdBefore(&queued[0], operation) {
+		return &queued[0]
+	}
+	return nil
+}
+
+// createdBefore orders the operations by their creation, then by their namespace and name
+func createdBefore(a, b *v1alpha1.CruiseControlOperation) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// startOperation asks Cruise Control to start the operation, an operation Cruise Control does not accept
+// stays queued and is retried
+func (r *CruiseControlOperationReconciler) startOperation(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, operation *v1alpha1.CruiseControlOperation, status *v1alpha1.CruiseControlOperationStatus) {
+	cc := newCruiseControlScaler(cluster.Namespace, cluster.Spec.GetKubernetesClusterDomain(), cluster.Spec.CruiseControlConfig.CruiseControlEndpoint, cluster.Name, cluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
+	taskId, summary, err := cc.StartOperation(operation.Spec)
+	if errors.Is(err, scale.ErrInvalidOperation) {
+		// an invalid operation would hold back the queue forever
+		r.finishOperation(operation, status, v1alpha1.CruiseControlOperationFailed, err.Error())
+		return
+	} else if err != nil {
+		reqLogger.Info("Cruise control did not start the operation", "error", err.Error())
+		status.Message = fmt.Sprintf("could not start the operation: %s", err)
+		return
+	}
+
+	now := metav1.Now()
+	status.State = v1alpha1.CruiseControlOperationRunning
+	status.TaskID = taskId
+	status.TaskState = string(v1beta1.CruiseControlTaskActive)
+	status.StartTime = &now
+	status.Summary = summary
+	status.Message = ""
+	reqLogger.Info("Started cruise control operation", "taskId", taskId)
+	r.Recorder.Eventf(operation, corev1.EventTypeNormal, ccOperationStartedReason,
+		"Cruise Control task %s started the %s operation", taskId, operation.Spec.Operation)
+}
+
+// checkOperationTask follows the Cruise Control task of a running operation until it finishes
+func (r *CruiseControlOperationReconciler) checkOperationTask(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, operation *v1alpha1.CruiseControlOperation, status *v1alpha1.CruiseControlOperationStatus) {
+	cc := newCruiseControlScaler(cluster.Namespace, cluster.Spec.GetKubernetesClusterDomain(), cluster.Spec.CruiseControlConfig.CruiseControlEndpoint, cluster.Name, cluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
+	taskState, err := cc.GetCCTaskState(status.TaskID)
+	if err != nil {
+		reqLogger.Info("Cruise control communication error checking running task", "taskId", status.TaskID)
+		status.Message = fmt.Sprintf("could not get the state of the task: %s", err)
+		return
+	}
+	status.TaskState = string(taskState)
+	status.Message = ""
+
+	switch taskState {
+	case v1beta1.CruiseControlTaskCompleted:
+		if status.Summary == nil {
+			summary, err := cc.GetCCTaskSummary(status.TaskID)
+			if err != nil {
+				reqLogger.Info("Could not get the summary of the completed task", "taskId", status.TaskID, "error", err.Error())
+			}
+			status.Summary = summary
+		}
+		r.finishOperation(operation, status, v1alpha1.CruiseControlOperationCompleted, "")
+	case v1beta1.CruiseControlTaskCompletedWithError:
+		r.finishOperation(operation, status, v1alpha1.CruiseControlOperationFailed, "Cruise Control task completed with error")
+	case v1beta1.CruiseControlTaskNotFound:
+		r.finishOperation(operation, status, v1alpha1.CruiseControlOperationFailed,
+			"Cruise Control task was not found, Cruise Control may have been restarted")
+	default:
+		reqLogger.Info("Cruise control task is still running", "taskId", status.TaskID, "state", taskState)
+	}
+}
+
+func (r *CruiseControlOperationReconciler) finishOperation(operation *v1alpha1.CruiseControlOperation, status *v1alpha1.CruiseControlOperationStatus, state v1alpha1.CruiseControlOperationState, message string) {
+	now := metav1.Now()
+	status.State = state
+	status.FinishTime = &now
+	status.Message = message
+
+	if state == v1alpha1.CruiseControlOperationFailed {
+		r.Recorder.Eventf(operation, corev1.EventTypeWarning, ccOperationFailedReason,
+			"Cruise Control task %s of the %s operation failed: %s", status.TaskID, operation.Spec.Operation, message)
+		return
+	}
+	r.Recorder.Eventf(operation, corev1.EventTypeNormal, ccOperationFinishedReason,
+		"Cruise Control task %s of the %s operation finished", status.TaskID, operation.Spec.Operation)
+}
+
+func (r *CruiseControlOperationReconciler) ensureClusterLabel(ctx context.Context, cluster *v1beta1.KafkaCluster, operation *v1alpha1.CruiseControlOperation) (*v1alpha1.CruiseControlOperation, error) {
+	labels := applyClusterRefLabel(cluster, operation.GetLabels())
+	if !reflect.DeepEqual(labels, operation.GetLabels()) {
+		operation.SetLabels(labels)
+		typeMeta := operation.TypeMeta
+		if err := r.Client.Update(ctx, operation); err != nil {
+			return nil, err
+		}
+		operation.TypeMeta = typeMeta
+	}
+	return operation, nil
+}
@@ -0,0 +1,143 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+)
+
+type fakeCruiseControlScaler struct {
+	scale.CruiseControlScaler
+	started   []string
+	taskState v1beta1.CruiseControlUserTaskState
+	summary   *v1alpha1.CruiseControlProposalSummary
+}
+
+func (f *fakeCruiseControlScaler) StartOperation(spec v1alpha1.CruiseControlOperationSpec) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	if spec.Operation == v1alpha1.CruiseControlOperationRemoveBroker && len(spec.BrokerIDs) == 0 {
+		return "", nil, scale.ErrInvalidOperation
+	}
+	f.started = append(f.started, string(spec.Operation))
+	return string(spec.Operation) + "-task", nil, nil
+}
+
+func (f *fakeCruiseControlScaler) GetCCTaskState(uTaskId string) (v1beta1.CruiseControlUserTaskState, error) {
+	return f.taskState, nil
+}
+
+func (f *fakeCruiseControlScaler) GetCCTaskSummary(uTaskId string) (*v1alpha1.CruiseControlProposalSummary, error) {
+	return f.summary, nil
+}
+
+func newMockCruiseControlOperation(name string, operation v1alpha1.CruiseControlOperationType, created time.Time) *v1alpha1.CruiseControlOperation {
+	return &v1alpha1.CruiseControlOperation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "kafka",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1alpha1.CruiseControlOperationSpec{
+			ClusterRef: v1alpha1.ClusterReference{Name: "kafka"},
+			Operation:  operation,
+		},
+	}
+}
+
+func TestCruiseControlOperationQueue(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	now := time.Now()
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	c := fake.NewFakeClientWithScheme(s, cluster,
+		newMockCruiseControlOperation("rebalance", v1alpha1.CruiseControlOperationRebalance, now.Add(-time.Minute)),
+		newMockCruiseControlOperation("leader-election", v1alpha1.CruiseControlOperationPreferredLeaderElection, now),
+		newMockCruiseControlOperation("invalid", v1alpha1.CruiseControlOperationRemoveBroker, now.Add(-time.Hour)),
+	)
+
+	scaler := &fakeCruiseControlScaler{taskState: v1beta1.CruiseControlTaskInExecution}
+	defer setCruiseControlScaler(scaler)()
+
+	r := &CruiseControlOperationReconciler{
+		Client:   c,
+		Scheme:   s,
+		Log:      logf.NullLogger{},
+		Recorder: record.NewFakeRecorder(10),
+	}
+	reconcileOperation := func(name string) *v1alpha1.CruiseControlOperation {
+		operation := &v1alpha1.CruiseControlOperation{}
+		reconcileAndGet(t, r, c, types.NamespacedName{Name: name, Namespace: "kafka"}, operation)
+		return operation
+	}
+
+	if operation := reconcileOperation("leader-election"); operation.Status.State != v1alpha1.CruiseControlOperationQueued {
+		t.Error("Expected the operation to wait for the earlier operations, got:", operation.Status)
+	}
+	if operation := reconcileOperation("invalid"); operation.Status.State != v1alpha1.CruiseControlOperationFailed {
+		t.Error("Expected the invalid operation to fail, got:", operation.Status)
+	}
+	if operation := reconcileOperation("leader-election"); operation.Status.State != v1alpha1.CruiseControlOperationQueued {
+		t.Error("Expected the operation to wait for the earlier rebalance, got:", operation.Status)
+	}
+
+	operation := reconcileOperation("rebalance")
+	if operation.Status.State != v1alpha1.CruiseControlOperationRunning || operation.Status.TaskID != "rebalance-task" {
+		t.Error("Expected the first operation to run, got:", operation.Status)
+	}
+	if operation.GetLabels()[clusterRefLabel] != "kafka.kafka" {
+		t.Error("Expected the operation to be labeled with its cluster, got:", operation.GetLabels())
+	}
+	if operation := reconcileOperation("leader-election"); operation.Status.State != v1alpha1.CruiseControlOperationQueued {
+		t.Error("Expected the operation to wait for the running operation, got:", operation.Status)
+	}
+
+	scaler.taskState = v1beta1.CruiseControlTaskCompleted
+	scaler.summary = &v1alpha1.CruiseControlProposalSummary{NumReplicaMovements: 12, NumLeaderMovements: 3}
+	operation = reconcileOperation("rebalance")
+	if operation.Status.State != v1alpha1.CruiseControlOperationCompleted || operation.Status.FinishTime == nil {
+		t.Error("Expected the operation to be completed, got:", operation.Status)
+	}
+	if operation.Status.Summary == nil || operation.Status.Summary.NumReplicaMovements != 12 {
+		t.Error("Expected the summary of the completed task, got:", operation.Status.Summary)
+	}
+
+	scaler.taskState = v1beta1.CruiseControlTaskInExecution
+	if operation := reconcileOperation("leader-election"); operation.Status.State != v1alpha1.CruiseControlOperationRunning {
+		t.Error("Expected the next operation to run, got:", operation.Status)
+	}
+	if len(scaler.started) != 2 {
+		t.Error("Expected two started operations, got:", scaler.started)
+	}
+
+	operations := &v1alpha1.CruiseControlOperationList{}
+	if err := c.List(context.TODO(), operations, client.MatchingLabels{clusterRefLabel: "kafka.kafka"}); err != nil || len(operations.Items) != 3 {
+		t.Error("Expected three labeled operations, got:", len(operations.Items), err)
+	}
+}
@@ -18,23 +18,26 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"emperror.dev/errors"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
 	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
-	"github.com/banzaicloud/kafka-operator/pkg/scale"
 	ccutils "github.com/banzaicloud/kafka-operator/pkg/util/cruisecontrol"
 
 	kafkav1beta1 "github.com/banzaicloud/kafka-operator/api/v1beta1"
@@ -45,10 +48,23 @@ type CruiseControlTaskReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 
-	Log logr.Logger
+	Log      logr.Logger
+	Recorder record.EventRecorder
 }
 
+// Reasons of the events recorded on the cluster about the Cruise Control tasks
+const (
+	ccTaskStartedReason  = "CruiseControlTaskStarted"
+	ccTaskFinishedReason = "CruiseControlTaskFinished"
+	ccTaskFailedReason   = "CruiseControlTaskFailed"
+	ccTaskTimedOutReason = "CruiseControlTaskTimedOut"
+
+	ccScalingProposalReadyReason  = "ScalingProposalReady"
+	ccScalingProposalFailedReason = "ScalingProposalFailed"
+)
+
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *CruiseControlTaskReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -70,6 +86,11 @@ func (r *CruiseControlTaskReconciler) Reconcile(request ctrl.Request) (ctrl.Resu
 
 	log.V(1).Info("Reconciling")
 
+	if instance.Spec.IsPaused(v1beta1.SubsystemCruiseControlTask) {
+		log.Info("Cruise Control tasks of the cluster are paused")
+		return reconciled()
+	}
+
 	brokersWithRunningCCTask := make(map[string]v1beta1.BrokerState)
 	brokerVolumesWithRunningCCTask := make(map[string]map[string]v1beta1.VolumeState)
 	for brokerId, brokerStatus := range instance.Status.BrokersState {
@@ -134,13 +155,23 @@ func (r *CruiseControlTaskReconciler) Reconcile(request ctrl.Request) (ctrl.Resu
 	}
 
 	var taskId, startTime string
-	if len(brokersWithUpscaleRequired) > 0 {
-		err = r.handlePodAddCCTask(instance, brokersWithUpscaleRequired, log)
+	var approved bool
+	var result ctrl.Result
+	run := runningMaintenanceRun(instance.Status.Maintenance.Runs)
+	if run != nil && (len(brokersWithUpscaleRequired) > 0 || len(brokersWithDownscaleRequired) > 0 || len(brokersWithDiskRebalanceRequired) > 0) {
+		// Cruise Control executes one task at a time, the brokers wait for the maintenance task to finish
+		log.Info("Cruise Control tasks of the brokers wait for the maintenance task", "window", run.Window, "task", run.Task)
+	} else if len(brokersWithUpscaleRequired) > 0 {
+		if approved, err = r.approveScaling(instance, brokersWithUpscaleRequired, true, log); approved {
+			err = r.handlePodAddCCTask(instance, brokersWithUpscaleRequired, log)
+		}
 	} else if len(brokersWithDownscaleRequired) > 0 {
-		err = r.handlePodDeleteCCTask(instance, brokersWithDownscaleRequired, log)
+		if approved, err = r.approveScaling(instance, brokersWithDownscaleRequired, false, log); approved {
+			err = r.handlePodDeleteCCTask(instance, brokersWithDownscaleRequired, log)
+		}
 	} else if len(brokersWithDiskRebalanceRequired) > 0 {
 		// create new cc task, set status to running
-		cc := scale.NewCruiseControlScaler(instance.Namespace, instance.Spec.GetKubernetesClusterDomain(), instance.Spec.CruiseControlConfig.CruiseControlEndpoint, instance.Name)
+		cc := newCruiseControlScaler(instance.Namespace, instance.Spec.GetKubernetesClusterDomain(), instance.Spec.CruiseControlConfig.CruiseControlEndpoint, instance.Name, instance.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 		taskId, startTime, err = cc.RebalanceDisks(brokersWithDiskRebalanceRequired)
 		if err != nil {
 			log.Error(err, "executing disk rebalance cc task failed")
@@ -167,9 +198,19 @@ func (r *CruiseControlTaskReconciler) Reconcile(request ctrl.Request) (ctrl.Resu
 			if len(brokersVolumeStates) > 0 {
 				err = k8sutil.UpdateBrokerStatus(r.Client, brokerIds, instance, brokersVolumeStates, log)
 			}
+			if err == nil {
+				r.Recorder.Eventf(instance, corev1.EventTypeNormal, ccTaskStartedReason,
+					"Cruise Control task %s started to rebalance the disks of broker(s) %s", taskId, strings.Join(brokerIds, ","))
+			}
 		}
 	}
 
+	if err == nil {
+		brokersBusy := len(brokersWithRunningCCTask) > 0 || len(brokerVolumesWithRunningCCTask) > 0 ||
+			len(brokersWithUpscaleRequired) > 0 || len(brokersWithDownscaleRequired) > 0 || len(brokersWithDiskRebalanceRequired) > 0
+		result, err = r.reconcileMaintenance(instance, brokersBusy, log)
+	}
+
 	if err != nil {
 		switch errors.Cause(err).(type) {
 		case errorfactory.CruiseControlNotReady:
@@ -189,10 +230,10 @@ func (r *CruiseControlTaskReconciler) Reconcile(request ctrl.Request) (ctrl.Resu
 		}
 	}
 
-	return reconciled()
+	return result, nil
 }
 func (r *CruiseControlTaskReconciler) handlePodAddCCTask(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string, log logr.Logger) error {
-	cc := scale.NewCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name)
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 	uTaskId, taskStartTime, scaleErr := cc.UpScaleCluster(brokerIds)
 	if scaleErr != nil {
 		log.Info("Cannot upscale broker(s)", "brokerId(s)", brokerIds, "error", scaleErr.Error())
@@ -200,15 +241,17 @@ func (r *CruiseControlTaskReconciler) handlePodAddCCTask(kafkaCluster *v1beta1.K
 	}
 	statusErr := k8sutil.UpdateBrokerStatus(r.Client, brokerIds, kafkaCluster,
 		v1beta1.GracefulActionState{CruiseControlTaskId: uTaskId, CruiseControlState: v1beta1.GracefulUpscaleRunning,
-			TaskStarted: taskStartTime}, log)
+			TaskStarted: taskStartTime, Proposal: approvedProposal(kafkaCluster, brokerIds)}, log)
 	if statusErr != nil {
 		return errors.WrapIfWithDetails(statusErr, "could not update status for broker", "id(s)", brokerIds)
 	}
+	r.Recorder.Eventf(kafkaCluster, corev1.EventTypeNormal, ccTaskStartedReason,
+		"Cruise Control task %s started to add broker(s) %s", uTaskId, strings.Join(brokerIds, ","))
 	return nil
 }
 func (r *CruiseControlTaskReconciler) handlePodDeleteCCTask(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string, log logr.Logger) error {
 
-	cc := scale.NewCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name)
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 	uTaskId, taskStartTime, err := cc.DownsizeCluster(brokerIds)
 	if err != nil {
 		log.Info("cruise control communication error during downscaling broker(s)", "id(s)", brokerIds)
@@ -216,11 +259,144 @@ func (r *CruiseControlTaskReconciler) handlePodDeleteCCTask(kafkaCluster *v1beta
 	}
 	err = k8sutil.UpdateBrokerStatus(r.Client, brokerIds, kafkaCluster,
 		v1beta1.GracefulActionState{CruiseControlTaskId: uTaskId, CruiseControlState: v1beta1.GracefulDownscaleRunning,
-			TaskStarted: taskStartTime}, log)
+			TaskStarted: taskStartTime, Proposal: approvedProposal(kafkaCluster, brokerIds)}, log)
 	if err != nil {
 		return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", brokerIds)
 	}
+	r.Recorder.Eventf(kafkaCluster, corev1.EventTypeNormal, ccTaskStartedReason,
+		"Cruise Control task %s started to remove broker(s) %s", uTaskId, strings.Join(brokerIds, ","))
+
+	return nil
+}
+
+// approveScaling returns true when the graceful upscale or downscale of the brokers can be executed. When the cluster
+// requires approval, the dry-run proposal of the brokers is computed first and executed once the cluster is annotated
+// with its id.
+func (r *CruiseControlTaskReconciler) approveScaling(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string, upscale bool, log logr.Logger) (bool, error) {
+	if !kafkaCluster.Spec.CruiseControlConfig.IsScalingApprovalRequired() {
+		return true, nil
+	}
+	sort.Strings(brokerIds)
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
+
+	proposal := scalingProposal(kafkaCluster, brokerIds)
+	switch {
+	case proposal == nil:
+		taskId, summary, err := cc.ProposeScaling(brokerIds, upscale)
+		if err != nil {
+			log.Info("Cannot compute scaling proposal for broker(s)", "brokerId(s)", brokerIds, "error", err.Error())
+			return false, errorfactory.New(errorfactory.CruiseControlNotReady{}, err, fmt.Sprintf("broker id(s): %s", brokerIds))
+		}
+		proposal = &v1beta1.GracefulActionProposal{ID: taskId, BrokerIDs: brokerIds}
+		if summary != nil {
+			return false, r.updateComputedScalingProposal(kafkaCluster, proposal, summary, upscale, log)
+		}
+		if err := r.updateScalingProposal(kafkaCluster, brokerIds, proposal, log); err != nil {
+			return false, err
+		}
+		return false, errorfactory.New(errorfactory.CruiseControlTaskRunning{}, errors.New("scaling proposal is being computed"), fmt.Sprintf("cc task id: %s", taskId))
+	case !proposal.Computed:
+		status, err := cc.GetCCTaskState(proposal.ID)
+		if err != nil {
+			log.Info("Cruise control communication error checking scaling proposal", "taskId", proposal.ID)
+			return false, errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "cc communication error")
+		}
+		switch status {
+		case v1beta1.CruiseControlTaskCompleted:
+			summary, err := cc.GetCCTaskSummary(proposal.ID)
+			if err != nil {
+				return false, errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "cc communication error")
+			}
+			if summary == nil {
+				return false, r.recomputeScalingProposal(kafkaCluster, brokerIds, proposal, "completed without a summary", log)
+			}
+			return false, r.updateComputedScalingProposal(kafkaCluster, proposal, summary, upscale, log)
+		case v1beta1.CruiseControlTaskNotFound, v1beta1.CruiseControlTaskCompletedWithError:
+			return false, r.recomputeScalingProposal(kafkaCluster, brokerIds, proposal, fmt.Sprintf("failed with status %s", status), log)
+		default:
+			return false, errorfactory.New(errorfactory.CruiseControlTaskRunning{}, errors.New("scaling proposal is being computed"), fmt.Sprintf("cc task id: %s", proposal.ID))
+		}
+	case kafkaCluster.GetAnnotations()[v1beta1.ScalingApprovalAnnotation] == proposal.ID:
+		log.Info("Scaling proposal approved", "proposalId", proposal.ID, "brokerId(s)", brokerIds)
+		return true, nil
+	}
+
+	log.Info("Scaling proposal is waiting for approval", "proposalId", proposal.ID, "brokerId(s)", brokerIds)
+	return false, nil
+}
+
+// scalingProposal returns the proposal computed for exactly the given brokers, nil when the brokers changed since
+func scalingProposal(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string) *v1beta1.GracefulActionProposal {
+	var proposal *v1beta1.GracefulActionProposal
+	for _, brokerId := range brokerIds {
+		brokerProposal := kafkaCluster.Status.BrokersState[brokerId].GracefulActionState.Proposal
+		if brokerProposal == nil || (proposal != nil && brokerProposal.ID != proposal.ID) {
+			return nil
+		}
+		proposal = brokerProposal
+	}
+	if proposal == nil || !reflect.DeepEqual(proposal.BrokerIDs, brokerIds) {
+		return nil
+	}
+	return proposal
+}
+
+// approvedProposal returns the proposal of the brokers that was approved, nil if the cluster does not require approval
+func approvedProposal(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string) *v1beta1.GracefulActionProposal {
+	if !kafkaCluster.Spec.CruiseControlConfig.IsScalingApprovalRequired() {
+		return nil
+	}
+	return scalingProposal(kafkaCluster, brokerIds)
+}
+
+// recomputeScalingProposal drops the proposal of the brokers that has no result, so the next reconciliation computes
+// it again
+func (r *CruiseControlTaskReconciler) recomputeScalingProposal(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string,
+	proposal *v1beta1.GracefulActionProposal, reason string, log logr.Logger) error {
+	if err := r.updateScalingProposal(kafkaCluster, brokerIds, nil, log); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(kafkaCluster, corev1.EventTypeWarning, ccScalingProposalFailedReason,
+		"Cruise Control proposal %s of broker(s) %s %s, computing it again", proposal.ID, strings.Join(brokerIds, ","), reason)
+	return errorfactory.New(errorfactory.CruiseControlTaskFailure{}, errors.New("scaling proposal failed"), fmt.Sprintf("cc task id: %s", proposal.ID))
+}
+
+// updateComputedScalingProposal stores the summary of the computed proposal along with the duration of moving its data
+func (r *CruiseControlTaskReconciler) updateComputedScalingProposal(kafkaCluster *v1beta1.KafkaCluster, proposal *v1beta1.GracefulActionProposal,
+	summary *v1alpha1.CruiseControlProposalSummary, upscale bool, log logr.Logger) error {
+	rate := kafkaCluster.Spec.CruiseControlConfig.ScalingApproval.GetEstimatedReplicationRateMBps()
+	proposal.Computed = true
+	proposal.PartitionsMoved = summary.NumReplicaMovements
+	proposal.LeadersMoved = summary.NumLeaderMovements
+	proposal.DataToMoveMB = summary.DataToMoveMB
+	proposal.EstimatedDuration = (time.Duration(summary.DataToMoveMB/int64(rate)) * time.Second).String()
+
+	if err := r.updateScalingProposal(kafkaCluster, proposal.BrokerIDs, proposal, log); err != nil {
+		return err
+	}
+	action := "remove"
+	if upscale {
+		action = "add"
+	}
+	r.Recorder.Eventf(kafkaCluster, corev1.EventTypeNormal, ccScalingProposalReadyReason,
+		"Cruise Control proposal %s to %s broker(s) %s moves %d partition replicas and %d MB of data in an estimated %s, "+
+			"approve it by annotating the cluster with %s=%s", proposal.ID, action, strings.Join(proposal.BrokerIDs, ","),
+		proposal.PartitionsMoved, proposal.DataToMoveMB, proposal.EstimatedDuration, v1beta1.ScalingApprovalAnnotation, proposal.ID)
+	return nil
+}
 
+// updateScalingProposal sets the proposal of the brokers, keeping the rest of their graceful action state
+func (r *CruiseControlTaskReconciler) updateScalingProposal(kafkaCluster *v1beta1.KafkaCluster, brokerIds []string,
+	proposal *v1beta1.GracefulActionProposal, log logr.Logger) error {
+	brokersState := make(map[string]v1beta1.GracefulActionState, len(brokerIds))
+	for _, brokerId := range brokerIds {
+		state := kafkaCluster.Status.BrokersState[brokerId].GracefulActionState
+		state.Proposal = proposal
+		brokersState[brokerId] = state
+	}
+	if err := k8sutil.UpdateBrokerStatus(r.Client, brokerIds, kafkaCluster, brokersState, log); err != nil {
+		return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", strings.Join(brokerIds, ","))
+	}
 	return nil
 }
 
@@ -245,7 +421,7 @@ func (r *CruiseControlTaskReconciler) checkCCTaskState(kafkaCluster *v1beta1.Kaf
 	}
 
 	// check cc task status
-	cc := scale.NewCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name)
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 	status, err := cc.GetCCTaskState(ccTaskId)
 	if err != nil {
 		log.Info("Cruise control communication error checking running task", "taskId", ccTaskId)
@@ -271,16 +447,24 @@ func (r *CruiseControlTaskReconciler) checkCCTaskState(kafkaCluster *v1beta1.Kaf
 			}
 		}
 
+		if err = r.removeScalingApproval(kafkaCluster, brokersState); err != nil {
+			return err
+		}
 		err = k8sutil.UpdateBrokerStatus(r.Client, brokerIds, kafkaCluster, requiredBrokerCCState, log)
 
 		if err != nil {
 			return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", strings.Join(brokerIds, ","))
 		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeWarning, ccTaskFailedReason,
+			"Cruise Control task %s of broker(s) %s failed with status %s, rescheduling it", ccTaskId, strings.Join(brokerIds, ","), status)
 		return errorfactory.New(errorfactory.CruiseControlTaskFailure{}, err, "CC task failed", fmt.Sprintf("cc task id: %s", ccTaskId))
 	}
 
 	if status == v1beta1.CruiseControlTaskCompleted {
-		// cc task completed successfully
+		// cc task completed successfully, the proposal of the brokers and its approval are not needed anymore
+		if err = r.removeScalingApproval(kafkaCluster, brokersState); err != nil {
+			return err
+		}
 		var brokerIds []string
 		completedBrokerCCState := make(map[string]v1beta1.GracefulActionState, len(brokersState))
 		for brokerId, brokerState := range brokersState {
@@ -297,6 +481,8 @@ func (r *CruiseControlTaskReconciler) checkCCTaskState(kafkaCluster *v1beta1.Kaf
 		if err != nil {
 			return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", strings.Join(brokerIds, ","))
 		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeNormal, ccTaskFinishedReason,
+			"Cruise Control task %s of broker(s) %s finished", ccTaskId, strings.Join(brokerIds, ","))
 		return nil
 	}
 	var brokersWithTimedOutCCTask []string
@@ -327,17 +513,24 @@ func (r *CruiseControlTaskReconciler) checkCCTaskState(kafkaCluster *v1beta1.Kaf
 	// task timed out
 	if len(brokersWithTimedOutCCTask) > 0 {
 		log.Info("Killing Cruise control task", "taskId", ccTaskId)
-		cc := scale.NewCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name)
+		cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 		err = cc.KillCCTask()
 
 		if err != nil {
 			return errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "cc communication error")
 		}
 
+		if err = r.removeScalingApproval(kafkaCluster, brokersState); err != nil {
+			return err
+		}
+
 		err = k8sutil.UpdateBrokerStatus(r.Client, brokersWithTimedOutCCTask, kafkaCluster, timedOutBrokerCCState, log)
 		if err != nil {
 			return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", strings.Join(brokersWithTimedOutCCTask, ","))
 		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeWarning, ccTaskTimedOutReason,
+			"Cruise Control task %s of broker(s) %s did not finish in %.0f minutes, killed it", ccTaskId, strings.Join(brokersWithTimedOutCCTask, ","),
+			kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec.GetDurationMinutes())
 		return errorfactory.New(errorfactory.CruiseControlTaskTimeout{}, errors.New("cc task timed out"), fmt.Sprintf("cc task id: %s", ccTaskId))
 	}
 
@@ -346,6 +539,27 @@ func (r *CruiseControlTaskReconciler) checkCCTaskState(kafkaCluster *v1beta1.Kaf
 	return errorfactory.New(errorfactory.CruiseControlTaskRunning{}, errors.New("cc task is still running"), fmt.Sprintf("cc task id: %s", ccTaskId))
 }
 
+// removeScalingApproval removes the approval of the proposal of the finished task from the cluster, a rescheduled task
+// gets a new proposal to approve
+func (r *CruiseControlTaskReconciler) removeScalingApproval(kafkaCluster *v1beta1.KafkaCluster, brokersState map[string]v1beta1.BrokerState) error {
+	annotations := kafkaCluster.GetAnnotations()
+	approved, ok := annotations[v1beta1.ScalingApprovalAnnotation]
+	if !ok {
+		return nil
+	}
+	for _, brokerState := range brokersState {
+		if proposal := brokerState.GracefulActionState.Proposal; proposal != nil && proposal.ID == approved {
+			delete(annotations, v1beta1.ScalingApprovalAnnotation)
+			kafkaCluster.SetAnnotations(annotations)
+			if err := k8sutil.UpdateCr(kafkaCluster, r.Client); err != nil {
+				return errors.WrapIfWithDetails(err, "could not remove the scaling approval from the cluster", "proposal", approved)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
 // getCorrectRequiredCCState returns the correct Required CC state based on that we upscale or downscale
 func (r *CruiseControlTaskReconciler) getCorrectRequiredCCState(ccState kafkav1beta1.CruiseControlState) (kafkav1beta1.CruiseControlState, error) {
 	if ccState.IsDownscale() {
@@ -379,7 +593,7 @@ func (r *CruiseControlTaskReconciler) checkVolumeCCTaskState(kafkaCluster *v1bet
 	}
 
 	// check cc task status
-	cc := scale.NewCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name)
+	cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 	status, err := cc.GetCCTaskState(ccTaskId)
 	if err != nil {
 		log.Info("Cruise control communication error checking running task", "taskId", ccTaskId)
@@ -408,6 +622,8 @@ func (r *CruiseControlTaskReconciler) checkVolumeCCTaskState(kafkaCluster *v1bet
 		if err != nil {
 			return errors.WrapIfWithDetails(err, "could not update status for broker volume(s)", "id(s)", strings.Join(brokerIds, ","))
 		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeWarning, ccTaskFailedReason,
+			"Cruise Control task %s rebalancing the disks of broker(s) %s failed with status %s, rescheduling it", ccTaskId, strings.Join(brokerIds, ","), status)
 		return errorfactory.New(errorfactory.CruiseControlTaskFailure{}, err, "CC task failed", fmt.Sprintf("cc task id: %s", ccTaskId))
 	}
 
@@ -434,6 +650,8 @@ func (r *CruiseControlTaskReconciler) checkVolumeCCTaskState(kafkaCluster *v1bet
 		if err != nil {
 			return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", strings.Join(brokerIds, ","))
 		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeNormal, ccTaskFinishedReason,
+			"Cruise Control task %s rebalancing the disks of broker(s) %s finished", ccTaskId, strings.Join(brokerIds, ","))
 		return nil
 	}
 
@@ -471,7 +689,7 @@ func (r *CruiseControlTaskReconciler) checkVolumeCCTaskState(kafkaCluster *v1bet
 	// task timed out
 	if len(brokersWithTimedOutCCTask) > 0 {
 		log.Info("Killing Cruise control task", "taskId", ccTaskId)
-		cc := scale.NewCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name)
+		cc := newCruiseControlScaler(kafkaCluster.Namespace, kafkaCluster.Spec.GetKubernetesClusterDomain(), kafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, kafkaCluster.Name, kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 
 		err = cc.KillCCTask()
 		if err != nil {
@@ -481,6 +699,9 @@ func (r *CruiseControlTaskReconciler) checkVolumeCCTaskState(kafkaCluster *v1bet
 		if err != nil {
 			return errors.WrapIfWithDetails(err, "could not update status for broker(s)", "id(s)", strings.Join(brokersWithTimedOutCCTask, ","))
 		}
+		r.Recorder.Eventf(kafkaCluster, corev1.EventTypeWarning, ccTaskTimedOutReason,
+			"Cruise Control task %s rebalancing the disks of broker(s) %s did not finish in %.0f minutes, killed it", ccTaskId,
+			strings.Join(brokersWithTimedOutCCTask, ","), kafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec.GetDurationMinutes())
 		return errorfactory.New(errorfactory.CruiseControlTaskTimeout{}, errors.New("cc task timed out"), fmt.Sprintf("cc task id: %s", ccTaskId))
 	}
 
@@ -505,7 +726,8 @@ func SetupCruiseControlWithManager(mgr ctrl.Manager) *ctrl.Builder {
 					new := e.ObjectNew.(*v1beta1.KafkaCluster)
 					if !reflect.DeepEqual(old.Status.BrokersState, new.Status.BrokersState) ||
 						old.GetDeletionTimestamp() != new.GetDeletionTimestamp() ||
-						old.GetGeneration() != new.GetGeneration() {
+						old.GetGeneration() != new.GetGeneration() ||
+						old.GetAnnotations()[v1beta1.ScalingApprovalAnnotation] != new.GetAnnotations()[v1beta1.ScalingApprovalAnnotation] {
 						return true
 					}
 					return false
@@ -0,0 +1,193 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+)
+
+type fakeScalingScaler struct {
+	scale.CruiseControlScaler
+	downsized [][]string
+	// computing proposals are only answered with their task id, as Cruise Control does with a 202
+	computing   bool
+	proposed    int
+	taskState   v1beta1.CruiseControlUserTaskState
+	taskSummary *v1alpha1.CruiseControlProposalSummary
+}
+
+func (f *fakeScalingScaler) ProposeScaling(brokerIds []string, upscale bool) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	f.proposed++
+	if f.computing {
+		return "proposal-1", nil, nil
+	}
+	return "proposal-1", &v1alpha1.CruiseControlProposalSummary{NumReplicaMovements: 40, DataToMoveMB: 30000}, nil
+}
+
+func (f *fakeScalingScaler) GetCCTaskState(uTaskId string) (v1beta1.CruiseControlUserTaskState, error) {
+	return f.taskState, nil
+}
+
+func (f *fakeScalingScaler) GetCCTaskSummary(uTaskId string) (*v1alpha1.CruiseControlProposalSummary, error) {
+	return f.taskSummary, nil
+}
+
+func (f *fakeScalingScaler) DownsizeCluster(brokerIds []string) (string, string, error) {
+	f.downsized = append(f.downsized, brokerIds)
+	return "downsize-task", "Mon, 19 Oct 2020 10:00:00 GMT", nil
+}
+
+func newScalingApprovalCluster() *v1beta1.KafkaCluster {
+	return &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			CruiseControlConfig: v1beta1.CruiseControlConfig{
+				ScalingApproval: &v1beta1.ScalingApprovalConfig{EstimatedReplicationRateMBps: 100},
+			},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"3": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulDownscaleRequired}},
+			},
+		},
+	}
+}
+
+func TestScalingApproval(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1beta1.AddToScheme(s)
+
+	cluster := newScalingApprovalCluster()
+	c := fake.NewFakeClientWithScheme(s, cluster)
+
+	scaler := &fakeScalingScaler{}
+	defer setCruiseControlScaler(scaler)()
+
+	r := &CruiseControlTaskReconciler{
+		Client:   c,
+		Scheme:   s,
+		Log:      logf.NullLogger{},
+		Recorder: record.NewFakeRecorder(10),
+	}
+	key := types.NamespacedName{Name: "kafka", Namespace: "kafka"}
+	reconcileCluster := func() *v1beta1.KafkaCluster {
+		cluster := &v1beta1.KafkaCluster{}
+		reconcileAndGet(t, r, c, key, cluster)
+		return cluster
+	}
+
+	state := reconcileCluster().Status.BrokersState["3"].GracefulActionState
+	if state.CruiseControlState != v1beta1.GracefulDownscaleRequired || len(scaler.downsized) != 0 {
+		t.Error("Expected the downscale to wait for approval, got:", state.CruiseControlState, scaler.downsized)
+	}
+	expected := v1beta1.GracefulActionProposal{ID: "proposal-1", BrokerIDs: []string{"3"}, Computed: true,
+		PartitionsMoved: 40, DataToMoveMB: 30000, EstimatedDuration: "5m0s"}
+	if state.Proposal == nil || state.Proposal.EstimatedDuration != expected.EstimatedDuration ||
+		state.Proposal.ID != expected.ID || state.Proposal.DataToMoveMB != expected.DataToMoveMB {
+		t.Error("Expected the proposal", expected, "got:", state.Proposal)
+	}
+
+	// a different proposal does not approve the downscale
+	cluster = reconcileCluster()
+	cluster.SetAnnotations(map[string]string{v1beta1.ScalingApprovalAnnotation: "proposal-0"})
+	if err := c.Update(context.TODO(), cluster); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if reconcileCluster(); len(scaler.downsized) != 0 {
+		t.Error("Expected the downscale to wait for the approval of its own proposal, got:", scaler.downsized)
+	}
+
+	cluster = reconcileCluster()
+	cluster.SetAnnotations(map[string]string{v1beta1.ScalingApprovalAnnotation: "proposal-1"})
+	if err := c.Update(context.TODO(), cluster); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	state = reconcileCluster().Status.BrokersState["3"].GracefulActionState
+	if state.CruiseControlState != v1beta1.GracefulDownscaleRunning || state.CruiseControlTaskId != "downsize-task" {
+		t.Error("Expected the approved downscale to run, got:", state)
+	}
+	if state.Proposal == nil || state.Proposal.ID != "proposal-1" {
+		t.Error("Expected the running downscale to keep its proposal, got:", state.Proposal)
+	}
+
+	// the proposal and its approval are dropped with the finished task
+	scaler.taskState = v1beta1.CruiseControlTaskCompleted
+	cluster = reconcileCluster()
+	state = cluster.Status.BrokersState["3"].GracefulActionState
+	if state.CruiseControlState != v1beta1.GracefulDownscaleSucceeded || state.Proposal != nil {
+		t.Error("Expected the downscale to succeed without its proposal, got:", state)
+	}
+	if approval, ok := cluster.GetAnnotations()[v1beta1.ScalingApprovalAnnotation]; ok {
+		t.Error("Expected the approval of the finished downscale to be removed, got:", approval)
+	}
+}
+
+func TestScalingProposalComputedLater(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1beta1.AddToScheme(s)
+	c := fake.NewFakeClientWithScheme(s, newScalingApprovalCluster())
+
+	scaler := &fakeScalingScaler{computing: true, taskState: v1beta1.CruiseControlTaskActive}
+	defer setCruiseControlScaler(scaler)()
+
+	recorder := record.NewFakeRecorder(10)
+	r := &CruiseControlTaskReconciler{Client: c, Scheme: s, Log: logf.NullLogger{}, Recorder: recorder}
+	key := types.NamespacedName{Name: "kafka", Namespace: "kafka"}
+	reconcileProposal := func() *v1beta1.GracefulActionProposal {
+		cluster := &v1beta1.KafkaCluster{}
+		reconcileAndGet(t, r, c, key, cluster)
+		return cluster.Status.BrokersState["3"].GracefulActionState.Proposal
+	}
+
+	if proposal := reconcileProposal(); proposal == nil || proposal.ID != "proposal-1" || proposal.Computed {
+		t.Error("Expected the proposal to be computed by Cruise Control, got:", proposal)
+	}
+	if proposal := reconcileProposal(); proposal == nil || proposal.Computed || len(recorder.Events) != 0 {
+		t.Error("Expected the proposal to wait for its task, got:", proposal, len(recorder.Events))
+	}
+
+	// a completed task without a summary is not a proposal to approve
+	scaler.taskState = v1beta1.CruiseControlTaskCompleted
+	if proposal := reconcileProposal(); proposal != nil {
+		t.Error("Expected the proposal without a summary to be dropped, got:", proposal)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, ccScalingProposalFailedReason) {
+		t.Error("Expected a scaling proposal failed event, got:", event)
+	}
+
+	scaler.taskSummary = &v1alpha1.CruiseControlProposalSummary{NumReplicaMovements: 12, NumLeaderMovements: 3, DataToMoveMB: 6000}
+	reconcileProposal()
+	proposal := reconcileProposal()
+	if scaler.proposed != 2 || proposal == nil || !proposal.Computed || proposal.PartitionsMoved != 12 ||
+		proposal.LeadersMoved != 3 || proposal.EstimatedDuration != "1m0s" {
+		t.Error("Expected the summary of the completed task in the proposal, got:", scaler.proposed, proposal)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, ccScalingProposalReadyReason) {
+		t.Error("Expected a scaling proposal ready event, got:", event)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/resources/kafka"
+)
+
+const (
+	reconciledReason = "Reconciled"
+
+	certificatesIssuedReason = "CertificatesIssued"
+	certificatesFailedReason = "CertificatesFailed"
+)
+
+// clusterConditions derives the conditions of the cluster from its status and the error of the last
+// reconciliation. The conditions the error says nothing about are left out to keep their last known state.
+func clusterConditions(cluster *v1beta1.KafkaCluster, reconcileErr error) []v1beta1.ClusterCondition {
+	condition := func(conditionType v1beta1.ClusterConditionType, status bool, reason, message string) v1beta1.ClusterCondition {
+		conditionStatus := metav1.ConditionFalse
+		if status {
+			conditionStatus = metav1.ConditionTrue
+		}
+		return v1beta1.ClusterCondition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: cluster.Generation,
+			Reason:             reason,
+			Message:            message,
+		}
+	}
+
+	var conditions []v1beta1.ClusterCondition
+	if reconcileErr == nil {
+		conditions = append(conditions,
+			condition(v1beta1.ClusterConditionReady, true, reconciledReason, "Cluster is reconciled"),
+			condition(v1beta1.ClusterConditionDegraded, false, reconciledReason, "Cluster is reconciled"),
+			condition(v1beta1.ClusterConditionListenersReady, true, reconciledReason, "Listeners are reconciled"),
+		)
+		if cluster.Spec.ListenersConfig.SSLSecrets == nil {
+			conditions = append(conditions, condition(v1beta1.ClusterConditionPKIReady, true, "SSLNotConfigured", "Cluster has no SSL configured"))
+		} else {
+			conditions = append(conditions, condition(v1beta1.ClusterConditionPKIReady, true, reconciledReason, "Certificates are issued"))
+		}
+		switch {
+		case cluster.Spec.CruiseControlConfig.CruiseControlEndpoint != "":
+			conditions = append(conditions, condition(v1beta1.ClusterConditionCruiseControlReady, true, "ExternalCruiseControl",
+				fmt.Sprintf("Cruise Control is managed outside of the operator at %s", cluster.Spec.CruiseControlConfig.CruiseControlEndpoint)))
+		case cluster.Status.CruiseControlTopicStatus == v1beta1.CruiseControlTopicReady:
+			conditions = append(conditions, condition(v1beta1.ClusterConditionCruiseControlReady, true, reconciledReason, "Cruise Control is reconciled"))
+		default:
+			conditions = append(conditions, condition(v1beta1.ClusterConditionCruiseControlReady, false, string(v1beta1.CruiseControlTopicNotReady),
+				"Cruise Control metrics topic is not created yet"))
+		}
+	} else {
+		reason, message := errorfactory.Reason(reconcileErr), reconcileErr.Error()
+		conditions = append(conditions,
+			condition(v1beta1.ClusterConditionReady, false, reason, message),
+			condition(v1beta1.ClusterConditionDegraded, isDegradedError(reconcileErr), reason, message),
+		)
+		switch errors.Cause(reconcileErr).(type) {
+		case errorfactory.LoadBalancerIPNotReady:
+			conditions = append(conditions, condition(v1beta1.ClusterConditionListenersReady, false, reason, message))
+		case errorfactory.CruiseControlNotReady:
+			conditions = append(conditions, condition(v1beta1.ClusterConditionCruiseControlReady, false, reason, message))
+		}
+		if kafka.IsPKIError(reconcileErr) {
+			conditions = append(conditions, condition(v1beta1.ClusterConditionPKIReady, false, reason, message))
+		}
+	}
+
+	_, rollingUpgradeErr := errors.Cause(reconcileErr).(errorfactory.ReconcileRollingUpgrade)
+	if rollingUpgradeErr || cluster.Status.State == v1beta1.KafkaClusterRollingUpgrading {
+		conditions = append(conditions, condition(v1beta1.ClusterConditionRollingUpgradeInProgress, true,
+			string(v1beta1.KafkaClusterRollingUpgrading), "Brokers are being restarted one by one"))
+	} else {
+		conditions = append(conditions, condition(v1beta1.ClusterConditionRollingUpgradeInProgress, false,
+			"NoRollingUpgrade", "No rolling upgrade is running"))
+	}
+
+	if _, ok := errors.Cause(reconcileErr).(errorfactory.PerBrokerConfigNotReady); ok {
+		conditions = append(conditions, condition(v1beta1.ClusterConditionConfigInSync, false, errorfactory.Reason(reconcileErr), reconcileErr.Error()))
+	} else if outOfSync := outOfSyncBrokers(cluster); len(outOfSync) > 0 {
+		conditions = append(conditions, condition(v1beta1.ClusterConditionConfigInSync, false, string(v1beta1.ConfigOutOfSync),
+			fmt.Sprintf("Configuration of brokers %s is out of sync", strings.Join(outOfSync, ", "))))
+	} else {
+		conditions = append(conditions, condition(v1beta1.ClusterConditionConfigInSync, true, string(v1beta1.ConfigInSync),
+			"Every broker runs with the generated configuration"))
+	}
+
+	return append(conditions, pausedCondition(cluster))
+}
+
+// pausedCondition reports whether the reconciliation of the cluster or some of its subsystems is paused
+func pausedCondition(cluster *v1beta1.KafkaCluster) v1beta1.ClusterCondition {
+	condition := v1beta1.ClusterCondition{
+		Type:               v1beta1.ClusterConditionPaused,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: cluster.Generation,
+	}
+	switch {
+	case cluster.Spec.Paused:
+		condition.Reason = "ReconciliationPaused"
+		condition.Message = "The operator leaves the cluster, its topics and its users untouched"
+	case len(cluster.Spec.PausedSubsystems) > 0:
+		subsystems := make([]string, 0, len(cluster.Spec.PausedSubsystems))
+		for _, subsystem := range cluster.Spec.PausedSubsystems {
+			subsystems = append(subsystems, string(subsystem))
+		}
+		condition.Reason = "SubsystemsPaused"
+		condition.Message = fmt.Sprintf("Paused subsystems: %s", strings.Join(subsystems, ", "))
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NotPaused"
+		condition.Message = "Reconciliation is not paused"
+	}
+	return condition
+}
+
+// recordConditionEvents records an event when the PKI of the cluster turns ready or fails, it has to be
+// called before the conditions are stored to see their previous state
+func (r *KafkaClusterReconciler) recordConditionEvents(cluster *v1beta1.KafkaCluster, conditions []v1beta1.ClusterCondition) {
+	for _, condition := range conditions {
+		if condition.Type != v1beta1.ClusterConditionPKIReady || condition.Reason == "SSLNotConfigured" {
+			continue
+		}
+		changed := true
+		for _, previous := range cluster.Status.Conditions {
+			if previous.Type == condition.Type && previous.Status == condition.Status {
+				changed = false
+			}
+		}
+		if !changed {
+			continue
+		}
+		if condition.Status == metav1.ConditionTrue {
+			r.Recorder.Event(cluster, corev1.EventTypeNormal, certificatesIssuedReason, condition.Message)
+		} else {
+			r.Recorder.Event(cluster, corev1.EventTypeWarning, certificatesFailedReason, condition.Message)
+		}
+	}
+}
+
+// isDegradedError returns false for the errors the reconciliation is only waiting out
+func isDegradedError(err error) bool {
+	switch errors.Cause(err).(type) {
+	case errorfactory.BrokersUnreachable, errorfactory.BrokersNotReady, errorfactory.ResourceNotReady,
+		errorfactory.ReconcileRollingUpgrade, errorfactory.CruiseControlNotReady, errorfactory.CruiseControlTaskRunning,
+		errorfactory.PerBrokerConfigNotReady, errorfactory.LoadBalancerIPNotReady:
+		return false
+	default:
+		return true
+	}
+}
+
+// outOfSyncBrokers returns the ids of the brokers whose read-only or per-broker config is not applied yet
+func outOfSyncBrokers(cluster *v1beta1.KafkaCluster) []string {
+	var brokerIds []string
+	for brokerId, state := range cluster.Status.BrokersState {
+		if state.ConfigurationState == v1beta1.ConfigOutOfSync ||
+			state.PerBrokerConfigurationState == v1beta1.PerBrokerConfigOutOfSync ||
+			state.PerBrokerConfigurationState == v1beta1.PerBrokerConfigError {
+			brokerIds = append(brokerIds, brokerId)
+		}
+	}
+	sort.Strings(brokerIds)
+	return brokerIds
+}
@@ -0,0 +1,148 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+func findClusterCondition(conditions []v1beta1.ClusterCondition, conditionType v1beta1.ClusterConditionType) *v1beta1.ClusterCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestClusterConditions(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Status: v1beta1.KafkaClusterStatus{
+			CruiseControlTopicStatus: v1beta1.CruiseControlTopicReady,
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {ConfigurationState: v1beta1.ConfigInSync},
+			},
+		},
+	}
+
+	conditions := clusterConditions(cluster, nil)
+	for _, conditionType := range []v1beta1.ClusterConditionType{
+		v1beta1.ClusterConditionReady,
+		v1beta1.ClusterConditionPKIReady,
+		v1beta1.ClusterConditionCruiseControlReady,
+		v1beta1.ClusterConditionListenersReady,
+		v1beta1.ClusterConditionConfigInSync,
+	} {
+		condition := findClusterCondition(conditions, conditionType)
+		if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 3 {
+			t.Errorf("Expected %s to be true for generation 3, got: %v", conditionType, condition)
+		}
+	}
+	for _, conditionType := range []v1beta1.ClusterConditionType{v1beta1.ClusterConditionDegraded, v1beta1.ClusterConditionRollingUpgradeInProgress} {
+		if condition := findClusterCondition(conditions, conditionType); condition == nil || condition.Status != metav1.ConditionFalse {
+			t.Errorf("Expected %s to be false, got: %v", conditionType, condition)
+		}
+	}
+
+	// waiting for a load balancer is not degraded, and says nothing about the PKI
+	err := errors.WrapIf(errorfactory.New(errorfactory.LoadBalancerIPNotReady{}, errors.New("no ip"), "trying"), "failed")
+	conditions = clusterConditions(cluster, err)
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionReady); condition.Status != metav1.ConditionFalse || condition.Reason != "LoadBalancerIPNotReady" {
+		t.Error("Expected not ready due to load balancer, got:", condition)
+	}
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionListenersReady); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Error("Expected listeners not ready, got:", condition)
+	}
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionDegraded); condition.Status != metav1.ConditionFalse {
+		t.Error("Expected not degraded, got:", condition)
+	}
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionPKIReady); condition != nil {
+		t.Error("Expected PKI condition to be left unchanged, got:", condition)
+	}
+
+	cluster.Status.State = v1beta1.KafkaClusterRollingUpgrading
+	cluster.Status.BrokersState["0"] = v1beta1.BrokerState{ConfigurationState: v1beta1.ConfigOutOfSync}
+	conditions = clusterConditions(cluster, errors.New("unexpected"))
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionDegraded); condition.Status != metav1.ConditionTrue || condition.Reason != "ReconcileError" {
+		t.Error("Expected degraded due to unexpected error, got:", condition)
+	}
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionRollingUpgradeInProgress); condition.Status != metav1.ConditionTrue {
+		t.Error("Expected rolling upgrade in progress, got:", condition)
+	}
+	if condition := findClusterCondition(conditions, v1beta1.ClusterConditionConfigInSync); condition.Status != metav1.ConditionFalse {
+		t.Error("Expected config out of sync, got:", condition)
+	}
+}
+
+func TestRecordConditionEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(5)
+	r := &KafkaClusterReconciler{Recorder: recorder}
+	cluster := &v1beta1.KafkaCluster{}
+	cluster.Spec.ListenersConfig.SSLSecrets = &v1beta1.SSLSecrets{}
+
+	conditions := clusterConditions(cluster, nil)
+	r.recordConditionEvents(cluster, conditions)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected an event for the issued certificates, got %d", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	// no event while the PKI stays ready
+	cluster.Status.Conditions = conditions
+	r.recordConditionEvents(cluster, clusterConditions(cluster, nil))
+	if len(recorder.Events) != 0 {
+		t.Errorf("Expected no event for an unchanged condition, got: %s", <-recorder.Events)
+	}
+
+	pkiErr := errors.WithDetails(errorfactory.New(errorfactory.ResourceNotReady{}, errors.New("secret not found"), "waiting"), "component", "pki")
+	r.recordConditionEvents(cluster, clusterConditions(cluster, pkiErr))
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected an event for the failed PKI, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning "+certificatesFailedReason) {
+		t.Error("Expected a warning event, got:", event)
+	}
+}
+
+func TestPausedCondition(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{}
+	if condition := findClusterCondition(clusterConditions(cluster, nil), v1beta1.ClusterConditionPaused); condition == nil ||
+		condition.Status != metav1.ConditionFalse {
+		t.Error("Expected the cluster not to be paused, got:", condition)
+	}
+
+	cluster.Spec.PausedSubsystems = []v1beta1.PausableSubsystem{v1beta1.SubsystemRollingUpgrade, v1beta1.SubsystemCruiseControlTask}
+	if condition := pausedCondition(cluster); condition.Status != metav1.ConditionTrue ||
+		condition.Message != "Paused subsystems: RollingUpgrade, CruiseControlTask" {
+		t.Error("Expected the subsystems to be paused, got:", condition)
+	}
+
+	cluster.Spec.Paused = true
+	if condition := pausedCondition(cluster); condition.Status != metav1.ConditionTrue || condition.Reason != "ReconciliationPaused" {
+		t.Error("Expected the reconciliation to be paused, got:", condition)
+	}
+	if !cluster.Spec.IsPaused(v1beta1.SubsystemAlertManager) {
+		t.Error("Expected every subsystem to be paused with the cluster")
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -40,6 +41,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
 	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
 	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/metrics"
 	"github.com/banzaicloud/kafka-operator/pkg/pki"
 	"github.com/banzaicloud/kafka-operator/pkg/resources"
 	"github.com/banzaicloud/kafka-operator/pkg/resources/cruisecontrol"
@@ -49,6 +51,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/pkg/resources/kafka"
 	"github.com/banzaicloud/kafka-operator/pkg/resources/kafkamonitoring"
 	"github.com/banzaicloud/kafka-operator/pkg/resources/nodeportexternalaccess"
+	"github.com/banzaicloud/kafka-operator/pkg/resources/topicimport"
 	"github.com/banzaicloud/kafka-operator/pkg/util"
 )
 
@@ -64,6 +67,7 @@ type KafkaClusterReconciler struct {
 	Scheme              *runtime.Scheme
 	Namespaces          []string
 	KafkaClientProvider kafkaclient.Provider
+	Recorder            record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a KafkaCluster object and makes changes based on the state read
@@ -78,6 +82,7 @@ type KafkaClusterReconciler struct {
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups="policy",resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaclusters/status,verbs=get;update;patch
@@ -90,6 +95,14 @@ func (r *KafkaClusterReconciler) Reconcile(request ctrl.Request) (ctrl.Result, e
 
 	log.Info("Reconciling KafkaCluster")
 
+	// the series of a deleted cluster are dropped, they must not be recreated by its last reconciliation
+	deleted := false
+	defer func(start time.Time) {
+		if !deleted {
+			metrics.ObserveClusterReconcile(request.Namespace, request.Name, time.Since(start))
+		}
+	}(time.Now())
+
 	// Fetch the KafkaCluster instance
 	instance := &v1beta1.KafkaCluster{}
 	err := r.Get(ctx, request.NamespacedName, instance)
@@ -97,6 +110,8 @@ func (r *KafkaClusterReconciler) Reconcile(request ctrl.Request) (ctrl.Result, e
 		if apiErrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			metrics.DeleteCluster(request.Namespace, request.Name)
+			deleted = true
 			return reconciled()
 		}
 		// Error reading the object - requeue the request.
@@ -108,6 +123,14 @@ func (r *KafkaClusterReconciler) Reconcile(request ctrl.Request) (ctrl.Result, e
 		return r.checkFinalizers(ctx, log, instance)
 	}
 
+	if instance.Spec.Paused {
+		log.Info("Reconciliation of the cluster is paused")
+		if err := k8sutil.UpdateCRStatus(r.Client, instance, []v1beta1.ClusterCondition{pausedCondition(instance)}, log); err != nil {
+			return requeueWithError(log, err.Error(), err)
+		}
+		return reconciled()
+	}
+
 	if instance.Status.State != v1beta1.KafkaClusterRollingUpgrading {
 		if err := k8sutil.UpdateCRStatus(r.Client, instance, v1beta1.KafkaClusterReconciling, log); err != nil {
 			return requeueWithError(log, err.Error(), err)
@@ -120,13 +143,16 @@ func (r *KafkaClusterReconciler) Reconcile(request ctrl.Request) (ctrl.Result, e
 		nodeportexternalaccess.New(r.Client, instance),
 		kafkamonitoring.New(r.Client, instance),
 		cruisecontrolmonitoring.New(r.Client, instance),
-		kafka.New(r.Client, r.DirectClient, r.Scheme, instance, r.KafkaClientProvider),
+		kafka.New(r.Client, r.DirectClient, r.Scheme, instance, r.KafkaClientProvider, r.Recorder),
 		cruisecontrol.New(r.Client, instance),
+		topicimport.New(r.Client, instance, r.KafkaClientProvider),
 	}
 
 	for _, rec := range reconcilers {
 		err = rec.Reconcile(log)
 		if err != nil {
+			r.updateConditions(log, instance, err)
+			metrics.ClusterReconcileFailed(instance, err)
 			switch errors.Cause(err).(type) {
 			case errorfactory.BrokersUnreachable:
 				log.Info("Brokers unreachable, may still be starting up", "error", err.Error())
@@ -148,6 +174,11 @@ func (r *KafkaClusterReconciler) Reconcile(request ctrl.Request) (ctrl.Result, e
 				return ctrl.Result{
 					RequeueAfter: time.Duration(15) * time.Second,
 				}, nil
+			case errorfactory.RollingUpgradeTimeout:
+				log.Info("Rolling Upgrade stopped, restarted broker is not healthy", "error", err.Error())
+				return ctrl.Result{
+					RequeueAfter: time.Duration(30) * time.Second,
+				}, nil
 			case errorfactory.CruiseControlNotReady:
 				return ctrl.Result{
 					RequeueAfter: time.Duration(15) * time.Second,
@@ -190,9 +221,25 @@ func (r *KafkaClusterReconciler) Reconcile(request ctrl.Request) (ctrl.Result, e
 		return requeueWithError(log, err.Error(), err)
 	}
 
+	conditions := clusterConditions(instance, nil)
+	r.recordConditionEvents(instance, conditions)
+	if err := k8sutil.UpdateCRStatus(r.Client, instance, conditions, log); err != nil {
+		return requeueWithError(log, err.Error(), err)
+	}
+
 	return reconciled()
 }
 
+// updateConditions records the failed reconciliation in the conditions of the cluster, the reconcile error
+// is handled by the caller so a failed status update is only logged
+func (r *KafkaClusterReconciler) updateConditions(log logr.Logger, cluster *v1beta1.KafkaCluster, reconcileErr error) {
+	conditions := clusterConditions(cluster, reconcileErr)
+	r.recordConditionEvents(cluster, conditions)
+	if err := k8sutil.UpdateCRStatus(r.Client, cluster, conditions, log); err != nil {
+		log.Error(err, "could not update the conditions of the cluster")
+	}
+}
+
 func (r *KafkaClusterReconciler) checkFinalizers(ctx context.Context, log logr.Logger, cluster *v1beta1.KafkaCluster) (ctrl.Result, error) {
 	log.Info("KafkaCluster is marked for deletion, checking for children")
 
@@ -0,0 +1,215 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+)
+
+// consumerGroupRefreshInterval is how often the state and the lag of a consumer group is refreshed
+const consumerGroupRefreshInterval = 30 * time.Second
+
+// SetupKafkaConsumerGroupWithManager registers kafka consumer group controller with manager
+func SetupKafkaConsumerGroupWithManager(mgr ctrl.Manager) error {
+	// Create a new controller
+	r := &KafkaConsumerGroupReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Log:    ctrl.Log.WithName("controllers").WithName("KafkaConsumerGroup"),
+	}
+
+	c, err := controller.New("kafkaconsumergroup", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource KafkaConsumerGroup
+	err = c.Watch(&source.Kind{Type: &v1alpha1.KafkaConsumerGroup{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that KafkaConsumerGroupReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &KafkaConsumerGroupReconciler{}
+
+// KafkaConsumerGroupReconciler reconciles a KafkaConsumerGroup object
+type KafkaConsumerGroupReconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	Client client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaconsumergroups,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaconsumergroups/status,verbs=get;update;patch
+
+// Reconcile reconciles the kafka consumer group
+func (r *KafkaConsumerGroupReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := r.Log.WithValues("kafkaconsumergroup", request.NamespacedName, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling KafkaConsumerGroup")
+	var err error
+
+	// Get a context for the request
+	ctx := context.Background()
+
+	// Fetch the KafkaConsumerGroup instance
+	instance := &v1alpha1.KafkaConsumerGroup{}
+	if err = r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconciled()
+		}
+		// Error reading the object - requeue the request.
+		return requeueWithError(reqLogger, err.Error(), err)
+	}
+
+	// Nothing to clean up on the cluster, the group is owned by its consumers
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
+		return reconciled()
+	}
+
+	// Get the referenced kafkacluster
+	clusterNamespace := getClusterRefNamespace(instance.Namespace, instance.Spec.ClusterRef)
+	var cluster *v1beta1.KafkaCluster
+	if cluster, err = k8sutil.LookupKafkaCluster(r.Client, instance.Spec.ClusterRef.Name, clusterNamespace); err != nil {
+		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
+	}
+
+	if isReconcilePaused(cluster) {
+		return requeueWhilePaused(reqLogger)
+	}
+
+	// ensure kafkaCluster label
+	if instance, err = r.ensureClusterLabel(ctx, cluster, instance); err != nil {
+		return requeueWithError(reqLogger, "failed to ensure kafkacluster label on consumer group", err)
+	}
+
+	// Get a kafka connection
+	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
+	if err != nil {
+		return checkBrokerConnectionError(reqLogger, err)
+	}
+	defer close()
+
+	status := v1alpha1.KafkaConsumerGroupStatus{OffsetReset: instance.Status.OffsetReset}
+
+	if reset := instance.Spec.OffsetReset; reset != nil &&
+		(status.OffsetReset == nil || status.OffsetReset.ID != reset.ID || status.OffsetReset.State != v1alpha1.OffsetResetApplied) {
+		status.OffsetReset = r.resetOffsets(reqLogger, broker, instance.Spec.GroupID, reset)
+	}
+
+	if err = r.observeConsumerGroup(broker, instance.Spec.GroupID, &status); err != nil {
+		return requeueWithError(reqLogger, "failed to describe consumer group", err)
+	}
+
+	if !reflect.DeepEqual(status, instance.Status) {
+		instance.Status = status
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to update kafkaconsumergroup status", err)
+		}
+	}
+
+	reqLogger.Info("Observed consumer group")
+
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: consumerGroupRefreshInterval,
+	}, nil
+}
+
+// resetOffsets applies the offset reset of the spec and returns its status,
+// a reset that can not be applied yet is retried on the next refresh
+func (r *KafkaConsumerGroupReconciler) resetOffsets(reqLogger logr.Logger, broker kafkaclient.KafkaClient, groupID string, reset *v1alpha1.ConsumerGroupOffsetReset) *v1alpha1.OffsetResetStatus {
+	err := broker.ResetConsumerGroupOffsets(groupID, reset)
+	switch errors.Cause(err).(type) {
+	case nil:
+		reqLogger.Info("Reset consumer group offsets", "id", reset.ID, "topic", reset.Topic, "strategy", reset.Strategy)
+		return &v1alpha1.OffsetResetStatus{ID: reset.ID, State: v1alpha1.OffsetResetApplied}
+	case errorfactory.ConsumerGroupNotEmpty:
+		reqLogger.Info("Consumer group has active members, postponing offset reset", "id", reset.ID)
+		return &v1alpha1.OffsetResetStatus{ID: reset.ID, State: v1alpha1.OffsetResetPending, Message: "waiting for the consumer group to become empty"}
+	default:
+		reqLogger.Error(err, "failed to reset consumer group offsets", "id", reset.ID)
+		return &v1alpha1.OffsetResetStatus{ID: reset.ID, State: v1alpha1.OffsetResetFailed, Message: err.Error()}
+	}
+}
+
+// observeConsumerGroup fills the state, the members and the lag of the group in the status
+func (r *KafkaConsumerGroupReconciler) observeConsumerGroup(broker kafkaclient.KafkaClient, groupID string, status *v1alpha1.KafkaConsumerGroupStatus) error {
+	group, err := broker.DescribeConsumerGroup(groupID)
+	if err != nil {
+		return err
+	}
+	status.State = group.State
+	for memberID, member := range group.Members {
+		status.Members = append(status.Members, v1alpha1.ConsumerGroupMember{
+			MemberID:   memberID,
+			ClientID:   member.ClientId,
+			ClientHost: member.ClientHost,
+		})
+	}
+	sort.Slice(status.Members, func(i, j int) bool {
+		return status.Members[i].MemberID < status.Members[j].MemberID
+	})
+
+	lag, err := broker.ConsumerGroupLag(groupID)
+	if err != nil {
+		return err
+	}
+	if len(lag) > 0 {
+		status.Lag = lag
+	}
+	for _, partitionLag := range lag {
+		status.TotalLag += partitionLag.Lag
+	}
+	return nil
+}
+
+func (r *KafkaConsumerGroupReconciler) ensureClusterLabel(ctx context.Context, cluster *v1beta1.KafkaCluster, group *v1alpha1.KafkaConsumerGroup) (*v1alpha1.KafkaConsumerGroup, error) {
+	labels := applyClusterRefLabel(cluster, group.GetLabels())
+	if !reflect.DeepEqual(labels, group.GetLabels()) {
+		group.SetLabels(labels)
+		typeMeta := group.TypeMeta
+		if err := r.Client.Update(ctx, group); err != nil {
+			return nil, err
+		}
+		group.TypeMeta = typeMeta
+	}
+	return group, nil
+}
@@ -17,14 +17,23 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/Shopify/sarama"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -38,13 +47,22 @@ import (
 
 var topicFinalizer = "finalizer.kafkatopics.kafka.banzaicloud.io"
 
-// SetupKafkaTopicWithManager registers kafka topic controller with manager
-func SetupKafkaTopicWithManager(mgr ctrl.Manager) error {
+// Reasons of the events recorded on the topics
+const (
+	topicCreatedReason             = "TopicCreated"
+	topicPartitionsIncreasedReason = "PartitionsIncreased"
+	topicDriftRevertedReason       = "DriftReverted"
+)
+
+// SetupKafkaTopicWithManager registers kafka topic controller with manager, the topics
+// are checked for changes made outside of the operator every driftCheckInterval unless it is zero
+func SetupKafkaTopicWithManager(mgr ctrl.Manager, driftCheckInterval time.Duration) error {
 	// Create a new controller
 	r := &KafkaTopicReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Log:    ctrl.Log.WithName("controllers").WithName("KafkaTopic"),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("controllers").WithName("KafkaTopic"),
+		Recorder: mgr.GetEventRecorderFor("kafkatopic-controller"),
 	}
 
 	c, err := controller.New("kafkatopic", mgr, controller.Options{Reconciler: r})
@@ -58,7 +76,23 @@ func SetupKafkaTopicWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	return nil
+	if driftCheckInterval == 0 {
+		return nil
+	}
+
+	// The drifted topics are reconciled again when the periodic check of their cluster finds them
+	drifted := make(chan event.GenericEvent)
+	err = c.Watch(&source.Channel{Source: drifted}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return mgr.Add(&topicDriftChecker{
+		client:   mgr.GetClient(),
+		log:      ctrl.Log.WithName("controllers").WithName("KafkaTopicDrift"),
+		interval: driftCheckInterval,
+		drifted:  drifted,
+	})
 }
 
 // blank assignment to verify that KafkaTopicReconciler implements reconcile.KafkaTopicReconciler
@@ -68,13 +102,15 @@ var _ reconcile.Reconciler = &KafkaTopicReconciler{}
 type KafkaTopicReconciler struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	Client client.Client
-	Scheme *runtime.Scheme
-	Log    logr.Logger
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Log      logr.Logger
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkatopics,verbs=get;list;watch;create;update;patch;delete;deletecollection
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkatopics/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile reconciles the kafka topic
 func (r *KafkaTopicReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
@@ -113,6 +149,24 @@ func (r *KafkaTopicReconciler) Reconcile(request reconcile.Request) (reconcile.R
 		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
 	}
 
+	if isReconcilePaused(cluster) {
+		return requeueWhilePaused(reqLogger)
+	}
+
+	// Topics that are not deleted together with the CR need no broker connection to be released
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
+		if policy := getDeletionPolicy(instance.Spec.DeletionPolicy, cluster); policy != v1alpha1.DeletionPolicyDelete {
+			reqLogger.Info("Kafka topic is marked for deletion, keeping the topic on the cluster", "deletionPolicy", policy)
+			if err = r.releaseTopic(cluster, instance.Spec.Name, reqLogger); err != nil {
+				return requeueWithError(reqLogger, "failed to record the released topic", err)
+			}
+			if err = r.removeFinalizer(ctx, instance); err != nil {
+				return requeueWithError(reqLogger, "failed to remove finalizer from kafkatopic", err)
+			}
+			return reconciled()
+		}
+	}
+
 	// Get a kafka connection
 	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
 	if err != nil {
@@ -137,14 +191,36 @@ func (r *KafkaTopicReconciler) Reconcile(request reconcile.Request) (reconcile.R
 		return requeueWithError(reqLogger, instance.Spec.Name, errors.New("topic is still creating"))
 	}
 
+	reassignment := instance.Status.Reassignment
+	var drift []string
+
 	// we got a topic back
 	if existing != nil {
 		reqLogger.Info("Topic already exists, verifying configuration")
-		// Ensure partition count
-		if changed, err := broker.EnsurePartitionCount(instance.Spec.Name, instance.Spec.Partitions); err != nil {
-			return requeueWithError(reqLogger, "failed to ensure topic partition count", err)
-		} else if changed {
-			reqLogger.Info("Increased partition count for topic")
+		// The spec did not change since it was last applied, so any difference was made outside of the operator
+		if instance.Status.ObservedGeneration == instance.Generation {
+			if drift, err = detectDrift(broker, instance, existing); err != nil {
+				return requeueWithError(reqLogger, "failed to check topic for changes made outside of the operator", err)
+			}
+			if len(drift) > 0 {
+				reqLogger.Info("Topic was changed outside of the operator, reverting", "changes", drift)
+				r.Recorder.Eventf(instance, corev1.EventTypeWarning, topicDriftRevertedReason,
+					"Reverting changes made outside of the operator: %s", strings.Join(drift, ", "))
+			}
+		}
+		// Partitions can not be added or moved while a reassignment is in progress
+		if reassignment, err = r.checkReassignment(reqLogger, broker, instance, existing); err != nil {
+			return requeueWithError(reqLogger, "failed to ensure topic replication factor", err)
+		}
+		if reassignment == nil || reassignment.State != v1alpha1.TopicReassignmentInProgress {
+			// Ensure partition count
+			if changed, err := broker.EnsurePartitionCount(instance.Spec.Name, instance.Spec.Partitions); err != nil {
+				return requeueWithError(reqLogger, "failed to ensure topic partition count", err)
+			} else if changed {
+				reqLogger.Info("Increased partition count for topic")
+				r.Recorder.Eventf(instance, corev1.EventTypeNormal, topicPartitionsIncreasedReason,
+					"Increased the partition count of topic %s to %d", instance.Spec.Name, instance.Spec.Partitions)
+			}
 		}
 		// Ensure topic configurations
 		if err = broker.EnsureTopicConfig(instance.Spec.Name, util.MapStringStringPointer(instance.Spec.Config)); err != nil {
@@ -163,6 +239,8 @@ func (r *KafkaTopicReconciler) Reconcile(request reconcile.Request) (reconcile.R
 		}); err != nil {
 			return requeueWithError(reqLogger, "failed to create kafka topic", err)
 		}
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, topicCreatedReason,
+			"Created topic %s with %d partitions and replication factor %d", instance.Spec.Name, instance.Spec.Partitions, instance.Spec.ReplicationFactor)
 
 	}
 
@@ -182,17 +260,180 @@ func (r *KafkaTopicReconciler) Reconcile(request reconcile.Request) (reconcile.R
 		return requeueWithError(reqLogger, "failed to update KafkaTopic", err)
 	}
 
-	// set topic status as created
-	instance.Status = v1alpha1.KafkaTopicStatus{State: v1alpha1.TopicStateCreated}
+	// set topic status as created along with what the cluster reports about the topic
+	status, observed := r.observeTopic(reqLogger, broker, instance.Spec.Name)
+	status.State = v1alpha1.TopicStateCreated
+	status.ObservedGeneration = instance.Generation
+	status.Reassignment = reassignment
+	status.Conditions = setTopicCondition(instance.Status.Conditions, inSyncCondition(instance.Generation, drift))
+	instance.Status = *status
 	if err := r.Client.Status().Update(ctx, instance); err != nil {
 		return requeueWithError(reqLogger, "failed to update kafkatopic status", err)
 	}
 
+	// check back on the replicas being moved, or on a topic the brokers do not report yet
+	if (reassignment != nil && reassignment.State == v1alpha1.TopicReassignmentInProgress) || !observed {
+		if observed {
+			reqLogger.Info("Partition reassignment is in progress", "partitions", reassignment.PartitionsInProgress)
+		}
+		return ctrl.Result{
+			Requeue:      true,
+			RequeueAfter: time.Duration(15) * time.Second,
+		}, nil
+	}
+
 	reqLogger.Info("Ensured topic")
 
 	return reconciled()
 }
 
+// observeTopic returns the status of the topic as reported by the brokers, the returned
+// flag is false when the topic could not be described yet
+func (r *KafkaTopicReconciler) observeTopic(reqLogger logr.Logger, broker kafkaclient.KafkaClient, topic string) (*v1alpha1.KafkaTopicStatus, bool) {
+	meta, err := broker.DescribeTopic(topic)
+	if err != nil {
+		reqLogger.Info("Could not describe topic, it may still be creating", "error", err.Error())
+		return &v1alpha1.KafkaTopicStatus{}, false
+	}
+	status := broker.TopicMetaToStatus(meta)
+
+	entries, err := broker.DescribeTopicConfig(topic)
+	if err != nil {
+		reqLogger.Info("Could not describe topic config", "error", err.Error())
+		return status, false
+	}
+	for _, entry := range entries {
+		if entry.Default {
+			continue
+		}
+		if status.Config == nil {
+			status.Config = make(map[string]string)
+		}
+		status.Config[entry.Name] = entry.Value
+	}
+	return status, true
+}
+
+// detectDrift lists the differences between the spec and the topic on the cluster
+func detectDrift(broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic, existing *sarama.TopicDetail) ([]string, error) {
+	drift := make([]string, 0)
+	if existing.NumPartitions != topic.Spec.Partitions {
+		drift = append(drift, fmt.Sprintf("partitions %d", existing.NumPartitions))
+	}
+	// the replicas reported during a reassignment include the ones being moved
+	reassigning := topic.Status.Reassignment != nil && topic.Status.Reassignment.State == v1alpha1.TopicReassignmentInProgress
+	if !reassigning && existing.ReplicationFactor != int16(topic.Spec.ReplicationFactor) {
+		drift = append(drift, fmt.Sprintf("replicationFactor %d", existing.ReplicationFactor))
+	}
+
+	entries, err := broker.DescribeTopicConfig(topic.Spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Source == sarama.SourceTopic {
+			overrides[entry.Name] = entry.Value
+		}
+	}
+	keys := make([]string, 0)
+	for key, value := range overrides {
+		if desired, ok := topic.Spec.Config[key]; !ok || desired != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range topic.Spec.Config {
+		if _, ok := overrides[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		drift = append(drift, fmt.Sprintf("config %s", key))
+	}
+	return drift, nil
+}
+
+// inSyncCondition reports whether the topic had to be changed back to match the spec
+func inSyncCondition(generation int64, drift []string) v1alpha1.TopicCondition {
+	if len(drift) > 0 {
+		return v1alpha1.TopicCondition{
+			Type:               v1alpha1.TopicConditionInSync,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "OutOfBandChange",
+			Message:            fmt.Sprintf("Topic was changed outside of the operator: %s", strings.Join(drift, ", ")),
+		}
+	}
+	return v1alpha1.TopicCondition{
+		Type:               v1alpha1.TopicConditionInSync,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Reconciled",
+		Message:            "Topic matches the spec",
+	}
+}
+
+// setTopicCondition replaces the condition of the same type, keeping its transition time if the status did not change
+func setTopicCondition(conditions []v1alpha1.TopicCondition, condition v1alpha1.TopicCondition) []v1alpha1.TopicCondition {
+	condition.LastTransitionTime = metav1.Now()
+	updated := make([]v1alpha1.TopicCondition, 0, len(conditions)+1)
+	for _, existing := range conditions {
+		if existing.Type != condition.Type {
+			updated = append(updated, existing)
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+	return append(updated, condition)
+}
+
+// checkReassignment reports an ongoing partition reassignment of the topic, or starts one
+// when the replication factor of the topic differs from the spec
+func (r *KafkaTopicReconciler) checkReassignment(reqLogger logr.Logger, broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic, existing *sarama.TopicDetail) (*v1alpha1.TopicReassignmentStatus, error) {
+	reassignment := topic.Status.Reassignment
+	ongoing, err := broker.ListTopicReassignments(topic.Spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ongoing) > 0 {
+		replicationFactor := topic.Spec.ReplicationFactor
+		if reassignment != nil {
+			replicationFactor = reassignment.ReplicationFactor
+		}
+		return &v1alpha1.TopicReassignmentStatus{
+			ReplicationFactor:    replicationFactor,
+			State:                v1alpha1.TopicReassignmentInProgress,
+			PartitionsInProgress: int32(len(ongoing)),
+		}, nil
+	}
+
+	if existing.ReplicationFactor != int16(topic.Spec.ReplicationFactor) {
+		if err := broker.AlterTopicReplicationFactor(topic.Spec.Name, topic.Spec.ReplicationFactor); err != nil {
+			return nil, err
+		}
+		reqLogger.Info("Started partition reassignment to change the replication factor",
+			"from", existing.ReplicationFactor, "to", topic.Spec.ReplicationFactor)
+		return &v1alpha1.TopicReassignmentStatus{
+			ReplicationFactor:    topic.Spec.ReplicationFactor,
+			State:                v1alpha1.TopicReassignmentInProgress,
+			PartitionsInProgress: existing.NumPartitions,
+		}, nil
+	}
+
+	if reassignment != nil && reassignment.State == v1alpha1.TopicReassignmentInProgress {
+		reqLogger.Info("Partition reassignment completed", "replicationFactor", reassignment.ReplicationFactor)
+		return &v1alpha1.TopicReassignmentStatus{
+			ReplicationFactor: reassignment.ReplicationFactor,
+			State:             v1alpha1.TopicReassignmentCompleted,
+		}, nil
+	}
+	return reassignment, nil
+}
+
 func (r *KafkaTopicReconciler) ensureClusterLabel(ctx context.Context, cluster *v1beta1.KafkaCluster, topic *v1alpha1.KafkaTopic) (*v1alpha1.KafkaTopic, error) {
 	labels := applyClusterRefLabel(cluster, topic.GetLabels())
 	if !reflect.DeepEqual(labels, topic.GetLabels()) {
@@ -226,6 +467,16 @@ func (r *KafkaTopicReconciler) checkFinalizers(ctx context.Context, reqLogger lo
 	return reconciled()
 }
 
+// releaseTopic records the topic kept on the cluster, so the topic import does not create a KafkaTopic for it again
+func (r *KafkaTopicReconciler) releaseTopic(cluster *v1beta1.KafkaCluster, name string, log logr.Logger) error {
+	status := cluster.Status.TopicImport
+	if cluster.Spec.TopicImportConfig == nil || util.StringSliceContains(status.ReleasedTopics, name) {
+		return nil
+	}
+	status.ReleasedTopics = append(append([]string{}, status.ReleasedTopics...), name)
+	return k8sutil.UpdateCRStatus(r.Client, cluster, status, log)
+}
+
 func (r *KafkaTopicReconciler) removeFinalizer(ctx context.Context, topic *v1alpha1.KafkaTopic) error {
 	topic.SetFinalizers(util.StringSliceRemove(topic.GetFinalizers(), topicFinalizer))
 	_, err := r.updateAndFetchLatest(ctx, topic)
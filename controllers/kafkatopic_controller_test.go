@@ -0,0 +1,154 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+func TestDetectDrift(t *testing.T) {
+	broker, _ := kafkaclient.NewMockFromCluster(nil, &v1beta1.KafkaCluster{})
+	if err := broker.CreateTopic(&kafkaclient.CreateTopicOptions{
+		Name:              "test-topic",
+		Partitions:        3,
+		ReplicationFactor: 1,
+		Config: map[string]*string{
+			"retention.ms":   util.StringPointer("1000"),
+			"cleanup.policy": util.StringPointer("compact"),
+		},
+	}); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	existing, _ := broker.GetTopic("test-topic")
+
+	topic := &v1alpha1.KafkaTopic{
+		Spec: v1alpha1.KafkaTopicSpec{
+			Name:              "test-topic",
+			Partitions:        3,
+			ReplicationFactor: 1,
+			Config: map[string]string{
+				"retention.ms":   "1000",
+				"cleanup.policy": "compact",
+			},
+		},
+	}
+	if drift, err := detectDrift(broker, topic, existing); err != nil || len(drift) != 0 {
+		t.Error("Expected no drift, got:", drift, err)
+	}
+
+	topic.Spec.Partitions = 2
+	topic.Spec.ReplicationFactor = 2
+	topic.Spec.Config = map[string]string{
+		"retention.ms":     "2000",
+		"compression.type": "lz4",
+	}
+	expected := []string{
+		"partitions 3",
+		"replicationFactor 1",
+		"config cleanup.policy",
+		"config compression.type",
+		"config retention.ms",
+	}
+	if drift, err := detectDrift(broker, topic, existing); err != nil || !reflect.DeepEqual(drift, expected) {
+		t.Error("Expected:", expected, "got:", drift, err)
+	}
+
+	// the replication factor is in flux while partitions are reassigned
+	topic.Status.Reassignment = &v1alpha1.TopicReassignmentStatus{ReplicationFactor: 2, State: v1alpha1.TopicReassignmentInProgress}
+	if drift, _ := detectDrift(broker, topic, existing); len(drift) != 4 {
+		t.Error("Expected replication factor to be ignored during reassignment, got:", drift)
+	}
+}
+
+func TestSetTopicCondition(t *testing.T) {
+	transition := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions := []v1alpha1.TopicCondition{
+		{Type: v1alpha1.TopicConditionInSync, Status: metav1.ConditionTrue, LastTransitionTime: transition},
+	}
+
+	conditions = setTopicCondition(conditions, inSyncCondition(2, nil))
+	if len(conditions) != 1 || !conditions[0].LastTransitionTime.Equal(&transition) || conditions[0].ObservedGeneration != 2 {
+		t.Error("Expected unchanged transition time and updated generation, got:", conditions)
+	}
+
+	conditions = setTopicCondition(conditions, inSyncCondition(2, []string{"config retention.ms"}))
+	if len(conditions) != 1 || conditions[0].Status != metav1.ConditionFalse || conditions[0].LastTransitionTime.Equal(&transition) {
+		t.Error("Expected condition to transition to false, got:", conditions)
+	}
+	if conditions[0].Message != "Topic was changed outside of the operator: config retention.ms" {
+		t.Error("Unexpected condition message:", conditions[0].Message)
+	}
+}
+
+func TestReleaseRetainedTopic(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	for _, importConfig := range []*v1beta1.TopicImportConfig{{}, nil} {
+		deletion := metav1.Now()
+		cluster := &v1beta1.KafkaCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+			Spec: v1beta1.KafkaClusterSpec{
+				DefaultDeletionPolicy: v1beta1.DeletionPolicy(v1alpha1.DeletionPolicyRetain),
+				TopicImportConfig:     importConfig,
+			},
+		}
+		topic := &v1alpha1.KafkaTopic{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "kafka", DeletionTimestamp: &deletion, Finalizers: []string{topicFinalizer}},
+			Spec:       v1alpha1.KafkaTopicSpec{Name: "orders", ClusterRef: v1alpha1.ClusterReference{Name: "kafka"}},
+		}
+		c := fake.NewFakeClientWithScheme(s, cluster, topic)
+		r := &KafkaTopicReconciler{Client: c, Scheme: s, Log: logf.NullLogger{}, Recorder: record.NewFakeRecorder(10)}
+
+		if _, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "orders", Namespace: "kafka"}}); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		cluster = &v1beta1.KafkaCluster{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: "kafka", Namespace: "kafka"}, cluster); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		released := cluster.Status.TopicImport.ReleasedTopics
+		if importConfig != nil && !reflect.DeepEqual(released, []string{"orders"}) {
+			t.Error("Expected the retained topic to be recorded as released, got:", released)
+		}
+		if importConfig == nil && len(released) != 0 {
+			t.Error("Expected no released topics without topic import, got:", released)
+		}
+		releasedTopic := &v1alpha1.KafkaTopic{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: "orders", Namespace: "kafka"}, releasedTopic); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		if len(releasedTopic.GetFinalizers()) != 0 {
+			t.Error("Expected the finalizer to be removed, got:", releasedTopic.GetFinalizers())
+		}
+	}
+}
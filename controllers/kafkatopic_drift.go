@@ -0,0 +1,130 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+)
+
+// topicDriftChecker periodically compares the topics of every cluster against their spec
+// over a single broker connection per cluster, and hands the drifted ones to the topic controller
+type topicDriftChecker struct {
+	client   client.Client
+	log      logr.Logger
+	interval time.Duration
+	drifted  chan<- event.GenericEvent
+}
+
+// Start runs the drift check until the manager stops
+func (c *topicDriftChecker) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			c.checkClusters(stop)
+		}
+	}
+}
+
+func (c *topicDriftChecker) checkClusters(stop <-chan struct{}) {
+	clusters := &v1beta1.KafkaClusterList{}
+	if err := c.client.List(context.TODO(), clusters); err != nil {
+		c.log.Error(err, "failed to list kafka clusters")
+		return
+	}
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) || isReconcilePaused(cluster) || cluster.Status.State != v1beta1.KafkaClusterRunning {
+			continue
+		}
+		log := c.log.WithValues("kafkacluster", cluster.Name, "namespace", cluster.Namespace)
+		drifted, err := c.checkCluster(log, cluster)
+		if err != nil {
+			log.Error(err, "failed to check topics for changes made outside of the operator")
+			continue
+		}
+		for _, topic := range drifted {
+			log.Info("Topic was changed outside of the operator", "kafkatopic", topic.Name, "namespace", topic.Namespace)
+			select {
+			case c.drifted <- event.GenericEvent{Meta: topic, Object: topic}:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// checkCluster returns the applied topics of the cluster which differ from their spec
+func (c *topicDriftChecker) checkCluster(log logr.Logger, cluster *v1beta1.KafkaCluster) ([]*v1alpha1.KafkaTopic, error) {
+	topicList := &v1alpha1.KafkaTopicList{}
+	if err := c.client.List(context.TODO(), topicList,
+		client.MatchingLabels{clusterRefLabel: clusterLabelString(cluster)},
+	); err != nil {
+		return nil, err
+	}
+
+	// only the topics whose spec was applied last can tell a change made outside of the operator
+	topics := make([]*v1alpha1.KafkaTopic, 0, len(topicList.Items))
+	for i := range topicList.Items {
+		topic := &topicList.Items[i]
+		if k8sutil.IsMarkedForDeletion(topic.ObjectMeta) || topic.Status.State != v1alpha1.TopicStateCreated ||
+			topic.Status.ObservedGeneration != topic.Generation {
+			continue
+		}
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		return nil, nil
+	}
+
+	broker, close, err := newBrokerConnection(log, c.client, cluster)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	existing, err := broker.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+	drifted := make([]*v1alpha1.KafkaTopic, 0)
+	for _, topic := range topics {
+		detail, ok := existing[topic.Spec.Name]
+		if !ok {
+			log.Info("Topic is missing from the cluster", "kafkatopic", topic.Name, "namespace", topic.Namespace)
+			continue
+		}
+		drift, err := detectDrift(broker, topic, &detail)
+		if err != nil {
+			return nil, err
+		}
+		if len(drift) > 0 {
+			drifted = append(drifted, topic)
+		}
+	}
+	return drifted, nil
+}
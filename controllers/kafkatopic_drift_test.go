@@ -0,0 +1,90 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+)
+
+func TestTopicDriftCheckCluster(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Status:     v1beta1.KafkaClusterStatus{State: v1beta1.KafkaClusterRunning},
+	}
+	newTopic := func(name string, partitions int32, generation, observedGeneration int64) *v1alpha1.KafkaTopic {
+		return &v1alpha1.KafkaTopic{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  "kafka",
+				Generation: generation,
+				Labels:     map[string]string{clusterRefLabel: clusterLabelString(cluster)},
+			},
+			Spec: v1alpha1.KafkaTopicSpec{Name: name, Partitions: partitions, ReplicationFactor: 1},
+			Status: v1alpha1.KafkaTopicStatus{
+				State:              v1alpha1.TopicStateCreated,
+				ObservedGeneration: observedGeneration,
+			},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(s, cluster,
+		newTopic("in-sync", 3, 1, 1),
+		newTopic("drifted", 3, 1, 1),
+		// the spec of this one is not applied yet, the topic controller takes care of it anyway
+		newTopic("pending", 6, 2, 1),
+	)
+
+	broker, _ := kafkaclient.NewMockFromCluster(nil, cluster)
+	for _, name := range []string{"in-sync", "drifted", "pending"} {
+		partitions := int32(3)
+		if name == "drifted" {
+			partitions = 6
+		}
+		if err := broker.CreateTopic(&kafkaclient.CreateTopicOptions{Name: name, Partitions: partitions, ReplicationFactor: 1}); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+	}
+	connections := 0
+	SetNewKafkaFromCluster(func(client.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, error) {
+		connections++
+		return broker, nil
+	})
+	defer SetNewKafkaFromCluster(kafkaclient.NewFromCluster)
+
+	checker := &topicDriftChecker{client: c, log: logf.NullLogger{}}
+	drifted, err := checker.checkCluster(logf.NullLogger{}, cluster)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if len(drifted) != 1 || drifted[0].Name != "drifted" {
+		t.Error("Expected only the drifted topic to be reported, got:", drifted)
+	}
+	if connections != 1 {
+		t.Error("Expected a single broker connection for the cluster, got:", connections)
+	}
+}
@@ -16,19 +16,27 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
 	"emperror.dev/errors"
 	"github.com/go-logr/logr"
 	certv1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -39,6 +47,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
 	"github.com/banzaicloud/kafka-operator/pkg/pki"
 	"github.com/banzaicloud/kafka-operator/pkg/util"
+	certutil "github.com/banzaicloud/kafka-operator/pkg/util/cert"
 	kafkautil "github.com/banzaicloud/kafka-operator/pkg/util/kafka"
 
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -46,13 +55,22 @@ import (
 
 var userFinalizer = "finalizer.kafkausers.kafka.banzaicloud.io"
 
+const scramPasswordLength = 32
+
+// Reasons of the events recorded on the users
+const (
+	certificateIssuedReason = "CertificateIssued"
+	certificateFailedReason = "CertificateFailed"
+)
+
 // SetupKafkaUserWithManager registers KafkaUser controller to the manager
 func SetupKafkaUserWithManager(mgr ctrl.Manager, certManagerNamespace bool) error {
 	// Create a new reconciler
 	r := &KafkaUserReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Log:    ctrl.Log.WithName("controllers").WithName("KafkaUser"),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("controllers").WithName("KafkaUser"),
+		Recorder: mgr.GetEventRecorderFor("kafkauser-controller"),
 	}
 
 	// Create a new controller
@@ -67,6 +85,15 @@ func SetupKafkaUserWithManager(mgr ctrl.Manager, certManagerNamespace bool) erro
 		return err
 	}
 
+	// Watch the generated scram credential secrets, so a changed password is pushed to the brokers
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &v1alpha1.KafkaUser{},
+	})
+	if err != nil {
+		return err
+	}
+
 	if certManagerNamespace {
 		err = c.Watch(&source.Kind{Type: &certv1.Certificate{}}, &handler.EnqueueRequestForOwner{
 			IsController: true,
@@ -88,16 +115,19 @@ var _ reconcile.Reconciler = &KafkaUserReconciler{}
 type KafkaUserReconciler struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	Client client.Client
-	Scheme *runtime.Scheme
-	Log    logr.Logger
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Log      logr.Logger
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkausers,verbs=get;list;watch;create;update;patch;delete;deletecollection
 // +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkausers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=issuers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=clusterissuers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile reads that state of the cluster for a KafkaUser object and makes changes based on the state read
 // and what is in the KafkaUser.Spec
@@ -135,12 +165,32 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
 	}
 
+	if isReconcilePaused(cluster) {
+		return requeueWhilePaused(reqLogger)
+	}
+
+	deletionPolicy := getDeletionPolicy(instance.Spec.DeletionPolicy, cluster)
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) && deletionPolicy == v1alpha1.DeletionPolicyOrphan {
+		reqLogger.Info("Kafka user is marked for deletion, leaving its ACLs, quotas and credentials in place")
+		if err = r.releaseSCRAMSecret(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to release scram credentials secret of kafkauser", err)
+		}
+		if err = r.removeFinalizer(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to remove finalizer from kafkauser", err)
+		}
+		return reconciled()
+	}
+
 	var kafkaUser string
+	var certIssued bool
 
-	if instance.Spec.GetIfCertShouldBeCreated() {
+	if instance.Spec.GetAuthenticationType() == v1alpha1.UserAuthenticationSCRAMSHA512 {
+		// SCRAM principals are the plain user names
+		kafkaUser = instance.Name
+	} else if instance.Spec.GetIfCertShouldBeCreated() {
 
-		// Avoid panic if the user wants to create a kafka user but the cluster is in plaintext mode
-		// TODO: refactor this and use webhook to validate if the cluster is eligible to create a kafka user
+		// Avoid panic if the user wants to create a kafka user but the cluster is in plaintext mode,
+		// the validating webhook rejects such users but it may not be enabled
 		if cluster.Spec.ListenersConfig.SSLSecrets == nil && instance.Spec.PKIBackendSpec == nil {
 			return requeueWithError(reqLogger, "could not create kafka user since user specific PKI not configured", errors.New("failed to create kafka user"))
 		}
@@ -169,10 +219,10 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 					RequeueAfter: time.Duration(5) * time.Second,
 				}, nil
 			case errorfactory.FatalReconcileError:
-				// TODO: (tinyzimmer) - Sleep for longer for now to give user time to see the error
-				// But really we should catch these kinds of issues in a pre-admission hook in a future PR
+				// Sleep for longer to give user time to see the error
 				// The user can fix while this is looping and it will pick it up next reconcile attempt
 				reqLogger.Error(err, "Fatal error attempting to reconcile the user certificate. If using vault perhaps a permissions issue or improperly configured PKI?")
+				r.Recorder.Event(instance, corev1.EventTypeWarning, certificateFailedReason, err.Error())
 				return ctrl.Result{
 					Requeue:      true,
 					RequeueAfter: time.Duration(15) * time.Second,
@@ -180,6 +230,7 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 			case errorfactory.VaultAPIFailure:
 				// Same as above in terms of things that could be checked pre-flight on the cluster
 				reqLogger.Error(err, "Vault API error attempting to reconcile the user certificate. If using vault perhaps a permissions issue or improperly configured PKI?")
+				r.Recorder.Event(instance, corev1.EventTypeWarning, certificateFailedReason, err.Error())
 				return ctrl.Result{
 					Requeue:      true,
 					RequeueAfter: time.Duration(15) * time.Second,
@@ -196,6 +247,7 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 			}
 		}
 		kafkaUser = user.DN()
+		certIssued = true
 		// check if marked for deletion and remove created certs
 		if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
 			reqLogger.Info("Kafka user is marked for deletion, revoking certificates")
@@ -209,7 +261,7 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 
 	// check if marked for deletion and remove kafka ACLs
 	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
-		return r.checkFinalizers(ctx, reqLogger, cluster, instance, kafkaUser)
+		return r.checkFinalizers(ctx, reqLogger, cluster, instance, kafkaUser, deletionPolicy)
 	}
 
 	// ensure a kafkaCluster label
@@ -217,24 +269,47 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 		return requeueWithError(reqLogger, "failed to ensure kafkacluster label on user", err)
 	}
 
-	// If topic grants supplied, grab a broker connection and set ACLs
-	if len(instance.Spec.TopicGrants) > 0 {
-		broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
-		if err != nil {
-			return checkBrokerConnectionError(reqLogger, err)
+	var scramCredentialsHash string
+	if instance.Spec.GetAuthenticationType() == v1alpha1.UserAuthenticationSCRAMSHA512 {
+		if !util.IsSASLEnabled(cluster.Spec.ListenersConfig) {
+			return requeueWithError(reqLogger, "could not create scram credentials since the cluster has no sasl listener", errors.New("failed to create kafka user"))
+		}
+		if scramCredentialsHash, err = r.reconcileSCRAMCredentials(ctx, reqLogger, cluster, instance); err != nil {
+			switch errors.Cause(err).(type) {
+			case errorfactory.BrokersUnreachable, errorfactory.BrokersNotReady:
+				return checkBrokerConnectionError(reqLogger, err)
+			default:
+				return requeueWithError(reqLogger, "failed to reconcile scram credentials for kafkauser", err)
+			}
 		}
-		defer close()
+	}
 
-		// TODO (tinyzimmer): Should probably take this opportunity to see if we are removing any ACLs
-		for _, grant := range instance.Spec.TopicGrants {
-			reqLogger.Info(fmt.Sprintf("Ensuring %s ACLs for User: %s -> Topic: %s", grant.AccessType, kafkaUser, grant.TopicName))
-			// CreateUserACLs returns no error if the ACLs already exist
-			if err = broker.CreateUserACLs(grant.AccessType, grant.PatternType, kafkaUser, grant.TopicName); err != nil {
+	// ACLs present in the status were created earlier and have to be removed once their grants are dropped
+	var acls []string
+	if instance.Spec.HasACLGrants() || len(instance.Status.ACLs) > 0 {
+		if acls, err = r.reconcileUserACLs(reqLogger, cluster, kafkaUser, &instance.Spec); err != nil {
+			switch errors.Cause(err).(type) {
+			case errorfactory.BrokersUnreachable, errorfactory.BrokersNotReady:
+				return checkBrokerConnectionError(reqLogger, err)
+			default:
 				return requeueWithError(reqLogger, "failed to ensure ACLs for kafkauser", err)
 			}
 		}
 	}
 
+	// Quotas present in the status were applied earlier and have to be removed once dropped from the spec
+	var quotas *v1alpha1.UserQuotas
+	if instance.Spec.Quotas != nil || instance.Status.Quotas != nil {
+		if quotas, err = r.reconcileUserQuotas(reqLogger, cluster, kafkaUser, instance.Spec.Quotas); err != nil {
+			switch errors.Cause(err).(type) {
+			case errorfactory.BrokersUnreachable, errorfactory.BrokersNotReady:
+				return checkBrokerConnectionError(reqLogger, err)
+			default:
+				return requeueWithError(reqLogger, "failed to ensure quotas for kafkauser", err)
+			}
+		}
+	}
+
 	// ensure a finalizer for cleanup on deletion
 	if !util.StringSliceContains(instance.GetFinalizers(), userFinalizer) {
 		r.addFinalizer(reqLogger, instance)
@@ -243,12 +318,17 @@ func (r *KafkaUserReconciler) Reconcile(request reconcile.Request) (reconcile.Re
 		}
 	}
 
+	if certIssued && instance.Status.State != v1alpha1.UserStateCreated {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, certificateIssuedReason,
+			"Issued certificate for %s into secret %s", kafkaUser, instance.Spec.SecretName)
+	}
+
 	// set user status
 	instance.Status = v1alpha1.KafkaUserStatus{
-		State: v1alpha1.UserStateCreated,
-	}
-	if len(instance.Spec.TopicGrants) > 0 {
-		instance.Status.ACLs = kafkautil.GrantsToACLStrings(kafkaUser, instance.Spec.TopicGrants)
+		State:                v1alpha1.UserStateCreated,
+		ACLs:                 acls,
+		Quotas:               quotas,
+		SCRAMCredentialsHash: scramCredentialsHash,
 	}
 	if err := r.Client.Status().Update(ctx, instance); err != nil {
 		return requeueWithError(reqLogger, "failed to update kafkauser status", err)
@@ -276,14 +356,17 @@ func (r *KafkaUserReconciler) updateAndFetchLatest(ctx context.Context, user *v1
 	return user, nil
 }
 
-func (r *KafkaUserReconciler) checkFinalizers(ctx context.Context, reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, instance *v1alpha1.KafkaUser, user string) (reconcile.Result, error) {
+func (r *KafkaUserReconciler) checkFinalizers(ctx context.Context, reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, instance *v1alpha1.KafkaUser, user string, deletionPolicy v1alpha1.DeletionPolicy) (reconcile.Result, error) {
 	// run finalizers
 	var err error
 	if util.StringSliceContains(instance.GetFinalizers(), userFinalizer) {
-		if len(instance.Spec.TopicGrants) > 0 {
-			if err = r.finalizeKafkaUserACLs(reqLogger, cluster, user); err != nil {
-				return requeueWithError(reqLogger, "failed to finalize kafkauser", err)
+		if deletionPolicy != v1alpha1.DeletionPolicyDelete {
+			reqLogger.Info("Keeping user ACLs, quotas and credentials on the cluster", "deletionPolicy", deletionPolicy)
+			if err = r.releaseSCRAMSecret(ctx, instance); err != nil {
+				return requeueWithError(reqLogger, "failed to release scram credentials secret of kafkauser", err)
 			}
+		} else if err = r.finalizeKafkaUser(reqLogger, cluster, instance, user); err != nil {
+			return requeueWithError(reqLogger, "failed to finalize kafkauser", err)
 		}
 		// remove finalizer
 		if err = r.removeFinalizer(ctx, instance); err != nil {
@@ -293,6 +376,27 @@ func (r *KafkaUserReconciler) checkFinalizers(ctx context.Context, reqLogger log
 	return reconciled()
 }
 
+// finalizeKafkaUser removes the ACLs, quotas and SCRAM credentials of the user from the cluster
+func (r *KafkaUserReconciler) finalizeKafkaUser(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, instance *v1alpha1.KafkaUser, user string) error {
+	var err error
+	if instance.Spec.HasACLGrants() || len(instance.Status.ACLs) > 0 {
+		if err = r.finalizeKafkaUserACLs(reqLogger, cluster, user); err != nil {
+			return err
+		}
+	}
+	if instance.Spec.Quotas != nil || instance.Status.Quotas != nil {
+		if err = r.finalizeKafkaUserQuotas(reqLogger, cluster, user); err != nil {
+			return errors.WrapIf(err, "failed to finalize kafkauser quotas")
+		}
+	}
+	if instance.Spec.GetAuthenticationType() == v1alpha1.UserAuthenticationSCRAMSHA512 {
+		if err = r.finalizeKafkaUserSCRAMCredentials(reqLogger, cluster, user); err != nil {
+			return errors.WrapIf(err, "failed to finalize kafkauser scram credentials")
+		}
+	}
+	return nil
+}
+
 func (r *KafkaUserReconciler) removeFinalizer(ctx context.Context, user *v1alpha1.KafkaUser) error {
 	user.SetFinalizers(util.StringSliceRemove(user.GetFinalizers(), userFinalizer))
 	_, err := r.updateAndFetchLatest(ctx, user)
@@ -317,6 +421,212 @@ func (r *KafkaUserReconciler) finalizeKafkaUserACLs(reqLogger logr.Logger, clust
 	return nil
 }
 
+// reconcileUserACLs creates the ACLs of the user grants and deletes every other ACL bound to the user principal.
+// It returns the ACLs left on the cluster.
+func (r *KafkaUserReconciler) reconcileUserACLs(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string, spec *v1alpha1.KafkaUserSpec) ([]string, error) {
+	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	// the Create calls return no error if the ACLs already exist
+	for _, grant := range spec.TopicGrants {
+		reqLogger.Info(fmt.Sprintf("Ensuring %s ACLs for User: %s -> Topic: %s", grant.AccessType, user, grant.TopicName))
+		if err = broker.CreateUserACLs(grant.AccessType, grant.PatternType, user, grant.TopicName); err != nil {
+			return nil, err
+		}
+	}
+	for _, grant := range spec.GetGroupGrants() {
+		reqLogger.Info(fmt.Sprintf("Ensuring read ACLs for User: %s -> Group: %s", user, grant.GroupName))
+		if err = broker.CreateUserGroupACLs(grant.PatternType, user, grant.GroupName); err != nil {
+			return nil, err
+		}
+	}
+	for _, grant := range spec.TransactionalIDGrants {
+		reqLogger.Info(fmt.Sprintf("Ensuring ACLs for User: %s -> TransactionalID: %s", user, grant.TransactionalID))
+		if err = broker.CreateUserTransactionalIDACLs(grant.PatternType, user, grant.TransactionalID); err != nil {
+			return nil, err
+		}
+	}
+	for _, operation := range spec.ClusterOperations {
+		reqLogger.Info(fmt.Sprintf("Ensuring %s ACL for User: %s -> Cluster", operation, user))
+		if err = broker.CreateUserClusterACLs(operation, user); err != nil {
+			return nil, err
+		}
+	}
+
+	desired := kafkautil.UserACLStrings(user, spec)
+	current, err := broker.DescribeUserACLs(user)
+	if err != nil {
+		return nil, err
+	}
+	acls := make([]string, 0, len(desired))
+	for _, resourceAcls := range current {
+		for _, acl := range resourceAcls.Acls {
+			aclString := kafkautil.ACLToString(resourceAcls.Resource, acl)
+			if util.StringSliceContains(desired, aclString) {
+				acls = append(acls, aclString)
+				continue
+			}
+			reqLogger.Info(fmt.Sprintf("Deleting stale ACL for User: %s -> %s", user, aclString))
+			if err = broker.DeleteUserACL(resourceAcls.Resource, *acl); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(acls)
+	return acls, nil
+}
+
+func (r *KafkaUserReconciler) finalizeKafkaUserSCRAMCredentials(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string) error {
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		reqLogger.Info("Cluster is being deleted, skipping scram credential deletion")
+		return nil
+	}
+	reqLogger.Info("Deleting user scram credentials from kafka")
+	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+	return broker.DeleteUserSCRAMCredentials(user)
+}
+
+func (r *KafkaUserReconciler) finalizeKafkaUserQuotas(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string) error {
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		reqLogger.Info("Cluster is being deleted, skipping quota deletion")
+		return nil
+	}
+	reqLogger.Info("Deleting user quotas from kafka")
+	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+	return broker.AlterUserQuotas(user, nil)
+}
+
+// reconcileUserQuotas sets the desired client quotas of the user on the cluster, a nil quotas removes all of them.
+// It returns the quotas the cluster reports for the user once they were applied.
+func (r *KafkaUserReconciler) reconcileUserQuotas(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string, quotas *v1alpha1.UserQuotas) (*v1alpha1.UserQuotas, error) {
+	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	reqLogger.Info(fmt.Sprintf("Ensuring quotas for User: %s", user))
+	if err = broker.AlterUserQuotas(user, quotas); err != nil {
+		return nil, err
+	}
+	return broker.DescribeUserQuotas(user)
+}
+
+// reconcileSCRAMCredentials makes sure the user secret holds a SCRAM user name and password and pushes
+// them to the brokers. The credentials are upserted again when the secret changed since they were pushed,
+// so editing the password in the secret rotates it on the cluster as well, or when they are missing from
+// the cluster. It returns the hash of the secret version the credentials were pushed from.
+func (r *KafkaUserReconciler) reconcileSCRAMCredentials(ctx context.Context, reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user *v1alpha1.KafkaUser) (string, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: user.Spec.SecretName, Namespace: user.Namespace}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", errorfactory.New(errorfactory.APIFailure{}, err, "failed to get user secret", "secret", user.Spec.SecretName)
+	}
+
+	if apierrors.IsNotFound(err) {
+		reqLogger.Info("Creating scram credentials secret", "secret", user.Spec.SecretName)
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      user.Spec.SecretName,
+				Namespace: user.Namespace,
+				Labels:    map[string]string{clusterRefLabel: clusterLabelString(cluster)},
+			},
+			Data: map[string][]byte{
+				v1beta1.SASLUsernameKey: []byte(user.Name),
+				v1beta1.SASLPasswordKey: certutil.GeneratePass(scramPasswordLength),
+			},
+		}
+		if err = controllerutil.SetControllerReference(user, secret, r.Scheme); err != nil {
+			return "", errorfactory.New(errorfactory.InternalError{}, err, "failed to set owner reference on user secret")
+		}
+		if err = r.Client.Create(ctx, secret); err != nil {
+			return "", errorfactory.New(errorfactory.APIFailure{}, err, "failed to create user secret", "secret", user.Spec.SecretName)
+		}
+	} else if string(secret.Data[v1beta1.SASLUsernameKey]) != user.Name || len(secret.Data[v1beta1.SASLPasswordKey]) == 0 {
+		// The secret might have been created by hand, keep the password if one was given
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte, 2)
+		}
+		secret.Data[v1beta1.SASLUsernameKey] = []byte(user.Name)
+		if len(secret.Data[v1beta1.SASLPasswordKey]) == 0 {
+			secret.Data[v1beta1.SASLPasswordKey] = certutil.GeneratePass(scramPasswordLength)
+		}
+		if err = r.Client.Update(ctx, secret); err != nil {
+			return "", errorfactory.New(errorfactory.APIFailure{}, err, "failed to update user secret", "secret", user.Spec.SecretName)
+		}
+	}
+
+	broker, close, err := newBrokerConnection(reqLogger, r.Client, cluster)
+	if err != nil {
+		return "", err
+	}
+	defer close()
+
+	// every upsert salts the credentials anew, so they are left alone while the secret is unchanged
+	hash := scramCredentialsHash(secret)
+	if hash == user.Status.SCRAMCredentialsHash {
+		exists, err := broker.HasUserSCRAMCredentials(user.Name)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return hash, nil
+		}
+	}
+
+	reqLogger.Info(fmt.Sprintf("Ensuring scram credentials for User: %s", user.Name))
+	if err = broker.UpsertUserSCRAMCredentials(user.Name, string(secret.Data[v1beta1.SASLPasswordKey])); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// scramCredentialsHash identifies the version of the user secret, a recreated secret gets a new hash as well
+func scramCredentialsHash(secret *corev1.Secret) string {
+	hash := sha256.Sum256([]byte(string(secret.UID) + "/" + secret.ResourceVersion))
+	return hex.EncodeToString(hash[:])
+}
+
+// releaseSCRAMSecret removes the owner reference of the user from its SCRAM credentials secret, so the password of the
+// credentials kept on the cluster is not garbage collected together with the user
+func (r *KafkaUserReconciler) releaseSCRAMSecret(ctx context.Context, user *v1alpha1.KafkaUser) error {
+	if user.Spec.GetAuthenticationType() != v1alpha1.UserAuthenticationSCRAMSHA512 {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: user.Spec.SecretName, Namespace: user.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errorfactory.New(errorfactory.APIFailure{}, err, "failed to get user secret", "secret", user.Spec.SecretName)
+	}
+	ownerRefs := make([]metav1.OwnerReference, 0, len(secret.OwnerReferences))
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID != user.UID {
+			ownerRefs = append(ownerRefs, ref)
+		}
+	}
+	if len(ownerRefs) == len(secret.OwnerReferences) {
+		return nil
+	}
+	secret.OwnerReferences = ownerRefs
+	if err := r.Client.Update(ctx, secret); err != nil {
+		return errorfactory.New(errorfactory.APIFailure{}, err, "failed to release user secret", "secret", user.Spec.SecretName)
+	}
+	return nil
+}
+
 func (r *KafkaUserReconciler) addFinalizer(reqLogger logr.Logger, user *v1alpha1.KafkaUser) {
 	reqLogger.Info("Adding Finalizer for the KafkaUser")
 	user.SetFinalizers(append(user.GetFinalizers(), userFinalizer))
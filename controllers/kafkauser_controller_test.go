@@ -0,0 +1,217 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+func TestReleaseOrphanedUserSecret(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	deletion := metav1.Now()
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	user := &v1alpha1.KafkaUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "kafka", UID: "user-uid", DeletionTimestamp: &deletion,
+			Finalizers: []string{userFinalizer}},
+		Spec: v1alpha1.KafkaUserSpec{
+			SecretName:         "app-scram",
+			ClusterRef:         v1alpha1.ClusterReference{Name: "kafka"},
+			AuthenticationType: v1alpha1.UserAuthenticationSCRAMSHA512,
+			DeletionPolicy:     v1alpha1.DeletionPolicyOrphan,
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-scram", Namespace: "kafka", OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "kafka.banzaicloud.io/v1alpha1", Kind: "KafkaUser", Name: "app", UID: "user-uid", Controller: util.BoolPointer(true)},
+		}},
+	}
+	c := fake.NewFakeClientWithScheme(s, cluster, user, secret)
+	r := &KafkaUserReconciler{Client: c, Scheme: s, Log: logf.NullLogger{}, Recorder: record.NewFakeRecorder(10)}
+
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: "kafka"}}); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	released := &corev1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "app-scram", Namespace: "kafka"}, released); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if len(released.OwnerReferences) != 0 {
+		t.Error("Expected the secret of the kept credentials to be released by the user, got:", released.OwnerReferences)
+	}
+}
+
+type fakeSCRAMClient struct {
+	kafkaclient.KafkaClient
+	credentials map[string]string
+	upserts     int
+}
+
+func (f *fakeSCRAMClient) HasUserSCRAMCredentials(user string) (bool, error) {
+	_, ok := f.credentials[user]
+	return ok, nil
+}
+
+func (f *fakeSCRAMClient) UpsertUserSCRAMCredentials(user, password string) error {
+	f.credentials[user] = password
+	f.upserts++
+	return nil
+}
+
+func (f *fakeSCRAMClient) Close() error { return nil }
+
+// fakeQuotaClient applies the quotas the way a broker without request quotas would
+type fakeQuotaClient struct {
+	kafkaclient.KafkaClient
+	quotas *v1alpha1.UserQuotas
+}
+
+func (f *fakeQuotaClient) AlterUserQuotas(user string, quotas *v1alpha1.UserQuotas) error {
+	f.quotas = quotas.DeepCopy()
+	if f.quotas != nil {
+		f.quotas.RequestPercentage = nil
+	}
+	return nil
+}
+
+func (f *fakeQuotaClient) DescribeUserQuotas(user string) (*v1alpha1.UserQuotas, error) {
+	return f.quotas.DeepCopy(), nil
+}
+
+func (f *fakeQuotaClient) Close() error { return nil }
+
+func TestReconcileSCRAMCredentials(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			ListenersConfig: v1beta1.ListenersConfig{
+				InternalListeners: []v1beta1.InternalListenerConfig{{
+					CommonListenerSpec: v1beta1.CommonListenerSpec{Type: v1beta1.ListenerTypeSASLPlaintext, Name: "internal", ContainerPort: 9092},
+				}},
+			},
+		},
+	}
+	user := &v1alpha1.KafkaUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "kafka"},
+		Spec: v1alpha1.KafkaUserSpec{
+			SecretName:         "app-scram",
+			ClusterRef:         v1alpha1.ClusterReference{Name: "kafka"},
+			AuthenticationType: v1alpha1.UserAuthenticationSCRAMSHA512,
+		},
+	}
+	c := fake.NewFakeClientWithScheme(s, cluster, user)
+	r := &KafkaUserReconciler{Client: c, Scheme: s, Log: logf.NullLogger{}, Recorder: record.NewFakeRecorder(10)}
+
+	broker := &fakeSCRAMClient{credentials: map[string]string{}}
+	SetNewKafkaFromCluster(func(client.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, error) { return broker, nil })
+	defer SetNewKafkaFromCluster(kafkaclient.NewFromCluster)
+
+	key := types.NamespacedName{Name: "app", Namespace: "kafka"}
+	reconcileUser := func() {
+		if _, err := r.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+	}
+
+	reconcileUser()
+	reconcileUser()
+	if broker.upserts != 1 {
+		t.Error("Expected the credentials to be upserted once for an unchanged secret, got:", broker.upserts)
+	}
+
+	// the credentials removed from the cluster are restored
+	delete(broker.credentials, "app")
+	reconcileUser()
+	if broker.upserts != 2 {
+		t.Error("Expected the missing credentials to be upserted, got:", broker.upserts)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "app-scram", Namespace: "kafka"}, secret); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	secret.Data[v1beta1.SASLPasswordKey] = []byte("rotated")
+	if err := c.Update(context.TODO(), secret); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	reconcileUser()
+	if broker.upserts != 3 || broker.credentials["app"] != "rotated" {
+		t.Error("Expected the rotated password to be upserted, got:", broker.upserts, broker.credentials["app"])
+	}
+}
+
+func TestReconcileUserQuotasStatus(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = v1alpha1.AddToScheme(s)
+	_ = v1beta1.AddToScheme(s)
+
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	producerByteRate, requestPercentage := int64(1024), int32(50)
+	user := &v1alpha1.KafkaUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "kafka"},
+		Spec: v1alpha1.KafkaUserSpec{
+			SecretName: "app-tls",
+			ClusterRef: v1alpha1.ClusterReference{Name: "kafka"},
+			CreateCert: util.BoolPointer(false),
+			Quotas:     &v1alpha1.UserQuotas{ProducerByteRate: &producerByteRate, RequestPercentage: &requestPercentage},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(s, cluster, user)
+	r := &KafkaUserReconciler{Client: c, Scheme: s, Log: logf.NullLogger{}, Recorder: record.NewFakeRecorder(10)}
+
+	broker := &fakeQuotaClient{}
+	SetNewKafkaFromCluster(func(client.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, error) { return broker, nil })
+	defer SetNewKafkaFromCluster(kafkaclient.NewFromCluster)
+
+	key := types.NamespacedName{Name: "app", Namespace: "kafka"}
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	reconciled := &v1alpha1.KafkaUser{}
+	if err := c.Get(context.TODO(), key, reconciled); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expected := &v1alpha1.UserQuotas{ProducerByteRate: &producerByteRate}
+	if !reflect.DeepEqual(reconciled.Status.Quotas, expected) {
+		t.Error("Expected the quotas reported by the cluster in the status, got:", reconciled.Status.Quotas)
+	}
+}
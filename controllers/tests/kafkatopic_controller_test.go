@@ -165,9 +165,10 @@ var _ = Describe("KafkaTopic", func() {
 					Namespace: namespace,
 				},
 				Spec: v1alpha1.KafkaTopicSpec{
-					Name:              topicName,
-					Partitions:        17,
-					ReplicationFactor: 19,
+					Name:       topicName,
+					Partitions: 17,
+					// a different replication factor would start a partition reassignment
+					ReplicationFactor: 13,
 					Config: map[string]string{
 						"key1": "value1",
 						"key2": "value2",
@@ -210,9 +210,11 @@ var _ = Describe("KafkaTopic", func() {
 
 		Expect(user.Status.ACLs).To(ConsistOf(
 			"User:CN=kafkauser-1,Topic,ANY,test-topic-1,Describe,Allow,*",
+			"User:CN=kafkauser-1,Topic,ANY,test-topic-1,DescribeConfigs,Allow,*",
 			"User:CN=kafkauser-1,Topic,ANY,test-topic-1,Read,Allow,*",
 			"User:CN=kafkauser-1,Group,LITERAL,*,Read,Allow,*",
 			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,Describe,Allow,*",
+			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,DescribeConfigs,Allow,*",
 			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,Create,Allow,*",
 			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,Write,Allow,*",
 		))
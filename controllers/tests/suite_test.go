@@ -149,6 +149,7 @@ var _ = BeforeSuite(func(done Done) {
 		Log:                 ctrl.Log.WithName("controllers").WithName("KafkaCluster"),
 		Scheme:              mgr.GetScheme(),
 		KafkaClientProvider: kafkaclient.NewMockProvider(),
+		Recorder:            mgr.GetEventRecorderFor("kafkacluster-controller"),
 	}
 
 	err = controllers.SetupKafkaClusterWithManager(mgr, kafkaClusterReconciler.Log).Complete(&kafkaClusterReconciler)
@@ -161,9 +162,10 @@ var _ = BeforeSuite(func(done Done) {
 	Expect(err).NotTo(HaveOccurred())
 
 	kafkaClusterCCReconciler := controllers.CruiseControlTaskReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Log:    ctrl.Log.WithName("controller").WithName("CruiseControlTask"),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("controller").WithName("CruiseControlTask"),
+		Recorder: mgr.GetEventRecorderFor("cruisecontroltask-controller"),
 	}
 
 	err = controllers.SetupCruiseControlWithManager(mgr).Complete(&kafkaClusterCCReconciler)
@@ -4,7 +4,7 @@ go 1.15
 
 require (
 	emperror.dev/errors v0.7.0
-	github.com/Shopify/sarama v1.27.1
+	github.com/Shopify/sarama v1.30.0
 	github.com/banzaicloud/bank-vaults/pkg/sdk v0.3.1
 	github.com/banzaicloud/istio-client-go v0.0.9
 	github.com/banzaicloud/istio-operator v0.0.0-20200407070503-3f7dc6953a7b
@@ -14,7 +14,6 @@ require (
 	github.com/ghodss/yaml v1.0.1-0.20190212211648-25d852aebe32
 	github.com/go-logr/logr v0.1.0
 	github.com/golang/protobuf v1.4.2
-	github.com/golang/snappy v0.0.2 // indirect
 	github.com/google/uuid v1.1.2 // indirect
 	github.com/hashicorp/go-hclog v0.12.2 // indirect
 	github.com/hashicorp/go-retryablehttp v0.6.7 // indirect
@@ -25,7 +24,6 @@ require (
 	github.com/imdario/mergo v0.3.11
 	github.com/influxdata/influxdb v1.7.6 // indirect
 	github.com/jetstack/cert-manager v0.15.2
-	github.com/klauspost/compress v1.11.1 // indirect
 	github.com/lestrrat-go/backoff v1.0.0
 	github.com/magiconair/properties v1.8.4 // indirect
 	github.com/mattn/go-colorable v0.1.7 // indirect
@@ -34,12 +32,15 @@ require (
 	github.com/oxtoacart/bpool v0.0.0-20190530202638-03653db5a59c // indirect
 	github.com/pavel-v-chernykh/keystore-go v2.1.0+incompatible
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/prometheus/client_golang v1.4.1 // indirect
+	github.com/prometheus/client_golang v1.4.1
 	github.com/prometheus/common v0.9.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/spf13/cast v1.3.1 // indirect
 	github.com/spf13/viper v1.7.1 // indirect
+	github.com/xdg-go/scram v1.0.2
 	go.uber.org/atomic v1.5.1 // indirect
 	go.uber.org/zap v1.10.0
+	gomodules.xyz/jsonpatch/v2 v2.0.1
 	google.golang.org/appengine v1.6.6 // indirect
 	google.golang.org/grpc v1.27.1 // indirect
 	gotest.tools v2.2.0+incompatible
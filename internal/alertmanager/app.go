@@ -19,12 +19,13 @@ import (
 
 	"github.com/banzaicloud/kafka-operator/internal/alertmanager/receiver"
 	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // NewApp returns HTTPHandler
-func NewApp(log logr.Logger, client client.Client) http.Handler {
+func NewApp(log logr.Logger, client client.Client, recorder record.EventRecorder) http.Handler {
 	mux := http.NewServeMux()
-	mux.Handle(receiver.APIEndPoint, receiver.NewHTTPHandler(log, client))
+	mux.Handle(receiver.APIEndPoint, receiver.NewHTTPHandler(log, client, recorder))
 	return mux
 }
@@ -18,8 +18,10 @@ import (
 	"errors"
 	"sync"
 
+	"github.com/banzaicloud/kafka-operator/pkg/metrics"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/common/model"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -29,7 +31,7 @@ type CurrentAlerts interface {
 	AlertGC(AlertState) error
 	DeleteAlert(model.Fingerprint) error
 	ListAlerts() map[model.Fingerprint]*currentAlertStruct
-	HandleAlert(model.Fingerprint, client.Client, int, logr.Logger) (*currentAlertStruct, error)
+	HandleAlert(model.Fingerprint, client.Client, record.EventRecorder, int, logr.Logger) (*currentAlertStruct, error)
 	GetRollingUpgradeAlertCount() int
 	IgnoreCCStatusCheck(bool)
 }
@@ -58,6 +60,7 @@ type currentAlertStruct struct {
 type examiner struct {
 	Alert          *currentAlertStruct
 	Client         client.Client
+	Recorder       record.EventRecorder
 	IgnoreCCStatus bool
 	Log            logr.Logger
 }
@@ -116,7 +119,7 @@ func (a *currentAlerts) AlertGC(alert AlertState) error {
 	return nil
 }
 
-func (a *currentAlerts) HandleAlert(alertFp model.Fingerprint, client client.Client, rollingUpgradeAlertCount int, log logr.Logger) (*currentAlertStruct, error) {
+func (a *currentAlerts) HandleAlert(alertFp model.Fingerprint, client client.Client, recorder record.EventRecorder, rollingUpgradeAlertCount int, log logr.Logger) (*currentAlertStruct, error) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	if _, ok := a.alerts[alertFp]; !ok {
@@ -126,6 +129,7 @@ func (a *currentAlerts) HandleAlert(alertFp model.Fingerprint, client client.Cli
 		e := &examiner{
 			Alert:          a.alerts[alertFp],
 			Client:         client,
+			Recorder:       recorder,
 			IgnoreCCStatus: a.IgnoreCCStatus,
 			Log:            log,
 		}
@@ -138,6 +142,9 @@ func (a *currentAlerts) HandleAlert(alertFp model.Fingerprint, client client.Cli
 		if err != nil {
 			return nil, err
 		}
+		if alertProcessed {
+			metrics.AlertProcessed(string(a.alerts[alertFp].Annotations["command"]))
+		}
 		a.alerts[alertFp].Processed = alertProcessed
 	}
 	return a.alerts[alertFp], nil
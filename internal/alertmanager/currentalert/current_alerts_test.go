@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -196,7 +197,7 @@ func TestGetCurrentAlerts(t *testing.T) {
 		t.Error("Listing alerts failed a1")
 	}
 
-	currAlert, err := alerts1.HandleAlert(testAlert1.FingerPrint, c, 0, log)
+	currAlert, err := alerts1.HandleAlert(testAlert1.FingerPrint, c, record.NewFakeRecorder(1), 0, log)
 	if err != nil {
 		t.Error("Hanlde alert failed a1 with error", err)
 	}
@@ -231,7 +232,7 @@ func TestGetCurrentAlerts(t *testing.T) {
 		t.Error("2222 alert wasn't deleted")
 	}
 
-	_, err = alerts3.HandleAlert(model.Fingerprint(2222), c, 0, log)
+	_, err = alerts3.HandleAlert(model.Fingerprint(2222), c, record.NewFakeRecorder(1), 0, log)
 	expected := "alert doesn't exist"
 	if err == nil || err.Error() != expected {
 		t.Error("alert with 2222 isn't the expected", err)
@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
@@ -50,6 +51,15 @@ const (
 	ResizePvcCommand = "resizePvc"
 )
 
+// Reasons of the events recorded on the cluster about the commands run for alerts
+const (
+	volumeAddedReason        = "VolumeAdded"
+	volumeResizedReason      = "VolumeResized"
+	brokerAddedReason        = "BrokerAdded"
+	brokerRemovedReason      = "BrokerRemoved"
+	alertCommandFailedReason = "AlertCommandFailed"
+)
+
 // GetCommandList returns list of supported commands
 func GetCommandList() []string {
 	return []string{
@@ -99,6 +109,12 @@ func (e *examiner) examineAlert(rollingUpgradeAlertCount int) (bool, error) {
 		return false, errors.New("kafkaCR is nil")
 	}
 
+	// the alert is kept unprocessed to be run once the cluster is resumed
+	if cr.Spec.IsPaused(v1beta1.SubsystemAlertManager) {
+		e.Log.Info("alert is skipped as the cluster is paused", "kafka_cr", cr.Name)
+		return false, nil
+	}
+
 	if err := k8sutil.UpdateCrWithRollingUpgrade(rollingUpgradeAlertCount, cr, e.Client); err != nil {
 		return false, err
 	}
@@ -117,7 +133,12 @@ func (e *examiner) examineAlert(rollingUpgradeAlertCount int) (bool, error) {
 		}
 	}
 
-	return e.processAlert(ds)
+	processed, err := e.processAlert(ds)
+	if err != nil {
+		e.Recorder.Eventf(cr, corev1.EventTypeWarning, alertCommandFailedReason, "Command %s of alert %s failed: %s",
+			e.Alert.Annotations["command"], e.Alert.Labels["alertname"], err)
+	}
+	return processed, err
 }
 
 func (e *examiner) processAlert(ds disableScaling) (bool, error) {
@@ -128,7 +149,7 @@ func (e *examiner) processAlert(ds disableScaling) (bool, error) {
 		if err := validators.ValidateAlert(); err != nil {
 			return false, err
 		}
-		err := addPvc(e.Log, e.Alert.Labels, e.Alert.Annotations, e.Client)
+		err := addPvc(e.Log, e.Alert.Labels, e.Alert.Annotations, e.Client, e.Recorder)
 		if err != nil {
 			return false, err
 		}
@@ -139,7 +160,7 @@ func (e *examiner) processAlert(ds disableScaling) (bool, error) {
 		if err := validators.ValidateAlert(); err != nil {
 			return false, err
 		}
-		err := resizePvc(e.Log, e.Alert.Labels, e.Alert.Annotations, e.Client)
+		err := resizePvc(e.Log, e.Alert.Labels, e.Alert.Annotations, e.Client, e.Recorder)
 		if err != nil {
 			return false, err
 		}
@@ -154,7 +175,7 @@ func (e *examiner) processAlert(ds disableScaling) (bool, error) {
 			e.Log.Info("downscale is skipped due to downscale limit")
 			return false, nil
 		}
-		err := downScale(e.Log, e.Alert.Labels, e.Client)
+		err := downScale(e.Log, e.Alert.Labels, e.Client, e.Recorder)
 		if err != nil {
 			return false, err
 		}
@@ -169,7 +190,7 @@ func (e *examiner) processAlert(ds disableScaling) (bool, error) {
 			e.Log.Info("upscale is skipped due to upscale limit")
 			return false, nil
 		}
-		err := upScale(e.Log, e.Alert.Labels, e.Alert.Annotations, e.Client)
+		err := upScale(e.Log, e.Alert.Labels, e.Alert.Annotations, e.Client, e.Recorder)
 		if err != nil {
 			return false, err
 		}
@@ -180,7 +201,7 @@ func (e *examiner) processAlert(ds disableScaling) (bool, error) {
 	return false, nil
 }
 
-func addPvc(log logr.Logger, alertLabels model.LabelSet, alertAnnotations model.LabelSet, client client.Client) error {
+func addPvc(log logr.Logger, alertLabels model.LabelSet, alertAnnotations model.LabelSet, client client.Client, recorder record.EventRecorder) error {
 	var storageClassName *string
 
 	if alertAnnotations["storageClass"] != "" {
@@ -229,6 +250,13 @@ func addPvc(log logr.Logger, alertLabels model.LabelSet, alertAnnotations model.
 			},
 		}}
 
+	// the CR is fetched for the event before the volume is added, so a failing lookup can not make the retry of the
+	// alert add a second volume
+	cr, err := k8sutil.GetCr(pvc.Labels["kafka_cr"], string(alertLabels["namespace"]), client)
+	if err != nil {
+		return err
+	}
+
 	err = k8sutil.AddPvToSpecificBroker(pvc.Labels["brokerId"], pvc.Labels["kafka_cr"], string(alertLabels["namespace"]), &storageConfig, client)
 	if err != nil {
 		return err
@@ -236,10 +264,13 @@ func addPvc(log logr.Logger, alertLabels model.LabelSet, alertAnnotations model.
 
 	log.Info(fmt.Sprintf("PV successfully added to broker %s with the following storage configuration: %+v", pvc.Labels["brokerId"], &storageConfig))
 
+	recorder.Eventf(cr, corev1.EventTypeNormal, volumeAddedReason, "Added a %s volume mounted at %s to broker %s as alert %s fired",
+		alertAnnotations["diskSize"], storageConfig.MountPath, pvc.Labels["brokerId"], alertLabels["alertname"])
+
 	return nil
 }
 
-func resizePvc(log logr.Logger, labels model.LabelSet, annotiations model.LabelSet, client client.Client) error {
+func resizePvc(log logr.Logger, labels model.LabelSet, annotiations model.LabelSet, client client.Client, recorder record.EventRecorder) error {
 
 	pvc, err := getPvc(string(labels["persistentvolumeclaim"]), string(labels["namespace"]), client)
 	if err != nil {
@@ -291,11 +322,13 @@ func resizePvc(log logr.Logger, labels model.LabelSet, annotiations model.LabelS
 	}
 
 	log.Info("successfully resized broker pvc", "mount path", pvc.Annotations["mountPath"], "broker id", pvc.Labels["brokerId"])
+	recorder.Eventf(cr, corev1.EventTypeNormal, volumeResizedReason, "Increased the volume mounted at %s of broker %s by %s as alert %s fired",
+		pvc.Annotations["mountPath"], pvc.Labels["brokerId"], incrementBy.String(), labels["alertname"])
 
 	return nil
 }
 
-func downScale(log logr.Logger, labels model.LabelSet, client client.Client) error {
+func downScale(log logr.Logger, labels model.LabelSet, client client.Client, recorder record.EventRecorder) error {
 
 	cr, err := k8sutil.GetCr(string(labels["kafka_cr"]), string(labels["namespace"]), client)
 	if err != nil {
@@ -314,7 +347,7 @@ func downScale(log logr.Logger, labels model.LabelSet, client client.Client) err
 		return nil
 	}
 
-	cc := scale.NewCruiseControlScaler(string(labels["namespace"]), cr.Spec.GetKubernetesClusterDomain(), cr.Spec.CruiseControlConfig.CruiseControlEndpoint, cr.Name)
+	cc := scale.NewCruiseControlScaler(string(labels["namespace"]), cr.Spec.GetKubernetesClusterDomain(), cr.Spec.CruiseControlConfig.CruiseControlEndpoint, cr.Name, cr.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 	brokerId, err := cc.GetBrokerIDWithLeastPartition()
 	if err != nil {
 		return err
@@ -323,10 +356,11 @@ func downScale(log logr.Logger, labels model.LabelSet, client client.Client) err
 	if err != nil {
 		return err
 	}
+	recorder.Eventf(cr, corev1.EventTypeNormal, brokerRemovedReason, "Removing broker %s as alert %s fired", brokerId, labels["alertname"])
 	return nil
 }
 
-func upScale(log logr.Logger, labels model.LabelSet, annotations model.LabelSet, client client.Client) error {
+func upScale(log logr.Logger, labels model.LabelSet, annotations model.LabelSet, client client.Client, recorder record.EventRecorder) error {
 
 	cr, err := k8sutil.GetCr(string(labels["kafka_cr"]), string(labels["namespace"]), client)
 	if err != nil {
@@ -395,6 +429,7 @@ func upScale(log logr.Logger, labels model.LabelSet, annotations model.LabelSet,
 	if err != nil {
 		return err
 	}
+	recorder.Eventf(cr, corev1.EventTypeNormal, brokerAddedReason, "Adding broker %d as alert %s fired", broker.Id, labels["alertname"])
 	return nil
 }
 
@@ -27,6 +27,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -208,12 +209,16 @@ func Test_resizePvc(t *testing.T) {
 				t.Error("kafka cluster creation failed", err)
 			}
 
+			recorder := record.NewFakeRecorder(len(tt.alertList))
 			for _, alert := range tt.alertList {
-				err := resizePvc(logf.NullLogger{}, alert.Labels, alert.Annotations, testClient)
+				err := resizePvc(logf.NullLogger{}, alert.Labels, alert.Annotations, testClient, recorder)
 				if err != nil {
 					t.Errorf("process.resizePvc() error = %v", err)
 				}
 			}
+			if len(recorder.Events) != len(tt.alertList) {
+				t.Errorf("expected an event for every resize, got %d", len(recorder.Events))
+			}
 
 			var kafkaCluster v1beta1.KafkaCluster
 			err = testClient.Get(
@@ -380,7 +385,7 @@ func Test_addPvc(t *testing.T) {
 			}
 
 			for _, alert := range tt.alertList {
-				err := addPvc(logf.NullLogger{}, alert.Labels, alert.Annotations, testClient)
+				err := addPvc(logf.NullLogger{}, alert.Labels, alert.Annotations, testClient, record.NewFakeRecorder(1))
 				if err != nil {
 					t.Errorf("process.addPvc() error = %v", err)
 				}
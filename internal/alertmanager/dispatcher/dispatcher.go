@@ -16,16 +16,19 @@ package dispatcher
 
 import (
 	"github.com/banzaicloud/kafka-operator/internal/alertmanager/currentalert"
+	"github.com/banzaicloud/kafka-operator/pkg/metrics"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/common/model"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Dispatcher calls actors based on alert annotations
-func Dispatcher(promAlerts []model.Alert, log logr.Logger, client client.Client) {
+func Dispatcher(promAlerts []model.Alert, log logr.Logger, client client.Client, recorder record.EventRecorder) {
 
 	storedAlerts := currentalert.GetCurrentAlerts()
 	for _, promAlert := range alertFilter(promAlerts) {
+		metrics.AlertReceived(string(promAlert.Annotations["command"]))
 		store := currentalert.AlertState{
 			FingerPrint: promAlert.Fingerprint(),
 			Status:      promAlert.Status(),
@@ -41,7 +44,7 @@ func Dispatcher(promAlerts []model.Alert, log logr.Logger, client client.Client)
 	rollingUpgradeAlertCount := storedAlerts.GetRollingUpgradeAlertCount()
 	for key, value := range storedAlerts.ListAlerts() {
 		log.Info("Stored Alert", "key", key, "status", value.Status, "labels", value.Labels, "annotations", value.Annotations, "processed", value.Processed)
-		_, err := storedAlerts.HandleAlert(key, client, rollingUpgradeAlertCount, log)
+		_, err := storedAlerts.HandleAlert(key, client, recorder, rollingUpgradeAlertCount, log)
 		if err != nil {
 			log.Error(err, "failed to handle alert", "fingerprint", key)
 		}
@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -27,23 +28,25 @@ const APIEndPoint = "/"
 
 // HTTPController collects the greeting use cases and exposes them as HTTP handlers.
 type HTTPController struct {
-	Logger logr.Logger
-	Client client.Client
+	Logger   logr.Logger
+	Client   client.Client
+	Recorder record.EventRecorder
 }
 
 // NewHTTPHandler returns a new HTTP handler for the greeter.
-func NewHTTPHandler(log logr.Logger, client client.Client) http.Handler {
+func NewHTTPHandler(log logr.Logger, client client.Client, recorder record.EventRecorder) http.Handler {
 	mux := http.NewServeMux()
-	controller := NewHTTPController(log, client)
+	controller := NewHTTPController(log, client, recorder)
 	mux.HandleFunc(APIEndPoint, controller.reciveAlert)
 	return mux
 }
 
 // NewHTTPController returns a new HTTPController instance.
-func NewHTTPController(log logr.Logger, client client.Client) *HTTPController {
+func NewHTTPController(log logr.Logger, client client.Client, recorder record.EventRecorder) *HTTPController {
 	return &HTTPController{
-		Logger: log,
-		Client: client,
+		Logger:   log,
+		Client:   client,
+		Recorder: recorder,
 	}
 }
 
@@ -56,7 +59,7 @@ func (a *HTTPController) reciveAlert(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "reading request body failed", http.StatusInternalServerError)
 			return
 		}
-		err = alertReciever(a.Logger, alert, a.Client)
+		err = alertReciever(a.Logger, alert, a.Client, a.Recorder)
 		if err != nil {
 			http.Error(w, "alert receiver error", http.StatusBadRequest)
 			return
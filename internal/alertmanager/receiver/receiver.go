@@ -20,16 +20,17 @@ import (
 	"github.com/banzaicloud/kafka-operator/internal/alertmanager/dispatcher"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/common/model"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func alertReciever(log logr.Logger, alert []byte, client client.Client) error {
+func alertReciever(log logr.Logger, alert []byte, client client.Client, recorder record.EventRecorder) error {
 	promAlerts := make([]model.Alert, 0)
 	err := json.Unmarshal(alert, &promAlerts)
 	if err != nil {
 		return err
 	}
 
-	dispatcher.Dispatcher(promAlerts, log, client)
+	dispatcher.Dispatcher(promAlerts, log, client, recorder)
 	return nil
 }
@@ -33,6 +33,7 @@ import (
 	"flag"
 	"os"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 
@@ -43,11 +44,13 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	banzaicloudv1alpha1 "github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	banzaicloudv1beta1 "github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/controllers"
 	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/metrics"
 	"github.com/banzaicloud/kafka-operator/pkg/util"
 	"github.com/banzaicloud/kafka-operator/pkg/webhook"
 	// +kubebuilder:scaffold:imports
@@ -81,6 +84,7 @@ func main() {
 		developmentLogging   bool
 		verboseLogging       bool
 		certManagerEnabled   bool
+		topicDriftInterval   time.Duration
 	)
 
 	flag.StringVar(&namespaces, "namespaces", "", "Comma separated list of namespaces where operator listens for resources")
@@ -92,6 +96,8 @@ func main() {
 	flag.BoolVar(&developmentLogging, "development", false, "Enable development logging")
 	flag.BoolVar(&verboseLogging, "verbose", false, "Enable verbose logging")
 	flag.BoolVar(&certManagerEnabled, "cert-manager-enabled", false, "Enable cert-manager integration")
+	flag.DurationVar(&topicDriftInterval, "topic-drift-check-interval", 5*time.Minute,
+		"How often the topics of each cluster are checked for changes made outside of the operator, 0 disables the check")
 	flag.Parse()
 
 	ctrl.SetLogger(util.CreateLogger(verboseLogging, developmentLogging))
@@ -141,6 +147,7 @@ func main() {
 		Namespaces:          namespaceList,
 		Log:                 ctrl.Log.WithName("controllers").WithName("KafkaCluster"),
 		KafkaClientProvider: kafkaclient.NewDefaultProvider(),
+		Recorder:            mgr.GetEventRecorderFor("kafkacluster-controller"),
 	}
 
 	if err = controllers.SetupKafkaClusterWithManager(mgr, kafkaClusterReconciler.Log).Complete(kafkaClusterReconciler); err != nil {
@@ -148,7 +155,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = controllers.SetupKafkaTopicWithManager(mgr); err != nil {
+	if err = controllers.SetupKafkaTopicWithManager(mgr, topicDriftInterval); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KafkaTopic")
 		os.Exit(1)
 	}
@@ -158,10 +165,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = controllers.SetupKafkaConsumerGroupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaConsumerGroup")
+		os.Exit(1)
+	}
+
+	if err = controllers.SetupCruiseControlOperationWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CruiseControlOperation")
+		os.Exit(1)
+	}
+
 	kafkaClusterCCReconciler := &controllers.CruiseControlTaskReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Log:    ctrl.Log.WithName("controller").WithName("CruiseControlTask"),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("controller").WithName("CruiseControlTask"),
+		Recorder: mgr.GetEventRecorderFor("cruisecontroltask-controller"),
 	}
 
 	if err = controllers.SetupCruiseControlWithManager(mgr).Complete(kafkaClusterCCReconciler); err != nil {
@@ -173,6 +191,8 @@ func main() {
 		webhook.SetupServerHandlers(mgr, webhookCertDir)
 	}
 
+	ctrlmetrics.Registry.MustRegister(metrics.NewStatusCollector(mgr.GetClient(), ctrl.Log.WithName("metrics")))
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -14,7 +14,11 @@
 
 package errorfactory
 
-import "emperror.dev/errors"
+import (
+	"reflect"
+
+	"emperror.dev/errors"
+)
 
 // ResourceNotReady states that resource is not ready
 type ResourceNotReady struct{ error }
@@ -58,6 +62,9 @@ type FatalReconcileError struct{ error }
 // ReconcileRollingUpgrade states that rolling upgrade is reconciling
 type ReconcileRollingUpgrade struct{ error }
 
+// RollingUpgradeTimeout states that a broker restarted by the rolling upgrade did not get healthy in time
+type RollingUpgradeTimeout struct{ error }
+
 // CruiseControlNotReady states that CC is not ready to receive connection
 type CruiseControlNotReady struct{ error }
 
@@ -76,6 +83,9 @@ type PerBrokerConfigNotReady struct{ error }
 // LoadBalancerIPNotReady states that the LoadBalancer IP is not yet created
 type LoadBalancerIPNotReady struct{ error }
 
+// ConsumerGroupNotEmpty states that the consumer group has active members
+type ConsumerGroupNotEmpty struct{ error }
+
 // New creates a new error factory error
 func New(t interface{}, err error, msg string, wrapArgs ...interface{}) error {
 	wrapped := errors.WrapIfWithDetails(err, msg, wrapArgs...)
@@ -108,6 +118,8 @@ func New(t interface{}, err error, msg string, wrapArgs ...interface{}) error {
 		return FatalReconcileError{wrapped}
 	case ReconcileRollingUpgrade:
 		return ReconcileRollingUpgrade{wrapped}
+	case RollingUpgradeTimeout:
+		return RollingUpgradeTimeout{wrapped}
 	case CruiseControlNotReady:
 		return CruiseControlNotReady{wrapped}
 	case CruiseControlTaskRunning:
@@ -120,6 +132,18 @@ func New(t interface{}, err error, msg string, wrapArgs ...interface{}) error {
 		return PerBrokerConfigNotReady{wrapped}
 	case LoadBalancerIPNotReady:
 		return LoadBalancerIPNotReady{wrapped}
+	case ConsumerGroupNotEmpty:
+		return ConsumerGroupNotEmpty{wrapped}
 	}
 	return wrapped
 }
+
+// Reason returns the name of the error factory type the error was created with, to be used as
+// the reason of status conditions. Errors from outside of the error factory are reported as ReconcileError.
+func Reason(err error) string {
+	cause := reflect.TypeOf(errors.Cause(err))
+	if cause != nil && cause.PkgPath() == reflect.TypeOf(InternalError{}).PkgPath() {
+		return cause.Name()
+	}
+	return "ReconcileError"
+}
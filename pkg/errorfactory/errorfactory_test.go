@@ -36,8 +36,10 @@ var errorTypes = []error{
 	TooManyResources{},
 	InternalError{},
 	FatalReconcileError{},
+	RollingUpgradeTimeout{},
 	CruiseControlNotReady{},
 	CruiseControlTaskRunning{},
+	ConsumerGroupNotEmpty{},
 }
 
 func TestNew(t *testing.T) {
@@ -53,3 +55,13 @@ func TestNew(t *testing.T) {
 		}
 	}
 }
+
+func TestReason(t *testing.T) {
+	err := emperrors.WrapIf(New(BrokersNotReady{}, errors.New("test-error"), "test-message"), "wrapped")
+	if reason := Reason(err); reason != "BrokersNotReady" {
+		t.Error("Expected: BrokersNotReady got:", reason)
+	}
+	if reason := Reason(errors.New("test-error")); reason != "ReconcileError" {
+		t.Error("Expected: ReconcileError got:", reason)
+	}
+}
@@ -17,7 +17,11 @@ package k8sutil
 import (
 	"context"
 
+	"emperror.dev/errors"
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -29,6 +33,27 @@ func LookupKafkaCluster(client runtimeClient.Client, clusterName, clusterNamespa
 	return
 }
 
+// LookupSASLAdminCredentials returns the SCRAM user name and password stored in the secret referenced by the SASL config of the cluster
+func LookupSASLAdminCredentials(client runtimeClient.Client, cluster *v1beta1.KafkaCluster) (username, password string, err error) {
+	if cluster.Spec.ListenersConfig.SASLConfig == nil {
+		return "", "", errorfactory.New(errorfactory.InternalError{}, errors.New("sasl config is missing"), "sasl listener configured without listenersConfig.saslConfig")
+	}
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Name: cluster.Spec.ListenersConfig.SASLConfig.AdminSecretName, Namespace: cluster.Namespace}
+	if err = client.Get(context.TODO(), secretName, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", "", errorfactory.New(errorfactory.ResourceNotReady{}, err, "sasl admin secret not found", "secret", secretName.Name)
+		}
+		return "", "", errorfactory.New(errorfactory.APIFailure{}, err, "failed to get sasl admin secret", "secret", secretName.Name)
+	}
+	username = string(secret.Data[v1beta1.SASLUsernameKey])
+	password = string(secret.Data[v1beta1.SASLPasswordKey])
+	if username == "" || password == "" {
+		return "", "", errorfactory.New(errorfactory.InternalError{}, errors.New("missing credentials"), "sasl admin secret must contain a username and a password", "secret", secretName.Name)
+	}
+	return username, password, nil
+}
+
 // This could be used if we get rid of the "intermediate" certificate we create for now during cluster creation
 // func LookupControllerSecret(client runtimeClient.Client, clusterName, clusterNamespace, controllerTempl string) (secret *corev1.Secret, err error) {
 // 	secret = &corev1.Secret{}
@@ -105,6 +105,8 @@ func generateBrokerState(brokerIds []string, cluster *banzaicloudv1beta1.KafkaCl
 			brokerState.ConfigurationState = s
 		case banzaicloudv1beta1.PerBrokerConfigurationState:
 			brokerState.PerBrokerConfigurationState = s
+		case banzaicloudv1beta1.LeadershipState:
+			brokerState.LeadershipState = s
 		case map[string]banzaicloudv1beta1.VolumeState:
 			if brokerState.GracefulActionState.VolumeStates == nil {
 				brokerState.GracefulActionState.VolumeStates = make(map[string]banzaicloudv1beta1.VolumeState)
@@ -180,6 +182,16 @@ func UpdateCRStatus(c client.Client, cluster *v1beta1.KafkaCluster, state interf
 		cluster.Status.State = s
 	case banzaicloudv1beta1.CruiseControlTopicStatus:
 		cluster.Status.CruiseControlTopicStatus = s
+	case []banzaicloudv1beta1.ClusterCondition:
+		cluster.Status.Conditions = mergeClusterConditions(cluster.Status.Conditions, s)
+	case banzaicloudv1beta1.KafkaVersionStatus:
+		cluster.Status.KafkaVersion = s
+	case banzaicloudv1beta1.KRaftStatus:
+		cluster.Status.KRaft = s
+	case banzaicloudv1beta1.MaintenanceStatus:
+		cluster.Status.Maintenance = s
+	case banzaicloudv1beta1.TopicImportStatus:
+		cluster.Status.TopicImport = s
 	}
 
 	err := c.Status().Update(context.Background(), cluster)
@@ -202,6 +214,16 @@ func UpdateCRStatus(c client.Client, cluster *v1beta1.KafkaCluster, state interf
 			cluster.Status.State = s
 		case banzaicloudv1beta1.CruiseControlTopicStatus:
 			cluster.Status.CruiseControlTopicStatus = s
+		case []banzaicloudv1beta1.ClusterCondition:
+			cluster.Status.Conditions = mergeClusterConditions(cluster.Status.Conditions, s)
+		case banzaicloudv1beta1.KafkaVersionStatus:
+			cluster.Status.KafkaVersion = s
+		case banzaicloudv1beta1.KRaftStatus:
+			cluster.Status.KRaft = s
+		case banzaicloudv1beta1.MaintenanceStatus:
+			cluster.Status.Maintenance = s
+		case banzaicloudv1beta1.TopicImportStatus:
+			cluster.Status.TopicImport = s
 		}
 
 		err = c.Status().Update(context.Background(), cluster)
@@ -218,12 +240,75 @@ func UpdateCRStatus(c client.Client, cluster *v1beta1.KafkaCluster, state interf
 	return nil
 }
 
+// mergeClusterConditions replaces the existing conditions with the ones of the same type and appends the new ones,
+// keeping the transition time of the conditions whose status did not change
+func mergeClusterConditions(existing, conditions []v1beta1.ClusterCondition) []v1beta1.ClusterCondition {
+	merged := append([]v1beta1.ClusterCondition(nil), existing...)
+	for _, condition := range conditions {
+		condition.LastTransitionTime = metav1.Now()
+		found := false
+		for i := range merged {
+			if merged[i].Type != condition.Type {
+				continue
+			}
+			if merged[i].Status == condition.Status {
+				condition.LastTransitionTime = merged[i].LastTransitionTime
+			}
+			merged[i] = condition
+			found = true
+		}
+		if !found {
+			merged = append(merged, condition)
+		}
+	}
+	return merged
+}
+
 // UpdateRollingUpgradeState updates the state of the cluster with rolling upgrade info
 func UpdateRollingUpgradeState(c client.Client, cluster *v1beta1.KafkaCluster, time time.Time, logger logr.Logger) error {
+	timeStamp := time.Format("2006-01-02 15:04:05")
+	err := updateRollingUpgradeStatus(c, cluster, func(status *v1beta1.RollingUpgradeStatus) {
+		status.LastSuccess = timeStamp
+		// the rolling upgrade is finished, the next one starts without waiting for the last restart
+		status.LastBrokerRestart = ""
+		status.GateReason = ""
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("Rolling upgrade status updated", "status", timeStamp)
+	return nil
+}
+
+// UpdateRollingUpgradeBrokerRestart records the time a broker pod was deleted by the rolling upgrade
+func UpdateRollingUpgradeBrokerRestart(c client.Client, cluster *v1beta1.KafkaCluster, restartTime time.Time, logger logr.Logger) error {
+	timeStamp := restartTime.UTC().Format(time.RFC3339)
+	err := updateRollingUpgradeStatus(c, cluster, func(status *v1beta1.RollingUpgradeStatus) {
+		status.LastBrokerRestart = timeStamp
+	})
+	if err != nil {
+		return err
+	}
+	logger.V(1).Info("Rolling upgrade broker restart recorded", "time", timeStamp)
+	return nil
+}
+
+// UpdateRollingUpgradeGate records why the rolling upgrade is held back, an empty reason releases the gate
+func UpdateRollingUpgradeGate(c client.Client, cluster *v1beta1.KafkaCluster, reason string, logger logr.Logger) error {
+	err := updateRollingUpgradeStatus(c, cluster, func(status *v1beta1.RollingUpgradeStatus) {
+		status.GateReason = reason
+	})
+	if err != nil {
+		return err
+	}
+	logger.V(1).Info("Rolling upgrade gate recorded", "reason", reason)
+	return nil
+}
+
+func updateRollingUpgradeStatus(c client.Client, cluster *v1beta1.KafkaCluster, update func(*v1beta1.RollingUpgradeStatus)) error {
 	typeMeta := cluster.TypeMeta
 
-	timeStamp := time.Format("2006-01-02 15:04:05")
-	cluster.Status.RollingUpgrade.LastSuccess = timeStamp
+	update(&cluster.Status.RollingUpgrade)
 
 	err := c.Status().Update(context.Background(), cluster)
 	if apierrors.IsNotFound(err) {
@@ -241,7 +326,7 @@ func UpdateRollingUpgradeState(c client.Client, cluster *v1beta1.KafkaCluster, t
 			return errors.WrapIf(err, "could not get config for updating status")
 		}
 
-		cluster.Status.RollingUpgrade.LastSuccess = timeStamp
+		update(&cluster.Status.RollingUpgrade)
 
 		err = c.Status().Update(context.Background(), cluster)
 		if apierrors.IsNotFound(err) {
@@ -253,7 +338,6 @@ func UpdateRollingUpgradeState(c client.Client, cluster *v1beta1.KafkaCluster, t
 	}
 	// update loses the typeMeta of the config that's used later when setting ownerrefs
 	cluster.TypeMeta = typeMeta
-	logger.Info("Rolling upgrade status updated", "status", timeStamp)
 	return nil
 }
 
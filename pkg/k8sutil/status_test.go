@@ -0,0 +1,58 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+func TestMergeClusterConditions(t *testing.T) {
+	transition := metav1.NewTime(time.Now().Add(-time.Hour))
+	existing := []v1beta1.ClusterCondition{
+		{Type: v1beta1.ClusterConditionReady, Status: metav1.ConditionTrue, LastTransitionTime: transition},
+		{Type: v1beta1.ClusterConditionPKIReady, Status: metav1.ConditionTrue, LastTransitionTime: transition},
+		{Type: v1beta1.ClusterConditionDegraded, Status: metav1.ConditionFalse, LastTransitionTime: transition},
+	}
+
+	merged := mergeClusterConditions(existing, []v1beta1.ClusterCondition{
+		{Type: v1beta1.ClusterConditionReady, Status: metav1.ConditionTrue, Reason: "Reconciled"},
+		{Type: v1beta1.ClusterConditionDegraded, Status: metav1.ConditionTrue, Reason: "APIFailure"},
+		{Type: v1beta1.ClusterConditionConfigInSync, Status: metav1.ConditionTrue},
+	})
+
+	if len(merged) != 4 {
+		t.Fatal("Expected 4 conditions, got:", merged)
+	}
+	if merged[0].Reason != "Reconciled" || !merged[0].LastTransitionTime.Equal(&transition) {
+		t.Error("Expected unchanged status to keep its transition time, got:", merged[0])
+	}
+	if merged[1].Type != v1beta1.ClusterConditionPKIReady || !merged[1].LastTransitionTime.Equal(&transition) {
+		t.Error("Expected condition missing from the update to be kept, got:", merged[1])
+	}
+	if merged[2].Status != metav1.ConditionTrue || merged[2].LastTransitionTime.Equal(&transition) {
+		t.Error("Expected changed status to get a new transition time, got:", merged[2])
+	}
+	if merged[3].Type != v1beta1.ClusterConditionConfigInSync {
+		t.Error("Expected new condition to be appended, got:", merged[3])
+	}
+	if existing[2].Status != metav1.ConditionFalse {
+		t.Error("Expected existing conditions to be left untouched")
+	}
+}
@@ -34,20 +34,44 @@ type KafkaClient interface {
 	NumBrokers() int
 	ListTopics() (map[string]sarama.TopicDetail, error)
 	CreateTopic(*CreateTopicOptions) error
+	ValidateTopic(*CreateTopicOptions) error
 	EnsurePartitionCount(string, int32) (bool, error)
 	EnsureTopicConfig(string, map[string]*string) error
+	ValidateTopicConfig(string, map[string]*string) error
 	DeleteTopic(string, bool) error
 	GetTopic(string) (*sarama.TopicDetail, error)
 	DescribeTopic(string) (*sarama.TopicMetadata, error)
+	DescribeTopicConfig(string) ([]sarama.ConfigEntry, error)
+	ListTopicReassignments(string) (map[int32]*sarama.PartitionReplicaReassignmentsStatus, error)
+	AlterTopicReplicationFactor(string, int32) error
 	CreateUserACLs(v1alpha1.KafkaAccessType, v1alpha1.KafkaPatternType, string, string) error
+	CreateUserGroupACLs(v1alpha1.KafkaPatternType, string, string) error
+	CreateUserTransactionalIDACLs(v1alpha1.KafkaPatternType, string, string) error
+	CreateUserClusterACLs(v1alpha1.KafkaClusterOperation, string) error
 	ListUserACLs() ([]sarama.ResourceAcls, error)
+	DescribeUserACLs(string) ([]sarama.ResourceAcls, error)
+	DeleteUserACL(sarama.Resource, sarama.Acl) error
 	DeleteUserACLs(string) error
+	UpsertUserSCRAMCredentials(string, string) error
+	HasUserSCRAMCredentials(string) (bool, error)
+	DeleteUserSCRAMCredentials(string) error
+	DescribeUserQuotas(string) (*v1alpha1.UserQuotas, error)
+	AlterUserQuotas(string, *v1alpha1.UserQuotas) error
+	DescribeConsumerGroup(string) (*sarama.GroupDescription, error)
+	ConsumerGroupLag(string) ([]v1alpha1.ConsumerGroupLag, error)
+	ResetConsumerGroupOffsets(string, *v1alpha1.ConsumerGroupOffsetReset) error
 
 	Brokers() map[int32]string
 	DescribeCluster() ([]*sarama.Broker, int32, error)
 
 	OfflineReplicaCount() (int, error)
 	AllReplicaInSync() (bool, error)
+	UnderMinISRPartitionCount() (int, error)
+
+	LeaderPartitions(int32) (map[string][]int32, error)
+	PreferredLeaderPartitions(int32) (map[string][]int32, error)
+	OutOfSyncPartitions(int32) (map[string][]int32, error)
+	ReorderPartitionReplicas(int32, map[string][]int32, bool) error
 
 	AlterPerBrokerConfig(int32, map[string]*string, bool) error
 	DescribePerBrokerConfig(int32, []string) ([]*sarama.ConfigEntry, error)
@@ -70,8 +94,9 @@ type kafkaClient struct {
 	brokers []*sarama.Broker
 
 	// client funcs for mocking
-	newClusterAdmin func([]string, *sarama.Config) (sarama.ClusterAdmin, error)
-	newClient       func([]string, *sarama.Config) (sarama.Client, error)
+	newClusterAdmin  func([]string, *sarama.Config) (sarama.ClusterAdmin, error)
+	newClient        func([]string, *sarama.Config) (sarama.Client, error)
+	newOffsetManager func(string, sarama.Client) (sarama.OffsetManager, error)
 }
 
 func New(opts *KafkaConfig) KafkaClient {
@@ -81,6 +106,7 @@ func New(opts *KafkaConfig) KafkaClient {
 	}
 	kclient.newClusterAdmin = sarama.NewClusterAdmin
 	kclient.newClient = sarama.NewClient
+	kclient.newOffsetManager = sarama.NewOffsetManagerFromClient
 	return kclient
 }
 
@@ -155,6 +181,13 @@ func (k *kafkaClient) getSaramaConfig() (config *sarama.Config) {
 		config.Net.TLS.Enable = true
 		config.Net.TLS.Config = k.opts.TLSConfig
 	}
+	if k.opts.UseSASL {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		config.Net.SASL.User = k.opts.SASLUser
+		config.Net.SASL.Password = k.opts.SASLPassword
+		config.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMSHA512Client
+	}
 	config.Version = apiVersion
 	return
 }
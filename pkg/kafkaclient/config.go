@@ -20,6 +20,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
 	"github.com/banzaicloud/kafka-operator/pkg/pki"
 	clientutil "github.com/banzaicloud/kafka-operator/pkg/util/client"
 )
@@ -32,6 +33,10 @@ type KafkaConfig struct {
 	UseSSL    bool
 	TLSConfig *tls.Config
 
+	UseSASL      bool
+	SASLUser     string
+	SASLPassword string
+
 	OperationTimeout int64
 }
 
@@ -48,5 +53,14 @@ func ClusterConfig(client client.Client, cluster *v1beta1.KafkaCluster) (*KafkaC
 		conf.UseSSL = true
 		conf.TLSConfig = tlsConfig
 	}
+	if clientutil.UseSASL(cluster) {
+		username, password, err := k8sutil.LookupSASLAdminCredentials(client, cluster)
+		if err != nil {
+			return conf, err
+		}
+		conf.UseSASL = true
+		conf.SASLUser = username
+		conf.SASLPassword = password
+	}
 	return conf, nil
 }
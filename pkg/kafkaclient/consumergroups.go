@@ -0,0 +1,234 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"fmt"
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+// DescribeConsumerGroup returns the state and the members of the consumer group
+func (k *kafkaClient) DescribeConsumerGroup(groupID string) (*sarama.GroupDescription, error) {
+	groups, err := k.admin.DescribeConsumerGroups([]string{groupID})
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe consumer group", "group", groupID)
+	}
+	if len(groups) == 0 {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, errors.New("empty response"), "could not describe consumer group", "group", groupID)
+	}
+	if groups[0].Err != sarama.ErrNoError {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, groups[0].Err, "could not describe consumer group", "group", groupID)
+	}
+	return groups[0], nil
+}
+
+// ConsumerGroupLag returns the lag of the consumer group on every partition it committed an offset for
+func (k *kafkaClient) ConsumerGroupLag(groupID string) ([]v1alpha1.ConsumerGroupLag, error) {
+	offsets, err := k.listConsumerGroupOffsets(groupID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	lag := make([]v1alpha1.ConsumerGroupLag, 0)
+	for topic, partitions := range offsets {
+		for partition, committed := range partitions {
+			if committed < 0 {
+				// nothing committed on the partition
+				continue
+			}
+			logEndOffset, err := k.client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not get log end offset", "topic", topic, "partition", partition)
+			}
+			partitionLag := logEndOffset - committed
+			if partitionLag < 0 {
+				partitionLag = 0
+			}
+			lag = append(lag, v1alpha1.ConsumerGroupLag{
+				Topic:           topic,
+				Partition:       partition,
+				CommittedOffset: committed,
+				LogEndOffset:    logEndOffset,
+				Lag:             partitionLag,
+			})
+		}
+	}
+	sort.Slice(lag, func(i, j int) bool {
+		if lag[i].Topic != lag[j].Topic {
+			return lag[i].Topic < lag[j].Topic
+		}
+		return lag[i].Partition < lag[j].Partition
+	})
+	return lag, nil
+}
+
+// ResetConsumerGroupOffsets moves the committed offsets of the consumer group on a topic.
+// The group has to be empty, otherwise its members would overwrite the reset offsets.
+func (k *kafkaClient) ResetConsumerGroupOffsets(groupID string, reset *v1alpha1.ConsumerGroupOffsetReset) error {
+	group, err := k.DescribeConsumerGroup(groupID)
+	if err != nil {
+		return err
+	}
+	if len(group.Members) > 0 {
+		return errorfactory.New(errorfactory.ConsumerGroupNotEmpty{},
+			fmt.Errorf("consumer group has %d members", len(group.Members)), "could not reset consumer group offsets", "group", groupID)
+	}
+
+	targets, err := k.offsetResetTargets(reset)
+	if err != nil {
+		return err
+	}
+
+	offsetManager, err := k.newOffsetManager(groupID, k.client)
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not create offset manager", "group", groupID)
+	}
+	partitionManagers := make([]sarama.PartitionOffsetManager, 0, len(targets))
+	for partition, offset := range targets {
+		partitionManager, err := offsetManager.ManagePartition(reset.Topic, partition)
+		if err != nil {
+			closeOffsetManagers(offsetManager, partitionManagers)
+			return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not manage partition offset", "group", groupID, "partition", partition)
+		}
+		// sarama only marks offsets ahead of the current one and only resets to offsets behind it,
+		// a group without a committed offset starts from -1
+		partitionManager.MarkOffset(offset, "")
+		partitionManager.ResetOffset(offset, "")
+		partitionManagers = append(partitionManagers, partitionManager)
+	}
+	offsetManager.Commit()
+	closeOffsetManagers(offsetManager, partitionManagers)
+
+	// the offset manager only logs commit errors, so check what was committed
+	partitions := make([]int32, 0, len(targets))
+	for partition := range targets {
+		partitions = append(partitions, partition)
+	}
+	committed, err := k.listConsumerGroupOffsets(groupID, map[string][]int32{reset.Topic: partitions})
+	if err != nil {
+		return err
+	}
+	for partition, offset := range targets {
+		if committed[reset.Topic][partition] != offset {
+			return errorfactory.New(errorfactory.BrokersRequestError{}, errors.New("committed offset differs from the reset offset"),
+				"could not reset consumer group offsets", "group", groupID, "partition", partition, "offset", offset)
+		}
+	}
+	return nil
+}
+
+// offsetResetTargets returns the offset per partition the reset moves the group to,
+// explicit offsets are kept within the offsets available on the partition
+func (k *kafkaClient) offsetResetTargets(reset *v1alpha1.ConsumerGroupOffsetReset) (map[int32]int64, error) {
+	partitions := reset.Partitions
+	if reset.Strategy == v1alpha1.OffsetResetStrategyOffsets {
+		partitions = make([]int32, 0, len(reset.Offsets))
+		for _, offset := range reset.Offsets {
+			partitions = append(partitions, offset.Partition)
+		}
+	} else if len(partitions) == 0 {
+		var err error
+		if partitions, err = k.client.Partitions(reset.Topic); err != nil {
+			return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list topic partitions", "topic", reset.Topic)
+		}
+	}
+
+	targets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		earliest, err := k.client.GetOffset(reset.Topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not get earliest offset", "topic", reset.Topic, "partition", partition)
+		}
+		latest, err := k.client.GetOffset(reset.Topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not get latest offset", "topic", reset.Topic, "partition", partition)
+		}
+
+		switch reset.Strategy {
+		case v1alpha1.OffsetResetStrategyEarliest:
+			targets[partition] = earliest
+		case v1alpha1.OffsetResetStrategyLatest:
+			targets[partition] = latest
+		case v1alpha1.OffsetResetStrategyTimestamp:
+			if reset.Timestamp == nil {
+				return nil, errorfactory.New(errorfactory.InternalError{}, errors.New("missing timestamp"), "invalid offset reset", "id", reset.ID)
+			}
+			offset, err := k.client.GetOffset(reset.Topic, partition, *reset.Timestamp)
+			if err != nil {
+				return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not get offset for timestamp", "topic", reset.Topic, "partition", partition)
+			}
+			// no message at or after the timestamp
+			if offset < 0 {
+				offset = latest
+			}
+			targets[partition] = offset
+		case v1alpha1.OffsetResetStrategyOffsets:
+			for _, offset := range reset.Offsets {
+				if offset.Partition != partition {
+					continue
+				}
+				switch {
+				case offset.Offset < earliest:
+					targets[partition] = earliest
+				case offset.Offset > latest:
+					targets[partition] = latest
+				default:
+					targets[partition] = offset.Offset
+				}
+			}
+		default:
+			return nil, errorfactory.New(errorfactory.InternalError{}, fmt.Errorf("unknown strategy: %s", reset.Strategy), "invalid offset reset", "id", reset.ID)
+		}
+	}
+	return targets, nil
+}
+
+func (k *kafkaClient) listConsumerGroupOffsets(groupID string, topicPartitions map[string][]int32) (map[string]map[int32]int64, error) {
+	response, err := k.admin.ListConsumerGroupOffsets(groupID, topicPartitions)
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list consumer group offsets", "group", groupID)
+	}
+	if response.Err != sarama.ErrNoError {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, response.Err, "could not list consumer group offsets", "group", groupID)
+	}
+	offsets := make(map[string]map[int32]int64, len(response.Blocks))
+	for topic, partitions := range response.Blocks {
+		offsets[topic] = make(map[int32]int64, len(partitions))
+		for partition, block := range partitions {
+			if block.Err != sarama.ErrNoError {
+				return nil, errorfactory.New(errorfactory.BrokersRequestError{}, block.Err, "could not list consumer group offsets", "group", groupID, "topic", topic)
+			}
+			offsets[topic][partition] = block.Offset
+		}
+	}
+	return offsets, nil
+}
+
+func closeOffsetManagers(offsetManager sarama.OffsetManager, partitionManagers []sarama.PartitionOffsetManager) {
+	for _, partitionManager := range partitionManagers {
+		if err := partitionManager.Close(); err != nil {
+			log.Error(err, "could not close partition offset manager")
+		}
+	}
+	if err := offsetManager.Close(); err != nil {
+		log.Error(err, "could not close offset manager")
+	}
+}
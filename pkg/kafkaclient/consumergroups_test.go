@@ -0,0 +1,140 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+// newConsumerGroupMockClient returns a client whose admin and client share the mocked offsets
+func newConsumerGroupMockClient() (*kafkaClient, *mockClusterAdmin) {
+	client := newOpenedMockClient()
+	admin := newEmptyMockClusterAdmin(false)
+	client.admin = admin
+	client.client = admin
+	admin.mockTopics["test-topic"] = sarama.TopicDetail{NumPartitions: 2, ReplicationFactor: 1}
+	return client, admin
+}
+
+func TestConsumerGroupLag(t *testing.T) {
+	client, admin := newConsumerGroupMockClient()
+	admin.mockOffsets["test-group"] = map[string]map[int32]int64{
+		"test-topic": {1: 40, 0: 100, 2: -1},
+	}
+
+	lag, err := client.ConsumerGroupLag("test-group")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := []v1alpha1.ConsumerGroupLag{
+		{Topic: "test-topic", Partition: 0, CommittedOffset: 100, LogEndOffset: mockLogEndOffset, Lag: 0},
+		{Topic: "test-topic", Partition: 1, CommittedOffset: 40, LogEndOffset: mockLogEndOffset, Lag: 60},
+	}
+	if !reflect.DeepEqual(lag, expected) {
+		t.Error("Expected:", expected, "got:", lag)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err := client.ConsumerGroupLag("test-group"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestResetConsumerGroupOffsets(t *testing.T) {
+	client, admin := newConsumerGroupMockClient()
+
+	cases := []struct {
+		reset    *v1alpha1.ConsumerGroupOffsetReset
+		expected map[int32]int64
+	}{
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "latest", Topic: "test-topic", Strategy: v1alpha1.OffsetResetStrategyLatest,
+			},
+			expected: map[int32]int64{0: mockLogEndOffset, 1: mockLogEndOffset},
+		},
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "earliest", Topic: "test-topic", Partitions: []int32{1}, Strategy: v1alpha1.OffsetResetStrategyEarliest,
+			},
+			expected: map[int32]int64{0: mockLogEndOffset, 1: 0},
+		},
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "timestamp", Topic: "test-topic", Strategy: v1alpha1.OffsetResetStrategyTimestamp, Timestamp: util.Int64Pointer(255),
+			},
+			expected: map[int32]int64{0: 26, 1: 26},
+		},
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "timestamp-in-future", Topic: "test-topic", Partitions: []int32{0}, Strategy: v1alpha1.OffsetResetStrategyTimestamp, Timestamp: util.Int64Pointer(5000),
+			},
+			expected: map[int32]int64{0: mockLogEndOffset, 1: 26},
+		},
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "offsets", Topic: "test-topic", Strategy: v1alpha1.OffsetResetStrategyOffsets,
+				Offsets: []v1alpha1.PartitionOffset{{Partition: 0, Offset: 42}, {Partition: 1, Offset: 500}},
+			},
+			expected: map[int32]int64{0: 42, 1: mockLogEndOffset},
+		},
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "offsets-forward", Topic: "test-topic", Strategy: v1alpha1.OffsetResetStrategyOffsets,
+				Offsets: []v1alpha1.PartitionOffset{{Partition: 0, Offset: 80}},
+			},
+			expected: map[int32]int64{0: 80, 1: mockLogEndOffset},
+		},
+		{
+			reset: &v1alpha1.ConsumerGroupOffsetReset{
+				ID: "latest-again", Topic: "test-topic", Strategy: v1alpha1.OffsetResetStrategyLatest,
+			},
+			expected: map[int32]int64{0: mockLogEndOffset, 1: mockLogEndOffset},
+		},
+	}
+
+	for _, testCase := range cases {
+		if err := client.ResetConsumerGroupOffsets("test-group", testCase.reset); err != nil {
+			t.Error("Expected no error for", testCase.reset.ID, "got:", err)
+		}
+		if offsets := admin.mockOffsets["test-group"]["test-topic"]; !reflect.DeepEqual(offsets, testCase.expected) {
+			t.Error("Expected:", testCase.expected, "for", testCase.reset.ID, "got:", offsets)
+		}
+	}
+
+	if err := client.ResetConsumerGroupOffsets("test-group", &v1alpha1.ConsumerGroupOffsetReset{
+		ID: "no-timestamp", Topic: "test-topic", Strategy: v1alpha1.OffsetResetStrategyTimestamp,
+	}); err == nil {
+		t.Error("Expected error for missing timestamp, got nil")
+	}
+
+	admin.mockGroups["test-group"] = &sarama.GroupDescription{
+		GroupId: "test-group",
+		State:   "Stable",
+		Members: map[string]*sarama.GroupMemberDescription{"consumer-1": {ClientId: "consumer", ClientHost: "/10.0.0.1"}},
+	}
+	err := client.ResetConsumerGroupOffsets("test-group", cases[0].reset)
+	if _, ok := errors.Cause(err).(errorfactory.ConsumerGroupNotEmpty); !ok {
+		t.Error("Expected ConsumerGroupNotEmpty error, got:", err)
+	}
+}
@@ -16,6 +16,7 @@ package kafkaclient
 
 import (
 	"fmt"
+	"strconv"
 
 	"emperror.dev/errors"
 )
@@ -71,3 +72,54 @@ func (k *kafkaClient) AllReplicaInSync() (bool, error) {
 	log.Info("all replicas are in sync")
 	return true, nil
 }
+
+// UnderMinISRPartitionCount returns the number of partitions with fewer in-sync replicas than the
+// min.insync.replicas of their topic, these partitions refuse the writes of producers using acks=all
+func (k *kafkaClient) UnderMinISRPartitionCount() (int, error) {
+	availableTopics, err := k.client.Topics()
+	if err != nil {
+		return 0, errors.WrapIf(err, "could not fetch topics")
+	}
+	underMinISRCount := 0
+	for _, topic := range availableTopics {
+		minISR, err := k.topicMinISR(topic)
+		if err != nil {
+			return 0, err
+		}
+		partitions, err := k.client.Partitions(topic)
+		if err != nil {
+			return 0, errors.WrapIfWithDetails(err, "could not fetch partition", "topic", topic)
+		}
+		for _, partition := range partitions {
+			isrReplicas, err := k.client.InSyncReplicas(topic, partition)
+			if err != nil {
+				return 0, errors.WrapIfWithDetails(err, "could not fetch isr replicas", "topic", topic, "partition", partition)
+			}
+			if len(isrReplicas) < minISR {
+				underMinISRCount++
+			}
+		}
+	}
+	log.Info(fmt.Sprintf("under min ISR partition count is %d", underMinISRCount))
+	return underMinISRCount, nil
+}
+
+// topicMinISR returns the effective min.insync.replicas of the topic, the brokers report the
+// cluster default for the topics not overriding it
+func (k *kafkaClient) topicMinISR(topic string) (int, error) {
+	entries, err := k.DescribeTopicConfig(topic)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if entry.Name != "min.insync.replicas" {
+			continue
+		}
+		minISR, err := strconv.Atoi(entry.Value)
+		if err != nil {
+			return 0, errors.WrapIfWithDetails(err, "invalid min.insync.replicas", "topic", topic, "value", entry.Value)
+		}
+		return minISR, nil
+	}
+	return 1, nil
+}
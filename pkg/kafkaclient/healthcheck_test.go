@@ -0,0 +1,60 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+func TestUnderMinISRPartitionCount(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := newEmptyMockClusterAdmin(false)
+	client.admin = admin
+	client.client = admin
+	admin.mockTopics["strict-topic"] = sarama.TopicDetail{
+		NumPartitions:     2,
+		ReplicationFactor: 3,
+		ConfigEntries:     map[string]*string{"min.insync.replicas": util.StringPointer("2")},
+	}
+	admin.mockTopics["default-topic"] = sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 3}
+	admin.mockPartitions["strict-topic"] = []*sarama.PartitionMetadata{
+		{ID: 0, Replicas: []int32{0, 1, 2}, Isr: []int32{0}},
+		{ID: 1, Replicas: []int32{0, 1, 2}, Isr: []int32{0, 1}},
+	}
+	admin.mockPartitions["default-topic"] = []*sarama.PartitionMetadata{
+		{ID: 0, Replicas: []int32{0, 1, 2}, Isr: []int32{2}},
+	}
+
+	count, err := client.UnderMinISRPartitionCount()
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if count != 1 {
+		t.Error("Expected one partition under min ISR, got:", count)
+	}
+
+	admin.mockTopics["invalid-topic"] = sarama.TopicDetail{
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		ConfigEntries:     map[string]*string{"min.insync.replicas": util.StringPointer("two")},
+	}
+	if _, err := client.UnderMinISRPartitionCount(); err == nil {
+		t.Error("Expected error for invalid min.insync.replicas, got nil")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+// LeaderPartitions returns the partitions led by the broker, by topic, that have another in-sync replica to take over
+func (k *kafkaClient) LeaderPartitions(brokerId int32) (map[string][]int32, error) {
+	return k.brokerPartitions(func(partition *sarama.PartitionMetadata) bool {
+		return partition.Leader == brokerId && len(partition.Isr) > 1
+	})
+}
+
+// PreferredLeaderPartitions returns the replicated partitions the broker is the preferred leader of, by topic
+func (k *kafkaClient) PreferredLeaderPartitions(brokerId int32) (map[string][]int32, error) {
+	return k.brokerPartitions(func(partition *sarama.PartitionMetadata) bool {
+		return len(partition.Replicas) > 1 && partition.Replicas[0] == brokerId
+	})
+}
+
+// OutOfSyncPartitions returns the partitions, by topic, whose replica on the broker is not in sync
+func (k *kafkaClient) OutOfSyncPartitions(brokerId int32) (map[string][]int32, error) {
+	return k.brokerPartitions(func(partition *sarama.PartitionMetadata) bool {
+		return containsBroker(partition.Replicas, brokerId) && !containsBroker(partition.Isr, brokerId)
+	})
+}
+
+func (k *kafkaClient) brokerPartitions(match func(*sarama.PartitionMetadata) bool) (map[string][]int32, error) {
+	topics, err := k.admin.ListTopics()
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list topics")
+	}
+	partitions := make(map[string][]int32)
+	for topic := range topics {
+		meta, err := k.DescribeTopic(topic)
+		if err != nil {
+			return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe topic", "topic", topic)
+		}
+		for _, partition := range meta.Partitions {
+			if match(partition) {
+				partitions[topic] = append(partitions[topic], partition.ID)
+			}
+		}
+		sort.Slice(partitions[topic], func(i, j int) bool { return partitions[topic][i] < partitions[topic][j] })
+	}
+	return partitions, nil
+}
+
+// ReorderPartitionReplicas moves the broker to the front of the replicas of the given partitions, making it
+// their preferred leader, or to their end. The replica sets stay the same, so no data is moved, but the
+// leaders only change with the next preferred leader election.
+func (k *kafkaClient) ReorderPartitionReplicas(brokerId int32, partitions map[string][]int32, preferred bool) error {
+	for topic, ids := range partitions {
+		// a reassignment sent for the topic would override the ongoing one
+		reassignments, err := k.ListTopicReassignments(topic)
+		if err != nil {
+			return err
+		}
+		if len(reassignments) > 0 {
+			return errorfactory.New(errorfactory.BrokersRequestError{}, errors.New("partition reassignment in progress"),
+				"could not reorder replicas", "topic", topic)
+		}
+
+		meta, err := k.DescribeTopic(topic)
+		if err != nil {
+			return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe topic", "topic", topic)
+		}
+		assignment := make([][]int32, len(meta.Partitions))
+		for _, partition := range meta.Partitions {
+			if int(partition.ID) >= len(assignment) {
+				return errorfactory.New(errorfactory.InternalError{}, errors.New("missing metadata of partitions"),
+					"could not reorder replicas", "topic", topic)
+			}
+			assignment[partition.ID] = partition.Replicas
+		}
+
+		changed := false
+		for _, id := range ids {
+			if int(id) >= len(assignment) || !containsBroker(assignment[id], brokerId) {
+				continue
+			}
+			reordered := moveReplica(assignment[id], brokerId, preferred)
+			for i := range reordered {
+				if reordered[i] != assignment[id][i] {
+					changed = true
+				}
+			}
+			assignment[id] = reordered
+		}
+		if !changed {
+			continue
+		}
+		if err := k.admin.AlterPartitionReassignments(topic, assignment); err != nil {
+			return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not reorder replicas", "topic", topic)
+		}
+	}
+	return nil
+}
+
+// moveReplica returns a copy of the replicas with the broker moved to the front or to the end
+func moveReplica(replicas []int32, brokerId int32, front bool) []int32 {
+	reordered := make([]int32, 0, len(replicas))
+	if front {
+		reordered = append(reordered, brokerId)
+	}
+	for _, replica := range replicas {
+		if replica != brokerId {
+			reordered = append(reordered, replica)
+		}
+	}
+	if !front {
+		reordered = append(reordered, brokerId)
+	}
+	return reordered
+}
+
+func containsBroker(brokers []int32, brokerId int32) bool {
+	for _, broker := range brokers {
+		if broker == brokerId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func newLeadershipMockClient() (*kafkaClient, *mockClusterAdmin) {
+	client := newOpenedMockClient()
+	admin := newEmptyMockClusterAdmin(false)
+	client.admin = admin
+	admin.mockTopics["test-topic"] = sarama.TopicDetail{NumPartitions: 3, ReplicationFactor: 2}
+	admin.mockPartitions["test-topic"] = []*sarama.PartitionMetadata{
+		{ID: 0, Leader: 1, Replicas: []int32{1, 2}, Isr: []int32{1, 2}},
+		{ID: 1, Leader: 1, Replicas: []int32{2, 1}, Isr: []int32{1}},
+		{ID: 2, Leader: 2, Replicas: []int32{2, 1}, Isr: []int32{2}},
+	}
+	return client, admin
+}
+
+func TestBrokerPartitions(t *testing.T) {
+	client, _ := newLeadershipMockClient()
+
+	if led, err := client.LeaderPartitions(1); err != nil {
+		t.Error("Expected no error, got:", err)
+	} else if expected := map[string][]int32{"test-topic": {0}}; !reflect.DeepEqual(led, expected) {
+		t.Error("Expected:", expected, "got:", led)
+	}
+	if preferred, err := client.PreferredLeaderPartitions(2); err != nil {
+		t.Error("Expected no error, got:", err)
+	} else if expected := map[string][]int32{"test-topic": {1, 2}}; !reflect.DeepEqual(preferred, expected) {
+		t.Error("Expected:", expected, "got:", preferred)
+	}
+	if outOfSync, err := client.OutOfSyncPartitions(1); err != nil {
+		t.Error("Expected no error, got:", err)
+	} else if expected := map[string][]int32{"test-topic": {2}}; !reflect.DeepEqual(outOfSync, expected) {
+		t.Error("Expected:", expected, "got:", outOfSync)
+	}
+}
+
+func TestReorderPartitionReplicas(t *testing.T) {
+	client, admin := newLeadershipMockClient()
+
+	if err := client.ReorderPartitionReplicas(1, map[string][]int32{"test-topic": {0}}, false); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := [][]int32{{2, 1}, {2, 1}, {2, 1}}
+	for i, partition := range admin.mockPartitions["test-topic"] {
+		if !reflect.DeepEqual(partition.Replicas, expected[i]) {
+			t.Error("Expected:", expected[i], "got:", partition.Replicas)
+		}
+	}
+
+	if err := client.ReorderPartitionReplicas(1, map[string][]int32{"test-topic": {0, 1}}, true); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected = [][]int32{{1, 2}, {1, 2}, {2, 1}}
+	for i, partition := range admin.mockPartitions["test-topic"] {
+		if !reflect.DeepEqual(partition.Replicas, expected[i]) {
+			t.Error("Expected:", expected[i], "got:", partition.Replicas)
+		}
+	}
+
+	admin.mockReassignments["test-topic"] = map[int32]*sarama.PartitionReplicaReassignmentsStatus{0: {}}
+	if err := client.ReorderPartitionReplicas(1, map[string][]int32{"test-topic": {2}}, true); err == nil {
+		t.Error("Expected error while a reassignment is in progress, got nil")
+	}
+}
+
+func TestMoveReplica(t *testing.T) {
+	if replicas := moveReplica([]int32{3, 1, 2}, 3, false); !reflect.DeepEqual(replicas, []int32{1, 2, 3}) {
+		t.Error("Expected the broker to be moved to the end, got:", replicas)
+	}
+	if replicas := moveReplica([]int32{1, 2, 3}, 3, true); !reflect.DeepEqual(replicas, []int32{3, 1, 2}) {
+		t.Error("Expected the broker to be moved to the front, got:", replicas)
+	}
+}
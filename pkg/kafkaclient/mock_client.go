@@ -16,6 +16,7 @@ package kafkaclient
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -31,6 +32,25 @@ type mockClusterAdmin struct {
 	failOps    bool
 	mockTopics map[string]sarama.TopicDetail
 	mockACLs   map[sarama.Resource]*sarama.ResourceAcls
+	mockSCRAM  map[string]sarama.AlterUserScramCredentialsUpsert
+	mockQuotas map[string]map[string]float64
+	// mockGroups and mockOffsets are keyed by group id, the offsets by topic and partition below that
+	mockGroups  map[string]*sarama.GroupDescription
+	mockOffsets map[string]map[string]map[int32]int64
+	// mockPartitions overrides the described partitions of a topic, reassignments complete right away
+	mockPartitions    map[string][]*sarama.PartitionMetadata
+	mockReassignments map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus
+}
+
+// mockTopicConfigs are the topic configs known to the mock brokers
+var mockTopicConfigs = map[string]bool{
+	"cleanup.policy":      true,
+	"compression.type":    true,
+	"max.message.bytes":   true,
+	"min.insync.replicas": true,
+	"retention.bytes":     true,
+	"retention.ms":        true,
+	"segment.bytes":       true,
 }
 
 func NewMockFromCluster(client client.Client, cluster *v1beta1.KafkaCluster) (KafkaClient, error) {
@@ -39,9 +59,15 @@ func NewMockFromCluster(client client.Client, cluster *v1beta1.KafkaCluster) (Ka
 
 func newEmptyMockClusterAdmin(failOps bool) *mockClusterAdmin {
 	return &mockClusterAdmin{
-		mockTopics: make(map[string]sarama.TopicDetail, 0),
-		mockACLs:   make(map[sarama.Resource]*sarama.ResourceAcls, 0),
-		failOps:    failOps,
+		mockTopics:        make(map[string]sarama.TopicDetail, 0),
+		mockACLs:          make(map[sarama.Resource]*sarama.ResourceAcls, 0),
+		mockSCRAM:         make(map[string]sarama.AlterUserScramCredentialsUpsert, 0),
+		mockQuotas:        make(map[string]map[string]float64, 0),
+		mockGroups:        make(map[string]*sarama.GroupDescription, 0),
+		mockOffsets:       make(map[string]map[string]map[int32]int64, 0),
+		mockPartitions:    make(map[string][]*sarama.PartitionMetadata, 0),
+		mockReassignments: make(map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, 0),
+		failOps:           failOps,
 	}
 }
 
@@ -69,10 +95,11 @@ func newMockOpts() *KafkaConfig {
 
 func newMockClient() *kafkaClient {
 	return &kafkaClient{
-		opts:            newMockOpts(),
-		timeout:         time.Duration(kafkaDefaultTimeout) * time.Second,
-		newClusterAdmin: newMockClusterAdmin,
-		newClient:       newMockKafkaClient,
+		opts:             newMockOpts(),
+		timeout:          time.Duration(kafkaDefaultTimeout) * time.Second,
+		newClusterAdmin:  newMockClusterAdmin,
+		newClient:        newMockKafkaClient,
+		newOffsetManager: newMockOffsetManager,
 	}
 }
 
@@ -84,6 +111,13 @@ func newOpenedMockClient() *kafkaClient {
 
 func (m *mockClusterAdmin) Close() error { return nil }
 
+func (m *mockClusterAdmin) Controller() (*sarama.Broker, error) {
+	if m.failOps {
+		return nil, errors.New("bad controller")
+	}
+	return &sarama.Broker{}, nil
+}
+
 func (m *mockClusterAdmin) DescribeCluster() ([]*sarama.Broker, int32, error) {
 	if m.failOps {
 		return []*sarama.Broker{}, 0, errors.New("bad describe cluster")
@@ -105,6 +139,9 @@ func (m *mockClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetad
 	if m.failOps {
 		return []*sarama.TopicMetadata{}, errors.New("bad describe topics")
 	}
+	if partitions, ok := m.mockPartitions[topics[0]]; ok {
+		return []*sarama.TopicMetadata{{Name: topics[0], Partitions: partitions, Err: sarama.ErrNoError}}, nil
+	}
 	switch topics[0] {
 	case "test-topic", "already-created-topic":
 		return []*sarama.TopicMetadata{
@@ -139,6 +176,9 @@ func (m *mockClusterAdmin) CreateTopic(name string, detail *sarama.TopicDetail,
 	if _, ok := m.mockTopics[name]; ok {
 		return errors.New("already exists")
 	}
+	if validateOnly {
+		return validateMockTopicConfig(detail.ConfigEntries)
+	}
 	m.mockTopics[name] = *detail
 	return nil
 }
@@ -162,6 +202,24 @@ func (m *mockClusterAdmin) AlterConfig(resource sarama.ConfigResourceType, topic
 	if m.failOps {
 		return errors.New("bad alter config")
 	}
+	if validateOnly {
+		return validateMockTopicConfig(conf)
+	}
+	return nil
+}
+
+// validateMockTopicConfig rejects the configs unknown to the mock brokers like Kafka does on a validateOnly request
+func validateMockTopicConfig(conf map[string]*string) error {
+	for name, value := range conf {
+		if !mockTopicConfigs[name] {
+			msg := fmt.Sprintf("Unknown topic config name: %s", name)
+			return &sarama.TopicError{Err: sarama.ErrInvalidConfig, ErrMsg: &msg}
+		}
+		if value == nil || *value == "" {
+			msg := fmt.Sprintf("Invalid value for configuration %s", name)
+			return &sarama.TopicError{Err: sarama.ErrInvalidConfig, ErrMsg: &msg}
+		}
+	}
 	return nil
 }
 
@@ -169,6 +227,35 @@ func (m *mockClusterAdmin) CreatePartitions(topic string, count int32, assn [][]
 	return nil
 }
 
+func (m *mockClusterAdmin) AlterPartitionReassignments(topic string, assignment [][]int32) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return errors.New("bad alter partition reassignments")
+	}
+	partitions := make([]*sarama.PartitionMetadata, 0, len(assignment))
+	for id, replicas := range assignment {
+		partitions = append(partitions, &sarama.PartitionMetadata{ID: int32(id), Leader: replicas[0], Replicas: replicas, Isr: replicas})
+	}
+	m.mockPartitions[topic] = partitions
+	return nil
+}
+
+func (m *mockClusterAdmin) ListPartitionReassignments(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad list partition reassignments")
+	}
+	status := make(map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus)
+	if reassignments, ok := m.mockReassignments[topic]; ok {
+		status[topic] = reassignments
+	}
+	return status, nil
+}
+
 func (m *mockClusterAdmin) CreateACL(resource sarama.Resource, acl sarama.Acl) error {
 	m.Lock()
 	defer m.Unlock()
@@ -198,9 +285,21 @@ func (m *mockClusterAdmin) ListAcls(filter sarama.AclFilter) ([]sarama.ResourceA
 	m.Lock()
 	defer m.Unlock()
 
-	acls := make([]sarama.ResourceAcls, len(m.mockACLs))
+	acls := make([]sarama.ResourceAcls, 0, len(m.mockACLs))
 	for _, acl := range m.mockACLs {
-		acls = append(acls, *acl)
+		if filter.Principal == nil {
+			acls = append(acls, *acl)
+			continue
+		}
+		matching := sarama.ResourceAcls{Resource: acl.Resource}
+		for _, v := range acl.Acls {
+			if v.Principal == *filter.Principal {
+				matching.Acls = append(matching.Acls, v)
+			}
+		}
+		if len(matching.Acls) > 0 {
+			acls = append(acls, matching)
+		}
 	}
 	return acls, nil
 }
@@ -212,10 +311,37 @@ func (m *mockClusterAdmin) DeleteACL(filter sarama.AclFilter, validateOnly bool)
 	if m.failOps {
 		return []sarama.MatchingAcl{}, errors.New("bad create acl")
 	}
+	// a filter with a resource name deletes a single binding
+	if filter.ResourceName != nil {
+		resource := sarama.Resource{
+			ResourceType:        filter.ResourceType,
+			ResourceName:        *filter.ResourceName,
+			ResourcePatternType: filter.ResourcePatternTypeFilter,
+		}
+		resourceAcls, ok := m.mockACLs[resource]
+		if !ok {
+			return []sarama.MatchingAcl{}, nil
+		}
+		matches := make([]sarama.MatchingAcl, 0)
+		kept := make([]*sarama.Acl, 0, len(resourceAcls.Acls))
+		for _, acl := range resourceAcls.Acls {
+			if acl.Principal == *filter.Principal && acl.Host == *filter.Host &&
+				acl.Operation == filter.Operation && acl.PermissionType == filter.PermissionType {
+				matches = append(matches, sarama.MatchingAcl{Resource: resource, Acl: *acl})
+				continue
+			}
+			kept = append(kept, acl)
+		}
+		resourceAcls.Acls = kept
+		if len(kept) == 0 {
+			delete(m.mockACLs, resource)
+		}
+		return matches, nil
+	}
 	switch *filter.Principal {
-	case "test-user":
+	case "User:test-user":
 		return []sarama.MatchingAcl{sarama.MatchingAcl{}}, nil
-	case "with-error":
+	case "User:with-error":
 		return []sarama.MatchingAcl{sarama.MatchingAcl{Err: sarama.ErrUnknown}}, nil
 	default:
 		// for mock it's enough to erase the whole map
@@ -225,9 +351,324 @@ func (m *mockClusterAdmin) DeleteACL(filter sarama.AclFilter, validateOnly bool)
 }
 
 func (m *mockClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
-	return []sarama.ConfigEntry{}, nil
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return []sarama.ConfigEntry{}, errors.New("bad describe config")
+	}
+	entries := []sarama.ConfigEntry{}
+	if resource.Type != sarama.TopicResource {
+		return entries, nil
+	}
+	detail, ok := m.mockTopics[resource.Name]
+	if !ok {
+		return entries, nil
+	}
+	entries = append(entries, sarama.ConfigEntry{Name: "cleanup.policy", Value: "delete", Default: true, Source: sarama.SourceDefault})
+	for name, value := range detail.ConfigEntries {
+		if value != nil {
+			entries = append(entries, sarama.ConfigEntry{Name: name, Value: *value, Source: sarama.SourceTopic})
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockClusterAdmin) UpsertUserScramCredentials(upserts []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad upsert scram credentials")
+	}
+	results := make([]*sarama.AlterUserScramCredentialsResult, 0, len(upserts))
+	for _, upsert := range upserts {
+		m.mockSCRAM[upsert.Name] = upsert
+		results = append(results, &sarama.AlterUserScramCredentialsResult{User: upsert.Name})
+	}
+	return results, nil
 }
 
+func (m *mockClusterAdmin) DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad describe scram credentials")
+	}
+	results := make([]*sarama.DescribeUserScramCredentialsResult, 0, len(users))
+	for _, user := range users {
+		result := &sarama.DescribeUserScramCredentialsResult{User: user}
+		if upsert, ok := m.mockSCRAM[user]; ok {
+			result.CredentialInfos = []*sarama.UserScramCredentialsResponseInfo{{Mechanism: upsert.Mechanism, Iterations: upsert.Iterations}}
+		} else {
+			result.ErrorCode = errResourceNotFound
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (m *mockClusterAdmin) DeleteUserScramCredentials(deletes []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad delete scram credentials")
+	}
+	results := make([]*sarama.AlterUserScramCredentialsResult, 0, len(deletes))
+	for _, del := range deletes {
+		result := &sarama.AlterUserScramCredentialsResult{User: del.Name}
+		if _, ok := m.mockSCRAM[del.Name]; ok {
+			delete(m.mockSCRAM, del.Name)
+		} else {
+			result.ErrorCode = errResourceNotFound
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (m *mockClusterAdmin) DescribeClientQuotas(components []sarama.QuotaFilterComponent, strict bool) ([]sarama.DescribeClientQuotasEntry, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad describe client quotas")
+	}
+	entries := make([]sarama.DescribeClientQuotasEntry, 0)
+	for _, component := range components {
+		values, ok := m.mockQuotas[component.Match]
+		if !ok {
+			continue
+		}
+		entry := sarama.DescribeClientQuotasEntry{
+			Entity: []sarama.QuotaEntityComponent{{EntityType: component.EntityType, Name: component.Match}},
+			Values: make(map[string]float64, len(values)),
+		}
+		for key, value := range values {
+			entry.Values[key] = value
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (m *mockClusterAdmin) AlterClientQuotas(entity []sarama.QuotaEntityComponent, op sarama.ClientQuotasOp, validateOnly bool) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return errors.New("bad alter client quotas")
+	}
+	for _, component := range entity {
+		values, ok := m.mockQuotas[component.Name]
+		if !ok {
+			values = make(map[string]float64)
+			m.mockQuotas[component.Name] = values
+		}
+		if op.Remove {
+			delete(values, op.Key)
+		} else {
+			values[op.Key] = op.Value
+		}
+		if len(values) == 0 {
+			delete(m.mockQuotas, component.Name)
+		}
+	}
+	return nil
+}
+
+// the mock partitions hold offsets 0 to mockLogEndOffset, with a message every 10 milliseconds from the epoch
+const mockLogEndOffset = 100
+
+func (m *mockClusterAdmin) Partitions(topic string) ([]int32, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad partitions")
+	}
+	detail, ok := m.mockTopics[topic]
+	if !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	partitions := make([]int32, 0, detail.NumPartitions)
+	for i := int32(0); i < detail.NumPartitions; i++ {
+		partitions = append(partitions, i)
+	}
+	return partitions, nil
+}
+
+func (m *mockClusterAdmin) Topics() ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad topics")
+	}
+	topics := make([]string, 0, len(m.mockTopics))
+	for topic := range m.mockTopics {
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// InSyncReplicas reports the isr of the mock partitions, or a fully replicated partition otherwise
+func (m *mockClusterAdmin) InSyncReplicas(topic string, partition int32) ([]int32, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad in sync replicas")
+	}
+	for _, meta := range m.mockPartitions[topic] {
+		if meta.ID == partition {
+			return meta.Isr, nil
+		}
+	}
+	isr := make([]int32, 0, m.mockTopics[topic].ReplicationFactor)
+	for i := int32(0); i < int32(m.mockTopics[topic].ReplicationFactor); i++ {
+		isr = append(isr, i)
+	}
+	return isr, nil
+}
+
+func (m *mockClusterAdmin) GetOffset(topic string, partition int32, time int64) (int64, error) {
+	if m.failOps {
+		return 0, errors.New("bad get offset")
+	}
+	switch {
+	case time == sarama.OffsetOldest:
+		return 0, nil
+	case time == sarama.OffsetNewest:
+		return mockLogEndOffset, nil
+	case (time+9)/10 >= mockLogEndOffset:
+		return -1, nil
+	default:
+		return (time + 9) / 10, nil
+	}
+}
+
+func (m *mockClusterAdmin) DescribeConsumerGroups(groups []string) ([]*sarama.GroupDescription, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad describe consumer groups")
+	}
+	descriptions := make([]*sarama.GroupDescription, 0, len(groups))
+	for _, group := range groups {
+		description, ok := m.mockGroups[group]
+		if !ok {
+			description = &sarama.GroupDescription{GroupId: group, State: "Dead", Members: map[string]*sarama.GroupMemberDescription{}}
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions, nil
+}
+
+func (m *mockClusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad list consumer group offsets")
+	}
+	response := &sarama.OffsetFetchResponse{}
+	if topicPartitions == nil {
+		for topic, partitions := range m.mockOffsets[group] {
+			for partition, offset := range partitions {
+				response.AddBlock(topic, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+			}
+		}
+		return response, nil
+	}
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			offset, ok := m.mockOffsets[group][topic][partition]
+			if !ok {
+				offset = -1
+			}
+			response.AddBlock(topic, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		}
+	}
+	return response, nil
+}
+
+func (m *mockClusterAdmin) commitOffset(group, topic string, partition int32, offset int64) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.mockOffsets[group]; !ok {
+		m.mockOffsets[group] = make(map[string]map[int32]int64)
+	}
+	if _, ok := m.mockOffsets[group][topic]; !ok {
+		m.mockOffsets[group][topic] = make(map[int32]int64)
+	}
+	m.mockOffsets[group][topic][partition] = offset
+}
+
+type mockOffsetManager struct {
+	sarama.OffsetManager
+	group      string
+	admin      *mockClusterAdmin
+	partitions []*mockPartitionOffsetManager
+}
+
+type mockPartitionOffsetManager struct {
+	sarama.PartitionOffsetManager
+	topic     string
+	partition int32
+	offset    int64
+	dirty     bool
+}
+
+func newMockOffsetManager(group string, client sarama.Client) (sarama.OffsetManager, error) {
+	return &mockOffsetManager{group: group, admin: client.(*mockClusterAdmin)}, nil
+}
+
+// ManagePartition starts from the committed offset of the group like the sarama offset manager does
+func (m *mockOffsetManager) ManagePartition(topic string, partition int32) (sarama.PartitionOffsetManager, error) {
+	m.admin.Lock()
+	offset, ok := m.admin.mockOffsets[m.group][topic][partition]
+	m.admin.Unlock()
+	if !ok {
+		offset = -1
+	}
+	pom := &mockPartitionOffsetManager{topic: topic, partition: partition, offset: offset}
+	m.partitions = append(m.partitions, pom)
+	return pom, nil
+}
+
+func (m *mockOffsetManager) Commit() {
+	for _, pom := range m.partitions {
+		if pom.dirty {
+			m.admin.commitOffset(m.group, pom.topic, pom.partition, pom.offset)
+		}
+	}
+}
+
+func (m *mockOffsetManager) Close() error { return nil }
+
+// MarkOffset only moves the offset forward, as in sarama
+func (m *mockPartitionOffsetManager) MarkOffset(offset int64, metadata string) {
+	if offset > m.offset {
+		m.offset = offset
+		m.dirty = true
+	}
+}
+
+// ResetOffset only moves the offset backward, as in sarama
+func (m *mockPartitionOffsetManager) ResetOffset(offset int64, metadata string) {
+	if offset <= m.offset {
+		m.offset = offset
+		m.dirty = true
+	}
+}
+
+func (m *mockPartitionOffsetManager) Close() error { return nil }
+
 func shallowCopy(original map[string]sarama.TopicDetail) map[string]sarama.TopicDetail {
 	returnMap := make(map[string]sarama.TopicDetail, len(original))
 	for k, v := range original {
@@ -0,0 +1,123 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+const (
+	producerByteRateQuota  = "producer_byte_rate"
+	consumerByteRateQuota  = "consumer_byte_rate"
+	requestPercentageQuota = "request_percentage"
+)
+
+func userQuotaEntity(user string) []sarama.QuotaEntityComponent {
+	return []sarama.QuotaEntityComponent{{
+		EntityType: sarama.QuotaEntityUser,
+		MatchType:  sarama.QuotaMatchExact,
+		Name:       user,
+	}}
+}
+
+// DescribeUserQuotas returns the client quotas currently set for the user principal, nil if there are none
+func (k *kafkaClient) DescribeUserQuotas(user string) (*v1alpha1.UserQuotas, error) {
+	entries, err := k.admin.DescribeClientQuotas([]sarama.QuotaFilterComponent{{
+		EntityType: sarama.QuotaEntityUser,
+		MatchType:  sarama.QuotaMatchExact,
+		Match:      user,
+	}}, true)
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe user quotas", "user", user)
+	}
+
+	values := make(map[string]float64)
+	for _, entry := range entries {
+		for key, value := range entry.Values {
+			values[key] = value
+		}
+	}
+	return quotaValuesToUserQuotas(values), nil
+}
+
+// AlterUserQuotas makes the client quotas of the user principal match the given quotas,
+// quotas that are not set in the given ones are removed from the cluster
+func (k *kafkaClient) AlterUserQuotas(user string, quotas *v1alpha1.UserQuotas) error {
+	current, err := k.DescribeUserQuotas(user)
+	if err != nil {
+		return err
+	}
+	currentValues := userQuotasToQuotaValues(current)
+	desiredValues := userQuotasToQuotaValues(quotas)
+
+	ops := make([]sarama.ClientQuotasOp, 0)
+	for _, key := range []string{producerByteRateQuota, consumerByteRateQuota, requestPercentageQuota} {
+		desired, desiredSet := desiredValues[key]
+		value, currentSet := currentValues[key]
+		switch {
+		case desiredSet && (!currentSet || value != desired):
+			ops = append(ops, sarama.ClientQuotasOp{Key: key, Value: desired})
+		case !desiredSet && currentSet:
+			ops = append(ops, sarama.ClientQuotasOp{Key: key, Remove: true})
+		}
+	}
+
+	for _, op := range ops {
+		if err := k.admin.AlterClientQuotas(userQuotaEntity(user), op, false); err != nil {
+			return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not alter user quota", "user", user, "quota", op.Key)
+		}
+	}
+	return nil
+}
+
+func userQuotasToQuotaValues(quotas *v1alpha1.UserQuotas) map[string]float64 {
+	values := make(map[string]float64)
+	if quotas == nil {
+		return values
+	}
+	if quotas.ProducerByteRate != nil {
+		values[producerByteRateQuota] = float64(*quotas.ProducerByteRate)
+	}
+	if quotas.ConsumerByteRate != nil {
+		values[consumerByteRateQuota] = float64(*quotas.ConsumerByteRate)
+	}
+	if quotas.RequestPercentage != nil {
+		values[requestPercentageQuota] = float64(*quotas.RequestPercentage)
+	}
+	return values
+}
+
+func quotaValuesToUserQuotas(values map[string]float64) *v1alpha1.UserQuotas {
+	if len(values) == 0 {
+		return nil
+	}
+	quotas := &v1alpha1.UserQuotas{}
+	if value, ok := values[producerByteRateQuota]; ok {
+		rate := int64(value)
+		quotas.ProducerByteRate = &rate
+	}
+	if value, ok := values[consumerByteRateQuota]; ok {
+		rate := int64(value)
+		quotas.ConsumerByteRate = &rate
+	}
+	if value, ok := values[requestPercentageQuota]; ok {
+		percentage := int32(value)
+		quotas.RequestPercentage = &percentage
+	}
+	return quotas
+}
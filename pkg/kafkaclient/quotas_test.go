@@ -0,0 +1,74 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+func TestAlterUserQuotas(t *testing.T) {
+	client := newOpenedMockClient()
+
+	quotas := &v1alpha1.UserQuotas{
+		ProducerByteRate:  util.Int64Pointer(1048576),
+		RequestPercentage: util.Int32Pointer(200),
+	}
+	if err := client.AlterUserQuotas("test-user", quotas); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := map[string]float64{
+		producerByteRateQuota:  1048576,
+		requestPercentageQuota: 200,
+	}
+	if values := client.admin.(*mockClusterAdmin).mockQuotas["test-user"]; !reflect.DeepEqual(values, expected) {
+		t.Error("Expected:", expected, "got:", values)
+	}
+
+	described, err := client.DescribeUserQuotas("test-user")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if !reflect.DeepEqual(described, quotas) {
+		t.Error("Expected:", quotas, "got:", described)
+	}
+
+	// quotas missing from the spec are removed
+	quotas = &v1alpha1.UserQuotas{ConsumerByteRate: util.Int64Pointer(2048)}
+	if err := client.AlterUserQuotas("test-user", quotas); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected = map[string]float64{consumerByteRateQuota: 2048}
+	if values := client.admin.(*mockClusterAdmin).mockQuotas["test-user"]; !reflect.DeepEqual(values, expected) {
+		t.Error("Expected:", expected, "got:", values)
+	}
+
+	if err := client.AlterUserQuotas("test-user", nil); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if described, err = client.DescribeUserQuotas("test-user"); err != nil || described != nil {
+		t.Error("Expected no quotas, got:", described, err)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.AlterUserQuotas("test-user", quotas); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
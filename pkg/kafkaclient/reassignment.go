@@ -0,0 +1,166 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+// ListTopicReassignments returns the partitions of the topic that are being reassigned
+func (k *kafkaClient) ListTopicReassignments(topic string) (map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+	// nil partitions list every ongoing reassignment of the topic
+	status, err := k.admin.ListPartitionReassignments(topic, nil)
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list partition reassignments", "topic", topic)
+	}
+	if status[topic] == nil {
+		return map[int32]*sarama.PartitionReplicaReassignmentsStatus{}, nil
+	}
+	return status[topic], nil
+}
+
+// AlterTopicReplicationFactor starts a partition reassignment that gives every partition of the topic
+// the requested number of replicas, it returns without waiting for the replicas to move
+func (k *kafkaClient) AlterTopicReplicationFactor(topic string, replicationFactor int32) error {
+	meta, err := k.DescribeTopic(topic)
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe topic", "topic", topic)
+	}
+
+	brokerRacks := make(map[int32]string, len(k.brokers))
+	for _, broker := range k.brokers {
+		brokerRacks[broker.ID()] = broker.Rack()
+	}
+
+	plan, err := replicaReassignmentPlan(meta.Partitions, brokerRacks, int(replicationFactor))
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "could not plan partition reassignment", "topic", topic)
+	}
+
+	if err := k.admin.AlterPartitionReassignments(topic, plan); err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not reassign partitions", "topic", topic)
+	}
+	return nil
+}
+
+// replicaReassignmentPlan returns the replicas of every partition, indexed by partition id, after changing
+// the replication factor. Current replicas are kept where possible and the preferred leader stays first.
+// New replicas go to racks the partition is not on yet, then to the brokers holding the fewest replicas.
+func replicaReassignmentPlan(partitions []*sarama.PartitionMetadata, brokerRacks map[int32]string, replicationFactor int) ([][]int32, error) {
+	if replicationFactor < 1 {
+		return nil, errors.Errorf("invalid replication factor: %d", replicationFactor)
+	}
+	if replicationFactor > len(brokerRacks) {
+		return nil, errors.Errorf("replication factor %d is larger than the number of brokers %d", replicationFactor, len(brokerRacks))
+	}
+
+	brokerIDs := make([]int32, 0, len(brokerRacks))
+	for id := range brokerRacks {
+		brokerIDs = append(brokerIDs, id)
+	}
+	sort.Slice(brokerIDs, func(i, j int) bool { return brokerIDs[i] < brokerIDs[j] })
+
+	sorted := make([]*sarama.PartitionMetadata, len(partitions))
+	copy(sorted, partitions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	// replica count per broker, new replicas are spread over the least loaded brokers
+	load := make(map[int32]int, len(brokerIDs))
+	for _, partition := range sorted {
+		for _, replica := range partition.Replicas {
+			load[replica]++
+		}
+	}
+
+	plan := make([][]int32, len(sorted))
+	for i, partition := range sorted {
+		if partition.ID != int32(i) {
+			return nil, errors.Errorf("missing metadata of partition %d", i)
+		}
+
+		replicas := make([]int32, 0, replicationFactor)
+		racks := make(map[string]bool)
+		add := func(broker int32) {
+			replicas = append(replicas, broker)
+			racks[brokerRacks[broker]] = true
+		}
+		contains := func(broker int32) bool {
+			for _, replica := range replicas {
+				if replica == broker {
+					return true
+				}
+			}
+			return false
+		}
+
+		// the preferred leader is kept, then current replicas on new racks, then the other current replicas
+		current := make([]int32, 0, len(partition.Replicas))
+		for _, replica := range partition.Replicas {
+			if _, ok := brokerRacks[replica]; ok {
+				current = append(current, replica)
+			}
+		}
+		if len(current) > 0 {
+			add(current[0])
+		}
+		for _, replica := range current {
+			if len(replicas) < replicationFactor && !contains(replica) && !racks[brokerRacks[replica]] {
+				add(replica)
+			}
+		}
+		for _, replica := range current {
+			if len(replicas) < replicationFactor && !contains(replica) {
+				add(replica)
+			}
+		}
+		for _, replica := range partition.Replicas {
+			if !contains(replica) {
+				load[replica]--
+			}
+		}
+
+		for len(replicas) < replicationFactor {
+			var candidate int32 = -1
+			for _, broker := range brokerIDs {
+				if contains(broker) {
+					continue
+				}
+				if candidate == -1 {
+					candidate = broker
+					continue
+				}
+				newRack, candidateNewRack := !racks[brokerRacks[broker]], !racks[brokerRacks[candidate]]
+				if newRack != candidateNewRack {
+					if newRack {
+						candidate = broker
+					}
+					continue
+				}
+				if load[broker] < load[candidate] {
+					candidate = broker
+				}
+			}
+			add(candidate)
+			load[candidate]++
+		}
+		plan[i] = replicas
+	}
+	return plan, nil
+}
@@ -0,0 +1,116 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestReplicaReassignmentPlan(t *testing.T) {
+	brokerRacks := map[int32]string{
+		0: "zone-a",
+		1: "zone-a",
+		2: "zone-b",
+		3: "zone-c",
+	}
+	partitions := []*sarama.PartitionMetadata{
+		{ID: 1, Replicas: []int32{1, 0}},
+		{ID: 0, Replicas: []int32{0, 1}},
+	}
+
+	// increase: the leader stays first and new replicas go to the racks the partition is missing
+	plan, err := replicaReassignmentPlan(partitions, brokerRacks, 3)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := [][]int32{{0, 1, 2}, {1, 0, 3}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Error("Expected:", expected, "got:", plan)
+	}
+
+	// decrease: replicas on a rack the partition already uses are dropped first
+	partitions = []*sarama.PartitionMetadata{
+		{ID: 0, Replicas: []int32{0, 1, 2}},
+		{ID: 1, Replicas: []int32{2, 0, 1}},
+	}
+	plan, err = replicaReassignmentPlan(partitions, brokerRacks, 2)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected = [][]int32{{0, 2}, {2, 0}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Error("Expected:", expected, "got:", plan)
+	}
+
+	// without racks the new replicas are spread over the least loaded brokers
+	partitions = []*sarama.PartitionMetadata{
+		{ID: 0, Replicas: []int32{0}},
+		{ID: 1, Replicas: []int32{1}},
+		{ID: 2, Replicas: []int32{0}},
+	}
+	plan, err = replicaReassignmentPlan(partitions, map[int32]string{0: "", 1: "", 2: ""}, 2)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected = [][]int32{{0, 2}, {1, 2}, {0, 1}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Error("Expected:", expected, "got:", plan)
+	}
+
+	if _, err := replicaReassignmentPlan(partitions, brokerRacks, 5); err == nil {
+		t.Error("Expected error for replication factor larger than the number of brokers, got nil")
+	}
+	if _, err := replicaReassignmentPlan(partitions, brokerRacks, 0); err == nil {
+		t.Error("Expected error for invalid replication factor, got nil")
+	}
+}
+
+func TestAlterTopicReplicationFactor(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+	admin.mockPartitions["test-topic"] = []*sarama.PartitionMetadata{
+		{ID: 0, Leader: 0, Replicas: []int32{0}},
+	}
+
+	if err := client.AlterTopicReplicationFactor("test-topic", 1); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.AlterTopicReplicationFactor("test-topic", 2); err == nil {
+		t.Error("Expected error for replication factor larger than the number of brokers, got nil")
+	}
+
+	reassignments, err := client.ListTopicReassignments("test-topic")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if len(reassignments) != 0 {
+		t.Error("Expected no reassignments, got:", reassignments)
+	}
+
+	admin.mockReassignments["test-topic"] = map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+		0: {Replicas: []int32{0, 1}, AddingReplicas: []int32{1}},
+	}
+	if reassignments, err = client.ListTopicReassignments("test-topic"); err != nil || len(reassignments) != 1 {
+		t.Error("Expected one reassignment, got:", reassignments, err)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.AlterTopicReplicationFactor("test-topic", 1); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
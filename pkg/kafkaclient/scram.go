@@ -0,0 +1,132 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+const (
+	scramIterations = 4096
+	scramSaltLength = 32
+	// errResourceNotFound is returned by the brokers when deleting SCRAM credentials of an unknown user,
+	// sarama does not define it yet
+	errResourceNotFound sarama.KError = 91
+)
+
+// xdgSCRAMClient implements sarama.SCRAMClient for the SCRAM-SHA-512 mechanism
+type xdgSCRAMClient struct {
+	*scram.Client
+	*scram.ClientConversation
+}
+
+func newSCRAMSHA512Client() sarama.SCRAMClient {
+	return &xdgSCRAMClient{}
+}
+
+func (x *xdgSCRAMClient) Begin(userName, password, authzID string) (err error) {
+	x.Client, err = scram.HashGeneratorFcn(sha512.New).NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	x.ClientConversation = x.Client.NewConversation()
+	return nil
+}
+
+func (x *xdgSCRAMClient) Step(challenge string) (string, error) {
+	return x.ClientConversation.Step(challenge)
+}
+
+func (x *xdgSCRAMClient) Done() bool {
+	return x.ClientConversation.Done()
+}
+
+// UpsertUserSCRAMCredentials creates or replaces the SCRAM-SHA-512 credentials of the user
+func (k *kafkaClient) UpsertUserSCRAMCredentials(username, password string) error {
+	salt := make([]byte, scramSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "could not generate scram salt")
+	}
+	results, err := k.admin.UpsertUserScramCredentials([]sarama.AlterUserScramCredentialsUpsert{
+		{
+			Name:       username,
+			Mechanism:  sarama.SCRAM_MECHANISM_SHA_512,
+			Iterations: scramIterations,
+			Salt:       salt,
+			Password:   []byte(password),
+		},
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not upsert user scram credentials", "user", username)
+	}
+	return alterSCRAMResultsError(results)
+}
+
+// HasUserSCRAMCredentials returns true if the user has SCRAM-SHA-512 credentials on the cluster
+func (k *kafkaClient) HasUserSCRAMCredentials(username string) (bool, error) {
+	results, err := k.admin.DescribeUserScramCredentials([]string{username})
+	if err != nil {
+		return false, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe user scram credentials", "user", username)
+	}
+	for _, result := range results {
+		if result.User != username || result.ErrorCode == errResourceNotFound {
+			continue
+		}
+		if result.ErrorCode != sarama.ErrNoError {
+			return false, errorfactory.New(errorfactory.BrokersRequestError{}, result.ErrorCode, "could not describe user scram credentials", "user", username)
+		}
+		for _, info := range result.CredentialInfos {
+			if info.Mechanism == sarama.SCRAM_MECHANISM_SHA_512 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// DeleteUserSCRAMCredentials removes the SCRAM-SHA-512 credentials of the user, missing credentials are not an error
+func (k *kafkaClient) DeleteUserSCRAMCredentials(username string) error {
+	results, err := k.admin.DeleteUserScramCredentials([]sarama.AlterUserScramCredentialsDelete{
+		{
+			Name:      username,
+			Mechanism: sarama.SCRAM_MECHANISM_SHA_512,
+		},
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not delete user scram credentials", "user", username)
+	}
+	return alterSCRAMResultsError(results)
+}
+
+func alterSCRAMResultsError(results []*sarama.AlterUserScramCredentialsResult) error {
+	var combined error
+	for _, result := range results {
+		if result.ErrorCode == sarama.ErrNoError || result.ErrorCode == errResourceNotFound {
+			continue
+		}
+		combined = errors.Combine(combined, errors.WrapIfWithDetails(result.ErrorCode, "altering scram credentials failed", "user", result.User))
+	}
+	if combined != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, combined, "could not alter user scram credentials")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestUpsertUserSCRAMCredentials(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if err := client.UpsertUserSCRAMCredentials("test-user", "test-password"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	upsert, ok := client.admin.(*mockClusterAdmin).mockSCRAM["test-user"]
+	if !ok {
+		t.Fatal("Expected scram credentials for test-user, got none")
+	}
+	if upsert.Mechanism != sarama.SCRAM_MECHANISM_SHA_512 {
+		t.Error("Expected SCRAM-SHA-512 mechanism, got:", upsert.Mechanism)
+	}
+	if upsert.Iterations != scramIterations || len(upsert.Salt) != scramSaltLength {
+		t.Error("Expected default iterations and salt length, got:", upsert.Iterations, len(upsert.Salt))
+	}
+	if string(upsert.Password) != "test-password" {
+		t.Error("Expected test-password, got:", string(upsert.Password))
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.UpsertUserSCRAMCredentials("test-user", "test-password"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestHasUserSCRAMCredentials(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if ok, err := client.HasUserSCRAMCredentials("test-user"); err != nil || ok {
+		t.Error("Expected no scram credentials for test-user, got:", ok, err)
+	}
+	if err := client.UpsertUserSCRAMCredentials("test-user", "test-password"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if ok, err := client.HasUserSCRAMCredentials("test-user"); err != nil || !ok {
+		t.Error("Expected scram credentials for test-user, got:", ok, err)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err := client.HasUserSCRAMCredentials("test-user"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestDeleteUserSCRAMCredentials(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if err := client.UpsertUserSCRAMCredentials("test-user", "test-password"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.DeleteUserSCRAMCredentials("test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if _, ok := client.admin.(*mockClusterAdmin).mockSCRAM["test-user"]; ok {
+		t.Error("Expected scram credentials to be deleted")
+	}
+	// deleting unknown credentials is not an error
+	if err := client.DeleteUserSCRAMCredentials("test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.DeleteUserSCRAMCredentials("test-user"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestAlterSCRAMResultsError(t *testing.T) {
+	results := []*sarama.AlterUserScramCredentialsResult{
+		{User: "ok"},
+		{User: "missing", ErrorCode: errResourceNotFound},
+	}
+	if err := alterSCRAMResultsError(results); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	results = append(results, &sarama.AlterUserScramCredentialsResult{User: "bad", ErrorCode: sarama.ErrUnknown})
+	if err := alterSCRAMResultsError(results); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -17,9 +17,11 @@ package kafkaclient
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
 )
 
@@ -68,6 +70,38 @@ func (k *kafkaClient) DescribeTopic(topic string) (meta *sarama.TopicMetadata, e
 	return
 }
 
+// DescribeTopicConfig returns the effective configuration of a topic, including the defaults
+func (k *kafkaClient) DescribeTopicConfig(topic string) ([]sarama.ConfigEntry, error) {
+	entries, err := k.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe topic config", "topic", topic)
+	}
+	return entries, nil
+}
+
+// TopicMetaToStatus summarizes the partition metadata of a topic into a KafkaTopic status
+func (k *kafkaClient) TopicMetaToStatus(meta *sarama.TopicMetadata) *v1alpha1.KafkaTopicStatus {
+	status := &v1alpha1.KafkaTopicStatus{
+		Partitions:         int32(len(meta.Partitions)),
+		LeaderDistribution: make(map[string]int32),
+	}
+	for _, partition := range meta.Partitions {
+		// the replication factor is reported the same way as the topic details do
+		if partition.ID == 0 {
+			status.ReplicationFactor = int32(len(partition.Replicas))
+		}
+		if partition.Leader < 0 || partition.Err == sarama.ErrLeaderNotAvailable {
+			status.OfflinePartitions++
+		} else {
+			status.LeaderDistribution[strconv.Itoa(int(partition.Leader))]++
+		}
+		if len(partition.Isr) < len(partition.Replicas) {
+			status.UnderReplicatedPartitions++
+		}
+	}
+	return status
+}
+
 // CreateTopic creates a topic with the given options
 func (k *kafkaClient) CreateTopic(opts *CreateTopicOptions) (err error) {
 	err = k.admin.CreateTopic(opts.Name, &sarama.TopicDetail{
@@ -81,6 +115,15 @@ func (k *kafkaClient) CreateTopic(opts *CreateTopicOptions) (err error) {
 	return
 }
 
+// ValidateTopic asks the brokers whether the topic could be created with the given options, without creating it
+func (k *kafkaClient) ValidateTopic(opts *CreateTopicOptions) error {
+	return k.admin.CreateTopic(opts.Name, &sarama.TopicDetail{
+		NumPartitions:     opts.Partitions,
+		ReplicationFactor: opts.ReplicationFactor,
+		ConfigEntries:     opts.Config,
+	}, true)
+}
+
 // DeleteTopic deletes a topic - when wait is specified, the method will not
 // return until the topic doesn't appear in the cluster topic list.
 func (k *kafkaClient) DeleteTopic(topicName string, wait bool) error {
@@ -136,3 +179,8 @@ func (k *kafkaClient) EnsurePartitionCount(topic string, desired int32) (changed
 func (k *kafkaClient) EnsureTopicConfig(topic string, desiredConf map[string]*string) error {
 	return k.admin.AlterConfig(sarama.TopicResource, topic, desiredConf, false)
 }
+
+// ValidateTopicConfig asks the brokers whether the configuration overrides could be set on the topic, without setting them
+func (k *kafkaClient) ValidateTopicConfig(topic string, config map[string]*string) error {
+	return k.admin.AlterConfig(sarama.TopicResource, topic, config, true)
+}
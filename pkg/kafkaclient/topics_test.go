@@ -15,9 +15,13 @@
 package kafkaclient
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
 )
 
 func TestListTopics(t *testing.T) {
@@ -132,6 +136,40 @@ func TestEnsureTopicConfig(t *testing.T) {
 	}
 }
 
+func TestValidateTopic(t *testing.T) {
+	client := newOpenedMockClient()
+	opts := &CreateTopicOptions{
+		Name:              "test-topic",
+		Partitions:        1,
+		ReplicationFactor: 1,
+		Config:            map[string]*string{"retention.ms": util.StringPointer("1000")},
+	}
+	if err := client.ValidateTopic(opts); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if topic, _ := client.GetTopic("test-topic"); topic != nil {
+		t.Error("Expected topic not to be created, got:", topic)
+	}
+	opts.Config["retention"] = util.StringPointer("1000")
+	if err := client.ValidateTopic(opts); err == nil {
+		t.Error("Expected error for unknown topic config, got nil")
+	}
+}
+
+func TestValidateTopicConfig(t *testing.T) {
+	client := newOpenedMockClient()
+	if err := client.ValidateTopicConfig("test-topic", map[string]*string{"cleanup.policy": util.StringPointer("compact")}); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.ValidateTopicConfig("test-topic", map[string]*string{"cleanup.policy": util.StringPointer("")}); err == nil {
+		t.Error("Expected error for invalid topic config value, got nil")
+	}
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.ValidateTopicConfig("test-topic", map[string]*string{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
 func TestEnsurePartitionCount(t *testing.T) {
 	client := newOpenedMockClient()
 	if changed, err := client.EnsurePartitionCount("test-topic", 1); err != nil {
@@ -151,3 +189,55 @@ func TestEnsurePartitionCount(t *testing.T) {
 		t.Error("Expected error, got nil")
 	}
 }
+
+func TestTopicMetaToStatus(t *testing.T) {
+	client := newOpenedMockClient()
+	status := client.TopicMetaToStatus(&sarama.TopicMetadata{
+		Name: "test-topic",
+		Partitions: []*sarama.PartitionMetadata{
+			{ID: 0, Leader: 1, Replicas: []int32{1, 2}, Isr: []int32{1, 2}},
+			{ID: 1, Leader: 2, Replicas: []int32{2, 1}, Isr: []int32{2}},
+			{ID: 2, Leader: 1, Replicas: []int32{1, 2}, Isr: []int32{1, 2}},
+			{ID: 3, Leader: -1, Replicas: []int32{2, 1}, Isr: []int32{}, Err: sarama.ErrLeaderNotAvailable},
+		},
+	})
+	expected := &v1alpha1.KafkaTopicStatus{
+		Partitions:                4,
+		ReplicationFactor:         2,
+		UnderReplicatedPartitions: 2,
+		OfflinePartitions:         1,
+		LeaderDistribution:        map[string]int32{"1": 2, "2": 1},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Error("Expected:", expected, "got:", status)
+	}
+}
+
+func TestDescribeTopicConfig(t *testing.T) {
+	client := newOpenedMockClient()
+	client.admin.CreateTopic("test-topic", &sarama.TopicDetail{
+		ConfigEntries: map[string]*string{"retention.ms": util.StringPointer("1000")},
+	}, false)
+
+	entries, err := client.DescribeTopicConfig("test-topic")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	overrides := 0
+	for _, entry := range entries {
+		if entry.Source == sarama.SourceTopic {
+			overrides++
+			if entry.Name != "retention.ms" || entry.Value != "1000" {
+				t.Error("Unexpected topic config override:", entry)
+			}
+		}
+	}
+	if overrides != 1 {
+		t.Error("Expected one topic config override, got:", entries)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err := client.DescribeTopicConfig("test-topic"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -38,16 +38,38 @@ func AclPatternTypeMapping(patternType v1alpha1.KafkaPatternType) sarama.AclReso
 	}
 }
 
+// clusterResourceName is the name of the single cluster resource of Kafka ACLs
+const clusterResourceName = "kafka-cluster"
+
+// ClusterOperationMapping maps the cluster operation from v1alpha1.KafkaClusterOperation to sarama.AclOperation
+func ClusterOperationMapping(operation v1alpha1.KafkaClusterOperation) sarama.AclOperation {
+	switch operation {
+	case v1alpha1.KafkaClusterOperationAlter:
+		return sarama.AclOperationAlter
+	case v1alpha1.KafkaClusterOperationAlterConfigs:
+		return sarama.AclOperationAlterConfigs
+	case v1alpha1.KafkaClusterOperationClusterAction:
+		return sarama.AclOperationClusterAction
+	case v1alpha1.KafkaClusterOperationCreate:
+		return sarama.AclOperationCreate
+	case v1alpha1.KafkaClusterOperationDescribe:
+		return sarama.AclOperationDescribe
+	case v1alpha1.KafkaClusterOperationDescribeConfigs:
+		return sarama.AclOperationDescribeConfigs
+	case v1alpha1.KafkaClusterOperationIdempotentWrite:
+		return sarama.AclOperationIdempotentWrite
+	default:
+		return sarama.AclOperationUnknown
+	}
+}
+
 // CreateUserACLs creates Kafka ACLs for the given access type and user
 // `literal` patternType will be used if patternType == ""
 func (k *kafkaClient) CreateUserACLs(accessType v1alpha1.KafkaAccessType, patternType v1alpha1.KafkaPatternType, dn string, topic string) (err error) {
 	userName := fmt.Sprintf("User:%s", dn)
-	if patternType == "" {
-		patternType = v1alpha1.KafkaPatternTypeDefault
-	}
-	aclPatternType := AclPatternTypeMapping(patternType)
-	if aclPatternType == sarama.AclPatternUnknown {
-		return errorfactory.New(errorfactory.InternalError{}, fmt.Errorf("unknown type: %s", patternType), "unrecognized pattern type")
+	aclPatternType, err := aclPatternTypeOrDefault(patternType)
+	if err != nil {
+		return err
 	}
 	switch accessType {
 	case v1alpha1.KafkaAccessTypeRead:
@@ -59,6 +81,73 @@ func (k *kafkaClient) CreateUserACLs(accessType v1alpha1.KafkaAccessType, patter
 	}
 }
 
+// CreateUserGroupACLs allows the user to read the given consumer group
+// `literal` patternType will be used if patternType == ""
+func (k *kafkaClient) CreateUserGroupACLs(patternType v1alpha1.KafkaPatternType, dn string, group string) error {
+	aclPatternType, err := aclPatternTypeOrDefault(patternType)
+	if err != nil {
+		return err
+	}
+	return k.createACL(fmt.Sprintf("User:%s", dn), sarama.Resource{
+		ResourceType:        sarama.AclResourceGroup,
+		ResourceName:        group,
+		ResourcePatternType: aclPatternType,
+	}, sarama.AclOperationRead)
+}
+
+// CreateUserTransactionalIDACLs allows the user to produce with the given transactional id
+// `literal` patternType will be used if patternType == ""
+func (k *kafkaClient) CreateUserTransactionalIDACLs(patternType v1alpha1.KafkaPatternType, dn string, transactionalID string) error {
+	aclPatternType, err := aclPatternTypeOrDefault(patternType)
+	if err != nil {
+		return err
+	}
+	resource := sarama.Resource{
+		ResourceType:        sarama.AclResourceTransactionalID,
+		ResourceName:        transactionalID,
+		ResourcePatternType: aclPatternType,
+	}
+	for _, operation := range []sarama.AclOperation{sarama.AclOperationDescribe, sarama.AclOperationWrite} {
+		if err = k.createACL(fmt.Sprintf("User:%s", dn), resource, operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateUserClusterACLs allows the user the given operation on the cluster
+func (k *kafkaClient) CreateUserClusterACLs(operation v1alpha1.KafkaClusterOperation, dn string) error {
+	aclOperation := ClusterOperationMapping(operation)
+	if aclOperation == sarama.AclOperationUnknown {
+		return errorfactory.New(errorfactory.InternalError{}, fmt.Errorf("unknown operation: %s", operation), "unrecognized cluster operation")
+	}
+	return k.createACL(fmt.Sprintf("User:%s", dn), sarama.Resource{
+		ResourceType:        sarama.AclResourceCluster,
+		ResourceName:        clusterResourceName,
+		ResourcePatternType: sarama.AclPatternLiteral,
+	}, aclOperation)
+}
+
+func aclPatternTypeOrDefault(patternType v1alpha1.KafkaPatternType) (sarama.AclResourcePatternType, error) {
+	if patternType == "" {
+		patternType = v1alpha1.KafkaPatternTypeDefault
+	}
+	aclPatternType := AclPatternTypeMapping(patternType)
+	if aclPatternType == sarama.AclPatternUnknown {
+		return aclPatternType, errorfactory.New(errorfactory.InternalError{}, fmt.Errorf("unknown type: %s", patternType), "unrecognized pattern type")
+	}
+	return aclPatternType, nil
+}
+
+func (k *kafkaClient) createACL(principal string, resource sarama.Resource, operation sarama.AclOperation) error {
+	return k.admin.CreateACL(resource, sarama.Acl{
+		Principal:      principal,
+		Host:           "*",
+		Operation:      operation,
+		PermissionType: sarama.AclPermissionAllow,
+	})
+}
+
 func (k *kafkaClient) ListUserACLs() ([]sarama.ResourceAcls, error) {
 	acls, err := k.admin.ListAcls(sarama.AclFilter{})
 	if err != nil {
@@ -67,10 +156,53 @@ func (k *kafkaClient) ListUserACLs() ([]sarama.ResourceAcls, error) {
 	return acls, nil
 }
 
+// DescribeUserACLs returns the ACLs bound to the principal of the given user
+func (k *kafkaClient) DescribeUserACLs(dn string) ([]sarama.ResourceAcls, error) {
+	principal := fmt.Sprintf("User:%s", dn)
+	acls, err := k.admin.ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		Principal:                 &principal,
+		Operation:                 sarama.AclOperationAny,
+		PermissionType:            sarama.AclPermissionAny,
+	})
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list user acls", "user", dn)
+	}
+	return acls, nil
+}
+
+// DeleteUserACL removes a single ACL binding
+func (k *kafkaClient) DeleteUserACL(resource sarama.Resource, acl sarama.Acl) error {
+	matches, err := k.admin.DeleteACL(sarama.AclFilter{
+		ResourceType:              resource.ResourceType,
+		ResourceName:              &resource.ResourceName,
+		ResourcePatternTypeFilter: resource.ResourcePatternType,
+		Principal:                 &acl.Principal,
+		Host:                      &acl.Host,
+		Operation:                 acl.Operation,
+		PermissionType:            acl.PermissionType,
+	}, false)
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not delete user acl", "principal", acl.Principal)
+	}
+	for _, match := range matches {
+		if match.Err != sarama.ErrNoError {
+			return errorfactory.New(errorfactory.BrokersRequestError{}, match.Err, "could not delete user acl", "principal", acl.Principal)
+		}
+	}
+	return nil
+}
+
 // DeleteUserACLs removes all ACLs for a given user
 func (k *kafkaClient) DeleteUserACLs(dn string) (err error) {
+	principal := fmt.Sprintf("User:%s", dn)
 	matches, err := k.admin.DeleteACL(sarama.AclFilter{
-		Principal: &dn,
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		Principal:                 &principal,
+		Operation:                 sarama.AclOperationAny,
+		PermissionType:            sarama.AclPermissionAny,
 	}, false)
 	if err != nil {
 		return
@@ -89,7 +221,7 @@ func (k *kafkaClient) createReadACLs(dn string, topic string, patternType sarama
 	}
 
 	// READ on topic
-	if err = k.admin.CreateACL(sarama.Resource{
+	err = k.admin.CreateACL(sarama.Resource{
 		ResourceType:        sarama.AclResourceTopic,
 		ResourceName:        topic,
 		ResourcePatternType: patternType,
@@ -98,20 +230,6 @@ func (k *kafkaClient) createReadACLs(dn string, topic string, patternType sarama
 		Host:           "*",
 		Operation:      sarama.AclOperationRead,
 		PermissionType: sarama.AclPermissionAllow,
-	}); err != nil {
-		return
-	}
-
-	// READ on groups
-	err = k.admin.CreateACL(sarama.Resource{
-		ResourceType:        sarama.AclResourceGroup,
-		ResourceName:        "*",
-		ResourcePatternType: sarama.AclPatternLiteral,
-	}, sarama.Acl{
-		Principal:      dn,
-		Host:           "*",
-		Operation:      sarama.AclOperationRead,
-		PermissionType: sarama.AclPermissionAllow,
 	})
 
 	return
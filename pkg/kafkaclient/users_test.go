@@ -94,3 +94,82 @@ func TestDeleteUserACLs(t *testing.T) {
 		t.Error("Expected error, got nil")
 	}
 }
+
+func TestDescribeAndDeleteUserACL(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if err := client.CreateUserACLs(v1alpha1.KafkaAccessTypeWrite, v1alpha1.KafkaPatternTypeLiteral, "test-user", "test-topic"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.CreateUserACLs(v1alpha1.KafkaAccessTypeWrite, v1alpha1.KafkaPatternTypeLiteral, "other-user", "test-topic"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	acls, err := client.DescribeUserACLs("test-user")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if len(acls) != 1 || len(acls[0].Acls) != 4 {
+		t.Fatal("Expected 4 ACLs on a single resource for test-user, got:", acls)
+	}
+	for _, acl := range acls[0].Acls {
+		if acl.Principal != "User:test-user" {
+			t.Error("Expected only ACLs of test-user, got:", acl.Principal)
+		}
+	}
+
+	if err := client.DeleteUserACL(acls[0].Resource, *acls[0].Acls[0]); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if acls, _ = client.DescribeUserACLs("test-user"); len(acls[0].Acls) != 3 {
+		t.Error("Expected 3 ACLs left for test-user, got:", acls)
+	}
+	if acls, _ = client.DescribeUserACLs("other-user"); len(acls[0].Acls) != 4 {
+		t.Error("Expected ACLs of other-user untouched, got:", acls)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.DeleteUserACL(acls[0].Resource, *acls[0].Acls[0]); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestCreateUserGroupTransactionalIDAndClusterACLs(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if err := client.CreateUserGroupACLs("", "test-user", "test-group"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.CreateUserGroupACLs("helloWorld", "test-user", "test-group"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := client.CreateUserTransactionalIDACLs(v1alpha1.KafkaPatternTypePrefixed, "test-user", "test-tx"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.CreateUserClusterACLs(v1alpha1.KafkaClusterOperationIdempotentWrite, "test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.CreateUserClusterACLs("helloWorld", "test-user"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+
+	expected := map[sarama.Resource]int{
+		{ResourceType: sarama.AclResourceGroup, ResourceName: "test-group", ResourcePatternType: sarama.AclPatternLiteral}:          1,
+		{ResourceType: sarama.AclResourceTransactionalID, ResourceName: "test-tx", ResourcePatternType: sarama.AclPatternPrefixed}:  2,
+		{ResourceType: sarama.AclResourceCluster, ResourceName: clusterResourceName, ResourcePatternType: sarama.AclPatternLiteral}: 1,
+	}
+	acls, _ := client.DescribeUserACLs("test-user")
+	if len(acls) != len(expected) {
+		t.Fatal("Expected ACLs on", len(expected), "resources, got:", acls)
+	}
+	for _, resourceAcls := range acls {
+		if count, ok := expected[resourceAcls.Resource]; !ok || count != len(resourceAcls.Acls) {
+			t.Error("Unexpected ACLs:", resourceAcls.Resource, len(resourceAcls.Acls))
+		}
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.CreateUserClusterACLs(v1alpha1.KafkaClusterOperationDescribe, "test-user"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+var (
+	rollingUpgradeInProgressDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", "rolling_upgrade_in_progress"),
+		"Whether a rolling upgrade of the cluster is in progress.",
+		[]string{"namespace", "cluster"}, nil)
+	rollingUpgradeErrorCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", "rolling_upgrade_error_count"),
+		"Number of failed health checks during the current rolling upgrade of the cluster.",
+		[]string{"namespace", "cluster"}, nil)
+	brokersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", "brokers"),
+		"Number of brokers of the cluster.",
+		[]string{"namespace", "cluster"}, nil)
+	brokersOutOfSyncDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", "brokers_out_of_sync"),
+		"Number of brokers waiting to be restarted with their new configuration.",
+		[]string{"namespace", "cluster"}, nil)
+	brokerCruiseControlStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "broker", "cruise_control_state"),
+		"Cruise Control graceful action state of the broker, the value is always 1.",
+		[]string{"namespace", "cluster", "broker", "state"}, nil)
+	userTopicGrantsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "topic_grants"),
+		"Number of topic grants of the user.",
+		[]string{"namespace", "user", "cluster"}, nil)
+	userACLsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "acls"),
+		"Number of ACLs created for the user.",
+		[]string{"namespace", "user", "cluster"}, nil)
+)
+
+// StatusCollector exports the state recorded in the status of the KafkaCluster and KafkaUser resources
+type StatusCollector struct {
+	client client.Reader
+	log    logr.Logger
+}
+
+// NewStatusCollector returns a collector listing the resources with the given client on every scrape
+func NewStatusCollector(client client.Reader, log logr.Logger) *StatusCollector {
+	return &StatusCollector{
+		client: client,
+		log:    log,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *StatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rollingUpgradeInProgressDesc
+	ch <- rollingUpgradeErrorCountDesc
+	ch <- brokersDesc
+	ch <- brokersOutOfSyncDesc
+	ch <- brokerCruiseControlStateDesc
+	ch <- userTopicGrantsDesc
+	ch <- userACLsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *StatusCollector) Collect(ch chan<- prometheus.Metric) {
+	clusters := &v1beta1.KafkaClusterList{}
+	if err := c.client.List(context.TODO(), clusters); err != nil {
+		c.log.Error(err, "could not list kafka clusters for metrics")
+	}
+	for _, cluster := range clusters.Items {
+		collectCluster(ch, &cluster)
+	}
+
+	users := &v1alpha1.KafkaUserList{}
+	if err := c.client.List(context.TODO(), users); err != nil {
+		c.log.Error(err, "could not list kafka users for metrics")
+	}
+	for _, user := range users.Items {
+		ch <- prometheus.MustNewConstMetric(userTopicGrantsDesc, prometheus.GaugeValue,
+			float64(len(user.Spec.TopicGrants)), user.Namespace, user.Name, user.Spec.ClusterRef.Name)
+		ch <- prometheus.MustNewConstMetric(userACLsDesc, prometheus.GaugeValue,
+			float64(len(user.Status.ACLs)), user.Namespace, user.Name, user.Spec.ClusterRef.Name)
+	}
+}
+
+func collectCluster(ch chan<- prometheus.Metric, cluster *v1beta1.KafkaCluster) {
+	rollingUpgrade := 0.0
+	if cluster.Status.State == v1beta1.KafkaClusterRollingUpgrading {
+		rollingUpgrade = 1
+	}
+	ch <- prometheus.MustNewConstMetric(rollingUpgradeInProgressDesc, prometheus.GaugeValue,
+		rollingUpgrade, cluster.Namespace, cluster.Name)
+	ch <- prometheus.MustNewConstMetric(rollingUpgradeErrorCountDesc, prometheus.GaugeValue,
+		float64(cluster.Status.RollingUpgrade.ErrorCount), cluster.Namespace, cluster.Name)
+	ch <- prometheus.MustNewConstMetric(brokersDesc, prometheus.GaugeValue,
+		float64(len(cluster.Spec.Brokers)), cluster.Namespace, cluster.Name)
+
+	outOfSync := 0
+	for brokerId, state := range cluster.Status.BrokersState {
+		if state.ConfigurationState == v1beta1.ConfigOutOfSync {
+			outOfSync++
+		}
+		if state.GracefulActionState.CruiseControlState != "" {
+			ch <- prometheus.MustNewConstMetric(brokerCruiseControlStateDesc, prometheus.GaugeValue, 1,
+				cluster.Namespace, cluster.Name, brokerId, string(state.GracefulActionState.CruiseControlState))
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(brokersOutOfSyncDesc, prometheus.GaugeValue,
+		float64(outOfSync), cluster.Namespace, cluster.Name)
+}
@@ -0,0 +1,121 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+const namespace = "kafka_operator"
+
+var (
+	clusterReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cluster",
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of the reconciliations of a KafkaCluster.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"namespace", "cluster"})
+
+	clusterReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cluster",
+		Name:      "reconcile_errors_total",
+		Help:      "Number of failed reconciliations of a KafkaCluster by the type of the error.",
+	}, []string{"namespace", "cluster", "reason"})
+
+	clusterOfflineReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cluster",
+		Name:      "offline_replicas",
+		Help:      "Number of offline partition replicas found by the last health check of the cluster.",
+	}, []string{"namespace", "cluster"})
+
+	clusterReplicasInSync = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cluster",
+		Name:      "replicas_in_sync",
+		Help:      "Whether every partition replica was in sync at the last health check of the cluster.",
+	}, []string{"namespace", "cluster"})
+
+	alertsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "alertmanager",
+		Name:      "alerts_received_total",
+		Help:      "Number of alerts received from Prometheus Alertmanager by their command.",
+	}, []string{"command"})
+
+	alertsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "alertmanager",
+		Name:      "alerts_processed_total",
+		Help:      "Number of alerts the command was run for.",
+	}, []string{"command"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		clusterReconcileDuration,
+		clusterReconcileErrors,
+		clusterOfflineReplicas,
+		clusterReplicasInSync,
+		alertsReceived,
+		alertsProcessed,
+	)
+}
+
+// ObserveClusterReconcile records how long the reconciliation of the cluster took
+func ObserveClusterReconcile(clusterNamespace, clusterName string, duration time.Duration) {
+	clusterReconcileDuration.WithLabelValues(clusterNamespace, clusterName).Observe(duration.Seconds())
+}
+
+// ClusterReconcileFailed counts the failed reconciliation of the cluster by the errorfactory type of the error
+func ClusterReconcileFailed(cluster *v1beta1.KafkaCluster, err error) {
+	clusterReconcileErrors.WithLabelValues(cluster.Namespace, cluster.Name, errorfactory.Reason(err)).Inc()
+}
+
+// SetClusterReplicaHealth records the result of the replica health check of the cluster
+func SetClusterReplicaHealth(cluster *v1beta1.KafkaCluster, offlineReplicaCount int, replicasInSync bool) {
+	clusterOfflineReplicas.WithLabelValues(cluster.Namespace, cluster.Name).Set(float64(offlineReplicaCount))
+	inSync := 0.0
+	if replicasInSync {
+		inSync = 1
+	}
+	clusterReplicasInSync.WithLabelValues(cluster.Namespace, cluster.Name).Set(inSync)
+}
+
+// DeleteCluster drops the health and duration series of a deleted cluster, the error counters keep their totals
+func DeleteCluster(clusterNamespace, clusterName string) {
+	labels := prometheus.Labels{"namespace": clusterNamespace, "cluster": clusterName}
+	clusterReconcileDuration.Delete(labels)
+	clusterOfflineReplicas.Delete(labels)
+	clusterReplicasInSync.Delete(labels)
+}
+
+// AlertReceived counts an alert received with the given command
+func AlertReceived(command string) {
+	alertsReceived.WithLabelValues(command).Inc()
+}
+
+// AlertProcessed counts an alert the given command was run for
+func AlertProcessed(command string) {
+	alertsProcessed.WithLabelValues(command).Inc()
+}
@@ -0,0 +1,107 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+)
+
+func TestClusterMetrics(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+
+	ClusterReconcileFailed(cluster, errorfactory.New(errorfactory.BrokersUnreachable{}, errors.New("dial failed"), "could not connect"))
+	if value := testutil.ToFloat64(clusterReconcileErrors.WithLabelValues("kafka", "kafka", "BrokersUnreachable")); value != 1 {
+		t.Error("Expected one BrokersUnreachable error, got:", value)
+	}
+
+	SetClusterReplicaHealth(cluster, 3, false)
+	if value := testutil.ToFloat64(clusterOfflineReplicas.WithLabelValues("kafka", "kafka")); value != 3 {
+		t.Error("Expected 3 offline replicas, got:", value)
+	}
+	if value := testutil.ToFloat64(clusterReplicasInSync.WithLabelValues("kafka", "kafka")); value != 0 {
+		t.Error("Expected replicas out of sync, got:", value)
+	}
+
+	DeleteCluster("kafka", "kafka")
+	if count := testutil.CollectAndCount(clusterOfflineReplicas); count != 0 {
+		t.Error("Expected the series of the deleted cluster to be dropped, got:", count)
+	}
+}
+
+func TestStatusCollector(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = v1beta1.AddToScheme(s)
+	_ = v1alpha1.AddToScheme(s)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{Id: 0}, {Id: 1}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			State: v1beta1.KafkaClusterRollingUpgrading,
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {ConfigurationState: v1beta1.ConfigInSync},
+				"1": {
+					ConfigurationState:  v1beta1.ConfigOutOfSync,
+					GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleRunning},
+				},
+			},
+		},
+	}
+	user := &v1alpha1.KafkaUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "user", Namespace: "kafka"},
+		Spec: v1alpha1.KafkaUserSpec{
+			ClusterRef:  v1alpha1.ClusterReference{Name: "kafka"},
+			TopicGrants: []v1alpha1.UserTopicGrant{{TopicName: "orders"}},
+		},
+		Status: v1alpha1.KafkaUserStatus{ACLs: []string{"acl-1", "acl-2"}},
+	}
+
+	collector := NewStatusCollector(fake.NewFakeClientWithScheme(s, cluster, user), logf.NullLogger{})
+	expected := `
+# HELP kafka_operator_broker_cruise_control_state Cruise Control graceful action state of the broker, the value is always 1.
+# TYPE kafka_operator_broker_cruise_control_state gauge
+kafka_operator_broker_cruise_control_state{broker="1",cluster="kafka",namespace="kafka",state="GracefulUpscaleRunning"} 1
+# HELP kafka_operator_cluster_brokers_out_of_sync Number of brokers waiting to be restarted with their new configuration.
+# TYPE kafka_operator_cluster_brokers_out_of_sync gauge
+kafka_operator_cluster_brokers_out_of_sync{cluster="kafka",namespace="kafka"} 1
+# HELP kafka_operator_cluster_rolling_upgrade_in_progress Whether a rolling upgrade of the cluster is in progress.
+# TYPE kafka_operator_cluster_rolling_upgrade_in_progress gauge
+kafka_operator_cluster_rolling_upgrade_in_progress{cluster="kafka",namespace="kafka"} 1
+# HELP kafka_operator_user_acls Number of ACLs created for the user.
+# TYPE kafka_operator_user_acls gauge
+kafka_operator_user_acls{cluster="kafka",namespace="kafka",user="user"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kafka_operator_broker_cruise_control_state",
+		"kafka_operator_cluster_brokers_out_of_sync",
+		"kafka_operator_cluster_rolling_upgrade_in_progress",
+		"kafka_operator_user_acls"); err != nil {
+		t.Error(err)
+	}
+}
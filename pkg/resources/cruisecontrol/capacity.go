@@ -0,0 +1,148 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cruisecontrol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	kafkautils "github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+// BrokerResources are the resources the brokers got in the Kubernetes cluster by broker id
+type BrokerResources map[string]BrokerResource
+
+// BrokerResource is the resources the pod and the volumes of a broker got
+type BrokerResource struct {
+	// CPU is the CPU limit of the kafka container, or its request when it has no limit
+	CPU *resource.Quantity
+	// Disks are the capacities of the volumes of the broker in bytes by their mount path
+	Disks map[string]int64
+	// Node is the node the pod of the broker runs on, it is only looked up when the capacity is derived from the nodes
+	Node *corev1.Node
+	// LastCapacity is the capacity of the broker in the current capacity config, the CPU and network capacity derived from
+	// the node are kept from it while the pod is not scheduled to a node, so restarting the broker does not change them
+	LastCapacity *Capacity
+}
+
+// brokerResources collects the resources of the broker pods and volumes, so the capacity config follows the resource
+// changes and the volume resizes of the brokers
+func (r *Reconciler) brokerResources(log logr.Logger) (BrokerResources, error) {
+	brokerResources := make(BrokerResources)
+	matchingLabels := client.MatchingLabels(kafkautils.LabelsForKafka(r.KafkaCluster.Name))
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(context.TODO(), pvcList, client.InNamespace(r.KafkaCluster.Namespace), matchingLabels); err != nil {
+		return nil, errorfactory.New(errorfactory.APIFailure{}, err, "listing broker volumes failed")
+	}
+	for _, pvc := range pvcList.Items {
+		brokerId, mountPath := pvc.Labels["brokerId"], pvc.Annotations["mountPath"]
+		size, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+		if brokerId == "" || mountPath == "" || !ok {
+			continue
+		}
+		resources := brokerResources[brokerId]
+		if resources.Disks == nil {
+			resources.Disks = make(map[string]int64)
+		}
+		resources.Disks[mountPath] = size.Value()
+		brokerResources[brokerId] = resources
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), podList, client.InNamespace(r.KafkaCluster.Namespace), matchingLabels); err != nil {
+		return nil, errorfactory.New(errorfactory.APIFailure{}, err, "listing broker pods failed")
+	}
+	for _, pod := range podList.Items {
+		brokerId := pod.Labels["brokerId"]
+		if brokerId == "" || pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		resources := brokerResources[brokerId]
+		for _, container := range pod.Spec.Containers {
+			if container.Name == "kafka" {
+				resources.CPU = containerCPU(container.Resources)
+			}
+		}
+		if r.KafkaCluster.Spec.CruiseControlConfig.NodeCapacity != nil && pod.Spec.NodeName != "" {
+			node := &corev1.Node{}
+			err := r.Client.Get(context.TODO(), types.NamespacedName{Name: pod.Spec.NodeName}, node)
+			switch {
+			case err == nil:
+				resources.Node = node
+			case apierrors.IsNotFound(err):
+				log.Info("node of the broker pod not found", "brokerId", brokerId, "node", pod.Spec.NodeName)
+			default:
+				return nil, errorfactory.New(errorfactory.APIFailure{}, err, "getting node of broker failed", "node", pod.Spec.NodeName)
+			}
+		}
+		brokerResources[brokerId] = resources
+	}
+
+	if r.KafkaCluster.Spec.CruiseControlConfig.NodeCapacity == nil {
+		return brokerResources, nil
+	}
+	lastCapacities, err := r.lastBrokerCapacities(log)
+	if err != nil {
+		return nil, err
+	}
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerId := strconv.Itoa(int(broker.Id))
+		resources := brokerResources[brokerId]
+		if capacity, ok := lastCapacities[brokerId]; ok && resources.Node == nil {
+			resources.LastCapacity = &capacity
+			brokerResources[brokerId] = resources
+		}
+	}
+
+	return brokerResources, nil
+}
+
+// lastBrokerCapacities returns the capacities of the brokers in the current capacity config by broker id
+func (r *Reconciler) lastBrokerCapacities(log logr.Logger) (map[string]Capacity, error) {
+	config := &corev1.ConfigMap{}
+	key := types.NamespacedName{Name: fmt.Sprintf(configAndVolumeNameTemplate, r.KafkaCluster.Name), Namespace: r.KafkaCluster.Namespace}
+	if err := r.Client.Get(context.TODO(), key, config); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errorfactory.New(errorfactory.APIFailure{}, err, "getting cruise control configmap failed", "name", key.Name)
+	}
+
+	capacities := make(map[string]Capacity)
+	data, ok := config.Data["capacity.json"]
+	if !ok {
+		return capacities, nil
+	}
+	capacityConfig := CapacityConfig{}
+	if err := json.Unmarshal([]byte(data), &capacityConfig); err != nil {
+		log.Info("could not parse the current capacity config", "error", err.Error())
+		return capacities, nil
+	}
+	for _, brokerCapacity := range capacityConfig.BrokerCapacities {
+		capacities[brokerCapacity.BrokerID] = brokerCapacity.Capacity
+	}
+	return capacities, nil
+}
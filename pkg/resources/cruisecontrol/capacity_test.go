@@ -0,0 +1,105 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cruisecontrol
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/resources"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+	kafkautils "github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+func TestBrokerResources(t *testing.T) {
+	labels := util.MergeLabels(kafkautils.LabelsForKafka("kafka"), map[string]string{"brokerId": "0"})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-0", Namespace: "kafka", Labels: labels},
+		Spec: corev1.PodSpec{
+			NodeName: "node-0",
+			Containers: []corev1.Container{{
+				Name: "kafka",
+				Resources: corev1.ResourceRequirements{
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				},
+			}},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-0-storage-0", Namespace: "kafka", Labels: labels,
+			Annotations: map[string]string{"mountPath": "/kafka-logs"}},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("20Gi")},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+	pendingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-1", Namespace: "kafka",
+			Labels: util.MergeLabels(kafkautils.LabelsForKafka("kafka"), map[string]string{"brokerId": "1"})},
+	}
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-cruisecontrol-config", Namespace: "kafka"},
+		Data: map[string]string{"capacity.json": `{"brokerCapacities":[` +
+			`{"brokerId":"0","capacity":{"DISK":{},"CPU":"100","NW_IN":"125000","NW_OUT":"125000"}},` +
+			`{"brokerId":"1","capacity":{"DISK":{},"CPU":"392","NW_IN":"1250000","NW_OUT":"1250000"}}]}`},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers:             []v1beta1.Broker{{Id: 0}, {Id: 1}},
+			CruiseControlConfig: v1beta1.CruiseControlConfig{NodeCapacity: &v1beta1.NodeCapacityConfig{AllocatableCPU: true}},
+		},
+	}
+	r := &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fake.NewFakeClientWithScheme(scheme.Scheme, pod, pendingPod, pvc, node, config),
+			KafkaCluster: cluster,
+		},
+	}
+
+	brokerResources, err := r.brokerResources(logf.NullLogger{})
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	resources := brokerResources["0"]
+	if resources.CPU == nil || resources.CPU.String() != "3" {
+		t.Error("Expected the cpu limit of the kafka container, got:", resources.CPU)
+	}
+	if resources.Disks["/kafka-logs"] != 10*1024*1024*1024 {
+		t.Error("Expected the capacity of the volume until its resize completes, got:", resources.Disks)
+	}
+	if resources.Node == nil || resources.Node.Name != "node-0" {
+		t.Error("Expected the node of the broker pod, got:", resources.Node)
+	}
+	if resources.LastCapacity != nil {
+		t.Error("Expected the capacity of the scheduled broker to be generated again, got:", resources.LastCapacity)
+	}
+	if last := brokerResources["1"].LastCapacity; last == nil || last.CPU != "392" || last.NWIN != "1250000" {
+		t.Error("Expected the last capacity of the broker whose pod is not scheduled, got:", last)
+	}
+}
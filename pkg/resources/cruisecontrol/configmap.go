@@ -20,19 +20,21 @@ import (
 	"strconv"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/pkg/resources/templates"
 	"github.com/banzaicloud/kafka-operator/pkg/util"
+	clientutil "github.com/banzaicloud/kafka-operator/pkg/util/client"
 	kafkautils "github.com/banzaicloud/kafka-operator/pkg/util/kafka"
 	zookeeperutils "github.com/banzaicloud/kafka-operator/pkg/util/zookeeper"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
-func (r *Reconciler) configMap(clientPass, capacityConfig string, log logr.Logger) runtime.Object {
+func (r *Reconciler) configMap(clientPass, saslUser, saslPass, capacityConfig string, log logr.Logger) runtime.Object {
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: templates.ObjectMeta(
 			fmt.Sprintf(configAndVolumeNameTemplate, r.KafkaCluster.Name),
@@ -43,12 +45,11 @@ func (r *Reconciler) configMap(clientPass, capacityConfig string, log logr.Logge
 			"cruisecontrol.properties": r.KafkaCluster.Spec.CruiseControlConfig.Config + fmt.Sprintf(`
 # The Kafka cluster to control.
 bootstrap.servers=%s:%d
-# The zookeeper connect of the Kafka cluster
-zookeeper.connect=%s
 `, generateBootstrapServer(r.KafkaCluster.Spec.HeadlessServiceEnabled, r.KafkaCluster.Name),
-				generateBootstrapServerPort(log, r.KafkaCluster.Spec.ListenersConfig.InternalListeners),
-				zookeeperutils.PrepareConnectionAddress(r.KafkaCluster.Spec.ZKAddresses, r.KafkaCluster.Spec.GetZkPath())) +
-				generateSSLConfig(&r.KafkaCluster.Spec.ListenersConfig, clientPass),
+				generateBootstrapServerPort(log, r.KafkaCluster.Spec.ListenersConfig.InternalListeners)) +
+				generateMetadataConfig(r.KafkaCluster) +
+				generateSSLConfig(&r.KafkaCluster.Spec.ListenersConfig, clientPass) +
+				generateSASLConfig(r.KafkaCluster, saslUser, saslPass),
 			"capacity.json":       capacityConfig,
 			"clusterConfigs.json": r.KafkaCluster.Spec.CruiseControlConfig.ClusterConfig,
 			"log4j.properties":    r.KafkaCluster.Spec.CruiseControlConfig.GetCCLog4jConfig(),
@@ -57,6 +58,19 @@ zookeeper.connect=%s
 	return configMap
 }
 
+// generateMetadataConfig points Cruise Control to the ZooKeeper of the cluster, in KRaft mode it detects the failed
+// brokers through the brokers instead
+func generateMetadataConfig(cluster *v1beta1.KafkaCluster) string {
+	if cluster.Spec.IsKRaft() {
+		return `# The Kafka cluster runs without ZooKeeper
+kafka.broker.failure.detection.enable=true
+`
+	}
+	return fmt.Sprintf(`# The zookeeper connect of the Kafka cluster
+zookeeper.connect=%s
+`, zookeeperutils.PrepareConnectionAddress(cluster.Spec.ZKAddresses, cluster.Spec.GetZkPath()))
+}
+
 func generateSSLConfig(l *v1beta1.ListenersConfig, clientPass string) (res string) {
 	if l.SSLSecrets != nil && util.IsSSLEnabledForInternalCommunication(l.InternalListeners) {
 		res = fmt.Sprintf(`
@@ -70,6 +84,25 @@ ssl.truststore.password=%s
 	return
 }
 
+// generateSASLConfig overrides the security protocol set by generateSSLConfig when the inner broker listener is a sasl_ssl one
+func generateSASLConfig(cluster *v1beta1.KafkaCluster, saslUser, saslPass string) (res string) {
+	if clientutil.UseSASL(cluster) {
+		res = fmt.Sprintf(`
+security.protocol=%s
+sasl.mechanism=%s
+sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required username="%s" password="%s";
+`, generateSecurityProtocol(cluster), v1beta1.SCRAMMechanism, saslUser, saslPass)
+	}
+	return
+}
+
+func generateSecurityProtocol(cluster *v1beta1.KafkaCluster) string {
+	if clientutil.UseSSL(cluster) {
+		return "SASL_SSL"
+	}
+	return "SASL_PLAINTEXT"
+}
+
 func generateBootstrapServer(headlessEnabled bool, clusterName string) string {
 	if headlessEnabled {
 		return fmt.Sprintf(kafkautils.HeadlessServiceTemplate, clusterName)
@@ -109,8 +142,9 @@ type Capacity struct {
 	NWOUT string            `json:"NW_OUT"`
 }
 
-// generateCapacityConfig generates a CC capacity config with default values or returns the manually overridden value if it exists
-func GenerateCapacityConfig(kafkaCluster *v1beta1.KafkaCluster, log logr.Logger, config *corev1.ConfigMap) string {
+// GenerateCapacityConfig generates a CC capacity config from the resources the brokers got, falling back to their config
+// and the default values, or returns the manually overridden value if it exists
+func GenerateCapacityConfig(kafkaCluster *v1beta1.KafkaCluster, brokerResources BrokerResources, log logr.Logger, config *corev1.ConfigMap) string {
 	log.Info("Generating capacity config")
 
 	// If there is already a config added manually, use that one
@@ -129,17 +163,23 @@ func GenerateCapacityConfig(kafkaCluster *v1beta1.KafkaCluster, log logr.Logger,
 	capacityConfig := CapacityConfig{}
 
 	for _, broker := range kafkaCluster.Spec.Brokers {
+		// the controllers that are not brokers have no partitions to balance
+		if kafkaCluster.Spec.IsKRaft() {
+			if bConfig, err := util.GetBrokerConfig(broker, kafkaCluster.Spec); err == nil && !bConfig.HasRole(v1beta1.ProcessRoleBroker) {
+				continue
+			}
+		}
+		resources := brokerResources[strconv.Itoa(int(broker.Id))]
 		brokerCapacity := BrokerCapacity{
 			BrokerID: fmt.Sprintf("%d", broker.Id),
 			Capacity: Capacity{
-				DISK:  generateBrokerDisks(broker, kafkaCluster.Spec, log),
-				CPU:   generateBrokerCPU(broker, kafkaCluster.Spec, log),
-				NWIN:  generateBrokerNetworkIn(broker, kafkaCluster.Spec, log),
-				NWOUT: generateBrokerNetworkOut(broker, kafkaCluster.Spec, log),
+				DISK:  generateBrokerDisks(broker, kafkaCluster.Spec, resources, log),
+				CPU:   generateBrokerCPU(broker, kafkaCluster.Spec, resources, log),
+				NWIN:  generateBrokerNetworkIn(broker, kafkaCluster.Spec, resources, log),
+				NWOUT: generateBrokerNetworkOut(broker, kafkaCluster.Spec, resources, log),
 			},
 			Doc: "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB.",
 		}
-
 		log.Info("The following brokerCapacity was generated", "brokerCapacity", brokerCapacity)
 
 		capacityConfig.BrokerCapacities = append(capacityConfig.BrokerCapacities, brokerCapacity)
@@ -154,7 +194,30 @@ func GenerateCapacityConfig(kafkaCluster *v1beta1.KafkaCluster, log logr.Logger,
 	return string(result)
 }
 
-func generateBrokerNetworkIn(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, log logr.Logger) string {
+// instanceTypeNetworkConfig returns the network capacity configured for the instance type of the node of the broker
+func instanceTypeNetworkConfig(kafkaClusterSpec v1beta1.KafkaClusterSpec, resources BrokerResource) (v1beta1.NetworkConfig, bool) {
+	nodeCapacity := kafkaClusterSpec.CruiseControlConfig.NodeCapacity
+	if nodeCapacity == nil || resources.Node == nil {
+		return v1beta1.NetworkConfig{}, false
+	}
+	instanceType, ok := resources.Node.Labels[corev1.LabelInstanceTypeStable]
+	if !ok {
+		instanceType = resources.Node.Labels[corev1.LabelInstanceType]
+	}
+	networkConfig, ok := nodeCapacity.InstanceTypeNetworkConfigs[instanceType]
+	return networkConfig, ok && instanceType != ""
+}
+
+// lastNodeCapacity returns the last capacity of the broker while the values derived from its node are unknown,
+// that is until its pod is scheduled again
+func lastNodeCapacity(kafkaClusterSpec v1beta1.KafkaClusterSpec, resources BrokerResource) *Capacity {
+	if kafkaClusterSpec.CruiseControlConfig.NodeCapacity == nil || resources.Node != nil {
+		return nil
+	}
+	return resources.LastCapacity
+}
+
+func generateBrokerNetworkIn(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, resources BrokerResource, log logr.Logger) string {
 	brokerConfig, err := util.GetBrokerConfig(broker, kafkaClusterSpec)
 	if err != nil {
 		log.V(warnLevel).Info("could not get incoming network resource limits falling back to default value")
@@ -163,12 +226,18 @@ func generateBrokerNetworkIn(broker v1beta1.Broker, kafkaClusterSpec v1beta1.Kaf
 	if brokerConfig.NetworkConfig != nil && brokerConfig.NetworkConfig.IncomingNetworkThroughPut != "" {
 		return brokerConfig.NetworkConfig.IncomingNetworkThroughPut
 	}
+	if networkConfig, ok := instanceTypeNetworkConfig(kafkaClusterSpec, resources); ok && networkConfig.IncomingNetworkThroughPut != "" {
+		return networkConfig.IncomingNetworkThroughPut
+	}
+	if last := lastNodeCapacity(kafkaClusterSpec, resources); last != nil && len(kafkaClusterSpec.CruiseControlConfig.NodeCapacity.InstanceTypeNetworkConfigs) > 0 {
+		return last.NWIN
+	}
 
 	log.Info("incoming network throughput is not set falling back to default value")
 	return storageConfigNWINDefaultValue
 }
 
-func generateBrokerNetworkOut(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, log logr.Logger) string {
+func generateBrokerNetworkOut(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, resources BrokerResource, log logr.Logger) string {
 	brokerConfig, err := util.GetBrokerConfig(broker, kafkaClusterSpec)
 	if err != nil {
 		log.V(warnLevel).Info("could not get outgoing network resource limits falling back to default value")
@@ -177,22 +246,57 @@ func generateBrokerNetworkOut(broker v1beta1.Broker, kafkaClusterSpec v1beta1.Ka
 	if brokerConfig.NetworkConfig != nil && brokerConfig.NetworkConfig.OutgoingNetworkThroughPut != "" {
 		return brokerConfig.NetworkConfig.OutgoingNetworkThroughPut
 	}
+	if networkConfig, ok := instanceTypeNetworkConfig(kafkaClusterSpec, resources); ok && networkConfig.OutgoingNetworkThroughPut != "" {
+		return networkConfig.OutgoingNetworkThroughPut
+	}
+	if last := lastNodeCapacity(kafkaClusterSpec, resources); last != nil && len(kafkaClusterSpec.CruiseControlConfig.NodeCapacity.InstanceTypeNetworkConfigs) > 0 {
+		return last.NWOUT
+	}
 
 	log.Info("outgoing network throughput is not set falling back to default value")
 	return storageConfigNWOUTDefaultValue
 }
 
-func generateBrokerCPU(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, log logr.Logger) string {
-	brokerConfig, err := util.GetBrokerConfig(broker, kafkaClusterSpec)
-	if err != nil {
-		log.V(warnLevel).Info("could not get cpu resource limits falling back to default value")
+// generateBrokerCPU returns the CPU limit of the broker, or its request when it has no limit, in percentage of a core
+func generateBrokerCPU(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, resources BrokerResource, log logr.Logger) string {
+	cpu := resources.CPU
+	if cpu == nil {
+		brokerConfig, err := util.GetBrokerConfig(broker, kafkaClusterSpec)
+		if err != nil {
+			log.V(warnLevel).Info("could not get cpu resource limits falling back to default value")
+			return storageConfigCPUDefaultValue
+		}
+		cpu = containerCPU(*brokerConfig.GetResources())
+	}
+	nodeCapacity := kafkaClusterSpec.CruiseControlConfig.NodeCapacity
+	if cpu == nil && nodeCapacity != nil && nodeCapacity.AllocatableCPU && resources.Node != nil {
+		if allocatable, ok := resources.Node.Status.Allocatable[corev1.ResourceCPU]; ok {
+			cpu = &allocatable
+		}
+	}
+	if last := lastNodeCapacity(kafkaClusterSpec, resources); cpu == nil && last != nil && nodeCapacity.AllocatableCPU {
+		return last.CPU
+	}
+	if cpu == nil {
+		log.Info("cpu resources are not set falling back to default value", "brokerId", broker.Id)
 		return storageConfigCPUDefaultValue
 	}
 
-	return strconv.Itoa(int(brokerConfig.GetResources().Limits.Cpu().ScaledValue(-2)))
+	return strconv.Itoa(int(cpu.ScaledValue(-2)))
+}
+
+// containerCPU returns the CPU limit of the container, or its request when it has no limit
+func containerCPU(resources corev1.ResourceRequirements) *resource.Quantity {
+	if cpu, ok := resources.Limits[corev1.ResourceCPU]; ok && !cpu.IsZero() {
+		return &cpu
+	}
+	if cpu, ok := resources.Requests[corev1.ResourceCPU]; ok && !cpu.IsZero() {
+		return &cpu
+	}
+	return nil
 }
 
-func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, log logr.Logger) map[string]string {
+func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, resources BrokerResource, log logr.Logger) map[string]string {
 	brokerDisks := map[string]string{}
 
 	// Get disks from the BrokerConfigGroup if it's in use
@@ -206,9 +310,15 @@ func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.Ka
 		parseMountPathWithSize(*brokerState.BrokerConfig, log, brokerState, brokerDisks)
 	}
 
+	// The volumes may have been resized since they were created, their capacity is the actual size
+	for mountPath, size := range resources.Disks {
+		if _, ok := brokerDisks[mountPath+"/kafka"]; ok {
+			brokerDisks[mountPath+"/kafka"] = strconv.FormatInt(size, 10)
+		}
+	}
+
 	return brokerDisks
 }
-
 func parseMountPathWithSize(brokerConfigGroup v1beta1.BrokerConfig, log logr.Logger, brokerState v1beta1.Broker, brokerDisks map[string]string) {
 	for _, storageConfig := range brokerConfigGroup.StorageConfigs {
 		int64Value, isConvertible := util.QuantityPointer(storageConfig.PvcSpec.Resources.Requests["storage"]).AsInt64()
@@ -21,6 +21,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
@@ -33,6 +34,7 @@ func TestGenerateCapacityConfig(t *testing.T) {
 	testCases := []struct {
 		testName              string
 		kafkaCluster          v1beta1.KafkaCluster
+		brokerResources       BrokerResources
 		expectedConfiguration string
 	}{
 		{
@@ -361,6 +363,157 @@ func TestGenerateCapacityConfig(t *testing.T) {
 					]
                   }`,
 		},
+		{
+			testName: "generate capacity config from the resources the brokers got",
+			kafkaCluster: v1beta1.KafkaCluster{
+				Spec: v1beta1.KafkaClusterSpec{
+					BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+						"default": {
+							Resources: &v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									"cpu": cpuQuantity,
+								}},
+							StorageConfigs: []v1beta1.StorageConfig{
+								{
+									MountPath: "/path-from-default",
+									PvcSpec: &v1.PersistentVolumeClaimSpec{
+										Resources: v1.ResourceRequirements{
+											Requests: v1.ResourceList{
+												"storage": quantity,
+											},
+										},
+									},
+								},
+							},
+						},
+						"memory-only": {
+							Resources: &v1.ResourceRequirements{
+								Limits: v1.ResourceList{
+									"memory": quantity,
+								}},
+						},
+						"network": {
+							Resources: &v1.ResourceRequirements{
+								Limits: v1.ResourceList{
+									"cpu": resource.MustParse("2"),
+								}},
+							NetworkConfig: &v1beta1.NetworkConfig{
+								IncomingNetworkThroughPut: "250000",
+								OutgoingNetworkThroughPut: "250000",
+							},
+						},
+					},
+					Brokers: []v1beta1.Broker{
+						{
+							Id:                0,
+							BrokerConfigGroup: "default",
+						},
+						{
+							Id:                1,
+							BrokerConfigGroup: "memory-only",
+						},
+						{
+							Id:                2,
+							BrokerConfigGroup: "memory-only",
+						},
+						{
+							Id:                3,
+							BrokerConfigGroup: "network",
+						},
+					},
+					CruiseControlConfig: v1beta1.CruiseControlConfig{
+						NodeCapacity: &v1beta1.NodeCapacityConfig{
+							AllocatableCPU: true,
+							InstanceTypeNetworkConfigs: map[string]v1beta1.NetworkConfig{
+								"m5.xlarge": {
+									IncomingNetworkThroughPut: "1250000",
+									OutgoingNetworkThroughPut: "1250000",
+								},
+							},
+						},
+					},
+				},
+			},
+			brokerResources: BrokerResources{
+				"0": {
+					Disks: map[string]int64{"/path-from-default": 21474836480},
+				},
+				"1": {
+					Node: &v1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{v1.LabelInstanceTypeStable: "m5.xlarge"},
+						},
+						Status: v1.NodeStatus{
+							Allocatable: v1.ResourceList{
+								"cpu": resource.MustParse("3920m"),
+							},
+						},
+					},
+				},
+				"2": {
+					LastCapacity: &Capacity{
+						DISK:  map[string]string{"/kafka-logs/kafka": "1073741824"},
+						CPU:   "392",
+						NWIN:  "1250000",
+						NWOUT: "1250000",
+					},
+				},
+				"3": {
+					LastCapacity: &Capacity{
+						DISK:  map[string]string{},
+						CPU:   "392",
+						NWIN:  "1250000",
+						NWOUT: "1250000",
+					},
+				},
+			},
+			expectedConfiguration: `{
+					"brokerCapacities": [
+                      {
+					  "brokerId": "0",
+					  "capacity": {
+					   "DISK": {
+						"/path-from-default/kafka": "21474836480"
+					   },
+					   "CPU": "200",
+					   "NW_IN": "125000",
+					   "NW_OUT": "125000"
+					  },
+					  "doc": "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB."
+					 },
+                     {
+					  "brokerId": "1",
+					  "capacity": {
+					   "DISK": {},
+					   "CPU": "392",
+					   "NW_IN": "1250000",
+					   "NW_OUT": "1250000"
+					  },
+					  "doc": "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB."
+					 },
+                     {
+					  "brokerId": "2",
+					  "capacity": {
+					   "DISK": {},
+					   "CPU": "392",
+					   "NW_IN": "1250000",
+					   "NW_OUT": "1250000"
+					  },
+					  "doc": "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB."
+					 },
+                     {
+					  "brokerId": "3",
+					  "capacity": {
+					   "DISK": {},
+					   "CPU": "200",
+					   "NW_IN": "250000",
+					   "NW_OUT": "250000"
+					  },
+					  "doc": "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB."
+					 }
+					]
+                  }`,
+		},
 	}
 
 	t.Parallel()
@@ -370,7 +523,7 @@ func TestGenerateCapacityConfig(t *testing.T) {
 
 		t.Run(test.testName, func(t *testing.T) {
 			var actual CapacityConfig
-			err := json.Unmarshal([]byte(GenerateCapacityConfig(&test.kafkaCluster, log.NullLogger{}, nil)), &actual)
+			err := json.Unmarshal([]byte(GenerateCapacityConfig(&test.kafkaCluster, test.brokerResources, log.NullLogger{}, nil)), &actual)
 			if err != nil {
 				t.Error(err, "could not actual unmarshal json")
 			}
@@ -30,6 +30,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
 	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
 	"github.com/banzaicloud/kafka-operator/pkg/resources"
+	clientutil "github.com/banzaicloud/kafka-operator/pkg/util/client"
 	pkicommon "github.com/banzaicloud/kafka-operator/pkg/util/pki"
 )
 
@@ -83,6 +84,13 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		return err
 	}
 
+	var saslUser, saslPass string
+	if clientutil.UseSASL(r.KafkaCluster) {
+		if saslUser, saslPass, err = k8sutil.LookupSASLAdminCredentials(r.Client, r.KafkaCluster); err != nil {
+			return err
+		}
+	}
+
 	if r.KafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint == "" {
 
 		genErr := generateCCTopic(r.KafkaCluster, r.Client, log.WithName("generateCCTopic"))
@@ -120,9 +128,13 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 					)
 				}
 			}
-			capacityConfig := GenerateCapacityConfig(r.KafkaCluster, log, config)
+			brokerResources, err := r.brokerResources(log)
+			if err != nil {
+				return err
+			}
+			capacityConfig := GenerateCapacityConfig(r.KafkaCluster, brokerResources, log, config)
 
-			o = r.configMap(clientPass, capacityConfig, log)
+			o = r.configMap(clientPass, saslUser, saslPass, capacityConfig, log)
 			err = k8sutil.Reconcile(log, r.Client, o, r.KafkaCluster)
 			if err != nil {
 				return errors.WrapIfWithDetails(err, "failed to reconcile resource", "resource", o.GetObjectKind().GroupVersionKind())
@@ -47,6 +47,12 @@ func (r *Reconciler) allBrokerService() runtime.Object {
 		})
 	}
 
+	selector := kafkautils.LabelsForKafka(r.KafkaCluster.Name)
+	if r.KafkaCluster.Spec.IsKRaft() {
+		// the controllers that are not brokers do not serve clients
+		selector[kafkautils.BrokerNodeLabel] = "true"
+	}
+
 	return &corev1.Service{
 		ObjectMeta: templates.ObjectMetaWithAnnotations(
 			fmt.Sprintf(kafkautils.AllBrokerServiceTemplate, r.KafkaCluster.Name),
@@ -56,7 +62,7 @@ func (r *Reconciler) allBrokerService() runtime.Object {
 		Spec: corev1.ServiceSpec{
 			Type:            corev1.ServiceTypeClusterIP,
 			SessionAffinity: corev1.ServiceAffinityNone,
-			Selector:        kafkautils.LabelsForKafka(r.KafkaCluster.Name),
+			Selector:        selector,
 			Ports:           usedPorts,
 		},
 	}
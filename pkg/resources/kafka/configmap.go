@@ -41,7 +41,11 @@ var kafkaConfigTemplate = `
 control.plane.listener.name={{ .ControlPlaneListener }}
 {{ end }}
 
+{{ if .KRaftConfig }}
+{{ .KRaftConfig }}
+{{ else }}
 zookeeper.connect={{ .ZookeeperConnectString }}
+{{ end }}
 
 {{ if .KafkaCluster.Spec.ListenersConfig.SSLSecrets }}
 
@@ -61,10 +65,18 @@ cruise.control.metrics.reporter.ssl.keystore.password={{ .ClientKeystorePassword
 {{ end }}
 {{ end }}
 
+{{ if .SASLConfig }}
+{{ .SASLConfig }}
+{{ end }}
+
+{{ if .BrokerNode }}
 metric.reporters=com.linkedin.kafka.cruisecontrol.metricsreporter.CruiseControlMetricsReporter
 cruise.control.metrics.reporter.bootstrap.servers={{ .CruiseControlBootstrapServers }}
 cruise.control.metrics.reporter.kubernetes.mode=true
+{{ end }}
+{{ if not .KRaftConfig }}
 broker.id={{ .Id }}
+{{ end }}
 
 {{ if .StorageConfig }}
 log.dirs={{ .StorageConfig }}
@@ -77,18 +89,34 @@ super.users={{ .SuperUsers }}
 {{ end }}
 `
 
-func (r *Reconciler) getConfigString(bConfig *v1beta1.BrokerConfig, id int32, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, serverPass, clientPass string, superUsers []string, log logr.Logger) string {
+func (r *Reconciler) getConfigString(bConfig *v1beta1.BrokerConfig, id int32, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, serverPass, clientPass, saslUser, saslPass string, superUsers []string, log logr.Logger) string {
+	listenerConfig := generateListenerSpecificConfig(&r.KafkaCluster.Spec.ListenersConfig, log)
+	advertisedListenerConfig := generateAdvertisedListenerConfig(id, r.KafkaCluster.Spec.ListenersConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses)
+	controlPlaneListener := generateControlPlaneListener(r.KafkaCluster.Spec.ListenersConfig.InternalListeners)
+	var kraftConfig string
+	if r.KafkaCluster.Spec.IsKRaft() {
+		listenerConfig = r.generateKRaftListenerConfig(bConfig, log)
+		if !r.isBrokerNode(bConfig) {
+			advertisedListenerConfig = ""
+		}
+		// the controllers replace the control plane listener in KRaft mode
+		controlPlaneListener = ""
+		kraftConfig = r.generateKRaftConfig(id, bConfig, log)
+	}
+
 	var out bytes.Buffer
 	t := template.Must(template.New("bConfig-config").Parse(kafkaConfigTemplate))
 	if err := t.Execute(&out, map[string]interface{}{
 		"KafkaCluster":                       r.KafkaCluster,
 		"Id":                                 id,
-		"ListenerConfig":                     generateListenerSpecificConfig(&r.KafkaCluster.Spec.ListenersConfig, log),
+		"ListenerConfig":                     listenerConfig,
+		"KRaftConfig":                        kraftConfig,
+		"BrokerNode":                         r.isBrokerNode(bConfig),
 		"SSLEnabledForInternalCommunication": r.KafkaCluster.Spec.ListenersConfig.SSLSecrets != nil && util.IsSSLEnabledForInternalCommunication(r.KafkaCluster.Spec.ListenersConfig.InternalListeners),
 		"ZookeeperConnectString":             zookeeperutils.PrepareConnectionAddress(r.KafkaCluster.Spec.ZKAddresses, r.KafkaCluster.Spec.GetZkPath()),
 		"CruiseControlBootstrapServers":      getInternalListener(r.KafkaCluster.Spec.ListenersConfig.InternalListeners, id, r.KafkaCluster.Spec.GetKubernetesClusterDomain(), r.KafkaCluster.Namespace, r.KafkaCluster.Name, r.KafkaCluster.Spec.HeadlessServiceEnabled),
 		"StorageConfig":                      generateStorageConfig(bConfig.StorageConfigs),
-		"AdvertisedListenersConfig":          generateAdvertisedListenerConfig(id, r.KafkaCluster.Spec.ListenersConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses),
+		"AdvertisedListenersConfig":          advertisedListenerConfig,
 		"SuperUsers":                         strings.Join(generateSuperUsers(superUsers), ";"),
 		"ServerKeystorePath":                 serverKeystorePath,
 		"ClientKeystorePath":                 clientKeystorePath,
@@ -96,7 +124,8 @@ func (r *Reconciler) getConfigString(bConfig *v1beta1.BrokerConfig, id int32, ex
 		"TrustStoreFile":                     v1alpha1.TLSJKSTrustStore,
 		"ServerKeystorePassword":             serverPass,
 		"ClientKeystorePassword":             clientPass,
-		"ControlPlaneListener":               generateControlPlaneListener(r.KafkaCluster.Spec.ListenersConfig.InternalListeners),
+		"ControlPlaneListener":               controlPlaneListener,
+		"SASLConfig":                         generateSASLConfig(&r.KafkaCluster.Spec.ListenersConfig, saslUser, saslPass),
 	}); err != nil {
 		log.Error(err, "error occurred during parsing the config template")
 	}
@@ -111,7 +140,7 @@ func generateSuperUsers(users []string) (suStrings []string) {
 	return
 }
 
-func (r *Reconciler) configMap(id int32, brokerConfig *v1beta1.BrokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, serverPass, clientPass string, superUsers []string, log logr.Logger) *corev1.ConfigMap {
+func (r *Reconciler) configMap(id int32, brokerConfig *v1beta1.BrokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, serverPass, clientPass, saslUser, saslPass string, superUsers []string, log logr.Logger) *corev1.ConfigMap {
 	brokerConf := &corev1.ConfigMap{
 		ObjectMeta: templates.ObjectMeta(
 			fmt.Sprintf(brokerConfigTemplate+"-%d", r.KafkaCluster.Name, id),
@@ -121,7 +150,7 @@ func (r *Reconciler) configMap(id int32, brokerConfig *v1beta1.BrokerConfig, ext
 			),
 			r.KafkaCluster,
 		),
-		Data: map[string]string{kafka.ConfigPropertyName: r.generateBrokerConfig(id, brokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, superUsers, log)},
+		Data: map[string]string{kafka.ConfigPropertyName: r.generateBrokerConfig(id, brokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, saslUser, saslPass, superUsers, log)},
 	}
 	if brokerConfig.Log4jConfig != "" {
 		brokerConf.Data["log4j.properties"] = brokerConfig.Log4jConfig
@@ -174,10 +203,10 @@ func generateControlPlaneListener(iListeners []v1beta1.InternalListenerConfig) s
 }
 
 func generateListenerSpecificConfig(l *v1beta1.ListenersConfig, log logr.Logger) string {
+	return formatListenerSpecificConfig(listenerSpecificConfigs(l, log))
+}
 
-	var interBrokerListenerName string
-	var securityProtocolMapConfig []string
-	var listenerConfig []string
+func listenerSpecificConfigs(l *v1beta1.ListenersConfig, log logr.Logger) (securityProtocolMapConfig, listenerConfig []string, interBrokerListenerName string) {
 
 	for _, iListener := range l.InternalListeners {
 		if iListener.UsedForInnerBrokerCommunication {
@@ -198,11 +227,51 @@ func generateListenerSpecificConfig(l *v1beta1.ListenersConfig, log logr.Logger)
 		securityProtocolMapConfig = append(securityProtocolMapConfig, fmt.Sprintf("%s:%s", UpperedListenerName, UpperedListenerType))
 		listenerConfig = append(listenerConfig, fmt.Sprintf("%s://:%d", UpperedListenerName, eListener.ContainerPort))
 	}
+	return securityProtocolMapConfig, listenerConfig, interBrokerListenerName
+}
+
+func formatListenerSpecificConfig(securityProtocolMapConfig, listenerConfig []string, interBrokerListenerName string) string {
 	return "listener.security.protocol.map=" + strings.Join(securityProtocolMapConfig, ",") + "\n" +
 		"inter.broker.listener.name=" + interBrokerListenerName + "\n" +
 		"listeners=" + strings.Join(listenerConfig, ",") + "\n"
 }
 
+// generateSASLConfig enables SCRAM on the sasl_plaintext and sasl_ssl listeners. Only the inter broker
+// listener gets the admin credentials, since the brokers and the metrics reporter use it as clients.
+func generateSASLConfig(l *v1beta1.ListenersConfig, saslUser, saslPass string) string {
+	if !util.IsSASLEnabled(*l) {
+		return ""
+	}
+	scramLoginModule := "org.apache.kafka.common.security.scram.ScramLoginModule required"
+	adminJaasConfig := fmt.Sprintf(`%s username="%s" password="%s";`, scramLoginModule, saslUser, saslPass)
+
+	saslConfig := []string{"sasl.enabled.mechanisms=" + v1beta1.SCRAMMechanism}
+	for _, iListener := range l.InternalListeners {
+		if !iListener.IsSASL() {
+			continue
+		}
+		jaasConfigKey := fmt.Sprintf("listener.name.%s.%s.sasl.jaas.config", strings.ToLower(iListener.Name), strings.ToLower(v1beta1.SCRAMMechanism))
+		if !iListener.UsedForInnerBrokerCommunication {
+			saslConfig = append(saslConfig, fmt.Sprintf("%s=%s;", jaasConfigKey, scramLoginModule))
+			continue
+		}
+		saslConfig = append(saslConfig,
+			fmt.Sprintf("%s=%s", jaasConfigKey, adminJaasConfig),
+			"sasl.mechanism.inter.broker.protocol="+v1beta1.SCRAMMechanism,
+			"cruise.control.metrics.reporter.security.protocol="+strings.ToUpper(iListener.Type),
+			"cruise.control.metrics.reporter.sasl.mechanism="+v1beta1.SCRAMMechanism,
+			"cruise.control.metrics.reporter.sasl.jaas.config="+adminJaasConfig,
+		)
+	}
+	for _, eListener := range l.ExternalListeners {
+		if eListener.IsSASL() {
+			saslConfig = append(saslConfig, fmt.Sprintf("listener.name.%s.%s.sasl.jaas.config=%s;",
+				strings.ToLower(eListener.Name), strings.ToLower(v1beta1.SCRAMMechanism), scramLoginModule))
+		}
+	}
+	return strings.Join(saslConfig, "\n")
+}
+
 func getInternalListener(iListeners []v1beta1.InternalListenerConfig, id int32, domain, namespace, crName string, headlessServiceEnabled bool) string {
 
 	internalListener := ""
@@ -220,7 +289,7 @@ func getInternalListener(iListeners []v1beta1.InternalListenerConfig, id int32,
 	return internalListener
 }
 
-func (r Reconciler) generateBrokerConfig(id int32, brokerConfig *v1beta1.BrokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, serverPass, clientPass string, superUsers []string, log logr.Logger) string {
+func (r Reconciler) generateBrokerConfig(id int32, brokerConfig *v1beta1.BrokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, serverPass, clientPass, saslUser, saslPass string, superUsers []string, log logr.Logger) string {
 	parsedReadOnlyClusterConfig := util.ParsePropertiesFormat(r.KafkaCluster.Spec.ReadOnlyConfig)
 	var parsedReadOnlyBrokerConfig = map[string]string{}
 
@@ -238,7 +307,7 @@ func (r Reconciler) generateBrokerConfig(id int32, brokerConfig *v1beta1.BrokerC
 	//Generate the Complete Configuration for the Broker
 	completeConfigMap := map[string]string{}
 
-	if err := mergo.Merge(&completeConfigMap, util.ParsePropertiesFormat(r.getConfigString(brokerConfig, id, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, superUsers, log))); err != nil {
+	if err := mergo.Merge(&completeConfigMap, util.ParsePropertiesFormat(r.getConfigString(brokerConfig, id, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, saslUser, saslPass, superUsers, log))); err != nil {
 		log.Error(err, "error occurred during merging operator generated configs")
 	}
 
@@ -246,6 +315,11 @@ func (r Reconciler) generateBrokerConfig(id int32, brokerConfig *v1beta1.BrokerC
 		log.Error(err, "error occurred during merging readOnly config to complete configs")
 	}
 
+	// the protocol versions are pinned during Kafka version upgrades, unless they are set in the readOnlyConfig
+	if err := mergo.Merge(&completeConfigMap, r.kafkaVersionConfig()); err != nil {
+		log.Error(err, "error occurred during merging kafka version configs")
+	}
+
 	completeConfig := make([]string, 0, len(completeConfigMap))
 
 	for key, value := range completeConfigMap {
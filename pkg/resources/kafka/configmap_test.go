@@ -251,7 +251,7 @@ zookeeper.connect=example.zk:2181/`,
 				},
 			}
 
-			generatedConfig := r.generateBrokerConfig(0, r.KafkaCluster.Spec.Brokers[0].BrokerConfig, map[string]v1beta1.ListenerStatusList{}, map[string]v1beta1.ListenerStatusList{}, controllerListenerStatus, "", "", []string{}, logf.NullLogger{})
+			generatedConfig := r.generateBrokerConfig(0, r.KafkaCluster.Spec.Brokers[0].BrokerConfig, map[string]v1beta1.ListenerStatusList{}, map[string]v1beta1.ListenerStatusList{}, controllerListenerStatus, "", "", "", "", []string{}, logf.NullLogger{})
 
 			if generatedConfig != test.expectedConfig {
 				t.Errorf("the expected config is %s, received: %s", test.expectedConfig, generatedConfig)
@@ -259,3 +259,53 @@ zookeeper.connect=example.zk:2181/`,
 		})
 	}
 }
+
+func TestGenerateSASLConfig(t *testing.T) {
+	listeners := &v1beta1.ListenersConfig{
+		InternalListeners: []v1beta1.InternalListenerConfig{
+			{
+				CommonListenerSpec: v1beta1.CommonListenerSpec{
+					Type:          "sasl_plaintext",
+					Name:          "internal",
+					ContainerPort: 29092,
+				},
+				UsedForInnerBrokerCommunication: true,
+			},
+			{
+				CommonListenerSpec: v1beta1.CommonListenerSpec{
+					Type:          "plaintext",
+					Name:          "controller",
+					ContainerPort: 29093,
+				},
+				UsedForControllerCommunication: true,
+			},
+		},
+		ExternalListeners: []v1beta1.ExternalListenerConfig{
+			{
+				CommonListenerSpec: v1beta1.CommonListenerSpec{
+					Type:          "sasl_ssl",
+					Name:          "external",
+					ContainerPort: 9094,
+				},
+			},
+		},
+	}
+
+	expected := `sasl.enabled.mechanisms=SCRAM-SHA-512
+listener.name.internal.scram-sha-512.sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required username="admin" password="secret";
+sasl.mechanism.inter.broker.protocol=SCRAM-SHA-512
+cruise.control.metrics.reporter.security.protocol=SASL_PLAINTEXT
+cruise.control.metrics.reporter.sasl.mechanism=SCRAM-SHA-512
+cruise.control.metrics.reporter.sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required username="admin" password="secret";
+listener.name.external.scram-sha-512.sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required;`
+
+	if generated := generateSASLConfig(listeners, "admin", "secret"); generated != expected {
+		t.Errorf("the expected config is %s, received: %s", expected, generated)
+	}
+
+	listeners.InternalListeners[0].Type = "plaintext"
+	listeners.ExternalListeners[0].Type = "ssl"
+	if generated := generateSASLConfig(listeners, "admin", "secret"); generated != "" {
+		t.Errorf("expected empty sasl config, received: %s", generated)
+	}
+}
@@ -31,6 +31,11 @@ import (
 )
 
 func (r *Reconciler) reconcilePerBrokerDynamicConfig(brokerId int32, brokerConfig *v1beta1.BrokerConfig, configMap *corev1.ConfigMap, log logr.Logger) error {
+	// the configs of the controllers that are not brokers can not be altered through the brokers
+	if !r.isBrokerNode(brokerConfig) {
+		return nil
+	}
+
 	kClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
 	if err != nil {
 		return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
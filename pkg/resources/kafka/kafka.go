@@ -21,6 +21,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/banzaicloud/k8s-objectmatcher/patch"
@@ -31,6 +32,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
@@ -38,6 +40,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
 	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
 	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/metrics"
 	"github.com/banzaicloud/kafka-operator/pkg/pki"
 	"github.com/banzaicloud/kafka-operator/pkg/resources"
 	"github.com/banzaicloud/kafka-operator/pkg/resources/templates"
@@ -66,19 +69,53 @@ const (
 	jmxVolumePath = "/opt/jmx-exporter/"
 	jmxVolumeName = "jmx-jar-data"
 	metricsPort   = 9020
+
+	scramAdminUsernameEnvVar = "SCRAM_ADMIN_USERNAME"
+	scramAdminPasswordEnvVar = "SCRAM_ADMIN_PASSWORD"
+
+	pkiComponent = "pki"
+
+	rollingUpgradeStartedReason = "RollingUpgradeStarted"
+	rollingUpgradeGatedReason   = "RollingUpgradeGated"
+	rollingUpgradeTimeoutReason = "RollingUpgradeTimeout"
+	leadershipRestoredReason    = "LeadershipRestored"
+	brokerPodRestartedReason    = "BrokerPodRestarted"
+
+	kafkaVersionUpgradeReason          = "KafkaVersionUpgrade"
+	kafkaVersionDowngradeRefusedReason = "KafkaVersionDowngradeRefused"
+
+	controllerQuorumChangeReason = "ControllerQuorumChange"
 )
 
+// IsPKIError returns true if the error was returned by the PKI backend of the cluster
+func IsPKIError(err error) bool {
+	details := errors.GetDetails(err)
+	for i := 0; i+1 < len(details); i += 2 {
+		if details[i] == "component" && details[i+1] == pkiComponent {
+			return true
+		}
+	}
+	return false
+}
+
 // Reconciler implements the Component Reconciler
 type Reconciler struct {
 	resources.Reconciler
 	Scheme              *runtime.Scheme
 	kafkaClientProvider kafkaclient.Provider
+	recorder            record.EventRecorder
+	// controllerID is the active controller the brokers were ordered by, -1 when it is unknown
+	controllerID int32
+	// restartedPods are the broker pods deleted during this reconciliation, the cache may not see them terminating yet
+	restartedPods []corev1.Pod
 }
 
 // New creates a new reconciler for Kafka
-func New(client client.Client, directClient client.Reader, scheme *runtime.Scheme, cluster *v1beta1.KafkaCluster, kafkaClientProvider kafkaclient.Provider) *Reconciler {
+func New(client client.Client, directClient client.Reader, scheme *runtime.Scheme, cluster *v1beta1.KafkaCluster,
+	kafkaClientProvider kafkaclient.Provider, recorder record.EventRecorder) *Reconciler {
 	return &Reconciler{
-		Scheme: scheme,
+		Scheme:   scheme,
+		recorder: recorder,
 		Reconciler: resources.Reconciler{
 			Client:       client,
 			DirectClient: directClient,
@@ -146,6 +183,14 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		return errorfactory.New(errorfactory.StatusUpdateError{}, statusErr, "updating deprecated status failed")
 	}
 
+	if err := r.reconcileKafkaVersion(log); err != nil {
+		return err
+	}
+
+	if err := r.reconcileKRaftStatus(log); err != nil {
+		return err
+	}
+
 	if r.KafkaCluster.Spec.HeadlessServiceEnabled {
 		o := r.headlessService()
 		err := k8sutil.Reconcile(log, r.Client, o, r.KafkaCluster)
@@ -192,7 +237,7 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 	if r.KafkaCluster.Spec.ListenersConfig.SSLSecrets != nil {
 		// reconcile the PKI
 		if err := pki.GetPKIManager(r.Client, r.KafkaCluster, v1beta1.PKIBackendProvided).ReconcilePKI(context.TODO(), log, r.Scheme, extListenerStatuses); err != nil {
-			return err
+			return errors.WithDetails(err, "component", pkiComponent)
 		}
 	}
 
@@ -203,6 +248,15 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		return err
 	}
 
+	// The SCRAM admin is used by the brokers themselves on SASL listeners, so it has to be a super user too
+	var saslUser, saslPass string
+	if util.IsSASLEnabled(r.KafkaCluster.Spec.ListenersConfig) {
+		if saslUser, saslPass, err = k8sutil.LookupSASLAdminCredentials(r.Client, r.KafkaCluster); err != nil {
+			return err
+		}
+		superUsers = append(superUsers, saslUser)
+	}
+
 	brokersVolumes := make(map[string][]*corev1.PersistentVolumeClaim, len(r.KafkaCluster.Spec.Brokers))
 	for _, broker := range r.KafkaCluster.Spec.Brokers {
 		brokerConfig, err := util.GetBrokerConfig(broker, r.KafkaCluster.Spec)
@@ -226,7 +280,14 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		}
 	}
 
-	reorderedBrokers := r.reorderBrokers(log, r.KafkaCluster.Spec.Brokers)
+	// the client is shared by the steps reading the state of the brokers, the rolling upgrade connects on its own
+	kClient, closeClient := r.newKafkaClient(log)
+	defer closeClient()
+
+	var reorderedBrokers []v1beta1.Broker
+	reorderedBrokers, r.controllerID = r.reorderBrokers(log, kClient, r.KafkaCluster.Spec.Brokers)
+	r.restartedPods = nil
+	reorderedBrokers = r.groupBrokersByRack(log, reorderedBrokers)
 	for _, broker := range reorderedBrokers {
 		brokerConfig, err := util.GetBrokerConfig(broker, r.KafkaCluster.Spec)
 		if err != nil {
@@ -235,7 +296,7 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 
 		var configMap *corev1.ConfigMap
 		if r.KafkaCluster.Spec.RackAwareness == nil {
-			configMap = r.configMap(broker.Id, brokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, superUsers, log)
+			configMap = r.configMap(broker.Id, brokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, saslUser, saslPass, superUsers, log)
 			err := k8sutil.Reconcile(log, r.Client, configMap, r.KafkaCluster)
 			if err != nil {
 				return errors.WrapIfWithDetails(err, "failed to reconcile resource", "resource", configMap.GetObjectKind().GroupVersionKind())
@@ -243,7 +304,7 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		} else {
 			if brokerState, ok := r.KafkaCluster.Status.BrokersState[strconv.Itoa(int(broker.Id))]; ok {
 				if brokerState.RackAwarenessState != "" {
-					configMap = r.configMap(broker.Id, brokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, superUsers, log)
+					configMap = r.configMap(broker.Id, brokerConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, serverPass, clientPass, saslUser, saslPass, superUsers, log)
 					err := k8sutil.Reconcile(log, r.Client, configMap, r.KafkaCluster)
 					if err != nil {
 						return errors.WrapIfWithDetails(err, "failed to reconcile resource", "resource", configMap.GetObjectKind().GroupVersionKind())
@@ -278,6 +339,16 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		return err
 	}
 
+	if err = r.advanceKafkaVersionUpgrade(log); err != nil {
+		return err
+	}
+
+	if err = r.advanceControllerQuorum(log); err != nil {
+		return err
+	}
+
+	r.updateReplicaHealthMetrics(log, kClient)
+
 	log.V(1).Info("Reconciled")
 
 	return nil
@@ -305,7 +376,7 @@ OUTERLOOP:
 
 	if len(deletedBrokers) > 0 {
 		if !arePodsAlreadyDeleted(deletedBrokers, log) {
-			cc := scale.NewCruiseControlScaler(r.KafkaCluster.Namespace, r.KafkaCluster.Spec.GetKubernetesClusterDomain(), r.KafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, r.KafkaCluster.Name)
+			cc := scale.NewCruiseControlScaler(r.KafkaCluster.Namespace, r.KafkaCluster.Spec.GetKubernetesClusterDomain(), r.KafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, r.KafkaCluster.Name, r.KafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
 			liveBrokers, err := cc.GetLiveKafkaBrokersFromCruiseControl(generateBrokerIdsFromPodSlice(deletedBrokers))
 
 			if err != nil {
@@ -347,6 +418,11 @@ OUTERLOOP:
 				continue
 			}
 
+			if r.isVoter(broker.Labels["brokerId"]) {
+				log.Info("broker is kept until the controller quorum runs without it", "brokerId", broker.Labels["brokerId"])
+				continue
+			}
+
 			if brokerState, ok := r.KafkaCluster.Status.BrokersState[broker.Labels["brokerId"]]; ok &&
 				brokerState.GracefulActionState.CruiseControlState != v1beta1.GracefulDownscaleSucceeded &&
 				brokerState.GracefulActionState.CruiseControlState != v1beta1.GracefulUpscaleRequired &&
@@ -511,7 +587,9 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod)
 		if val, ok := r.KafkaCluster.Status.BrokersState[desiredPod.Labels["brokerId"]]; ok && val.GracefulActionState.CruiseControlState != v1beta1.GracefulUpscaleSucceeded {
 			gracefulActionState := v1beta1.GracefulActionState{ErrorMessage: "CruiseControl not yet ready", CruiseControlState: v1beta1.GracefulUpscaleSucceeded}
 
-			if r.KafkaCluster.Status.CruiseControlTopicStatus == v1beta1.CruiseControlTopicReady {
+			// Cruise Control does not know the controllers that are not brokers, they have no partitions to rebalance
+			brokerNode := !r.KafkaCluster.Spec.IsKRaft() || desiredPod.Labels[kafka.BrokerNodeLabel] == "true"
+			if r.KafkaCluster.Status.CruiseControlTopicStatus == v1beta1.CruiseControlTopicReady && brokerNode {
 				gracefulActionState = v1beta1.GracefulActionState{ErrorMessage: "", CruiseControlState: v1beta1.GracefulUpscaleRequired}
 			}
 			statusErr = k8sutil.UpdateBrokerStatus(r.Client, []string{desiredPod.Labels["brokerId"]}, r.KafkaCluster, gracefulActionState, log)
@@ -568,7 +646,7 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod)
 				r.KafkaCluster.Status.BrokersState[currentPod.Labels["brokerId"]].ConfigurationState == v1beta1.ConfigInSync &&
 				!k8sutil.IsPodContainsEvictedContainer(currentPod) {
 				log.V(1).Info("resource is in sync")
-				return nil
+				return r.restoreLeadership(log, currentPod)
 			}
 		} else {
 			log.V(1).Info("kafka pod resource diffs",
@@ -582,57 +660,25 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod)
 			return errors.WrapIf(err, "could not apply last state to annotation")
 		}
 
+		// Broken pods are still replaced, only the restarts applying a new spec or configuration are held back
+		if r.KafkaCluster.Spec.IsPaused(v1beta1.SubsystemRollingUpgrade) &&
+			!k8sutil.IsPodContainsTerminatedContainer(currentPod) && !k8sutil.IsPodContainsEvictedContainer(currentPod) {
+			log.Info("rolling upgrade is paused, broker pod is not restarted", "pod", currentPod.GetName(), "brokerId", currentPod.Labels["brokerId"])
+			return nil
+		}
+
 		if !k8sutil.IsPodContainsTerminatedContainer(currentPod) {
 
 			if r.KafkaCluster.Status.State != v1beta1.KafkaClusterRollingUpgrading {
 				if err := k8sutil.UpdateCRStatus(r.Client, r.KafkaCluster, v1beta1.KafkaClusterRollingUpgrading, log); err != nil {
 					return errorfactory.New(errorfactory.StatusUpdateError{}, err, "setting state to rolling upgrade failed")
 				}
+				r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, rollingUpgradeStartedReason,
+					"Rolling upgrade started with broker %s", currentPod.Labels["brokerId"])
 			}
 
-			if r.KafkaCluster.Status.State == v1beta1.KafkaClusterRollingUpgrading {
-				// Check if any kafka pod is in terminating or pending state
-				podList := &corev1.PodList{}
-				matchingLabels := client.MatchingLabels(kafka.LabelsForKafka(r.KafkaCluster.Name))
-				err := r.Client.List(context.TODO(), podList, client.ListOption(client.InNamespace(r.KafkaCluster.Namespace)), client.ListOption(matchingLabels))
-				if err != nil {
-					return errors.WrapIf(err, "failed to reconcile resource")
-				}
-				for _, pod := range podList.Items {
-					if k8sutil.IsMarkedForDeletion(pod.ObjectMeta) {
-						return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("pod is still terminating"), "rolling upgrade in progress")
-					}
-					if k8sutil.IsPodContainsPendingContainer(&pod) {
-						return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("pod is still creating"), "rolling upgrade in progress")
-					}
-				}
-
-				errorCount := r.KafkaCluster.Status.RollingUpgrade.ErrorCount
-
-				kClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
-				if err != nil {
-					return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
-				}
-				defer func() {
-					if err := kClient.Close(); err != nil {
-						log.Error(err, "could not close client")
-					}
-				}()
-				offlineReplicaCount, err := kClient.OfflineReplicaCount()
-				if err != nil {
-					return errors.WrapIf(err, "health check failed")
-				}
-				replicasInSync, err := kClient.AllReplicaInSync()
-				if err != nil {
-					return errors.WrapIf(err, "health check failed")
-				}
-
-				if offlineReplicaCount > 0 || !replicasInSync {
-					errorCount++
-				}
-				if errorCount >= r.KafkaCluster.Spec.RollingUpgradeConfig.FailureThreshold {
-					return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("cluster is not healthy"), "rolling upgrade in progress")
-				}
+			if err := r.checkRollingUpgradeGates(log, currentPod); err != nil {
+				return err
 			}
 		}
 
@@ -640,6 +686,10 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod)
 		if err != nil {
 			return errorfactory.New(errorfactory.APIFailure{}, err, "deleting resource failed", "kind", desiredType)
 		}
+		r.restartedPods = append(r.restartedPods, *currentPod)
+		if err := k8sutil.UpdateRollingUpgradeBrokerRestart(r.Client, r.KafkaCluster, time.Now(), log); err != nil {
+			return errorfactory.New(errorfactory.StatusUpdateError{}, err, "recording broker restart failed")
+		}
 
 		// Print terminated container's statuses
 		if k8sutil.IsPodContainsTerminatedContainer(currentPod) {
@@ -651,6 +701,17 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod)
 			}
 		}
 		log.Info("broker pod deleted", "pod", currentPod.GetName(), "brokerId", currentPod.Labels["brokerId"])
+		switch {
+		case k8sutil.IsPodContainsTerminatedContainer(currentPod):
+			r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeWarning, brokerPodRestartedReason,
+				"Restarted pod %s of broker %s as it has a terminated container", currentPod.GetName(), currentPod.Labels["brokerId"])
+		case k8sutil.IsPodContainsEvictedContainer(currentPod):
+			r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeWarning, brokerPodRestartedReason,
+				"Restarted pod %s of broker %s as it was evicted", currentPod.GetName(), currentPod.Labels["brokerId"])
+		default:
+			r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, brokerPodRestartedReason,
+				"Restarted pod %s of broker %s to apply the changed pod spec or configuration", currentPod.GetName(), currentPod.Labels["brokerId"])
+		}
 	}
 	return nil
 
@@ -884,33 +945,66 @@ func getServiceFromExternalListener(client client.Client, cluster *v1beta1.Kafka
 	return foundLBService, nil
 }
 
-func (r *Reconciler) reorderBrokers(log logr.Logger, brokers []v1beta1.Broker) []v1beta1.Broker {
+// updateReplicaHealthMetrics refreshes the replica health gauges of the cluster, so they follow the cluster outside of
+// rolling upgrades too, a failing health check only leaves the last values in place
+func (r *Reconciler) updateReplicaHealthMetrics(log logr.Logger, kClient kafkaclient.KafkaClient) {
+	if kClient == nil {
+		log.V(1).Info("could not create Kafka client, thus could not update replica health metrics")
+		return
+	}
+
+	offlineReplicaCount, err := kClient.OfflineReplicaCount()
+	if err != nil {
+		log.V(1).Info("could not count offline replicas", "error", err.Error())
+		return
+	}
+	replicasInSync, err := kClient.AllReplicaInSync()
+	if err != nil {
+		log.V(1).Info("could not check in sync replicas", "error", err.Error())
+		return
+	}
+	metrics.SetClusterReplicaHealth(r.KafkaCluster, offlineReplicaCount, replicasInSync)
+}
+
+// newKafkaClient connects to the brokers, the returned client is nil if they can not be reached
+func (r *Reconciler) newKafkaClient(log logr.Logger) (kafkaclient.KafkaClient, func()) {
 	kClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
 	if err != nil {
-		log.Info("could not create Kafka client, thus could not determine controller")
-		return brokers
+		log.V(1).Info("could not create Kafka client", "error", err.Error())
+		return nil, func() {}
 	}
-	defer func() {
+	return kClient, func() {
 		if err := kClient.Close(); err != nil {
 			log.Error(err, "could not close client")
 		}
-	}()
+	}
+}
+
+// reorderBrokers moves the active controller to the end of the brokers and returns its id, or -1
+// together with the original order when the controller cannot be determined
+func (r *Reconciler) reorderBrokers(log logr.Logger, kClient kafkaclient.KafkaClient, brokers []v1beta1.Broker) ([]v1beta1.Broker, int32) {
+	if kClient == nil {
+		log.Info("could not create Kafka client, thus could not determine controller")
+		return brokers, -1
+	}
 
 	_, controllerID, err := kClient.DescribeCluster()
 	if err != nil {
 		log.Info("could not find controller broker")
-		return brokers
+		return brokers, -1
 	}
 
-	var controllerBroker v1beta1.Broker
+	var controllerBroker *v1beta1.Broker
 	reorderedBrokers := make([]v1beta1.Broker, 0, len(brokers))
-	for _, broker := range brokers {
-		if broker.Id == controllerID {
-			controllerBroker = broker
+	for i := range brokers {
+		if brokers[i].Id == controllerID {
+			controllerBroker = &brokers[i]
 		} else {
-			reorderedBrokers = append(reorderedBrokers, broker)
+			reorderedBrokers = append(reorderedBrokers, brokers[i])
 		}
 	}
-	reorderedBrokers = append(reorderedBrokers, controllerBroker)
-	return reorderedBrokers
+	if controllerBroker != nil {
+		reorderedBrokers = append(reorderedBrokers, *controllerBroker)
+	}
+	return reorderedBrokers, controllerID
 }
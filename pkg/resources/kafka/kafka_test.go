@@ -18,7 +18,13 @@ import (
 	"reflect"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/resources"
 )
 
 func TestGetBrokersWithPendingOrRunningCCTask(t *testing.T) {
@@ -290,3 +296,36 @@ func TestGetBrokersWithPendingOrRunningCCTask(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateReplicaHealthMetrics(t *testing.T) {
+	r := &Reconciler{
+		Reconciler: resources.Reconciler{
+			KafkaCluster: &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "kafka"}},
+		},
+		kafkaClientProvider: kafkaclient.NewMockProvider(),
+	}
+	kClient, closeClient := r.newKafkaClient(logf.NullLogger{})
+	defer closeClient()
+	r.updateReplicaHealthMetrics(logf.NullLogger{}, kClient)
+
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() != "kafka_operator_cluster_replicas_in_sync" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "cluster" && label.GetValue() == "healthy" {
+					found = metric.GetGauge().GetValue() == 1
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected the replicas of the cluster to be reported in sync outside of a rolling upgrade")
+	}
+}
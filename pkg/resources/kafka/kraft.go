@@ -0,0 +1,304 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+	"github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+// controllerListenerName is the name of the listener the KRaft controllers communicate on
+const controllerListenerName = "CONTROLLER"
+
+// reconcileKRaftStatus generates the id the storage of the brokers is formatted with and records the voters of
+// the quorum, before their first pod is created
+func (r *Reconciler) reconcileKRaftStatus(log logr.Logger) error {
+	if !r.KafkaCluster.Spec.IsKRaft() {
+		return nil
+	}
+	status := r.KafkaCluster.Status.KRaft
+	if status.ClusterID != "" && len(status.Voters) > 0 {
+		return nil
+	}
+	if status.ClusterID == "" {
+		clusterID, err := generateKRaftClusterID()
+		if err != nil {
+			return errorfactory.New(errorfactory.InternalError{}, err, "could not generate kraft cluster id")
+		}
+		status.ClusterID = clusterID
+	}
+	if len(status.Voters) == 0 {
+		status.Voters = r.controllerIDs(log)
+	}
+	if err := k8sutil.UpdateCRStatus(r.Client, r.KafkaCluster, status, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "updating kraft status failed")
+	}
+	return nil
+}
+
+// generateKRaftClusterID returns a random UUID in the URL safe base64 format of the kafka-storage.sh random-uuid command
+func generateKRaftClusterID() (string, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return "", err
+	}
+	// version 4, variant 2
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return base64.RawURLEncoding.EncodeToString(uuid), nil
+}
+
+// isBrokerNode returns true if the broker serves clients, which every broker does outside of KRaft mode
+func (r *Reconciler) isBrokerNode(bConfig *v1beta1.BrokerConfig) bool {
+	return !r.KafkaCluster.Spec.IsKRaft() || bConfig.HasRole(v1beta1.ProcessRoleBroker)
+}
+
+// isControllerNode returns true if the broker takes part in the KRaft controller quorum
+func (r *Reconciler) isControllerNode(bConfig *v1beta1.BrokerConfig) bool {
+	return r.KafkaCluster.Spec.IsKRaft() && bConfig.HasRole(v1beta1.ProcessRoleController)
+}
+
+// isControllerBroker returns true if the broker with the given id takes part in the KRaft controller quorum
+func (r *Reconciler) isControllerBroker(brokerId string) bool {
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		if strconv.Itoa(int(broker.Id)) != brokerId {
+			continue
+		}
+		bConfig, err := util.GetBrokerConfig(broker, r.KafkaCluster.Spec)
+		return err == nil && r.isControllerNode(bConfig)
+	}
+	return false
+}
+
+// generateKRaftConfig replaces the ZooKeeper connection and the broker id of the broker config in KRaft mode
+func (r *Reconciler) generateKRaftConfig(id int32, bConfig *v1beta1.BrokerConfig, log logr.Logger) string {
+	var roles []string
+	if bConfig.HasRole(v1beta1.ProcessRoleBroker) {
+		roles = append(roles, string(v1beta1.ProcessRoleBroker))
+	}
+	if bConfig.HasRole(v1beta1.ProcessRoleController) {
+		roles = append(roles, string(v1beta1.ProcessRoleController))
+	}
+
+	return fmt.Sprintf("process.roles=%s\nnode.id=%d\ncontroller.quorum.voters=%s\ncontroller.listener.names=%s\n",
+		strings.Join(roles, ","), id, strings.Join(r.controllerQuorumVoters(id, bConfig, log), ","), controllerListenerName)
+}
+
+// controllerIDs returns the ids of the brokers with the controller role in ascending order
+func (r *Reconciler) controllerIDs(log logr.Logger) []int32 {
+	var ids []int32
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		bConfig, err := util.GetBrokerConfig(broker, r.KafkaCluster.Spec)
+		if err != nil {
+			log.Error(err, "could not get the config of the broker", "brokerId", broker.Id)
+			continue
+		}
+		if bConfig.HasRole(v1beta1.ProcessRoleController) {
+			ids = append(ids, broker.Id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// controllerQuorumVoters returns the id@host:port addresses of the voters the broker has to know, ordered by id.
+// While the quorum changes, the voters keep running with the controllers they have in common with the new quorum,
+// and every other broker, including an added controller, knows the controllers of both quorums. So an added
+// controller is known by the brokers before the voters can elect it, and a removed one is forgotten by the brokers
+// only after the voters stopped electing it.
+func (r *Reconciler) controllerQuorumVoters(id int32, bConfig *v1beta1.BrokerConfig, log logr.Logger) []string {
+	desired := r.controllerIDs(log)
+	voters := r.KafkaCluster.Status.KRaft.Voters
+	if len(voters) == 0 {
+		voters = desired
+	}
+
+	var ids []int32
+	if bConfig.HasRole(v1beta1.ProcessRoleController) && containsID(voters, id) {
+		for _, voter := range voters {
+			if containsID(desired, voter) {
+				ids = append(ids, voter)
+			}
+		}
+	} else {
+		ids = append(ids, voters...)
+		for _, controller := range desired {
+			if !containsID(voters, controller) {
+				ids = append(ids, controller)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	port := r.KafkaCluster.Spec.KRaftConfig.GetControllerListenerPort()
+	addresses := make([]string, 0, len(ids))
+	for _, voter := range ids {
+		addresses = append(addresses, fmt.Sprintf("%d@%s:%d", voter, r.brokerHost(voter), port))
+	}
+	return addresses
+}
+
+// advanceControllerQuorum lets the voters switch to the new quorum once every broker runs with the voters of the
+// ongoing quorum change, the brokers that are not voters follow after the voters were restarted
+func (r *Reconciler) advanceControllerQuorum(log logr.Logger) error {
+	if !r.KafkaCluster.Spec.IsKRaft() {
+		return nil
+	}
+	status := r.KafkaCluster.Status.KRaft
+	desired := r.controllerIDs(log)
+	if len(status.Voters) == 0 || reflect.DeepEqual(status.Voters, desired) {
+		return nil
+	}
+	ready, err := r.brokersInSync()
+	if err != nil || !ready {
+		return err
+	}
+
+	log.Info("switching the controller quorum", "voters", status.Voters, "newVoters", desired)
+	status.Voters = desired
+	if err := k8sutil.UpdateCRStatus(r.Client, r.KafkaCluster, status, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "updating kraft voters failed")
+	}
+	r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, controllerQuorumChangeReason,
+		"Restarting the brokers with the controller quorum %s", formatIDs(desired))
+	// the broker configs with the new voters are generated by the next reconciliation
+	return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("controller quorum changed"), "controller quorum change in progress")
+}
+
+// brokersInSync returns true if every broker runs with its current configuration and its pod is ready
+func (r *Reconciler) brokersInSync() (bool, error) {
+	podList := &corev1.PodList{}
+	err := r.Client.List(context.TODO(), podList, client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(kafka.LabelsForKafka(r.KafkaCluster.Name)))
+	if err != nil {
+		return false, errorfactory.New(errorfactory.APIFailure{}, err, "could not list broker pods")
+	}
+	readyPods := make(map[string]bool, len(podList.Items))
+	for _, pod := range podList.Items {
+		// the pods deleted during this reconciliation may still be listed as ready by the cache
+		if !k8sutil.IsMarkedForDeletion(pod.ObjectMeta) && isPodReady(&pod) && !r.isRestartedPod(pod.Name) {
+			readyPods[pod.Labels["brokerId"]] = true
+		}
+	}
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerId := strconv.Itoa(int(broker.Id))
+		if r.KafkaCluster.Status.BrokersState[brokerId].ConfigurationState != v1beta1.ConfigInSync || !readyPods[brokerId] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isVoter returns true if the broker is a voter of the quorum. The pod of a broker removed from the cluster is kept
+// until the other voters run without it.
+func (r *Reconciler) isVoter(brokerId string) bool {
+	if !r.KafkaCluster.Spec.IsKRaft() {
+		return false
+	}
+	for _, voter := range r.KafkaCluster.Status.KRaft.Voters {
+		if strconv.Itoa(int(voter)) == brokerId {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []int32, id int32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func formatIDs(ids []int32) string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, strconv.Itoa(int(id)))
+	}
+	return strings.Join(s, ",")
+}
+
+// brokerHost returns the address the pod of the broker can be reached on inside the Kubernetes cluster
+func (r *Reconciler) brokerHost(id int32) string {
+	domain := r.KafkaCluster.Spec.GetKubernetesClusterDomain()
+	if r.KafkaCluster.Spec.HeadlessServiceEnabled {
+		return fmt.Sprintf("%s-%d.%s-headless.%s.svc.%s", r.KafkaCluster.Name, id, r.KafkaCluster.Name, r.KafkaCluster.Namespace, domain)
+	}
+	return fmt.Sprintf("%s-%d.%s.svc.%s", r.KafkaCluster.Name, id, r.KafkaCluster.Namespace, domain)
+}
+
+// controllerListenerSecurityProtocol returns SSL when the brokers use SSL for their internal communication, as the
+// controllers authenticate each other with the keystores of the brokers
+func controllerListenerSecurityProtocol(l *v1beta1.ListenersConfig) string {
+	if l.SSLSecrets != nil && util.IsSSLEnabledForInternalCommunication(l.InternalListeners) {
+		return "SSL"
+	}
+	return "PLAINTEXT"
+}
+
+// generateKRaftListenerConfig adds the controller listener to the listeners of the broker, the controllers that are
+// not brokers listen only on the controller listener
+func (r *Reconciler) generateKRaftListenerConfig(bConfig *v1beta1.BrokerConfig, log logr.Logger) string {
+	l := &r.KafkaCluster.Spec.ListenersConfig
+	controllerProtocol := fmt.Sprintf("%s:%s", controllerListenerName, controllerListenerSecurityProtocol(l))
+	controllerListener := fmt.Sprintf("%s://:%d", controllerListenerName, r.KafkaCluster.Spec.KRaftConfig.GetControllerListenerPort())
+
+	if !bConfig.HasRole(v1beta1.ProcessRoleBroker) {
+		return "listener.security.protocol.map=" + controllerProtocol + "\n" +
+			"listeners=" + controllerListener + "\n"
+	}
+
+	securityProtocolMapConfig, listenerConfig, interBrokerListenerName := listenerSpecificConfigs(l, log)
+	// the brokers connect to the controllers, so they have to know the security protocol of their listener
+	securityProtocolMapConfig = append(securityProtocolMapConfig, controllerProtocol)
+	if bConfig.HasRole(v1beta1.ProcessRoleController) {
+		listenerConfig = append(listenerConfig, controllerListener)
+	}
+	return formatListenerSpecificConfig(securityProtocolMapConfig, listenerConfig, interBrokerListenerName)
+}
+
+// generateStorageFormatScript formats the storage of the broker with the cluster id unless it is already formatted.
+// The SCRAM admin is registered in the bootstrap metadata, as there is no ZooKeeper to add it to.
+func generateStorageFormatScript(cluster *v1beta1.KafkaCluster) string {
+	if !cluster.Spec.IsKRaft() {
+		return ""
+	}
+	script := fmt.Sprintf("/opt/kafka/bin/kafka-storage.sh format --ignore-formatted --cluster-id %s --config /config/broker-config",
+		cluster.Status.KRaft.ClusterID)
+	if util.IsSASLEnabled(cluster.Spec.ListenersConfig) {
+		script += fmt.Sprintf(` --add-scram "%s=[name=${%s},password=${%s}]"`,
+			v1beta1.SCRAMMechanism, scramAdminUsernameEnvVar, scramAdminPasswordEnvVar)
+	}
+	return script + "\n"
+}
@@ -0,0 +1,278 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/resources"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+	"github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+func TestGenerateKRaftBrokerConfig(t *testing.T) {
+	r := Reconciler{
+		Scheme: scheme.Scheme,
+		Reconciler: resources.Reconciler{
+			KafkaCluster: &v1beta1.KafkaCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kafka",
+					Namespace: "kafka",
+				},
+				Spec: v1beta1.KafkaClusterSpec{
+					KRaftConfig: &v1beta1.KRaftConfig{},
+					ListenersConfig: v1beta1.ListenersConfig{
+						InternalListeners: []v1beta1.InternalListenerConfig{{
+							CommonListenerSpec: v1beta1.CommonListenerSpec{
+								Type:          "plaintext",
+								Name:          "internal",
+								ContainerPort: 9092,
+							},
+							UsedForInnerBrokerCommunication: true,
+						}},
+					},
+					Brokers: []v1beta1.Broker{
+						{
+							Id: 1,
+							BrokerConfig: &v1beta1.BrokerConfig{
+								Roles: []v1beta1.ProcessRole{v1beta1.ProcessRoleController},
+							},
+						},
+						{
+							Id: 0,
+							BrokerConfig: &v1beta1.BrokerConfig{
+								Roles: []v1beta1.ProcessRole{v1beta1.ProcessRoleBroker, v1beta1.ProcessRoleController},
+							},
+						},
+						{
+							Id:           2,
+							BrokerConfig: &v1beta1.BrokerConfig{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		testName       string
+		brokerIndex    int
+		expectedConfig string
+	}{
+		{
+			testName:    "combinedNode",
+			brokerIndex: 1,
+			expectedConfig: `advertised.listeners=INTERNAL://kafka-0.kafka.svc.cluster.local:9092
+controller.listener.names=CONTROLLER
+controller.quorum.voters=0@kafka-0.kafka.svc.cluster.local:29093,1@kafka-1.kafka.svc.cluster.local:29093
+cruise.control.metrics.reporter.bootstrap.servers=INTERNAL://kafka-0.kafka.svc.cluster.local:9092
+cruise.control.metrics.reporter.kubernetes.mode=true
+inter.broker.listener.name=INTERNAL
+listener.security.protocol.map=INTERNAL:PLAINTEXT,CONTROLLER:PLAINTEXT
+listeners=INTERNAL://:9092,CONTROLLER://:29093
+metric.reporters=com.linkedin.kafka.cruisecontrol.metricsreporter.CruiseControlMetricsReporter
+node.id=0
+process.roles=broker,controller`,
+		},
+		{
+			testName:    "controllerNode",
+			brokerIndex: 0,
+			expectedConfig: `controller.listener.names=CONTROLLER
+controller.quorum.voters=0@kafka-0.kafka.svc.cluster.local:29093,1@kafka-1.kafka.svc.cluster.local:29093
+listener.security.protocol.map=CONTROLLER:PLAINTEXT
+listeners=CONTROLLER://:29093
+node.id=1
+process.roles=controller`,
+		},
+		{
+			testName:    "brokerNode",
+			brokerIndex: 2,
+			expectedConfig: `advertised.listeners=INTERNAL://kafka-2.kafka.svc.cluster.local:9092
+controller.listener.names=CONTROLLER
+controller.quorum.voters=0@kafka-0.kafka.svc.cluster.local:29093,1@kafka-1.kafka.svc.cluster.local:29093
+cruise.control.metrics.reporter.bootstrap.servers=INTERNAL://kafka-2.kafka.svc.cluster.local:9092
+cruise.control.metrics.reporter.kubernetes.mode=true
+inter.broker.listener.name=INTERNAL
+listener.security.protocol.map=INTERNAL:PLAINTEXT,CONTROLLER:PLAINTEXT
+listeners=INTERNAL://:9092
+metric.reporters=com.linkedin.kafka.cruisecontrol.metricsreporter.CruiseControlMetricsReporter
+node.id=2
+process.roles=broker`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			broker := r.KafkaCluster.Spec.Brokers[test.brokerIndex]
+			intListenerStatus := map[string]v1beta1.ListenerStatusList{
+				"internal": {
+					{
+						Name:    fmt.Sprintf("broker-%d", broker.Id),
+						Address: fmt.Sprintf("kafka-%d.kafka.svc.cluster.local:9092", broker.Id),
+					},
+				},
+			}
+
+			generatedConfig := r.generateBrokerConfig(broker.Id, broker.BrokerConfig, map[string]v1beta1.ListenerStatusList{}, intListenerStatus, map[string]v1beta1.ListenerStatusList{}, "", "", "", "", []string{}, logf.NullLogger{})
+
+			if generatedConfig != test.expectedConfig {
+				t.Errorf("the expected config is %s, received: %s", test.expectedConfig, generatedConfig)
+			}
+		})
+	}
+}
+
+func TestGenerateKRaftClusterID(t *testing.T) {
+	clusterID, err := generateKRaftClusterID()
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	uuid, err := base64.RawURLEncoding.DecodeString(clusterID)
+	if err != nil || len(uuid) != 16 {
+		t.Error("Expected a base64 encoded UUID, got:", clusterID)
+	}
+}
+
+func newControllerQuorumReconciler(voters []int32, brokers ...v1beta1.Broker) *Reconciler {
+	_ = v1beta1.AddToScheme(scheme.Scheme)
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			KRaftConfig: &v1beta1.KRaftConfig{},
+			BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+				"controller": {Roles: []v1beta1.ProcessRole{v1beta1.ProcessRoleController}},
+			},
+			Brokers: brokers,
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{},
+			KRaft:        v1beta1.KRaftStatus{ClusterID: "test", Voters: voters},
+		},
+	}
+	for _, broker := range brokers {
+		cluster.Status.BrokersState[fmt.Sprint(broker.Id)] = v1beta1.BrokerState{ConfigurationState: v1beta1.ConfigInSync}
+	}
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fake.NewFakeClientWithScheme(scheme.Scheme, cluster.DeepCopy()),
+			KafkaCluster: cluster,
+		},
+		recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func TestControllerQuorumVoters(t *testing.T) {
+	brokers := []v1beta1.Broker{
+		{Id: 0, BrokerConfigGroup: "controller"},
+		{Id: 1, BrokerConfigGroup: "controller"},
+		{Id: 2, BrokerConfigGroup: "controller"},
+		{Id: 3},
+	}
+	tests := []struct {
+		testName string
+		voters   []int32
+		brokers  []v1beta1.Broker
+		expected map[int32]string
+	}{
+		{
+			testName: "stable quorum",
+			voters:   []int32{0, 1, 2},
+			brokers:  brokers,
+			expected: map[int32]string{0: "0,1,2", 2: "0,1,2", 3: "0,1,2"},
+		},
+		{
+			// the brokers and the added controller know it before the voters
+			testName: "added controller",
+			voters:   []int32{0, 1},
+			brokers:  brokers,
+			expected: map[int32]string{0: "0,1", 2: "0,1,2", 3: "0,1,2"},
+		},
+		{
+			// the voters forget the removed controller before the brokers
+			testName: "removed controller",
+			voters:   []int32{0, 1, 2},
+			brokers:  append([]v1beta1.Broker{brokers[0], brokers[1]}, brokers[3]),
+			expected: map[int32]string{0: "0,1", 3: "0,1,2"},
+		},
+	}
+
+	for _, test := range tests {
+		r := newControllerQuorumReconciler(test.voters, test.brokers...)
+		for id, expected := range test.expected {
+			bConfig, _ := util.GetBrokerConfig(brokers[id], r.KafkaCluster.Spec)
+			var voters []string
+			for _, address := range r.controllerQuorumVoters(id, bConfig, logf.NullLogger{}) {
+				voters = append(voters, strings.Split(address, "@")[0])
+			}
+			if strings.Join(voters, ",") != expected {
+				t.Errorf("%s: expected voters %s for broker %d, got: %s", test.testName, expected, id, strings.Join(voters, ","))
+			}
+		}
+	}
+}
+
+func TestAdvanceControllerQuorum(t *testing.T) {
+	r := newControllerQuorumReconciler([]int32{0, 1},
+		v1beta1.Broker{Id: 0, BrokerConfigGroup: "controller"},
+		v1beta1.Broker{Id: 1, BrokerConfigGroup: "controller"},
+		v1beta1.Broker{Id: 2, BrokerConfigGroup: "controller"},
+	)
+	var pods []runtime.Object
+	for _, id := range []string{"0", "1", "2"} {
+		pods = append(pods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka-" + id, Namespace: "kafka",
+				Labels: util.MergeLabels(kafka.LabelsForKafka("kafka"), map[string]string{"brokerId": id})},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+		})
+	}
+	r.Client = fake.NewFakeClientWithScheme(scheme.Scheme, append(pods, r.KafkaCluster.DeepCopy())...)
+
+	// the added controller has not been started with the new voters yet
+	r.KafkaCluster.Status.BrokersState["2"] = v1beta1.BrokerState{ConfigurationState: v1beta1.ConfigOutOfSync}
+	if err := r.advanceControllerQuorum(logf.NullLogger{}); err != nil {
+		t.Fatal("Expected no error while the brokers restart, got:", err)
+	}
+	if !reflect.DeepEqual(r.KafkaCluster.Status.KRaft.Voters, []int32{0, 1}) {
+		t.Error("Expected unchanged voters while the brokers restart, got:", r.KafkaCluster.Status.KRaft.Voters)
+	}
+
+	r.KafkaCluster.Status.BrokersState["2"] = v1beta1.BrokerState{ConfigurationState: v1beta1.ConfigInSync}
+	if _, ok := errors.Cause(r.advanceControllerQuorum(logf.NullLogger{})).(errorfactory.ReconcileRollingUpgrade); !ok {
+		t.Error("Expected ReconcileRollingUpgrade after the quorum switched")
+	}
+	if !reflect.DeepEqual(r.KafkaCluster.Status.KRaft.Voters, []int32{0, 1, 2}) {
+		t.Error("Expected the added controller to be a voter, got:", r.KafkaCluster.Status.KRaft.Voters)
+	}
+	if !r.isVoter("2") || r.isVoter("3") {
+		t.Error("Expected only the voters to be kept")
+	}
+}
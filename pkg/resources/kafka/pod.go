@@ -31,6 +31,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/pkg/util"
 	kafkautils "github.com/banzaicloud/kafka-operator/pkg/util/kafka"
 	pkicommon "github.com/banzaicloud/kafka-operator/pkg/util/pki"
+	zookeeperutils "github.com/banzaicloud/kafka-operator/pkg/util/zookeeper"
 )
 
 func (r *Reconciler) pod(id int32, brokerConfig *v1beta1.BrokerConfig, pvcs []corev1.PersistentVolumeClaim, log logr.Logger) runtime.Object {
@@ -53,6 +54,14 @@ func (r *Reconciler) pod(id int32, brokerConfig *v1beta1.BrokerConfig, pvcs []co
 		})
 	}
 
+	if r.isControllerNode(brokerConfig) {
+		kafkaBrokerContainerPorts = append(kafkaBrokerContainerPorts, corev1.ContainerPort{
+			Name:          strings.ToLower(controllerListenerName),
+			ContainerPort: r.KafkaCluster.Spec.KRaftConfig.GetControllerListenerPort(),
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
 	for _, envVar := range r.KafkaCluster.Spec.Envs {
 		if envVar.Name == "JMX_PORT" {
 			port, err := strconv.ParseInt(envVar.Value, 10, 32)
@@ -91,7 +100,7 @@ if [[ -n "$ENVOY_SIDECAR_STATUS" ]]; then
     fi
   done
 fi
-touch /var/run/wait/do-not-exit-yet
+` + generateSCRAMAdminBootstrapScript(r.KafkaCluster) + generateStorageFormatScript(r.KafkaCluster) + `touch /var/run/wait/do-not-exit-yet
 /opt/kafka/bin/kafka-server-start.sh /config/broker-config
 rm /var/run/wait/do-not-exit-yet`}
 
@@ -103,12 +112,17 @@ rm /var/run/wait/do-not-exit-yet`}
 		volumeMount = append(volumeMount, generateVolumeMountForSSL()...)
 	}
 
+	podLabels := map[string]string{"brokerId": fmt.Sprintf("%d", id)}
+	if r.KafkaCluster.Spec.IsKRaft() && brokerConfig.HasRole(v1beta1.ProcessRoleBroker) {
+		podLabels[kafkautils.BrokerNodeLabel] = "true"
+	}
+
 	pod := &corev1.Pod{
 		ObjectMeta: templates.ObjectMetaWithGeneratedNameAndAnnotations(
 			fmt.Sprintf("%s-%d-", r.KafkaCluster.Name, id),
 			util.MergeLabels(
 				kafkautils.LabelsForKafka(r.KafkaCluster.Name),
-				map[string]string{"brokerId": fmt.Sprintf("%d", id)},
+				podLabels,
 			),
 			brokerConfig.GetBrokerAnnotations(),
 			r.KafkaCluster,
@@ -161,7 +175,7 @@ fi`},
 						},
 					},
 					SecurityContext: brokerConfig.SecurityContext,
-					Env: generateEnvConfig(brokerConfig, []corev1.EnvVar{
+					Env: generateEnvConfig(brokerConfig, append([]corev1.EnvVar{
 						{
 							Name:  "CLASSPATH",
 							Value: "/opt/kafka/libs/extensions/*",
@@ -178,7 +192,7 @@ fi`},
 								},
 							},
 						},
-					}, r.KafkaCluster.Spec.Envs),
+					}, generateSCRAMAdminEnvVars(r.KafkaCluster)...), r.KafkaCluster.Spec.Envs),
 					Command: command,
 					Ports: append(kafkaBrokerContainerPorts, []corev1.ContainerPort{
 						{
@@ -324,6 +338,35 @@ func generateDataVolumeAndVolumeMount(pvcs []corev1.PersistentVolumeClaim) (volu
 	return
 }
 
+// generateSCRAMAdminBootstrapScript registers the SCRAM admin in zookeeper before the broker starts, since
+// the brokers can not authenticate each other on a SASL inter broker listener without it
+func generateSCRAMAdminBootstrapScript(cluster *v1beta1.KafkaCluster) string {
+	if !util.IsSASLEnabled(cluster.Spec.ListenersConfig) || cluster.Spec.IsKRaft() {
+		return ""
+	}
+	return fmt.Sprintf(`/opt/kafka/bin/kafka-configs.sh --zookeeper %s --alter --add-config "%s=[password=${%s}]" --entity-type users --entity-name "${%s}"
+`, zookeeperutils.PrepareConnectionAddress(cluster.Spec.ZKAddresses, cluster.Spec.GetZkPath()), v1beta1.SCRAMMechanism, scramAdminPasswordEnvVar, scramAdminUsernameEnvVar)
+}
+
+func generateSCRAMAdminEnvVars(cluster *v1beta1.KafkaCluster) []corev1.EnvVar {
+	if !util.IsSASLEnabled(cluster.Spec.ListenersConfig) || cluster.Spec.ListenersConfig.SASLConfig == nil {
+		return nil
+	}
+	secretKeyRef := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: cluster.Spec.ListenersConfig.SASLConfig.AdminSecretName},
+				Key:                  key,
+			},
+		}
+	}
+	envVars := []corev1.EnvVar{
+		{Name: scramAdminUsernameEnvVar, ValueFrom: secretKeyRef(v1beta1.SASLUsernameKey)},
+		{Name: scramAdminPasswordEnvVar, ValueFrom: secretKeyRef(v1beta1.SASLPasswordKey)},
+	}
+	return envVars
+}
+
 func generateVolumesForSSL(cluster *v1beta1.KafkaCluster) []corev1.Volume {
 	return []corev1.Volume{
 		{
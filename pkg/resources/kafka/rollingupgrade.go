@@ -0,0 +1,474 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/metrics"
+	"github.com/banzaicloud/kafka-operator/pkg/scale"
+	"github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+// checkRollingUpgradeGates returns a ReconcileRollingUpgrade error while the pod of the broker cannot be
+// restarted yet, and a RollingUpgradeTimeout error when the previously restarted broker did not get healthy in time
+func (r *Reconciler) checkRollingUpgradeGates(log logr.Logger, currentPod *corev1.Pod) error {
+	config := r.KafkaCluster.Spec.RollingUpgradeConfig
+	brokerId := currentPod.Labels["brokerId"]
+
+	restartingPods, err := r.restartingBrokerPods()
+	if err != nil {
+		return err
+	}
+	if len(restartingPods) > 0 {
+		if err := r.checkBrokerRestartTimeout(brokerId); err != nil {
+			return err
+		}
+		if !r.canRestartAlongside(currentPod, restartingPods) {
+			if k8sutil.IsMarkedForDeletion(restartingPods[0].ObjectMeta) {
+				return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("pod is still terminating"), "rolling upgrade in progress")
+			}
+			return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("pod is still creating"), "rolling upgrade in progress")
+		}
+		log.Info("restarting broker together with the brokers of the same rack", "brokerId", brokerId)
+	} else if pause := time.Duration(config.PauseBetweenBrokersSeconds) * time.Second; pause > 0 {
+		if lastRestart, ok := r.lastBrokerRestart(); ok && time.Since(lastRestart) < pause {
+			return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+				errors.New("waiting between broker restarts"), "rolling upgrade in progress", "pause", pause.String())
+		}
+	}
+
+	kClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+	}
+	defer func() {
+		if err := kClient.Close(); err != nil {
+			log.Error(err, "could not close client")
+		}
+	}()
+
+	// the replicas of the brokers restarted together are expected to be offline
+	if len(restartingPods) == 0 {
+		errorCount := r.KafkaCluster.Status.RollingUpgrade.ErrorCount
+
+		offlineReplicaCount, err := kClient.OfflineReplicaCount()
+		if err != nil {
+			return errors.WrapIf(err, "health check failed")
+		}
+		replicasInSync, err := kClient.AllReplicaInSync()
+		if err != nil {
+			return errors.WrapIf(err, "health check failed")
+		}
+		metrics.SetClusterReplicaHealth(r.KafkaCluster, offlineReplicaCount, replicasInSync)
+
+		if offlineReplicaCount > 0 || !replicasInSync {
+			errorCount++
+		}
+		if errorCount >= config.FailureThreshold {
+			if err := r.checkBrokerRestartTimeout(brokerId); err != nil {
+				return err
+			}
+			if err := r.setRollingUpgradeGate(log, fmt.Sprintf("broker %s waits for unhealthy replicas", brokerId),
+				fmt.Sprintf("Rolling upgrade is held back before restarting broker %s: %d offline replicas, all replicas in sync: %t, "+
+					"%d failures reached the threshold of %d", brokerId, offlineReplicaCount, replicasInSync,
+					errorCount, config.FailureThreshold)); err != nil {
+				return err
+			}
+			return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("cluster is not healthy"), "rolling upgrade in progress")
+		}
+	}
+
+	if config.WaitForUnderMinISR {
+		underMinISRCount, err := kClient.UnderMinISRPartitionCount()
+		if err != nil {
+			return errors.WrapIf(err, "health check failed")
+		}
+		if underMinISRCount > 0 {
+			if err := r.checkBrokerRestartTimeout(brokerId); err != nil {
+				return err
+			}
+			if err := r.setRollingUpgradeGate(log, fmt.Sprintf("broker %s waits for partitions under min ISR", brokerId),
+				fmt.Sprintf("Rolling upgrade is held back before restarting broker %s: %d partitions are under min ISR",
+					brokerId, underMinISRCount)); err != nil {
+				return err
+			}
+			return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("partitions are under min ISR"), "rolling upgrade in progress")
+		}
+	}
+
+	if err := r.setRollingUpgradeGate(log, "", ""); err != nil {
+		return err
+	}
+
+	// the controller reported by the brokers is not the active controller of the quorum in KRaft mode
+	if config.ControllerLast && !r.KafkaCluster.Spec.IsKRaft() {
+		if err := r.checkControllerLast(kClient, brokerId); err != nil {
+			return err
+		}
+	}
+	if config.MoveLeadershipBeforeRestart {
+		return r.moveLeadershipAway(log, kClient, brokerId)
+	}
+	return nil
+}
+
+// setRollingUpgradeGate records why the rolling upgrade is held back, the event is only emitted when the reason
+// changes, not on every requeue while the upgrade waits
+func (r *Reconciler) setRollingUpgradeGate(log logr.Logger, reason, message string) error {
+	if r.KafkaCluster.Status.RollingUpgrade.GateReason == reason {
+		return nil
+	}
+	if reason != "" {
+		r.recorder.Event(r.KafkaCluster, corev1.EventTypeWarning, rollingUpgradeGatedReason, message)
+	}
+	if err := k8sutil.UpdateRollingUpgradeGate(r.Client, r.KafkaCluster, reason, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade gate")
+	}
+	return nil
+}
+
+// moveLeadershipAway demotes the broker in Cruise Control, which moves it to the end of the replicas of its partitions
+// and the leadership of every partition it leads to another in-sync replica, until it leads no partition another
+// in-sync replica could take over
+func (r *Reconciler) moveLeadershipAway(log logr.Logger, kClient kafkaclient.KafkaClient, brokerId string) error {
+	id, err := strconv.ParseInt(brokerId, 10, 32)
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "invalid broker id", "brokerId", brokerId)
+	}
+
+	// the partitions are recorded before the demotion reorders their replicas, so the broker can always be made their preferred leader again
+	state := r.KafkaCluster.Status.BrokersState[brokerId].LeadershipState
+	preferred, err := kClient.PreferredLeaderPartitions(int32(id))
+	if err != nil {
+		return err
+	}
+	if demoted := mergePartitions(state.DemotedPartitions, preferred); countPartitions(demoted) != countPartitions(state.DemotedPartitions) {
+		state.DemotedPartitions = demoted
+		if err := k8sutil.UpdateBrokerStatus(r.Client, []string{brokerId}, r.KafkaCluster, state, log); err != nil {
+			return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not record the partitions of the broker")
+		}
+	}
+
+	led, err := kClient.LeaderPartitions(int32(id))
+	if err != nil {
+		return err
+	}
+	if len(led) == 0 {
+		return nil
+	}
+	taskId, err := r.runLeadershipTask(log, brokerId, state.CruiseControlTaskId, "broker demotion", func(cc scale.CruiseControlScaler) (string, error) {
+		taskId, _, err := cc.StartOperation(v1alpha1.CruiseControlOperationSpec{
+			Operation: v1alpha1.CruiseControlOperationDemoteBroker,
+			BrokerIDs: []int32{int32(id)},
+		})
+		return taskId, err
+	})
+	if err != nil {
+		return err
+	}
+	if taskId != state.CruiseControlTaskId {
+		state.CruiseControlTaskId = taskId
+		if err := k8sutil.UpdateBrokerStatus(r.Client, []string{brokerId}, r.KafkaCluster, state, log); err != nil {
+			return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not record the broker demotion")
+		}
+	}
+	return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("broker still leads partitions"),
+		"waiting for the leadership to move away from the broker", "brokerId", brokerId, "partitions", countPartitions(led))
+}
+
+// restoreLeadership makes the restarted broker the preferred leader of its partitions again once it is in sync
+// with every one of them, and runs a preferred leader election of their topics to give their leadership back
+func (r *Reconciler) restoreLeadership(log logr.Logger, currentPod *corev1.Pod) error {
+	brokerId := currentPod.Labels["brokerId"]
+	state := r.KafkaCluster.Status.BrokersState[brokerId].LeadershipState
+	if len(state.DemotedPartitions) == 0 {
+		return nil
+	}
+	if k8sutil.IsMarkedForDeletion(currentPod.ObjectMeta) || k8sutil.IsPodContainsPendingContainer(currentPod) {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("broker pod is not running yet"),
+			"waiting to give the leadership back to the broker", "brokerId", brokerId)
+	}
+	id, err := strconv.ParseInt(brokerId, 10, 32)
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "invalid broker id", "brokerId", brokerId)
+	}
+
+	kClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+	}
+	defer func() {
+		if err := kClient.Close(); err != nil {
+			log.Error(err, "could not close client")
+		}
+	}()
+
+	outOfSync, err := kClient.OutOfSyncPartitions(int32(id))
+	if err != nil {
+		return err
+	}
+	if len(outOfSync) > 0 {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("broker is not in sync"),
+			"waiting to give the leadership back to the broker", "brokerId", brokerId, "partitions", countPartitions(outOfSync))
+	}
+	if err := kClient.ReorderPartitionReplicas(int32(id), state.DemotedPartitions, true); err != nil {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, err, "could not give the leadership back to the broker", "brokerId", brokerId)
+	}
+	topics := make([]string, 0, len(state.DemotedPartitions))
+	for topic := range state.DemotedPartitions {
+		topics = append(topics, topic)
+	}
+	if _, err := r.runLeadershipTask(log, brokerId, "", "preferred leader election", func(cc scale.CruiseControlScaler) (string, error) {
+		return cc.RunPreferredLeaderElectionOfTopics(topics)
+	}); err != nil {
+		return err
+	}
+	if err := k8sutil.UpdateBrokerStatus(r.Client, []string{brokerId}, r.KafkaCluster, v1beta1.LeadershipState{}, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not clear the leadership state of the broker")
+	}
+	r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, leadershipRestoredReason,
+		"Broker %s is the preferred leader of %d partitions again", brokerId, countPartitions(state.DemotedPartitions))
+	return nil
+}
+
+// runLeadershipTask starts a task moving partition leadership in Cruise Control unless the given one is still
+// running, and returns the id of the running task
+func (r *Reconciler) runLeadershipTask(log logr.Logger, brokerId, taskId, task string, start func(scale.CruiseControlScaler) (string, error)) (string, error) {
+	cc := scale.NewCruiseControlScaler(r.KafkaCluster.Namespace, r.KafkaCluster.Spec.GetKubernetesClusterDomain(),
+		r.KafkaCluster.Spec.CruiseControlConfig.CruiseControlEndpoint, r.KafkaCluster.Name, r.KafkaCluster.Spec.CruiseControlConfig.CruiseControlTaskSpec)
+	if taskId != "" {
+		taskState, err := cc.GetCCTaskState(taskId)
+		if err != nil {
+			return "", errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, err, "could not get the state of the "+task)
+		}
+		if taskState == v1beta1.CruiseControlTaskActive || taskState == v1beta1.CruiseControlTaskInExecution {
+			return taskId, nil
+		}
+	}
+	taskId, err := start(cc)
+	if err != nil {
+		return "", errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, err, "could not start "+task)
+	}
+	log.Info(task+" started", "brokerId", brokerId, "taskId", taskId)
+	return taskId, nil
+}
+
+// mergePartitions returns the union of the partitions by topic
+func mergePartitions(partitions, others map[string][]int32) map[string][]int32 {
+	merged := make(map[string][]int32, len(partitions))
+	for _, source := range []map[string][]int32{partitions, others} {
+		for topic, ids := range source {
+			for _, id := range ids {
+				found := false
+				for _, mergedId := range merged[topic] {
+					found = found || mergedId == id
+				}
+				if !found {
+					merged[topic] = append(merged[topic], id)
+				}
+			}
+		}
+	}
+	for topic := range merged {
+		sort.Slice(merged[topic], func(i, j int) bool { return merged[topic][i] < merged[topic][j] })
+	}
+	return merged
+}
+
+func countPartitions(partitions map[string][]int32) int {
+	count := 0
+	for _, ids := range partitions {
+		count += len(ids)
+	}
+	return count
+}
+
+// restartingBrokerPods returns the broker pods terminating or being created, including the ones deleted
+// during this reconciliation which the cache may still list as running
+func (r *Reconciler) restartingBrokerPods() ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	matchingLabels := client.MatchingLabels(kafka.LabelsForKafka(r.KafkaCluster.Name))
+	err := r.Client.List(context.TODO(), podList, client.ListOption(client.InNamespace(r.KafkaCluster.Namespace)), client.ListOption(matchingLabels))
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to reconcile resource")
+	}
+
+	restartingPods := append([]corev1.Pod{}, r.restartedPods...)
+	for _, pod := range podList.Items {
+		if !k8sutil.IsMarkedForDeletion(pod.ObjectMeta) && !k8sutil.IsPodContainsPendingContainer(&pod) {
+			continue
+		}
+		restarted := false
+		for _, restartedPod := range r.restartedPods {
+			if restartedPod.Name == pod.Name {
+				restarted = true
+			}
+		}
+		if !restarted {
+			restartingPods = append(restartingPods, pod)
+		}
+	}
+	return restartingPods, nil
+}
+
+// canRestartAlongside returns true if the pod can be restarted while the given pods are restarting. With rack aware
+// replica placement the brokers of the same rack share no partitions, so they can be restarted together.
+func (r *Reconciler) canRestartAlongside(currentPod *corev1.Pod, restartingPods []corev1.Pod) bool {
+	limit := r.KafkaCluster.Spec.RollingUpgradeConfig.ConcurrentBrokerRestartsPerRack
+	if limit <= 1 || len(restartingPods) >= limit {
+		return false
+	}
+	rack := brokerRack(r.KafkaCluster, currentPod.Labels["brokerId"])
+	// restarting more than one controller at a time could cost the KRaft quorum its majority
+	if rack == "" || r.isControllerBroker(currentPod.Labels["brokerId"]) {
+		return false
+	}
+	for _, pod := range restartingPods {
+		if pod.Name == currentPod.Name || brokerRack(r.KafkaCluster, pod.Labels["brokerId"]) != rack ||
+			r.isControllerBroker(pod.Labels["brokerId"]) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupBrokersByRack orders the brokers rack by rack when the brokers of a rack are restarted together, so the brokers
+// of a rack waiting for a restart are reached one after the other even if their ids interleave with other racks. The
+// racks of the restarting brokers come first, and the controller broker is kept last.
+func (r *Reconciler) groupBrokersByRack(log logr.Logger, brokers []v1beta1.Broker) []v1beta1.Broker {
+	if r.KafkaCluster.Spec.RollingUpgradeConfig.ConcurrentBrokerRestartsPerRack <= 1 {
+		return brokers
+	}
+	restartingPods, err := r.restartingBrokerPods()
+	if err != nil {
+		log.Error(err, "could not list the restarting brokers")
+	}
+
+	rackOrder := make(map[string]int)
+	addRack := func(brokerId string) {
+		if rack := brokerRack(r.KafkaCluster, brokerId); rack != "" {
+			if _, ok := rackOrder[rack]; !ok {
+				rackOrder[rack] = len(rackOrder)
+			}
+		}
+	}
+	for _, pod := range restartingPods {
+		addRack(pod.Labels["brokerId"])
+	}
+	for _, broker := range brokers {
+		addRack(strconv.Itoa(int(broker.Id)))
+	}
+	// the brokers without a rack are restarted alone, after the racks
+	order := func(broker v1beta1.Broker) int {
+		if i, ok := rackOrder[brokerRack(r.KafkaCluster, strconv.Itoa(int(broker.Id)))]; ok {
+			return i
+		}
+		return len(rackOrder)
+	}
+
+	grouped := append([]v1beta1.Broker{}, brokers...)
+	last := len(grouped)
+	if last > 0 && grouped[last-1].Id == r.controllerID {
+		last--
+	}
+	sort.SliceStable(grouped[:last], func(i, j int) bool { return order(grouped[i]) < order(grouped[j]) })
+	return grouped
+}
+
+// brokerRack returns the broker.rack set in the read-only config of the broker, either by the user
+// or by the rack awareness of the operator
+func brokerRack(cluster *v1beta1.KafkaCluster, brokerId string) string {
+	for _, broker := range cluster.Spec.Brokers {
+		if strconv.Itoa(int(broker.Id)) != brokerId {
+			continue
+		}
+		for _, line := range strings.Split(broker.ReadOnlyConfig, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "broker.rack=") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "broker.rack="))
+			}
+		}
+	}
+	return ""
+}
+
+// lastBrokerRestart returns the time of the last broker restart of the running rolling upgrade
+func (r *Reconciler) lastBrokerRestart() (time.Time, bool) {
+	lastRestart, err := time.Parse(time.RFC3339, r.KafkaCluster.Status.RollingUpgrade.LastBrokerRestart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return lastRestart, true
+}
+
+func (r *Reconciler) checkBrokerRestartTimeout(brokerId string) error {
+	timeout := time.Duration(r.KafkaCluster.Spec.RollingUpgradeConfig.BrokerTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		return nil
+	}
+	lastRestart, ok := r.lastBrokerRestart()
+	if !ok || time.Since(lastRestart) <= timeout {
+		return nil
+	}
+	r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeWarning, rollingUpgradeTimeoutReason,
+		"Rolling upgrade is stopped before restarting broker %s: the previously restarted broker did not get healthy within %s",
+		brokerId, timeout)
+	return errorfactory.New(errorfactory.RollingUpgradeTimeout{}, errors.New("restarted broker is not healthy"),
+		"rolling upgrade timed out", "timeout", timeout.String(), "lastBrokerRestart", lastRestart)
+}
+
+// checkControllerLast looks up the active controller again right before the restart, as it may have moved
+// since the brokers were ordered, and holds its restart back until every other broker is in sync
+func (r *Reconciler) checkControllerLast(kClient kafkaclient.KafkaClient, brokerId string) error {
+	_, controllerID, err := kClient.DescribeCluster()
+	if err != nil {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, err, "could not determine controller broker")
+	}
+	if strconv.Itoa(int(controllerID)) != brokerId {
+		return nil
+	}
+	if controllerID != r.controllerID {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+			errors.New("controller moved since the brokers were ordered"), "rolling upgrade in progress", "controller", controllerID)
+	}
+	var outOfSync []string
+	for id, state := range r.KafkaCluster.Status.BrokersState {
+		if id != brokerId && state.ConfigurationState == v1beta1.ConfigOutOfSync {
+			outOfSync = append(outOfSync, id)
+		}
+	}
+	if len(outOfSync) > 0 {
+		sort.Strings(outOfSync)
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+			fmt.Errorf("brokers %s are restarted first", strings.Join(outOfSync, ", ")), "controller broker is restarted last")
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/resources"
+)
+
+func newRollingUpgradeReconciler(config v1beta1.RollingUpgradeConfig) *Reconciler {
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			KafkaCluster: &v1beta1.KafkaCluster{
+				Spec: v1beta1.KafkaClusterSpec{
+					RollingUpgradeConfig: config,
+					Brokers: []v1beta1.Broker{
+						{Id: 0, ReadOnlyConfig: "auto.create.topics.enable=false\nbroker.rack=zone-a\n"},
+						{Id: 1, ReadOnlyConfig: "broker.rack=zone-a\n"},
+						{Id: 2, ReadOnlyConfig: "broker.rack=zone-b\n"},
+						{Id: 3},
+					},
+				},
+			},
+		},
+		recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func brokerPod(brokerId string) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kafka-" + brokerId, Labels: map[string]string{"brokerId": brokerId}}}
+}
+
+func TestCanRestartAlongside(t *testing.T) {
+	r := newRollingUpgradeReconciler(v1beta1.RollingUpgradeConfig{ConcurrentBrokerRestartsPerRack: 2})
+	pod := brokerPod("1")
+
+	if !r.canRestartAlongside(&pod, []corev1.Pod{brokerPod("0")}) {
+		t.Error("Expected broker 1 to be restarted together with broker 0 of the same rack")
+	}
+	if r.canRestartAlongside(&pod, []corev1.Pod{brokerPod("2")}) {
+		t.Error("Expected broker 1 not to be restarted together with broker 2 of another rack")
+	}
+	if r.canRestartAlongside(&pod, []corev1.Pod{brokerPod("1")}) {
+		t.Error("Expected a restarting pod not to be restarted again")
+	}
+	noRack := brokerPod("3")
+	if r.canRestartAlongside(&noRack, []corev1.Pod{brokerPod("0")}) {
+		t.Error("Expected broker 3 without a rack not to be restarted together with others")
+	}
+
+	r.KafkaCluster.Spec.RollingUpgradeConfig.ConcurrentBrokerRestartsPerRack = 1
+	if r.canRestartAlongside(&pod, []corev1.Pod{brokerPod("0")}) {
+		t.Error("Expected brokers to be restarted one by one")
+	}
+}
+
+func TestGroupBrokersByRack(t *testing.T) {
+	r := newRollingUpgradeReconciler(v1beta1.RollingUpgradeConfig{ConcurrentBrokerRestartsPerRack: 2})
+	r.Client = fake.NewFakeClientWithScheme(scheme.Scheme)
+	r.controllerID = -1
+	brokers := r.KafkaCluster.Spec.Brokers
+	interleaved := []v1beta1.Broker{brokers[0], brokers[3], brokers[2], brokers[1]}
+	ids := func(brokers []v1beta1.Broker) []int32 {
+		var ids []int32
+		for _, broker := range brokers {
+			ids = append(ids, broker.Id)
+		}
+		return ids
+	}
+
+	if grouped := ids(r.groupBrokersByRack(logf.NullLogger{}, interleaved)); !reflect.DeepEqual(grouped, []int32{0, 1, 2, 3}) {
+		t.Error("Expected the brokers of zone-a to be grouped, got:", grouped)
+	}
+
+	// the rack of the restarting broker comes first
+	r.restartedPods = []corev1.Pod{brokerPod("2")}
+	if grouped := ids(r.groupBrokersByRack(logf.NullLogger{}, interleaved)); !reflect.DeepEqual(grouped, []int32{2, 0, 1, 3}) {
+		t.Error("Expected the brokers of zone-b to come first, got:", grouped)
+	}
+
+	r.controllerID = 1
+	if grouped := ids(r.groupBrokersByRack(logf.NullLogger{}, interleaved)); !reflect.DeepEqual(grouped, []int32{2, 0, 3, 1}) {
+		t.Error("Expected the controller broker to be kept last, got:", grouped)
+	}
+
+	r.KafkaCluster.Spec.RollingUpgradeConfig.ConcurrentBrokerRestartsPerRack = 1
+	if grouped := ids(r.groupBrokersByRack(logf.NullLogger{}, interleaved)); !reflect.DeepEqual(grouped, []int32{0, 3, 2, 1}) {
+		t.Error("Expected the order of the brokers to be kept, got:", grouped)
+	}
+}
+
+func TestCheckBrokerRestartTimeout(t *testing.T) {
+	r := newRollingUpgradeReconciler(v1beta1.RollingUpgradeConfig{BrokerTimeoutSeconds: 60})
+
+	if err := r.checkBrokerRestartTimeout("1"); err != nil {
+		t.Error("Expected no timeout without a recorded restart, got:", err)
+	}
+
+	r.KafkaCluster.Status.RollingUpgrade.LastBrokerRestart = time.Now().Add(-time.Minute * 2).UTC().Format(time.RFC3339)
+	err := r.checkBrokerRestartTimeout("1")
+	if _, ok := errors.Cause(err).(errorfactory.RollingUpgradeTimeout); !ok {
+		t.Error("Expected RollingUpgradeTimeout error, got:", err)
+	}
+	if len(r.recorder.(*record.FakeRecorder).Events) != 1 {
+		t.Error("Expected a timeout event")
+	}
+
+	r.KafkaCluster.Spec.RollingUpgradeConfig.BrokerTimeoutSeconds = 0
+	if err := r.checkBrokerRestartTimeout("1"); err != nil {
+		t.Error("Expected no timeout without a limit, got:", err)
+	}
+}
+
+func TestSetRollingUpgradeGate(t *testing.T) {
+	r := newKafkaVersionReconciler("2.6.0", v1beta1.KafkaVersionStatus{})
+	log := logf.NullLogger{}
+	events := r.recorder.(*record.FakeRecorder).Events
+
+	for i := 0; i < 3; i++ {
+		if err := r.setRollingUpgradeGate(log, "broker 1 waits for unhealthy replicas", "held back"); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+	}
+	if len(events) != 1 {
+		t.Error("Expected a single event while the gate holds, got:", len(events))
+	}
+	if err := r.setRollingUpgradeGate(log, "broker 1 waits for partitions under min ISR", "held back"); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if len(events) != 2 {
+		t.Error("Expected an event when the gate reason changes, got:", len(events))
+	}
+	if err := r.setRollingUpgradeGate(log, "", ""); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if len(events) != 2 || r.KafkaCluster.Status.RollingUpgrade.GateReason != "" {
+		t.Error("Expected the gate to be released without an event, got:", r.KafkaCluster.Status.RollingUpgrade)
+	}
+}
+
+func TestCheckControllerLast(t *testing.T) {
+	kClient, _ := kafkaclient.NewMockFromCluster(nil, nil)
+	r := newRollingUpgradeReconciler(v1beta1.RollingUpgradeConfig{ControllerLast: true})
+	// the mock cluster reports broker 0 as the controller
+	r.controllerID = 0
+	r.KafkaCluster.Status.BrokersState = map[string]v1beta1.BrokerState{
+		"0": {ConfigurationState: v1beta1.ConfigOutOfSync},
+		"1": {ConfigurationState: v1beta1.ConfigOutOfSync},
+	}
+
+	if err := r.checkControllerLast(kClient, "1"); err != nil {
+		t.Error("Expected broker 1 to be restarted, got:", err)
+	}
+	if err := r.checkControllerLast(kClient, "0"); err == nil {
+		t.Error("Expected the controller to wait for broker 1, got nil")
+	}
+
+	r.KafkaCluster.Status.BrokersState["1"] = v1beta1.BrokerState{ConfigurationState: v1beta1.ConfigInSync}
+	if err := r.checkControllerLast(kClient, "0"); err != nil {
+		t.Error("Expected the controller to be restarted last, got:", err)
+	}
+
+	r.controllerID = 2
+	if err := r.checkControllerLast(kClient, "0"); err == nil {
+		t.Error("Expected the restart to wait for the brokers to be ordered by the new controller, got nil")
+	}
+}
+
+func TestMergePartitions(t *testing.T) {
+	merged := mergePartitions(
+		map[string][]int32{"orders": {2, 0}},
+		map[string][]int32{"orders": {1, 2}, "payments": {3}},
+	)
+	expected := map[string][]int32{"orders": {0, 1, 2}, "payments": {3}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Error("Expected:", expected, "got:", merged)
+	}
+	if count := countPartitions(merged); count != 4 {
+		t.Error("Expected 4 partitions, got:", count)
+	}
+}
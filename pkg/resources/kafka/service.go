@@ -52,6 +52,17 @@ func (r *Reconciler) service(id int32, log logr.Logger) runtime.Object {
 		}
 	}
 
+	// the controller quorum voters are addressed through the services of the brokers
+	if r.KafkaCluster.Spec.IsKRaft() {
+		port := r.KafkaCluster.Spec.KRaftConfig.GetControllerListenerPort()
+		usedPorts = append(usedPorts, corev1.ServicePort{
+			Name:       strings.ToLower(controllerListenerName),
+			Port:       port,
+			TargetPort: intstr.FromInt(int(port)),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+
 	usedPorts = append(usedPorts, corev1.ServicePort{
 		Name:       "metrics",
 		Port:       metricsPort,
@@ -0,0 +1,235 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+// kafkaVersionConfig returns the protocol versions the brokers are pinned to while the Kafka version of the
+// cluster is declared
+func (r *Reconciler) kafkaVersionConfig() map[string]string {
+	protocolVersion := r.KafkaCluster.Status.KafkaVersion.ProtocolVersion
+	if r.KafkaCluster.Spec.KafkaVersion == "" || protocolVersion == "" {
+		return map[string]string{}
+	}
+	return map[string]string{
+		kafka.InterBrokerProtocolVersionConfigName: protocolVersion,
+		kafka.LogMessageFormatVersionConfigName:    protocolVersion,
+	}
+}
+
+// reconcileKafkaVersion starts the upgrade when the declared Kafka version changes, and refuses the downgrades
+// below the protocol version the brokers already use, as they could not read their logs anymore
+func (r *Reconciler) reconcileKafkaVersion(log logr.Logger) error {
+	desiredVersion := r.KafkaCluster.Spec.KafkaVersion
+	if desiredVersion == "" {
+		return nil
+	}
+	status := r.KafkaCluster.Status.KafkaVersion
+
+	desiredProtocolVersion, err := kafka.ProtocolVersion(desiredVersion)
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "invalid kafka version", "kafkaVersion", desiredVersion)
+	}
+
+	// the brokers of a new cluster, or of one declaring its version for the first time, use the protocol of their binaries
+	if status.Version == "" {
+		return r.updateKafkaVersionStatus(log, v1beta1.KafkaVersionStatus{
+			Version:         desiredVersion,
+			ProtocolVersion: desiredProtocolVersion,
+		})
+	}
+
+	switch status.UpgradePhase {
+	case v1beta1.KafkaVersionUpgradeRollingProtocol:
+		// the brokers can not go back to the old protocol, the next change is started once they all use the new one
+		return nil
+	case v1beta1.KafkaVersionUpgradeRollingBinaries:
+		if desiredVersion == status.TargetVersion {
+			return nil
+		}
+		if desiredVersion == status.Version {
+			r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, kafkaVersionUpgradeReason,
+				"Upgrade to Kafka %s is rolled back to %s", status.TargetVersion, status.Version)
+			return r.updateKafkaVersionStatus(log, v1beta1.KafkaVersionStatus{
+				Version:         status.Version,
+				ProtocolVersion: status.ProtocolVersion,
+			})
+		}
+	default:
+		if desiredVersion == status.Version {
+			return nil
+		}
+	}
+
+	cmp, err := kafka.CompareVersions(desiredProtocolVersion, status.ProtocolVersion)
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "invalid protocol version", "protocolVersion", status.ProtocolVersion)
+	}
+	switch {
+	case cmp < 0:
+		r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeWarning, kafkaVersionDowngradeRefusedReason,
+			"Kafka %s can not be used by brokers already using the protocol version %s", desiredVersion, status.ProtocolVersion)
+		return errorfactory.New(errorfactory.FatalReconcileError{}, errors.New("unsafe kafka downgrade"),
+			"kafka version is lower than the protocol version of the brokers", "kafkaVersion", desiredVersion,
+			"protocolVersion", status.ProtocolVersion)
+	case cmp == 0:
+		// the versions share their protocol, so only the binaries change
+		return r.updateKafkaVersionStatus(log, v1beta1.KafkaVersionStatus{
+			Version:         desiredVersion,
+			ProtocolVersion: status.ProtocolVersion,
+		})
+	}
+
+	r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, kafkaVersionUpgradeReason,
+		"Upgrade from Kafka %s to %s started, the brokers keep the protocol version %s", status.Version, desiredVersion, status.ProtocolVersion)
+	return r.updateKafkaVersionStatus(log, v1beta1.KafkaVersionStatus{
+		Version:         status.Version,
+		ProtocolVersion: status.ProtocolVersion,
+		TargetVersion:   desiredVersion,
+		UpgradePhase:    v1beta1.KafkaVersionUpgradeRollingBinaries,
+	})
+}
+
+// advanceKafkaVersionUpgrade moves the upgrade to its next phase once every broker was restarted for the
+// current one and the cluster is healthy
+func (r *Reconciler) advanceKafkaVersionUpgrade(log logr.Logger) error {
+	status := r.KafkaCluster.Status.KafkaVersion
+	if status.UpgradePhase == "" || r.KafkaCluster.Spec.IsPaused(v1beta1.SubsystemRollingUpgrade) {
+		return nil
+	}
+	if err := r.checkBrokersUpgraded(log); err != nil {
+		return err
+	}
+
+	switch status.UpgradePhase {
+	case v1beta1.KafkaVersionUpgradeRollingBinaries:
+		protocolVersion, err := kafka.ProtocolVersion(status.TargetVersion)
+		if err != nil {
+			return errorfactory.New(errorfactory.InternalError{}, err, "invalid kafka version", "kafkaVersion", status.TargetVersion)
+		}
+		if err := r.updateKafkaVersionStatus(log, v1beta1.KafkaVersionStatus{
+			Version:         status.TargetVersion,
+			ProtocolVersion: protocolVersion,
+			UpgradePhase:    v1beta1.KafkaVersionUpgradeRollingProtocol,
+		}); err != nil {
+			return err
+		}
+		r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, kafkaVersionUpgradeReason,
+			"Brokers run Kafka %s, restarting them with the protocol version %s", status.TargetVersion, protocolVersion)
+		// the broker configs with the new protocol version are generated by the next reconciliation
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("protocol version changed"), "kafka version upgrade in progress")
+	case v1beta1.KafkaVersionUpgradeRollingProtocol:
+		if err := r.updateKafkaVersionStatus(log, v1beta1.KafkaVersionStatus{
+			Version:         status.Version,
+			ProtocolVersion: status.ProtocolVersion,
+		}); err != nil {
+			return err
+		}
+		r.recorder.Eventf(r.KafkaCluster, corev1.EventTypeNormal, kafkaVersionUpgradeReason,
+			"Upgrade to Kafka %s finished", status.Version)
+	}
+	return nil
+}
+
+// checkBrokersUpgraded returns a ReconcileRollingUpgrade error until every broker runs a ready pod with the
+// generated configuration and no replica is offline or out of sync
+func (r *Reconciler) checkBrokersUpgraded(log logr.Logger) error {
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerId := strconv.Itoa(int(broker.Id))
+		if r.KafkaCluster.Status.BrokersState[brokerId].ConfigurationState != v1beta1.ConfigInSync {
+			return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("broker config is out of sync"),
+				"kafka version upgrade in progress", "brokerId", brokerId)
+		}
+	}
+
+	podList := &corev1.PodList{}
+	err := r.Client.List(context.TODO(), podList, client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(kafka.LabelsForKafka(r.KafkaCluster.Name)))
+	if err != nil {
+		return errorfactory.New(errorfactory.APIFailure{}, err, "could not list broker pods")
+	}
+	// the pods deleted during this reconciliation may still be listed as ready by the cache
+	readyPods := 0
+	for _, pod := range podList.Items {
+		if !k8sutil.IsMarkedForDeletion(pod.ObjectMeta) && isPodReady(&pod) && !r.isRestartedPod(pod.Name) {
+			readyPods++
+		}
+	}
+	if readyPods < len(r.KafkaCluster.Spec.Brokers) {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("broker pods are not ready"),
+			"kafka version upgrade in progress")
+	}
+
+	kClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+	}
+	defer func() {
+		if err := kClient.Close(); err != nil {
+			log.Error(err, "could not close client")
+		}
+	}()
+	offlineReplicaCount, err := kClient.OfflineReplicaCount()
+	if err != nil {
+		return errors.WrapIf(err, "health check failed")
+	}
+	replicasInSync, err := kClient.AllReplicaInSync()
+	if err != nil {
+		return errors.WrapIf(err, "health check failed")
+	}
+	if offlineReplicaCount > 0 || !replicasInSync {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("cluster is not healthy"),
+			"kafka version upgrade in progress", "offlineReplicas", offlineReplicaCount, "allReplicasInSync", replicasInSync)
+	}
+	return nil
+}
+
+func (r *Reconciler) updateKafkaVersionStatus(log logr.Logger, status v1beta1.KafkaVersionStatus) error {
+	if err := k8sutil.UpdateCRStatus(r.Client, r.KafkaCluster, status, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "updating kafka version status failed")
+	}
+	return nil
+}
+
+func (r *Reconciler) isRestartedPod(name string) bool {
+	for _, pod := range r.restartedPods {
+		if pod.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/resources"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+	"github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+func newKafkaVersionReconciler(kafkaVersion string, status v1beta1.KafkaVersionStatus) *Reconciler {
+	_ = v1beta1.AddToScheme(scheme.Scheme)
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec:       v1beta1.KafkaClusterSpec{KafkaVersion: kafkaVersion},
+		Status:     v1beta1.KafkaClusterStatus{KafkaVersion: status},
+	}
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fake.NewFakeClientWithScheme(scheme.Scheme, cluster.DeepCopy()),
+			KafkaCluster: cluster,
+		},
+		recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func TestReconcileKafkaVersion(t *testing.T) {
+	log := logf.NullLogger{}
+
+	r := newKafkaVersionReconciler("2.6.0", v1beta1.KafkaVersionStatus{})
+	if err := r.reconcileKafkaVersion(log); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expected := v1beta1.KafkaVersionStatus{Version: "2.6.0", ProtocolVersion: "2.6"}
+	if r.KafkaCluster.Status.KafkaVersion != expected {
+		t.Error("Expected the version of a new cluster to be recorded as", expected, "got:", r.KafkaCluster.Status.KafkaVersion)
+	}
+	if config := r.kafkaVersionConfig(); config["inter.broker.protocol.version"] != "2.6" || config["log.message.format.version"] != "2.6" {
+		t.Error("Expected the protocol versions to be pinned to 2.6, got:", config)
+	}
+
+	r.KafkaCluster.Spec.KafkaVersion = "2.7.0"
+	if err := r.reconcileKafkaVersion(log); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expected = v1beta1.KafkaVersionStatus{Version: "2.6.0", ProtocolVersion: "2.6", TargetVersion: "2.7.0",
+		UpgradePhase: v1beta1.KafkaVersionUpgradeRollingBinaries}
+	if r.KafkaCluster.Status.KafkaVersion != expected {
+		t.Error("Expected the upgrade to keep the old protocol version", expected, "got:", r.KafkaCluster.Status.KafkaVersion)
+	}
+
+	r.KafkaCluster.Spec.KafkaVersion = "2.6.0"
+	if err := r.reconcileKafkaVersion(log); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expected = v1beta1.KafkaVersionStatus{Version: "2.6.0", ProtocolVersion: "2.6"}
+	if r.KafkaCluster.Status.KafkaVersion != expected {
+		t.Error("Expected the upgrade to be rolled back to", expected, "got:", r.KafkaCluster.Status.KafkaVersion)
+	}
+
+	r.KafkaCluster.Spec.KafkaVersion = "2.6.1"
+	if err := r.reconcileKafkaVersion(log); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expected = v1beta1.KafkaVersionStatus{Version: "2.6.1", ProtocolVersion: "2.6"}
+	if r.KafkaCluster.Status.KafkaVersion != expected {
+		t.Error("Expected a patch version change to keep the protocol version", expected, "got:", r.KafkaCluster.Status.KafkaVersion)
+	}
+}
+
+func TestReconcileKafkaVersionDowngrade(t *testing.T) {
+	r := newKafkaVersionReconciler("2.5.1", v1beta1.KafkaVersionStatus{Version: "2.6.0", ProtocolVersion: "2.6"})
+
+	err := r.reconcileKafkaVersion(logf.NullLogger{})
+	if _, ok := errors.Cause(err).(errorfactory.FatalReconcileError); !ok {
+		t.Error("Expected FatalReconcileError for a downgrade below the protocol version, got:", err)
+	}
+	if len(r.recorder.(*record.FakeRecorder).Events) != 1 {
+		t.Error("Expected a downgrade refused event")
+	}
+}
+
+func TestCheckBrokersUpgraded(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-0", Namespace: "kafka",
+			Labels: util.MergeLabels(kafka.LabelsForKafka("kafka"), map[string]string{"brokerId": "0"})},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+	}
+	r := newKafkaVersionReconciler("2.7.0", v1beta1.KafkaVersionStatus{Version: "2.6.0", ProtocolVersion: "2.6",
+		TargetVersion: "2.7.0", UpgradePhase: v1beta1.KafkaVersionUpgradeRollingBinaries})
+	r.Client = fake.NewFakeClientWithScheme(scheme.Scheme, r.KafkaCluster.DeepCopy(), pod)
+	r.kafkaClientProvider = kafkaclient.NewMockProvider()
+	r.KafkaCluster.Spec.Brokers = []v1beta1.Broker{{Id: 0}}
+	r.KafkaCluster.Status.BrokersState = map[string]v1beta1.BrokerState{"0": {ConfigurationState: v1beta1.ConfigInSync}}
+
+	// the cache still lists the pod deleted during this reconciliation as ready
+	r.restartedPods = []corev1.Pod{*pod}
+	if _, ok := errors.Cause(r.checkBrokersUpgraded(logf.NullLogger{})).(errorfactory.ReconcileRollingUpgrade); !ok {
+		t.Error("Expected ReconcileRollingUpgrade while the broker restarts")
+	}
+
+	r.restartedPods = nil
+	if err := r.checkBrokersUpgraded(logf.NullLogger{}); err != nil {
+		t.Error("Expected the brokers to be upgraded, got:", err)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topicimport
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/errorfactory"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/resources"
+	"github.com/banzaicloud/kafka-operator/pkg/webhook"
+)
+
+const (
+	componentName = "topicimport"
+	// internalTopicPrefix marks the topics used by Kafka and its tooling, e.g. __consumer_offsets
+	internalTopicPrefix   = "__"
+	maxResourceNameLength = 253
+)
+
+var (
+	invalidResourceNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+	// resourceNameSeparators are the runs of separators DNS-1123 subdomains do not allow, like ".." or ".-"
+	resourceNameSeparators = regexp.MustCompile(`[.-]{2,}`)
+)
+
+// Reconciler implements the Component Reconciler
+type Reconciler struct {
+	resources.Reconciler
+	kafkaClientProvider kafkaclient.Provider
+}
+
+// New creates a new reconciler for importing unmanaged topics
+func New(client client.Client, cluster *v1beta1.KafkaCluster, kafkaClientProvider kafkaclient.Provider) *Reconciler {
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       client,
+			KafkaCluster: cluster,
+		},
+		kafkaClientProvider: kafkaClientProvider,
+	}
+}
+
+// Reconcile creates a KafkaTopic for every topic of the cluster that is not managed by one yet
+func (r *Reconciler) Reconcile(log logr.Logger) error {
+	config := r.KafkaCluster.Spec.TopicImportConfig
+	if config == nil {
+		return nil
+	}
+	log = log.WithValues("component", componentName)
+
+	log.V(1).Info("Reconciling")
+
+	excluded := make([]*regexp.Regexp, 0, len(config.ExcludedTopics))
+	for _, pattern := range config.ExcludedTopics {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errorfactory.New(errorfactory.InternalError{}, err, "invalid excluded topic pattern", "pattern", pattern)
+		}
+		excluded = append(excluded, re)
+	}
+
+	managed, err := r.managedTopics()
+	if err != nil {
+		return err
+	}
+
+	broker, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := broker.Close(); err != nil {
+			log.Error(err, "could not close client")
+		}
+	}()
+
+	topics, err := broker.ListTopics()
+	if err != nil {
+		return errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list topics")
+	}
+	if err := r.pruneReleasedTopics(topics, log); err != nil {
+		return err
+	}
+	released := make(map[string]bool, len(r.KafkaCluster.Status.TopicImport.ReleasedTopics))
+	for _, name := range r.KafkaCluster.Status.TopicImport.ReleasedTopics {
+		released[name] = true
+	}
+
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		if !managed[name] && !released[name] && importable(name, excluded) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if resourceName(name) == "" {
+			log.Info("Unmanaged topic name can not be turned into a KafkaTopic name, skipping", "topic", name)
+			continue
+		}
+		topic, err := r.kafkaTopic(broker, name, topics[name])
+		if err != nil {
+			return err
+		}
+		if err := r.Client.Create(context.TODO(), topic); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				log.Info("KafkaTopic with the name of the imported topic already exists, skipping", "topic", name, "kafkaTopic", topic.Name)
+				continue
+			}
+			if webhook.IsTopicPolicyViolation(err) {
+				log.Info("Unmanaged topic violates the topic policy of the cluster, skipping", "topic", name, "error", err.Error())
+				continue
+			}
+			if apierrors.IsInvalid(err) {
+				log.Info("KafkaTopic of the unmanaged topic is invalid, skipping", "topic", name, "error", err.Error())
+				continue
+			}
+			if webhook.IsAdmissionCantConnect(err) {
+				return errorfactory.New(errorfactory.ResourceNotReady{}, err, "topic admission failed to connect to kafka cluster")
+			}
+			return errorfactory.New(errorfactory.APIFailure{}, err, "could not create KafkaTopic for unmanaged topic", "topic", name)
+		}
+		log.Info("Imported unmanaged topic", "topic", name, "kafkaTopic", topic.Name, "namespace", topic.Namespace)
+	}
+
+	log.V(1).Info("Reconciled")
+
+	return nil
+}
+
+// managedTopics returns the names of the topics of the cluster that have a KafkaTopic
+func (r *Reconciler) managedTopics() (map[string]bool, error) {
+	topics := &v1alpha1.KafkaTopicList{}
+	if err := r.Client.List(context.TODO(), topics); err != nil {
+		return nil, errorfactory.New(errorfactory.APIFailure{}, err, "could not list KafkaTopics")
+	}
+	managed := make(map[string]bool, len(topics.Items))
+	for _, topic := range topics.Items {
+		clusterNamespace := topic.Spec.ClusterRef.Namespace
+		if clusterNamespace == "" {
+			clusterNamespace = topic.Namespace
+		}
+		if topic.Spec.ClusterRef.Name == r.KafkaCluster.Name && clusterNamespace == r.KafkaCluster.Namespace {
+			managed[topic.Spec.Name] = true
+		}
+	}
+	return managed, nil
+}
+
+// pruneReleasedTopics forgets the released topics that were deleted from the cluster, a topic created again with the
+// same name is imported
+func (r *Reconciler) pruneReleasedTopics(topics map[string]sarama.TopicDetail, log logr.Logger) error {
+	status := r.KafkaCluster.Status.TopicImport
+	kept := make([]string, 0, len(status.ReleasedTopics))
+	for _, name := range status.ReleasedTopics {
+		if _, ok := topics[name]; ok {
+			kept = append(kept, name)
+		}
+	}
+	if len(kept) == len(status.ReleasedTopics) {
+		return nil
+	}
+	status.ReleasedTopics = kept
+	if err := k8sutil.UpdateCRStatus(r.Client, r.KafkaCluster, status, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not prune released topics")
+	}
+	return nil
+}
+
+// kafkaTopic returns a KafkaTopic adopting the topic with its current partitions, replicas and config overrides
+func (r *Reconciler) kafkaTopic(broker kafkaclient.KafkaClient, name string, detail sarama.TopicDetail) (*v1alpha1.KafkaTopic, error) {
+	entries, err := broker.DescribeTopicConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	config := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Source == sarama.SourceTopic {
+			config[entry.Name] = entry.Value
+		}
+	}
+
+	namespace := r.KafkaCluster.Spec.TopicImportConfig.Namespace
+	if namespace == "" {
+		namespace = r.KafkaCluster.Namespace
+	}
+	return &v1alpha1.KafkaTopic{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(name),
+			Namespace: namespace,
+			Annotations: map[string]string{
+				v1alpha1.TopicAdoptAnnotation: "true",
+			},
+		},
+		Spec: v1alpha1.KafkaTopicSpec{
+			Name:              name,
+			Partitions:        detail.NumPartitions,
+			ReplicationFactor: int32(detail.ReplicationFactor),
+			Config:            config,
+			DeletionPolicy:    v1alpha1.DeletionPolicy(r.KafkaCluster.Spec.TopicImportConfig.GetDeletionPolicy()),
+			ClusterRef: v1alpha1.ClusterReference{
+				Name:      r.KafkaCluster.Name,
+				Namespace: r.KafkaCluster.Namespace,
+			},
+		},
+	}, nil
+}
+
+func importable(name string, excluded []*regexp.Regexp) bool {
+	if strings.HasPrefix(name, internalTopicPrefix) {
+		return false
+	}
+	for _, re := range excluded {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceName turns a topic name into a valid Kubernetes resource name, it is empty when the topic name can not be
+// turned into one
+func resourceName(topic string) string {
+	name := invalidResourceNameChars.ReplaceAllString(strings.ToLower(topic), "-")
+	name = resourceNameSeparators.ReplaceAllString(name, "-")
+	if len(name) > maxResourceNameLength {
+		name = name[:maxResourceNameLength]
+	}
+	name = strings.Trim(name, ".-")
+	if len(validation.IsDNS1123Subdomain(name)) > 0 {
+		return ""
+	}
+	return name
+}
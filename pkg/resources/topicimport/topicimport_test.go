@@ -0,0 +1,130 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topicimport
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+type staticProvider struct {
+	broker kafkaclient.KafkaClient
+}
+
+func (p *staticProvider) NewFromCluster(client.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, error) {
+	return p.broker, nil
+}
+
+func TestResourceName(t *testing.T) {
+	cases := map[string]string{
+		"orders":          "orders",
+		"Orders_V2":       "orders-v2",
+		"_private.events": "private.events",
+		"a__b..c":         "a-b-c",
+		"x.-y":            "x-y",
+		"a._-b":           "a-b",
+		"_":               "",
+		"-":               "",
+	}
+	for topic, expected := range cases {
+		if name := resourceName(topic); name != expected {
+			t.Errorf("Expected %q for topic %q, got: %q", expected, topic, name)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	_ = v1beta1.AddToScheme(scheme.Scheme)
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			TopicImportConfig: &v1beta1.TopicImportConfig{
+				Namespace:      "topics",
+				ExcludedTopics: []string{"^tmp-"},
+			},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			TopicImport: v1beta1.TopicImportStatus{ReleasedTopics: []string{"retained", "deleted"}},
+		},
+	}
+	broker, _ := kafkaclient.NewMockFromCluster(nil, cluster)
+	for _, name := range []string{"orders", "payments", "tmp-scratch", "__consumer_offsets", "_", "retained", "a__b..c"} {
+		if err := broker.CreateTopic(&kafkaclient.CreateTopicOptions{
+			Name:              name,
+			Partitions:        3,
+			ReplicationFactor: 1,
+			Config:            map[string]*string{"retention.ms": util.StringPointer("1000")},
+		}); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+	}
+
+	k8sClient := fake.NewFakeClientWithScheme(scheme.Scheme, cluster.DeepCopy(), &v1alpha1.KafkaTopic{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments-topic", Namespace: "kafka"},
+		Spec: v1alpha1.KafkaTopicSpec{
+			Name:       "payments",
+			ClusterRef: v1alpha1.ClusterReference{Name: "kafka"},
+		},
+	})
+	r := New(k8sClient, cluster, &staticProvider{broker: broker})
+	if err := r.Reconcile(logf.NullLogger{}); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	topics := &v1alpha1.KafkaTopicList{}
+	if err := k8sClient.List(context.TODO(), topics, client.InNamespace("topics")); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if len(topics.Items) != 2 {
+		t.Fatal("Expected only the unmanaged, not excluded topics to be imported, got:", topics.Items)
+	}
+	if topics.Items[0].Name != "a-b-c" || topics.Items[0].Spec.Name != "a__b..c" {
+		t.Error("Expected the topic to be imported with a valid resource name, got:", topics.Items[0].ObjectMeta)
+	}
+	imported := topics.Items[1]
+	if imported.Name != "orders" || imported.Spec.Name != "orders" || imported.Annotations[v1alpha1.TopicAdoptAnnotation] != "true" {
+		t.Error("Unexpected imported topic:", imported)
+	}
+	if imported.Spec.Partitions != 3 || imported.Spec.ReplicationFactor != 1 || imported.Spec.Config["retention.ms"] != "1000" {
+		t.Error("Expected the topic to be imported as is, got:", imported.Spec)
+	}
+	if imported.Spec.ClusterRef.Name != "kafka" || imported.Spec.ClusterRef.Namespace != "kafka" {
+		t.Error("Unexpected cluster reference:", imported.Spec.ClusterRef)
+	}
+	if imported.Spec.DeletionPolicy != v1alpha1.DeletionPolicyRetain {
+		t.Error("Expected the imported topic to be retained when its KafkaTopic is deleted, got:", imported.Spec.DeletionPolicy)
+	}
+	if released := cluster.Status.TopicImport.ReleasedTopics; len(released) != 1 || released[0] != "retained" {
+		t.Error("Expected the released topics deleted from the cluster to be forgotten, got:", released)
+	}
+
+	// nothing is imported when the topic import is not enabled
+	cluster.Spec.TopicImportConfig = nil
+	if err := New(nil, cluster, nil).Reconcile(logf.NullLogger{}); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+}
@@ -15,6 +15,7 @@
 package scale
 
 import (
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 )
 
@@ -54,6 +55,10 @@ func (mc *mockCruiseControlScaler) RunPreferedLeaderElectionInCluster() (string,
 	return "", nil
 }
 
+func (mc *mockCruiseControlScaler) RunPreferredLeaderElectionOfTopics(topics []string) (string, error) {
+	return "", nil
+}
+
 func (mc *mockCruiseControlScaler) KillCCTask() error {
 	return nil
 }
@@ -61,3 +66,19 @@ func (mc *mockCruiseControlScaler) KillCCTask() error {
 func (mc *mockCruiseControlScaler) GetCCTaskState(uTaskId string) (v1beta1.CruiseControlUserTaskState, error) {
 	return "", nil
 }
+
+func (mc *mockCruiseControlScaler) StartOperation(spec v1alpha1.CruiseControlOperationSpec) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	return "", nil, nil
+}
+
+func (mc *mockCruiseControlScaler) GetCCTaskSummary(uTaskId string) (*v1alpha1.CruiseControlProposalSummary, error) {
+	return nil, nil
+}
+
+func (mc *mockCruiseControlScaler) ProposeScaling(brokerIds []string, upscale bool) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	return "", nil, nil
+}
+
+func (mc *mockCruiseControlScaler) GetBrokerLoads() ([]BrokerLoad, error) {
+	return nil, nil
+}
@@ -21,10 +21,15 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	banzaicloudv1beta1 "github.com/banzaicloud/kafka-operator/api/v1beta1"
 	bcutil "github.com/banzaicloud/kafka-operator/pkg/util"
 )
@@ -37,12 +42,18 @@ const (
 	kafkaClusterStateAction = "kafka_cluster_state"
 	clusterLoadAction       = "load"
 	rebalanceAction         = "rebalance"
+	demoteBrokerAction      = "demote_broker"
+	fixOfflineAction        = "fix_offline_replicas"
 	killProposalAction      = "stop_proposal_execution"
 	serviceNameTemplate     = "%s-cruisecontrol-svc"
 	brokerAlive             = "ALIVE"
 )
 
 var errCruiseControlNotReturned200 = errors.New("non 200 response from cruise-control")
+
+// ErrInvalidOperation is returned for the operations cruise-control can not start with the given parameters,
+// sending them again does not help
+var ErrInvalidOperation = errors.New("invalid cruise-control operation")
 var newCruiseControlScaler = createNewDefaultCruiseControlScaler
 
 var log = logf.Log.WithName("cruise-control-methods")
@@ -55,8 +66,24 @@ type CruiseControlScaler interface {
 	RebalanceDisks(brokerIdsWithMountPath map[string][]string) (string, string, error)
 	RebalanceCluster() (string, error)
 	RunPreferedLeaderElectionInCluster() (string, error)
+	RunPreferredLeaderElectionOfTopics(topics []string) (string, error)
 	KillCCTask() error
 	GetCCTaskState(uTaskId string) (banzaicloudv1beta1.CruiseControlUserTaskState, error)
+	StartOperation(spec v1alpha1.CruiseControlOperationSpec) (string, *v1alpha1.CruiseControlProposalSummary, error)
+	GetCCTaskSummary(uTaskId string) (*v1alpha1.CruiseControlProposalSummary, error)
+	ProposeScaling(brokerIds []string, upscale bool) (string, *v1alpha1.CruiseControlProposalSummary, error)
+	GetBrokerLoads() ([]BrokerLoad, error)
+}
+
+// BrokerLoad is the load of an alive broker as reported by cruise-control
+type BrokerLoad struct {
+	BrokerID string
+	// DiskPct is the percentage of the disk capacity of the broker in use
+	DiskPct float64
+	// Leaders is the number of partitions led by the broker
+	Leaders int
+	// LogDirDiskPct is the percentage of the disk capacity in use of each log dir of the broker
+	LogDirDiskPct map[string]float64
 }
 
 type cruiseControlScaler struct {
@@ -65,33 +92,37 @@ type cruiseControlScaler struct {
 	kubernetesClusterDomain string
 	endpoint                string
 	clusterName             string
+	taskSpec                banzaicloudv1beta1.CruiseControlTaskSpec
 }
 
-func NewCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName string) CruiseControlScaler {
-	return newCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName)
+// NewCruiseControlScaler returns the client of the Cruise Control of a cluster, the requests moving replicas or
+// leadership are sent with the task options of the cluster
+func NewCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName string, taskSpec banzaicloudv1beta1.CruiseControlTaskSpec) CruiseControlScaler {
+	return newCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName, taskSpec)
 }
 
 func MockNewCruiseControlScaler() {
 	newCruiseControlScaler = createMockCruiseControlScaler
 }
 
-func createNewDefaultCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName string) CruiseControlScaler {
+func createNewDefaultCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName string, taskSpec banzaicloudv1beta1.CruiseControlTaskSpec) CruiseControlScaler {
 	return &cruiseControlScaler{
 		namespace:               namespace,
 		kubernetesClusterDomain: kubernetesClusterDomain,
 		endpoint:                endpoint,
 		clusterName:             clusterName,
+		taskSpec:                taskSpec,
 	}
 }
 
-func createMockCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName string) CruiseControlScaler {
+func createMockCruiseControlScaler(namespace, kubernetesClusterDomain, endpoint, clusterName string, taskSpec banzaicloudv1beta1.CruiseControlTaskSpec) CruiseControlScaler {
 	return &mockCruiseControlScaler{}
 }
 
 func (cc *cruiseControlScaler) generateUrlForCC(action string, options map[string]string) string {
 	optionURL := ""
 	for option, value := range options {
-		optionURL = optionURL + option + "=" + value + "&"
+		optionURL = optionURL + option + "=" + url.QueryEscape(value) + "&"
 	}
 	if cc.endpoint != "" {
 		return "http://" + cc.endpoint + "/" + basePath + "/" + action + "?" + strings.TrimSuffix(optionURL, "&")
@@ -99,9 +130,49 @@ func (cc *cruiseControlScaler) generateUrlForCC(action string, options map[strin
 	return "http://" + fmt.Sprintf(serviceNameTemplate, cc.clusterName) + "." + cc.namespace + ".svc." + cc.kubernetesClusterDomain + ":8090/" + basePath + "/" + action + "?" + strings.TrimSuffix(optionURL, "&")
 }
 
+// withTaskOptions adds the task options of the cluster supported by the action to the options the request did not set
+func (cc *cruiseControlScaler) withTaskOptions(action string, options map[string]string) map[string]string {
+	taskOptions := make(map[string]string)
+	switch action {
+	case addBrokerAction, removeBrokerAction, fixOfflineAction, rebalanceAction:
+		// the disks are rebalanced with the intra-broker goals only
+		if len(cc.taskSpec.Goals) > 0 && options["rebalance_disk"] != "true" {
+			taskOptions["goals"] = strings.Join(cc.taskSpec.Goals, ",")
+			if cc.taskSpec.SkipHardGoalCheck {
+				taskOptions["skip_hard_goal_check"] = "true"
+			}
+		}
+		if cc.taskSpec.ExcludedTopics != "" {
+			taskOptions["excluded_topics"] = cc.taskSpec.ExcludedTopics
+		}
+		if cc.taskSpec.ConcurrentPartitionMovementsPerBroker != nil {
+			taskOptions["concurrent_partition_movements_per_broker"] = strconv.Itoa(int(*cc.taskSpec.ConcurrentPartitionMovementsPerBroker))
+		}
+		fallthrough
+	case demoteBrokerAction:
+		if cc.taskSpec.ConcurrentLeaderMovements != nil {
+			taskOptions["concurrent_leader_movements"] = strconv.Itoa(int(*cc.taskSpec.ConcurrentLeaderMovements))
+		}
+		if cc.taskSpec.ReplicationThrottle != nil {
+			taskOptions["replication_throttle"] = strconv.FormatInt(*cc.taskSpec.ReplicationThrottle, 10)
+		}
+	}
+
+	for option, value := range options {
+		taskOptions[option] = value
+	}
+	// the goals of the request are not mixed with the hard goal check of the cluster
+	if _, ok := options["goals"]; ok {
+		if _, ok := options["skip_hard_goal_check"]; !ok {
+			delete(taskOptions, "skip_hard_goal_check")
+		}
+	}
+	return taskOptions
+}
+
 func (cc *cruiseControlScaler) postCruiseControl(action string, options map[string]string) (*http.Response, error) {
 
-	requestURL := cc.generateUrlForCC(action, options)
+	requestURL := cc.generateUrlForCC(action, cc.withTaskOptions(action, options))
 	rsp, err := http.Post(requestURL, "text/plain", nil)
 	if err != nil {
 		log.Error(err, "error during talking to cruise-control")
@@ -274,6 +345,62 @@ func (cc *cruiseControlScaler) GetLiveKafkaBrokersFromCruiseControl(brokerIds []
 	return aliveBrokers, nil
 }
 
+// GetBrokerLoads returns the load of the alive brokers along with the usage of their log dirs
+func (cc *cruiseControlScaler) GetBrokerLoads() ([]BrokerLoad, error) {
+	rsp, err := cc.getCruiseControl(clusterLoadAction, map[string]string{
+		"json":               "true",
+		"populate_disk_info": "true",
+	})
+	if err != nil {
+		log.Error(err, "can't get broker load from cruise-control")
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Brokers []struct {
+			Broker      float64
+			BrokerState string
+			DiskPct     float64
+			Leaders     float64
+			DiskState   map[string]struct {
+				DiskPct float64
+			}
+		}
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	loads := make([]BrokerLoad, 0, len(response.Brokers))
+	for _, broker := range response.Brokers {
+		if broker.BrokerState != brokerAlive {
+			continue
+		}
+		logDirDiskPct := make(map[string]float64, len(broker.DiskState))
+		for logDir, diskState := range broker.DiskState {
+			logDirDiskPct[logDir] = diskState.DiskPct
+		}
+		loads = append(loads, BrokerLoad{
+			BrokerID:      fmt.Sprintf("%g", broker.Broker),
+			DiskPct:       broker.DiskPct,
+			Leaders:       int(broker.Leaders),
+			LogDirDiskPct: logDirDiskPct,
+		})
+	}
+	return loads, nil
+}
+
 // GetBrokerIDWithLeastPartition returns
 func (cc *cruiseControlScaler) GetBrokerIDWithLeastPartition() (string, error) {
 
@@ -451,6 +578,34 @@ func (cc *cruiseControlScaler) RunPreferedLeaderElectionInCluster() (string, err
 	return uTaskId, nil
 }
 
+// RunPreferredLeaderElectionOfTopics runs leader election in Kafka cluster using CC, limited to the partitions of
+// the given topics by excluding every other topic
+func (cc *cruiseControlScaler) RunPreferredLeaderElectionOfTopics(topics []string) (string, error) {
+	quoted := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		quoted = append(quoted, regexp.QuoteMeta(topic))
+	}
+	sort.Strings(quoted)
+
+	options := map[string]string{
+		"dryrun":          "false",
+		"json":            "true",
+		"goals":           "PreferredLeaderElectionGoal",
+		"excluded_topics": fmt.Sprintf("^(?!(%s)$).*", strings.Join(quoted, "|")),
+	}
+
+	dResp, err := cc.postCruiseControl(rebalanceAction, options)
+	if err != nil {
+		log.Error(err, "can't run preferred leader election since post to cruise-control failed")
+		return "", err
+	}
+	log.Info("Initiated preferred leader election in cruise control", "topics", topics)
+
+	uTaskId := dResp.Header.Get("User-Task-Id")
+
+	return uTaskId, nil
+}
+
 // KillCCTask kills the specified CC task
 func (cc *cruiseControlScaler) KillCCTask() error {
 	options := map[string]string{
@@ -514,3 +669,199 @@ func (cc *cruiseControlScaler) GetCCTaskState(uTaskId string) (banzaicloudv1beta
 	log.Info("Cruise control task not found", "taskID", uTaskId)
 	return banzaicloudv1beta1.CruiseControlTaskNotFound, nil
 }
+
+// operationActionAndOptions returns the endpoint and the parameters of the Cruise Control request of an operation
+func operationActionAndOptions(spec v1alpha1.CruiseControlOperationSpec) (string, map[string]string, error) {
+	options := map[string]string{
+		"dryrun": strconv.FormatBool(spec.DryRun),
+		"json":   "true",
+	}
+
+	var action string
+	switch spec.Operation {
+	case v1alpha1.CruiseControlOperationRebalance:
+		action = rebalanceAction
+	case v1alpha1.CruiseControlOperationDemoteBroker:
+		action = demoteBrokerAction
+	case v1alpha1.CruiseControlOperationRemoveBroker:
+		action = removeBrokerAction
+	case v1alpha1.CruiseControlOperationFixOfflineReplicas:
+		action = fixOfflineAction
+	case v1alpha1.CruiseControlOperationPreferredLeaderElection:
+		action = rebalanceAction
+		options["goals"] = "PreferredLeaderElectionGoal"
+	default:
+		return "", nil, fmt.Errorf("unknown cruise control operation %s", spec.Operation)
+	}
+
+	if spec.Operation == v1alpha1.CruiseControlOperationDemoteBroker || spec.Operation == v1alpha1.CruiseControlOperationRemoveBroker {
+		if len(spec.BrokerIDs) == 0 {
+			return "", nil, fmt.Errorf("cruise control operation %s requires broker ids", spec.Operation)
+		}
+		brokerIds := make([]string, 0, len(spec.BrokerIDs))
+		for _, brokerId := range spec.BrokerIDs {
+			brokerIds = append(brokerIds, strconv.Itoa(int(brokerId)))
+		}
+		options["brokerid"] = strings.Join(brokerIds, ",")
+	}
+	if len(spec.Goals) > 0 && spec.Operation != v1alpha1.CruiseControlOperationPreferredLeaderElection {
+		options["goals"] = strings.Join(spec.Goals, ",")
+	}
+	if spec.ConcurrentPartitionMovementsPerBroker != nil {
+		options["concurrent_partition_movements_per_broker"] = strconv.Itoa(int(*spec.ConcurrentPartitionMovementsPerBroker))
+	}
+	if spec.ConcurrentLeaderMovements != nil {
+		options["concurrent_leader_movements"] = strconv.Itoa(int(*spec.ConcurrentLeaderMovements))
+	}
+	if spec.ReplicationThrottle != nil {
+		options["replication_throttle"] = strconv.FormatInt(*spec.ReplicationThrottle, 10)
+	}
+	return action, options, nil
+}
+
+// parseProposalSummary returns the summary of the proposals in a Cruise Control response
+func parseProposalSummary(input io.Reader) (*v1alpha1.CruiseControlProposalSummary, error) {
+	body, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Summary *struct {
+			NumReplicaMovements            int32
+			NumIntraBrokerReplicaMovements int32
+			NumLeaderMovements             int32
+			DataToMoveMB                   int64
+			IntraBrokerDataToMoveMB        int64
+			MonitoredPartitionsPercentage  float64
+			ExcludedTopics                 []string
+		}
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if response.Summary == nil {
+		return nil, nil
+	}
+
+	return &v1alpha1.CruiseControlProposalSummary{
+		NumReplicaMovements:            response.Summary.NumReplicaMovements,
+		NumIntraBrokerReplicaMovements: response.Summary.NumIntraBrokerReplicaMovements,
+		NumLeaderMovements:             response.Summary.NumLeaderMovements,
+		DataToMoveMB:                   response.Summary.DataToMoveMB,
+		IntraBrokerDataToMoveMB:        response.Summary.IntraBrokerDataToMoveMB,
+		MonitoredPartitionsPercentage:  strconv.FormatFloat(response.Summary.MonitoredPartitionsPercentage, 'f', -1, 64),
+		ExcludedTopics:                 response.Summary.ExcludedTopics,
+	}, nil
+}
+
+// StartOperation starts the operation requested by a CruiseControlOperation. The summary of the proposals is
+// returned when Cruise Control computed them in time, otherwise it can be fetched once the task completes.
+func (cc *cruiseControlScaler) StartOperation(spec v1alpha1.CruiseControlOperationSpec) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	action, options, err := operationActionAndOptions(spec)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s", ErrInvalidOperation, err)
+	}
+
+	rsp, err := cc.postCruiseControl(action, options)
+	if err != nil {
+		log.Error(err, "can't start operation since post to cruise-control failed", "operation", spec.Operation)
+		if rsp != nil && rsp.StatusCode >= 400 && rsp.StatusCode < 500 {
+			return "", nil, ErrInvalidOperation
+		}
+		return "", nil, err
+	}
+	defer func() {
+		if closeErr := rsp.Body.Close(); closeErr != nil {
+			log.Error(closeErr, "could not close cruise-control response")
+		}
+	}()
+	log.Info("Initiated operation in cruise control", "operation", spec.Operation, "dryRun", spec.DryRun)
+
+	return readProposalResponse(rsp)
+}
+
+// readProposalResponse returns the task id of a Cruise Control response and its summary of the proposals
+func readProposalResponse(rsp *http.Response) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	uTaskId := rsp.Header.Get("User-Task-Id")
+	// the proposals are still being computed when cruise control answers with 202
+	if rsp.StatusCode != http.StatusOK {
+		return uTaskId, nil, nil
+	}
+	summary, err := parseProposalSummary(rsp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	return uTaskId, summary, nil
+}
+
+// ProposeScaling computes the proposal of adding or removing the brokers without executing it. The summary of
+// the proposal is returned when Cruise Control computed it in time, otherwise it can be fetched once the task completes.
+func (cc *cruiseControlScaler) ProposeScaling(brokerIds []string, upscale bool) (string, *v1alpha1.CruiseControlProposalSummary, error) {
+	action := removeBrokerAction
+	if upscale {
+		liveBrokers, err := cc.GetLiveKafkaBrokersFromCruiseControl(brokerIds)
+		if err != nil {
+			return "", nil, err
+		}
+		if !bcutil.AreStringSlicesIdentical(liveBrokers, brokerIds) {
+			return "", nil, errors.New("broker(s) not yet ready in cruise-control")
+		}
+		action = addBrokerAction
+	}
+
+	rsp, err := cc.postCruiseControl(action, map[string]string{
+		"json":     "true",
+		"dryrun":   "true",
+		"brokerid": strings.Join(brokerIds, ","),
+	})
+	if err != nil {
+		log.Error(err, "can't compute scaling proposal since post to cruise-control failed")
+		return "", nil, err
+	}
+	defer func() {
+		if closeErr := rsp.Body.Close(); closeErr != nil {
+			log.Error(closeErr, "could not close cruise-control response")
+		}
+	}()
+	log.Info("Initiated scaling proposal in cruise control", "action", action)
+
+	return readProposalResponse(rsp)
+}
+
+// GetCCTaskSummary returns the summary of the proposals of a completed CC task, which Cruise Control returns as
+// the original response of the task, or nil while the task has no response yet
+func (cc *cruiseControlScaler) GetCCTaskSummary(uTaskId string) (*v1alpha1.CruiseControlProposalSummary, error) {
+	gResp, err := cc.getCruiseControl(getTaskListAction, map[string]string{
+		"json":                 "true",
+		"user_task_ids":        uTaskId,
+		"fetch_completed_task": "true",
+	})
+	if err != nil {
+		log.Error(err, "can't get task result from cruise-control")
+		return nil, err
+	}
+	defer func() {
+		if closeErr := gResp.Body.Close(); closeErr != nil {
+			log.Error(closeErr, "could not close cruise-control response")
+		}
+	}()
+
+	var taskLists struct {
+		UserTasks []struct {
+			UserTaskId       string
+			OriginalResponse string `json:"originalResponse"`
+		}
+	}
+	if err := json.NewDecoder(gResp.Body).Decode(&taskLists); err != nil {
+		return nil, err
+	}
+
+	for _, task := range taskLists.UserTasks {
+		if task.UserTaskId == uTaskId && task.OriginalResponse != "" {
+			return parseProposalSummary(strings.NewReader(task.OriginalResponse))
+		}
+	}
+	log.Info("Cruise control task has no response yet", "taskID", uTaskId)
+	return nil, nil
+}
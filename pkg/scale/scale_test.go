@@ -0,0 +1,190 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+func TestStartOperation(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/kafkacruisecontrol/user_tasks" {
+			_, _ = w.Write([]byte(`{"userTasks":[{"UserTaskId":"task-1","RequestURL":"POST /kafkacruisecontrol/demote_broker?brokerid=1,2",` +
+				`"ClientIdentity":"10.0.0.12","StartMs":"1602668405281","Status":"Completed",` +
+				`"originalResponse":"{\"summary\":{\"numReplicaMovements\":4,\"dataToMoveMB\":120,\"numLeaderMovements\":0},` +
+				`\"goalSummary\":[],\"version\":1}"}],"version":1}`))
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("User-Task-Id", "task-1")
+		if query.Get("dryrun") == "true" {
+			_, _ = w.Write([]byte(`{"summary":{"numReplicaMovements":12,"numLeaderMovements":3,"dataToMoveMB":2048,` +
+				`"monitoredPartitionsPercentage":99.5,"excludedTopics":["__consumer_offsets"]}}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"progress":[]}`))
+	}))
+	defer server.Close()
+
+	cc := createNewDefaultCruiseControlScaler("kafka", "cluster.local", strings.TrimPrefix(server.URL, "http://"), "kafka", v1beta1.CruiseControlTaskSpec{})
+
+	partitionMovements := int32(5)
+	taskId, summary, err := cc.StartOperation(v1alpha1.CruiseControlOperationSpec{
+		Operation:                             v1alpha1.CruiseControlOperationRebalance,
+		Goals:                                 []string{"RackAwareGoal", "DiskCapacityGoal"},
+		DryRun:                                true,
+		ConcurrentPartitionMovementsPerBroker: &partitionMovements,
+	})
+	if err != nil || taskId != "task-1" {
+		t.Fatal("Expected task-1 without error, got:", taskId, err)
+	}
+	if query.Get("goals") != "RackAwareGoal,DiskCapacityGoal" || query.Get("concurrent_partition_movements_per_broker") != "5" {
+		t.Error("Expected the goals and the concurrency in the request, got:", query)
+	}
+	if summary == nil || summary.NumReplicaMovements != 12 || summary.DataToMoveMB != 2048 ||
+		summary.MonitoredPartitionsPercentage != "99.5" || len(summary.ExcludedTopics) != 1 {
+		t.Error("Expected the summary of the proposals, got:", summary)
+	}
+
+	// the proposals of a task still in progress are fetched once it completes
+	_, summary, err = cc.StartOperation(v1alpha1.CruiseControlOperationSpec{
+		Operation: v1alpha1.CruiseControlOperationDemoteBroker,
+		BrokerIDs: []int32{1, 2},
+	})
+	if err != nil || summary != nil {
+		t.Error("Expected no summary for a task in progress, got:", summary, err)
+	}
+	if query.Get("brokerid") != "1,2" || query.Get("dryrun") != "false" {
+		t.Error("Expected the brokers to demote in the request, got:", query)
+	}
+	if summary, err = cc.GetCCTaskSummary("task-1"); err != nil || summary == nil || summary.NumReplicaMovements != 4 {
+		t.Error("Expected the summary of the completed task, got:", summary, err)
+	}
+	if summary, err = cc.GetCCTaskSummary("task-2"); err != nil || summary != nil {
+		t.Error("Expected no summary for a task without a response, got:", summary, err)
+	}
+
+	_, _, err = cc.StartOperation(v1alpha1.CruiseControlOperationSpec{Operation: v1alpha1.CruiseControlOperationRemoveBroker})
+	if !errors.Is(err, ErrInvalidOperation) {
+		t.Error("Expected ErrInvalidOperation for remove_broker without brokers, got:", err)
+	}
+}
+
+func TestProposeScaling(t *testing.T) {
+	var path string
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		w.Header().Set("User-Task-Id", "proposal-1")
+		_, _ = w.Write([]byte(`{"summary":{"numReplicaMovements":40,"numLeaderMovements":7,"dataToMoveMB":4096000}}`))
+	}))
+	defer server.Close()
+
+	cc := createNewDefaultCruiseControlScaler("kafka", "cluster.local", strings.TrimPrefix(server.URL, "http://"), "kafka", v1beta1.CruiseControlTaskSpec{})
+
+	taskId, summary, err := cc.ProposeScaling([]string{"3"}, false)
+	if err != nil || taskId != "proposal-1" {
+		t.Fatal("Expected proposal-1 without error, got:", taskId, err)
+	}
+	if path != "/kafkacruisecontrol/remove_broker" || query.Get("dryrun") != "true" || query.Get("brokerid") != "3" {
+		t.Error("Expected a dry-run removal of the broker, got:", path, query)
+	}
+	if summary == nil || summary.NumReplicaMovements != 40 || summary.DataToMoveMB != 4096000 {
+		t.Error("Expected the summary of the proposal, got:", summary)
+	}
+}
+
+func TestRunPreferredLeaderElectionOfTopics(t *testing.T) {
+	var path string
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		w.Header().Set("User-Task-Id", "election-1")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	cc := createNewDefaultCruiseControlScaler("kafka", "cluster.local", strings.TrimPrefix(server.URL, "http://"), "kafka",
+		v1beta1.CruiseControlTaskSpec{ExcludedTopics: "__.*"})
+
+	taskId, err := cc.RunPreferredLeaderElectionOfTopics([]string{"payments.v1", "orders"})
+	if err != nil || taskId != "election-1" {
+		t.Fatal("Expected election-1 without error, got:", taskId, err)
+	}
+	if path != "/kafkacruisecontrol/rebalance" || query.Get("goals") != "PreferredLeaderElectionGoal" {
+		t.Error("Expected a preferred leader election, got:", path, query)
+	}
+	if excluded := query.Get("excluded_topics"); excluded != `^(?!(orders|payments\.v1)$).*` {
+		t.Error("Expected every other topic to be excluded, got:", excluded)
+	}
+}
+
+func TestWithTaskOptions(t *testing.T) {
+	throttle, partitionMovements, leaderMovements := int64(50000000), int32(2), int32(100)
+	cc := &cruiseControlScaler{taskSpec: v1beta1.CruiseControlTaskSpec{
+		ReplicationThrottle:                   &throttle,
+		ConcurrentPartitionMovementsPerBroker: &partitionMovements,
+		ConcurrentLeaderMovements:             &leaderMovements,
+		ExcludedTopics:                        "^__.*",
+		Goals:                                 []string{"RackAwareGoal", "ReplicaCapacityGoal"},
+		SkipHardGoalCheck:                     true,
+	}}
+
+	options := cc.withTaskOptions(removeBrokerAction, map[string]string{"brokerid": "3", "dryrun": "false"})
+	expected := map[string]string{
+		"brokerid":             "3",
+		"dryrun":               "false",
+		"goals":                "RackAwareGoal,ReplicaCapacityGoal",
+		"skip_hard_goal_check": "true",
+		"excluded_topics":      "^__.*",
+		"concurrent_partition_movements_per_broker": "2",
+		"concurrent_leader_movements":               "100",
+		"replication_throttle":                      "50000000",
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Error("Expected the task options on remove_broker", expected, "got:", options)
+	}
+
+	options = cc.withTaskOptions(rebalanceAction, map[string]string{"goals": "PreferredLeaderElectionGoal"})
+	if options["goals"] != "PreferredLeaderElectionGoal" || options["skip_hard_goal_check"] != "" || options["replication_throttle"] != "50000000" {
+		t.Error("Expected the goals of the request to be kept without the hard goal check, got:", options)
+	}
+
+	options = cc.withTaskOptions(rebalanceAction, map[string]string{"rebalance_disk": "true"})
+	if options["goals"] != "" || options["excluded_topics"] != "^__.*" {
+		t.Error("Expected the disk rebalance without the goals of the cluster, got:", options)
+	}
+
+	options = cc.withTaskOptions(demoteBrokerAction, map[string]string{"replication_throttle": "1000"})
+	expected = map[string]string{"replication_throttle": "1000", "concurrent_leader_movements": "100"}
+	if !reflect.DeepEqual(options, expected) {
+		t.Error("Expected the leadership options on demote_broker", expected, "got:", options)
+	}
+
+	if options = cc.withTaskOptions(kafkaClusterStateAction, map[string]string{"json": "true"}); len(options) != 1 {
+		t.Error("Expected no task options on kafka_cluster_state, got:", options)
+	}
+}
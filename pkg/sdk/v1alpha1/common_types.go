@@ -20,12 +20,41 @@ type KafkaAccessType string
 // KafkaPatternType hold the Resource Pattern Type of kafka ACL
 type KafkaPatternType string
 
+// KafkaClusterOperation is an operation on the cluster resource of Kafka ACLs
+// +kubebuilder:validation:Enum={"Alter","AlterConfigs","ClusterAction","Create","Describe","DescribeConfigs","IdempotentWrite"}
+type KafkaClusterOperation string
+
+// DeletionPolicy defines what happens to the resources on the Kafka cluster when their custom resource is deleted
+// +kubebuilder:validation:Enum={"Delete","Retain","Orphan"}
+type DeletionPolicy string
+
+// CruiseControlOperationType defines the Cruise Control endpoint a CruiseControlOperation calls
+type CruiseControlOperationType string
+
+// CruiseControlOperationState defines the state of a CruiseControlOperation
+type CruiseControlOperationState string
+
+// OffsetResetStrategy defines where the offsets of a consumer group are moved
+type OffsetResetStrategy string
+
+// OffsetResetState defines the state of a consumer group offset reset
+type OffsetResetState string
+
 // TopicState defines the state of a KafkaTopic
 type TopicState string
 
+// TopicConditionType defines the type of a KafkaTopic condition
+type TopicConditionType string
+
+// TopicReassignmentState defines the state of a partition reassignment of a KafkaTopic
+type TopicReassignmentState string
+
 // UserState defines the state of a KafkaUser
 type UserState string
 
+// UserAuthenticationType defines how a KafkaUser authenticates to the cluster
+type UserAuthenticationType string
+
 // ClusterReference states a reference to a cluster for topic/user
 // provisioning
 type ClusterReference struct {
@@ -44,10 +73,66 @@ const (
 	KafkaPatternTypeMatch    KafkaPatternType = "match"
 	KafkaPatternTypePrefixed KafkaPatternType = "prefixed"
 	KafkaPatternTypeDefault  KafkaPatternType = "literal"
+	// Operations on the cluster resource. More info: https://kafka.apache.org/documentation/#operations_resources_and_protocols
+	KafkaClusterOperationAlter           KafkaClusterOperation = "Alter"
+	KafkaClusterOperationAlterConfigs    KafkaClusterOperation = "AlterConfigs"
+	KafkaClusterOperationClusterAction   KafkaClusterOperation = "ClusterAction"
+	KafkaClusterOperationCreate          KafkaClusterOperation = "Create"
+	KafkaClusterOperationDescribe        KafkaClusterOperation = "Describe"
+	KafkaClusterOperationDescribeConfigs KafkaClusterOperation = "DescribeConfigs"
+	KafkaClusterOperationIdempotentWrite KafkaClusterOperation = "IdempotentWrite"
+	// DeletionPolicyDelete removes the topic, or the ACLs, quotas, SCRAM credentials and certificate of the user
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the topic, or the ACLs, quotas and SCRAM credentials of the user on the cluster,
+	// only the user certificate is revoked
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan removes the finalizer without touching the cluster or the PKI
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// WildcardGroupName grants access to every consumer group
+	WildcardGroupName string = "*"
+	// Cruise Control operations
+	CruiseControlOperationRebalance               CruiseControlOperationType = "rebalance"
+	CruiseControlOperationDemoteBroker            CruiseControlOperationType = "demote_broker"
+	CruiseControlOperationRemoveBroker            CruiseControlOperationType = "remove_broker"
+	CruiseControlOperationFixOfflineReplicas      CruiseControlOperationType = "fix_offline_replicas"
+	CruiseControlOperationPreferredLeaderElection CruiseControlOperationType = "preferred_leader_election"
+	// CruiseControlOperationQueued states that the operation waits for the earlier operations of the cluster
+	CruiseControlOperationQueued CruiseControlOperationState = "Queued"
+	// CruiseControlOperationRunning states that Cruise Control is working on the operation
+	CruiseControlOperationRunning CruiseControlOperationState = "Running"
+	// CruiseControlOperationCompleted states that Cruise Control finished the operation
+	CruiseControlOperationCompleted CruiseControlOperationState = "Completed"
+	// CruiseControlOperationFailed states that the operation could not be finished
+	CruiseControlOperationFailed CruiseControlOperationState = "Failed"
+	// Consumer group offset reset strategies
+	OffsetResetStrategyEarliest  OffsetResetStrategy = "earliest"
+	OffsetResetStrategyLatest    OffsetResetStrategy = "latest"
+	OffsetResetStrategyTimestamp OffsetResetStrategy = "timestamp"
+	OffsetResetStrategyOffsets   OffsetResetStrategy = "offsets"
+	// OffsetResetPending states that the offset reset waits for the consumer group to become empty
+	OffsetResetPending OffsetResetState = "pending"
+	// OffsetResetApplied states that the offsets were reset
+	OffsetResetApplied OffsetResetState = "applied"
+	// OffsetResetFailed states that the offset reset could not be applied
+	OffsetResetFailed OffsetResetState = "failed"
 	// TopicStateCreated describes the status of a KafkaTopic as created
 	TopicStateCreated TopicState = "created"
+	// TopicAdoptAnnotation set to "true" on a KafkaTopic takes over the management of a topic that already
+	// exists on the cluster instead of rejecting the KafkaTopic
+	TopicAdoptAnnotation string = "kafka.banzaicloud.io/adopt-topic"
+	// TopicConditionInSync is true when the topic on the cluster was found matching the spec, it turns
+	// false when the topic was changed without the operator, e.g. with kafka-configs.sh
+	TopicConditionInSync TopicConditionType = "InSync"
+	// TopicReassignmentInProgress states that replicas of the topic are being moved
+	TopicReassignmentInProgress TopicReassignmentState = "inProgress"
+	// TopicReassignmentCompleted states that every partition has the requested replicas
+	TopicReassignmentCompleted TopicReassignmentState = "completed"
 	// UserStateCreated describes the status of a KafkaUser as created
 	UserStateCreated UserState = "created"
+	// UserAuthenticationTLS authenticates the user with a client certificate
+	UserAuthenticationTLS UserAuthenticationType = "tls"
+	// UserAuthenticationSCRAMSHA512 authenticates the user with SCRAM-SHA-512 credentials
+	UserAuthenticationSCRAMSHA512 UserAuthenticationType = "scram-sha-512"
 	// TLSJKSKeyStore is where a JKS keystore is stored in a user secret when requested
 	TLSJKSKeyStore string = "keystore.jks"
 	// TLSJKSTrustStore is where a JKS truststore is stored in a user secret when requested
@@ -0,0 +1,106 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CruiseControlOperationSpec defines the desired state of CruiseControlOperation
+// +k8s:openapi-gen=true
+type CruiseControlOperationSpec struct {
+	ClusterRef ClusterReference `json:"clusterRef"`
+	// +kubebuilder:validation:Enum={"rebalance","demote_broker","remove_broker","fix_offline_replicas","preferred_leader_election"}
+	Operation CruiseControlOperationType `json:"operation"`
+	// BrokerIDs are the brokers to demote or remove, required by the demote_broker and remove_broker operations
+	BrokerIDs []int32 `json:"brokerIDs,omitempty"`
+	// Goals replace the default goals of Cruise Control for the operation,
+	// the preferred_leader_election operation always uses the PreferredLeaderElectionGoal
+	Goals []string `json:"goals,omitempty"`
+	// DryRun only computes the proposals of the operation and reports their summary without executing them
+	DryRun bool `json:"dryRun,omitempty"`
+	// ConcurrentPartitionMovementsPerBroker is the number of replicas moved in or out of a broker at a time
+	// +kubebuilder:validation:Minimum=1
+	ConcurrentPartitionMovementsPerBroker *int32 `json:"concurrentPartitionMovementsPerBroker,omitempty"`
+	// ConcurrentLeaderMovements is the number of partition leaderships moved at a time
+	// +kubebuilder:validation:Minimum=1
+	ConcurrentLeaderMovements *int32 `json:"concurrentLeaderMovements,omitempty"`
+	// ReplicationThrottle is the upper bound in bytes per second of the replication traffic of the moved replicas
+	// +kubebuilder:validation:Minimum=1
+	ReplicationThrottle *int64 `json:"replicationThrottle,omitempty"`
+}
+
+// CruiseControlOperationStatus defines the observed state of CruiseControlOperation
+// +k8s:openapi-gen=true
+type CruiseControlOperationStatus struct {
+	// State is where the operation is in the queue of the cluster
+	State CruiseControlOperationState `json:"state,omitempty"`
+	// TaskID is the id of the user task of the operation in Cruise Control
+	TaskID string `json:"taskID,omitempty"`
+	// TaskState is the state of the user task reported by Cruise Control, e.g. Active, InExecution or Completed
+	TaskState  string       `json:"taskState,omitempty"`
+	StartTime  *metav1.Time `json:"startTime,omitempty"`
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+	// Summary describes the proposals Cruise Control computed for the operation
+	Summary *CruiseControlProposalSummary `json:"summary,omitempty"`
+	Message string                        `json:"message,omitempty"`
+}
+
+// CruiseControlProposalSummary is the summary of the proposals computed by Cruise Control
+type CruiseControlProposalSummary struct {
+	NumReplicaMovements            int32 `json:"numReplicaMovements"`
+	NumIntraBrokerReplicaMovements int32 `json:"numIntraBrokerReplicaMovements"`
+	NumLeaderMovements             int32 `json:"numLeaderMovements"`
+	DataToMoveMB                   int64 `json:"dataToMoveMB"`
+	IntraBrokerDataToMoveMB        int64 `json:"intraBrokerDataToMoveMB"`
+	// MonitoredPartitionsPercentage is the percentage of the partitions with enough metrics to be moved
+	MonitoredPartitionsPercentage string   `json:"monitoredPartitionsPercentage,omitempty"`
+	ExcludedTopics                []string `json:"excludedTopics,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CruiseControlOperation is the Schema for the cruisecontroloperations API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.operation",name="Operation",type="string"
+// +kubebuilder:printcolumn:JSONPath=".spec.dryRun",name="Dry Run",type="boolean"
+// +kubebuilder:printcolumn:JSONPath=".status.state",name="State",type="string"
+// +kubebuilder:printcolumn:JSONPath=".status.taskState",name="Task State",type="string"
+type CruiseControlOperation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CruiseControlOperationSpec   `json:"spec,omitempty"`
+	Status CruiseControlOperationStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CruiseControlOperationList contains a list of CruiseControlOperation
+type CruiseControlOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CruiseControlOperation `json:"items"`
+}
+
+// IsFinished returns true if the operation left the queue of the cluster for good
+func (o *CruiseControlOperation) IsFinished() bool {
+	return o.Status.State == CruiseControlOperationCompleted || o.Status.State == CruiseControlOperationFailed
+}
+
+func init() {
+	SchemeBuilder.Register(&CruiseControlOperation{}, &CruiseControlOperationList{})
+}
@@ -0,0 +1,114 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KafkaConsumerGroupSpec defines the desired state of KafkaConsumerGroup
+// +k8s:openapi-gen=true
+type KafkaConsumerGroupSpec struct {
+	// GroupID is the id of the consumer group on the Kafka cluster
+	GroupID    string           `json:"groupID"`
+	ClusterRef ClusterReference `json:"clusterRef"`
+	// OffsetReset moves the committed offsets of the group on a topic. It is only applied while the group
+	// has no members and only once per ID, change the ID to apply it again.
+	OffsetReset *ConsumerGroupOffsetReset `json:"offsetReset,omitempty"`
+}
+
+// ConsumerGroupOffsetReset describes where the committed offsets of a consumer group are moved
+type ConsumerGroupOffsetReset struct {
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	// Partitions are the partitions to reset, every partition of the topic when empty
+	Partitions []int32 `json:"partitions,omitempty"`
+	// +kubebuilder:validation:Enum={"earliest","latest","timestamp","offsets"}
+	Strategy OffsetResetStrategy `json:"strategy"`
+	// Timestamp in milliseconds since epoch, the timestamp strategy moves to the first offset at or after it
+	Timestamp *int64 `json:"timestamp,omitempty"`
+	// Offsets are the explicit offsets used by the offsets strategy
+	Offsets []PartitionOffset `json:"offsets,omitempty"`
+}
+
+// PartitionOffset is an offset on a single partition
+type PartitionOffset struct {
+	Partition int32 `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// KafkaConsumerGroupStatus defines the observed state of KafkaConsumerGroup
+// +k8s:openapi-gen=true
+type KafkaConsumerGroupStatus struct {
+	// State is the state of the group reported by the group coordinator, e.g. Empty or Stable
+	State    string                `json:"state,omitempty"`
+	Members  []ConsumerGroupMember `json:"members,omitempty"`
+	Lag      []ConsumerGroupLag    `json:"lag,omitempty"`
+	TotalLag int64                 `json:"totalLag"`
+	// OffsetReset reports the progress of the offset reset in the spec
+	OffsetReset *OffsetResetStatus `json:"offsetReset,omitempty"`
+}
+
+// ConsumerGroupMember is a member of a consumer group
+type ConsumerGroupMember struct {
+	MemberID   string `json:"memberID"`
+	ClientID   string `json:"clientID"`
+	ClientHost string `json:"clientHost"`
+}
+
+// ConsumerGroupLag is the lag of a consumer group on a single partition
+type ConsumerGroupLag struct {
+	Topic           string `json:"topic"`
+	Partition       int32  `json:"partition"`
+	CommittedOffset int64  `json:"committedOffset"`
+	LogEndOffset    int64  `json:"logEndOffset"`
+	Lag             int64  `json:"lag"`
+}
+
+// OffsetResetStatus is the state of an offset reset
+type OffsetResetStatus struct {
+	ID      string           `json:"id"`
+	State   OffsetResetState `json:"state"`
+	Message string           `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KafkaConsumerGroup is the Schema for the kafkaconsumergroups API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.groupID",name="Group",type="string"
+// +kubebuilder:printcolumn:JSONPath=".status.state",name="State",type="string"
+// +kubebuilder:printcolumn:JSONPath=".status.totalLag",name="Lag",type="integer"
+type KafkaConsumerGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaConsumerGroupSpec   `json:"spec,omitempty"`
+	Status KafkaConsumerGroupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KafkaConsumerGroupList contains a list of KafkaConsumerGroup
+type KafkaConsumerGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaConsumerGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaConsumerGroup{}, &KafkaConsumerGroupList{})
+}
@@ -26,12 +26,51 @@ type KafkaTopicSpec struct {
 	ReplicationFactor int32             `json:"replicationFactor"`
 	Config            map[string]string `json:"config,omitempty"`
 	ClusterRef        ClusterReference  `json:"clusterRef"`
+	// DeletionPolicy overrides the default deletion policy of the cluster, the topic is deleted from the cluster
+	// only with Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 // KafkaTopicStatus defines the observed state of KafkaTopic
 // +k8s:openapi-gen=true
 type KafkaTopicStatus struct {
 	State TopicState `json:"state"`
+	// ObservedGeneration is the generation of the KafkaTopic the status was observed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Partitions is the number of partitions of the topic on the cluster
+	Partitions int32 `json:"partitions,omitempty"`
+	// ReplicationFactor is the number of replicas of the topic partitions on the cluster
+	ReplicationFactor         int32 `json:"replicationFactor,omitempty"`
+	UnderReplicatedPartitions int32 `json:"underReplicatedPartitions,omitempty"`
+	OfflinePartitions         int32 `json:"offlinePartitions,omitempty"`
+	// LeaderDistribution is the number of partitions led by each broker, keyed by broker id
+	LeaderDistribution map[string]int32 `json:"leaderDistribution,omitempty"`
+	// Config holds the effective configs of the topic that differ from the Kafka defaults,
+	// including the ones inherited from broker level overrides
+	Config     map[string]string `json:"config,omitempty"`
+	Conditions []TopicCondition  `json:"conditions,omitempty"`
+	// Reassignment reports the progress of the partition reassignment changing the replication factor
+	Reassignment *TopicReassignmentStatus `json:"reassignment,omitempty"`
+}
+
+// TopicReassignmentStatus is the progress of a replication factor change
+type TopicReassignmentStatus struct {
+	// ReplicationFactor is the replication factor the partitions are reassigned to
+	ReplicationFactor int32                  `json:"replicationFactor"`
+	State             TopicReassignmentState `json:"state"`
+	// PartitionsInProgress is the number of partitions still moving replicas
+	PartitionsInProgress int32 `json:"partitionsInProgress,omitempty"`
+}
+
+// TopicCondition describes an aspect of the state of a KafkaTopic
+type TopicCondition struct {
+	Type   TopicConditionType     `json:"type"`
+	Status metav1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the generation of the KafkaTopic the condition was set for
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -29,6 +29,21 @@ type KafkaUserSpec struct {
 	IncludeJKS     bool             `json:"includeJKS,omitempty"`
 	CreateCert     *bool            `json:"createCert,omitempty"`
 	PKIBackendSpec *PKIBackendSpec  `json:"pkiBackendSpec,omitempty"`
+	// AuthenticationType selects how the user authenticates to the cluster. With scram-sha-512 the operator
+	// provisions SCRAM credentials for the User:<name> principal and stores them in SecretName instead of a certificate.
+	// +kubebuilder:validation:Enum={"tls","scram-sha-512"}
+	AuthenticationType UserAuthenticationType `json:"authenticationType,omitempty"`
+	// Quotas are the client quotas enforced by the brokers on the user principal
+	Quotas *UserQuotas `json:"quotas,omitempty"`
+	// GroupGrants give read access to consumer groups. Without them a read topic grant
+	// gives read access to every consumer group.
+	GroupGrants []UserGroupGrant `json:"groupGrants,omitempty"`
+	// TransactionalIDGrants give access to transactional ids for transactional producers
+	TransactionalIDGrants []UserTransactionalIDGrant `json:"transactionalIDGrants,omitempty"`
+	// ClusterOperations are the operations the user is allowed on the cluster resource
+	ClusterOperations []KafkaClusterOperation `json:"clusterOperations,omitempty"`
+	// DeletionPolicy overrides the default deletion policy of the cluster for the ACLs, quotas and credentials of the user
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 type PKIBackendSpec struct {
@@ -46,16 +61,49 @@ type UserTopicGrant struct {
 	PatternType KafkaPatternType `json:"patternType,omitempty"`
 }
 
+// UserGroupGrant is the desired read permission of the KafkaUser on consumer groups
+type UserGroupGrant struct {
+	GroupName string `json:"groupName"`
+	// +kubebuilder:validation:Enum={"literal","match","prefixed","any"}
+	PatternType KafkaPatternType `json:"patternType,omitempty"`
+}
+
+// UserTransactionalIDGrant is the desired permission of the KafkaUser to use transactional ids
+type UserTransactionalIDGrant struct {
+	TransactionalID string `json:"transactionalID"`
+	// +kubebuilder:validation:Enum={"literal","match","prefixed","any"}
+	PatternType KafkaPatternType `json:"patternType,omitempty"`
+}
+
+// UserQuotas defines the client quotas of the KafkaUser, unset quotas are not enforced
+type UserQuotas struct {
+	// ProducerByteRate is the maximum number of bytes per second the user can publish to a single broker
+	// +kubebuilder:validation:Minimum=0
+	ProducerByteRate *int64 `json:"producerByteRate,omitempty"`
+	// ConsumerByteRate is the maximum number of bytes per second the user can fetch from a single broker
+	// +kubebuilder:validation:Minimum=0
+	ConsumerByteRate *int64 `json:"consumerByteRate,omitempty"`
+	// RequestPercentage is the percentage of a single request handler and network thread the user can use
+	// +kubebuilder:validation:Minimum=0
+	RequestPercentage *int32 `json:"requestPercentage,omitempty"`
+}
+
 // KafkaUserStatus defines the observed state of KafkaUser
 // +k8s:openapi-gen=true
 type KafkaUserStatus struct {
 	State UserState `json:"state"`
 	ACLs  []string  `json:"acls,omitempty"`
+	// Quotas are the client quotas applied to the user on the cluster
+	Quotas *UserQuotas `json:"quotas,omitempty"`
+	// SCRAMCredentialsHash identifies the version of the secret whose password was pushed to the cluster as the
+	// SCRAM credentials of the user
+	SCRAMCredentialsHash string `json:"scramCredentialsHash,omitempty"`
 }
 
 //KafkaUser is the Schema for the kafka users API
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
+// +kubebuilder:webhook:failurePolicy="fail",name="kafkausers.kafka.banzaicloud.io",path="/validate",mutating=false,resources={"kafkausers"},verbs={"create","update"},groups={"kafka.banzaicloud.io"},versions={"v1alpha1"}
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 type KafkaUser struct {
@@ -80,8 +128,39 @@ func init() {
 }
 
 func (spec *KafkaUserSpec) GetIfCertShouldBeCreated() bool {
+	if spec.GetAuthenticationType() == UserAuthenticationSCRAMSHA512 {
+		return false
+	}
 	if spec.CreateCert != nil {
 		return *spec.CreateCert
 	}
 	return true
 }
+
+// GetAuthenticationType returns the authentication type of the user, defaults to tls
+func (spec *KafkaUserSpec) GetAuthenticationType() UserAuthenticationType {
+	if spec.AuthenticationType == "" {
+		return UserAuthenticationTLS
+	}
+	return spec.AuthenticationType
+}
+
+// GetGroupGrants returns the group grants of the user. When none are given but the user can read a topic,
+// read access on every group is granted, so existing consumers keep working.
+func (spec *KafkaUserSpec) GetGroupGrants() []UserGroupGrant {
+	if len(spec.GroupGrants) > 0 {
+		return spec.GroupGrants
+	}
+	for _, grant := range spec.TopicGrants {
+		if grant.AccessType == KafkaAccessTypeRead {
+			return []UserGroupGrant{{GroupName: WildcardGroupName, PatternType: KafkaPatternTypeLiteral}}
+		}
+	}
+	return nil
+}
+
+// HasACLGrants returns true if any ACL is granted to the user
+func (spec *KafkaUserSpec) HasACLGrants() bool {
+	return len(spec.TopicGrants) > 0 || len(spec.GroupGrants) > 0 ||
+		len(spec.TransactionalIDGrants) > 0 || len(spec.ClusterOperations) > 0
+}
@@ -38,13 +38,331 @@ func (in *ClusterReference) DeepCopy() *ClusterReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsumerGroupLag) DeepCopyInto(out *ConsumerGroupLag) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsumerGroupLag.
+func (in *ConsumerGroupLag) DeepCopy() *ConsumerGroupLag {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsumerGroupLag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsumerGroupMember) DeepCopyInto(out *ConsumerGroupMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsumerGroupMember.
+func (in *ConsumerGroupMember) DeepCopy() *ConsumerGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsumerGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsumerGroupOffsetReset) DeepCopyInto(out *ConsumerGroupOffsetReset) {
+	*out = *in
+	if in.Partitions != nil {
+		in, out := &in.Partitions, &out.Partitions
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Offsets != nil {
+		in, out := &in.Offsets, &out.Offsets
+		*out = make([]PartitionOffset, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsumerGroupOffsetReset.
+func (in *ConsumerGroupOffsetReset) DeepCopy() *ConsumerGroupOffsetReset {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsumerGroupOffsetReset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlOperation) DeepCopyInto(out *CruiseControlOperation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperation.
+func (in *CruiseControlOperation) DeepCopy() *CruiseControlOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CruiseControlOperation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlOperationList) DeepCopyInto(out *CruiseControlOperationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CruiseControlOperation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationList.
+func (in *CruiseControlOperationList) DeepCopy() *CruiseControlOperationList {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlOperationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CruiseControlOperationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlOperationSpec) DeepCopyInto(out *CruiseControlOperationSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.BrokerIDs != nil {
+		in, out := &in.BrokerIDs, &out.BrokerIDs
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Goals != nil {
+		in, out := &in.Goals, &out.Goals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConcurrentPartitionMovementsPerBroker != nil {
+		in, out := &in.ConcurrentPartitionMovementsPerBroker, &out.ConcurrentPartitionMovementsPerBroker
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConcurrentLeaderMovements != nil {
+		in, out := &in.ConcurrentLeaderMovements, &out.ConcurrentLeaderMovements
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReplicationThrottle != nil {
+		in, out := &in.ReplicationThrottle, &out.ReplicationThrottle
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationSpec.
+func (in *CruiseControlOperationSpec) DeepCopy() *CruiseControlOperationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlOperationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlOperationStatus) DeepCopyInto(out *CruiseControlOperationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(CruiseControlProposalSummary)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationStatus.
+func (in *CruiseControlOperationStatus) DeepCopy() *CruiseControlOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlProposalSummary) DeepCopyInto(out *CruiseControlProposalSummary) {
+	*out = *in
+	if in.ExcludedTopics != nil {
+		in, out := &in.ExcludedTopics, &out.ExcludedTopics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlProposalSummary.
+func (in *CruiseControlProposalSummary) DeepCopy() *CruiseControlProposalSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlProposalSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConsumerGroup) DeepCopyInto(out *KafkaConsumerGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConsumerGroup.
+func (in *KafkaConsumerGroup) DeepCopy() *KafkaConsumerGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConsumerGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaConsumerGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConsumerGroupList) DeepCopyInto(out *KafkaConsumerGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KafkaConsumerGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConsumerGroupList.
+func (in *KafkaConsumerGroupList) DeepCopy() *KafkaConsumerGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConsumerGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaConsumerGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConsumerGroupSpec) DeepCopyInto(out *KafkaConsumerGroupSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.OffsetReset != nil {
+		in, out := &in.OffsetReset, &out.OffsetReset
+		*out = new(ConsumerGroupOffsetReset)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConsumerGroupSpec.
+func (in *KafkaConsumerGroupSpec) DeepCopy() *KafkaConsumerGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConsumerGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConsumerGroupStatus) DeepCopyInto(out *KafkaConsumerGroupStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]ConsumerGroupMember, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lag != nil {
+		in, out := &in.Lag, &out.Lag
+		*out = make([]ConsumerGroupLag, len(*in))
+		copy(*out, *in)
+	}
+	if in.OffsetReset != nil {
+		in, out := &in.OffsetReset, &out.OffsetReset
+		*out = new(OffsetResetStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConsumerGroupStatus.
+func (in *KafkaConsumerGroupStatus) DeepCopy() *KafkaConsumerGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConsumerGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaTopic) DeepCopyInto(out *KafkaTopic) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopic.
@@ -123,6 +441,32 @@ func (in *KafkaTopicSpec) DeepCopy() *KafkaTopicSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaTopicStatus) DeepCopyInto(out *KafkaTopicStatus) {
 	*out = *in
+	if in.LeaderDistribution != nil {
+		in, out := &in.LeaderDistribution, &out.LeaderDistribution
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]TopicCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Reassignment != nil {
+		in, out := &in.Reassignment, &out.Reassignment
+		*out = new(TopicReassignmentStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicStatus.
@@ -218,6 +562,26 @@ func (in *KafkaUserSpec) DeepCopyInto(out *KafkaUserSpec) {
 		*out = new(PKIBackendSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(UserQuotas)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GroupGrants != nil {
+		in, out := &in.GroupGrants, &out.GroupGrants
+		*out = make([]UserGroupGrant, len(*in))
+		copy(*out, *in)
+	}
+	if in.TransactionalIDGrants != nil {
+		in, out := &in.TransactionalIDGrants, &out.TransactionalIDGrants
+		*out = make([]UserTransactionalIDGrant, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterOperations != nil {
+		in, out := &in.ClusterOperations, &out.ClusterOperations
+		*out = make([]KafkaClusterOperation, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaUserSpec.
@@ -238,6 +602,11 @@ func (in *KafkaUserStatus) DeepCopyInto(out *KafkaUserStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(UserQuotas)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaUserStatus.
@@ -250,6 +619,21 @@ func (in *KafkaUserStatus) DeepCopy() *KafkaUserStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OffsetResetStatus) DeepCopyInto(out *OffsetResetStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OffsetResetStatus.
+func (in *OffsetResetStatus) DeepCopy() *OffsetResetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OffsetResetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKIBackendSpec) DeepCopyInto(out *PKIBackendSpec) {
 	*out = *in
@@ -270,6 +654,97 @@ func (in *PKIBackendSpec) DeepCopy() *PKIBackendSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionOffset) DeepCopyInto(out *PartitionOffset) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionOffset.
+func (in *PartitionOffset) DeepCopy() *PartitionOffset {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionOffset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicCondition) DeepCopyInto(out *TopicCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicCondition.
+func (in *TopicCondition) DeepCopy() *TopicCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicReassignmentStatus) DeepCopyInto(out *TopicReassignmentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicReassignmentStatus.
+func (in *TopicReassignmentStatus) DeepCopy() *TopicReassignmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicReassignmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserGroupGrant) DeepCopyInto(out *UserGroupGrant) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGroupGrant.
+func (in *UserGroupGrant) DeepCopy() *UserGroupGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(UserGroupGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserQuotas) DeepCopyInto(out *UserQuotas) {
+	*out = *in
+	if in.ProducerByteRate != nil {
+		in, out := &in.ProducerByteRate, &out.ProducerByteRate
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ConsumerByteRate != nil {
+		in, out := &in.ConsumerByteRate, &out.ConsumerByteRate
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestPercentage != nil {
+		in, out := &in.RequestPercentage, &out.RequestPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserQuotas.
+func (in *UserQuotas) DeepCopy() *UserQuotas {
+	if in == nil {
+		return nil
+	}
+	out := new(UserQuotas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserTopicGrant) DeepCopyInto(out *UserTopicGrant) {
 	*out = *in
@@ -284,3 +759,18 @@ func (in *UserTopicGrant) DeepCopy() *UserTopicGrant {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserTransactionalIDGrant) DeepCopyInto(out *UserTransactionalIDGrant) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserTransactionalIDGrant.
+func (in *UserTransactionalIDGrant) DeepCopy() *UserTransactionalIDGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(UserTransactionalIDGrant)
+	in.DeepCopyInto(out)
+	return out
+}
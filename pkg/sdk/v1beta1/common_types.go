@@ -29,6 +29,9 @@ type CruiseControlUserTaskState string
 // ClusterState holds info about the cluster state
 type ClusterState string
 
+// ClusterConditionType defines the type of a KafkaCluster condition
+type ClusterConditionType string
+
 // ConfigurationState holds info about the configuration state
 type ConfigurationState string
 
@@ -38,9 +41,31 @@ type PerBrokerConfigurationState string
 // PKIBackend represents an interface implementing the PKIManager
 type PKIBackend string
 
+// DeletionPolicy defines what happens to the topics and users on the Kafka cluster when their custom resource is deleted
+// +kubebuilder:validation:Enum={"Delete","Retain","Orphan"}
+type DeletionPolicy string
+
 // CruiseControlVolumeState holds information about the state of volume rebalance
 type CruiseControlVolumeState string
 
+// ProcessRole is a KRaft process role of a broker
+// +kubebuilder:validation:Enum={"broker","controller"}
+type ProcessRole string
+
+// KafkaVersionUpgradePhase holds info about the phase of the Kafka version upgrade
+type KafkaVersionUpgradePhase string
+
+// MaintenanceTask is a Cruise Control task run in the maintenance windows
+// +kubebuilder:validation:Enum={"Rebalance","RebalanceDisks","PreferredLeaderElection"}
+type MaintenanceTask string
+
+// MaintenanceRunState holds info about the state of a task started in a maintenance window
+type MaintenanceRunState string
+
+// PausableSubsystem is a part of the reconciliation of a cluster that can be paused on its own
+// +kubebuilder:validation:Enum={"RollingUpgrade","CruiseControlTask","AlertManager"}
+type PausableSubsystem string
+
 func (r CruiseControlState) IsUpscale() bool {
 	return r == GracefulUpscaleRequired || r == GracefulUpscaleSucceeded || r == GracefulUpscaleRunning
 }
@@ -78,6 +103,68 @@ const (
 	PKIBackendProvided PKIBackend = "pki-backend-provided"
 )
 
+const (
+	// DeletionPolicyDelete removes the topics and the ACLs, quotas and credentials of the users from the cluster
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the topics and the ACLs, quotas and SCRAM credentials of the users on the cluster
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan leaves the cluster and the PKI untouched
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+
+	// SubsystemRollingUpgrade covers the restarts of the broker pods to apply a changed spec or configuration
+	SubsystemRollingUpgrade PausableSubsystem = "RollingUpgrade"
+	// SubsystemCruiseControlTask covers starting and tracking the Cruise Control tasks of the brokers
+	SubsystemCruiseControlTask PausableSubsystem = "CruiseControlTask"
+	// SubsystemAlertManager covers running the commands of the alerts received from Prometheus
+	SubsystemAlertManager PausableSubsystem = "AlertManager"
+)
+
+const (
+	// MaintenanceTaskRebalance moves replicas between the brokers to even out their disk usage
+	MaintenanceTaskRebalance MaintenanceTask = "Rebalance"
+	// MaintenanceTaskRebalanceDisks moves replicas between the volumes of the brokers
+	MaintenanceTaskRebalanceDisks MaintenanceTask = "RebalanceDisks"
+	// MaintenanceTaskPreferredLeaderElection moves the leadership of the partitions back to their preferred leaders
+	MaintenanceTaskPreferredLeaderElection MaintenanceTask = "PreferredLeaderElection"
+
+	// MaintenanceRunRunning states that the task is running in Cruise Control
+	MaintenanceRunRunning MaintenanceRunState = "Running"
+	// MaintenanceRunCompleted states that the task completed successfully
+	MaintenanceRunCompleted MaintenanceRunState = "Completed"
+	// MaintenanceRunFailed states that the task failed or was lost by Cruise Control
+	MaintenanceRunFailed MaintenanceRunState = "Failed"
+)
+
+const (
+	// ScalingApprovalAnnotation set on the KafkaCluster to the id of a graceful upscale or downscale proposal
+	// approves its execution
+	ScalingApprovalAnnotation = "kafka.banzaicloud.io/approved-scaling-proposal"
+)
+
+const (
+	// ProcessRoleBroker serves the clients and stores the partitions
+	ProcessRoleBroker ProcessRole = "broker"
+	// ProcessRoleController takes part in the KRaft quorum storing the cluster metadata
+	ProcessRoleController ProcessRole = "controller"
+)
+
+const (
+	// ListenerTypePlaintext is an unauthenticated, unencrypted listener
+	ListenerTypePlaintext = "plaintext"
+	// ListenerTypeSSL is a TLS listener authenticating clients with certificates
+	ListenerTypeSSL = "ssl"
+	// ListenerTypeSASLPlaintext is an unencrypted listener authenticating clients with SCRAM
+	ListenerTypeSASLPlaintext = "sasl_plaintext"
+	// ListenerTypeSASLSSL is a TLS listener authenticating clients with SCRAM
+	ListenerTypeSASLSSL = "sasl_ssl"
+	// SCRAMMechanism is the SASL mechanism enabled on the sasl_plaintext and sasl_ssl listeners
+	SCRAMMechanism = "SCRAM-SHA-512"
+	// SASLUsernameKey is where the SCRAM user name is stored in the admin and user secrets
+	SASLUsernameKey = "username"
+	// SASLPasswordKey is where the SCRAM password is stored in the admin and user secrets
+	SASLPasswordKey = "password"
+)
+
 // GracefulActionState holds information about GracefulAction State
 type GracefulActionState struct {
 	// ErrorMessage holds the information what happened with CC
@@ -90,6 +177,27 @@ type GracefulActionState struct {
 	CruiseControlState CruiseControlState `json:"cruiseControlState"`
 	// VolumeStates holds the information about the CC disk rebalance states and tasks
 	VolumeStates map[string]VolumeState `json:"volumeStates,omitempty"`
+	// Proposal holds the dry-run proposal of the upscale or downscale waiting for approval
+	// +optional
+	Proposal *GracefulActionProposal `json:"proposal,omitempty"`
+}
+
+// GracefulActionProposal holds the summary of the dry-run proposal of a graceful upscale or downscale
+type GracefulActionProposal struct {
+	// ID is the id of the dry-run CC task, the annotation approving the proposal has to hold it
+	ID string `json:"id"`
+	// BrokerIDs are the brokers the proposal was computed for
+	BrokerIDs []string `json:"brokerIds"`
+	// Computed is false while CC is still computing the proposal
+	Computed bool `json:"computed"`
+	// PartitionsMoved is the number of partition replicas moved between the brokers
+	PartitionsMoved int32 `json:"partitionsMoved,omitempty"`
+	// LeadersMoved is the number of partition leaderships moved between the brokers
+	LeadersMoved int32 `json:"leadersMoved,omitempty"`
+	// DataToMoveMB is the amount of data moved between the brokers
+	DataToMoveMB int64 `json:"dataToMoveMB,omitempty"`
+	// EstimatedDuration is how long moving the data takes at the estimated replication rate
+	EstimatedDuration string `json:"estimatedDuration,omitempty"`
 }
 
 type VolumeState struct {
@@ -113,6 +221,18 @@ type BrokerState struct {
 	ConfigurationState ConfigurationState `json:"configurationState"`
 	// PerBrokerConfigurationState holds info about the per-broker (dynamically updatable) config
 	PerBrokerConfigurationState PerBrokerConfigurationState `json:"perBrokerConfigurationState"`
+	// LeadershipState holds info about the partition leadership moved away from the broker for its restart
+	// +optional
+	LeadershipState LeadershipState `json:"leadershipState,omitempty"`
+}
+
+// LeadershipState holds the partitions whose leadership was moved away from a broker before restarting it
+type LeadershipState struct {
+	// DemotedPartitions are the partitions the broker is the preferred leader of, by topic, the broker is
+	// moved to the end of their replicas until it is back in sync
+	DemotedPartitions map[string][]int32 `json:"demotedPartitions,omitempty"`
+	// CruiseControlTaskId is the demotion of the broker moving the leadership away from it
+	CruiseControlTaskId string `json:"cruiseControlTaskId,omitempty"`
 }
 
 const (
@@ -167,6 +287,31 @@ const (
 	// KafkaClusterRunning states that the cluster is in running state
 	KafkaClusterRunning ClusterState = "ClusterRunning"
 
+	// KafkaVersionUpgradeRollingBinaries states that the brokers are restarted with the new Kafka version while
+	// the protocol versions of the old one are kept
+	KafkaVersionUpgradeRollingBinaries KafkaVersionUpgradePhase = "RollingBinaries"
+	// KafkaVersionUpgradeRollingProtocol states that the brokers are restarted with the protocol versions of the
+	// new Kafka version, after which the brokers can not be downgraded anymore
+	KafkaVersionUpgradeRollingProtocol KafkaVersionUpgradePhase = "RollingProtocolVersion"
+
+	// ClusterConditionReady is true when the last reconciliation of the cluster finished without errors
+	ClusterConditionReady ClusterConditionType = "Ready"
+	// ClusterConditionPKIReady is true when the certificates of the brokers and the operator are issued
+	ClusterConditionPKIReady ClusterConditionType = "PKIReady"
+	// ClusterConditionCruiseControlReady is true when Cruise Control and its metrics topic are up
+	ClusterConditionCruiseControlReady ClusterConditionType = "CruiseControlReady"
+	// ClusterConditionListenersReady is true when the external listeners have their addresses
+	ClusterConditionListenersReady ClusterConditionType = "ListenersReady"
+	// ClusterConditionRollingUpgradeInProgress is true while the brokers are restarted one by one
+	ClusterConditionRollingUpgradeInProgress ClusterConditionType = "RollingUpgradeInProgress"
+	// ClusterConditionConfigInSync is true when every broker runs with the generated configuration
+	ClusterConditionConfigInSync ClusterConditionType = "ConfigInSync"
+	// ClusterConditionDegraded is true when the reconciliation failed with an error that needs attention
+	// rather than just more time
+	ClusterConditionDegraded ClusterConditionType = "Degraded"
+	// ClusterConditionPaused is true when the reconciliation of the cluster or some of its subsystems is paused
+	ClusterConditionPaused ClusterConditionType = "Paused"
+
 	// ConfigInSync states that the generated brokerConfig is in sync with the Broker
 	ConfigInSync ConfigurationState = "ConfigInSync"
 	// ConfigOutOfSync states that the generated brokerConfig is out of sync with the Broker
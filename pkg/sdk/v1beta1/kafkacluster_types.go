@@ -39,7 +39,9 @@ type KafkaClusterSpec struct {
 	ListenersConfig        ListenersConfig `json:"listenersConfig"`
 	// ZKAddresses specifies the ZooKeeper connection string
 	// in the form hostname:port where host and port are the host and port of a ZooKeeper server.
-	ZKAddresses []string `json:"zkAddresses"`
+	// Required unless KRaftConfig is set
+	// +optional
+	ZKAddresses []string `json:"zkAddresses,omitempty"`
 	// ZKPath specifies the ZooKeeper chroot path as part
 	// of its ZooKeeper connection string which puts its data under some path in the global ZooKeeper namespace.
 	ZKPath               string                  `json:"zkPath,omitempty"`
@@ -65,6 +67,39 @@ type KafkaClusterSpec struct {
 	IstioIngressConfig      IstioIngressConfig  `json:"istioIngressConfig,omitempty"`
 	Envs                    []corev1.EnvVar     `json:"envs,omitempty"`
 	KubernetesClusterDomain string              `json:"kubernetesClusterDomain,omitempty"`
+	// TopicImportConfig turns on generating KafkaTopic resources for the topics of the cluster that have none
+	TopicImportConfig *TopicImportConfig `json:"topicImportConfig,omitempty"`
+	// DefaultDeletionPolicy is applied to the KafkaTopics and KafkaUsers of the cluster that set no deletion policy,
+	// Delete when omitted
+	DefaultDeletionPolicy DeletionPolicy `json:"defaultDeletionPolicy,omitempty"`
+	// TopicPolicy is enforced on the KafkaTopics of the cluster at admission
+	TopicPolicy *TopicPolicy `json:"topicPolicy,omitempty"`
+	// Paused stops the operator from changing the cluster, its KafkaTopics, KafkaUsers and KafkaConsumerGroups
+	// until it is set back to false, the cluster can still be deleted
+	Paused bool `json:"paused,omitempty"`
+	// PausedSubsystems stops only the given parts of the reconciliation of the cluster
+	PausedSubsystems []PausableSubsystem `json:"pausedSubsystems,omitempty"`
+	// KafkaVersion is the Kafka version of the ClusterImage, when it changes the operator first restarts the
+	// brokers with the inter.broker.protocol.version and log.message.format.version of the previous version,
+	// then with the ones of the new version. On a running cluster it has to be declared at the version the
+	// brokers run before it is changed together with the image
+	// +kubebuilder:validation:Pattern=^[0-9]+(\.[0-9]+){1,3}$
+	// +optional
+	KafkaVersion string `json:"kafkaVersion,omitempty"`
+	// KRaftConfig runs the cluster without ZooKeeper, the brokers with the controller role form the quorum
+	// storing the cluster metadata. It can only be set when the cluster is created. Brokers with the controller
+	// role can be added to or removed from the quorum one at a time, the roles of existing brokers can not be changed.
+	// +optional
+	KRaftConfig *KRaftConfig `json:"kRaftConfig,omitempty"`
+}
+
+// KRaftConfig defines the KRaft mode of the cluster
+type KRaftConfig struct {
+	// ControllerListenerPort is the container port the controllers listen on, 29093 when omitted
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	ControllerListenerPort int32 `json:"controllerListenerPort,omitempty"`
 }
 
 // KafkaClusterStatus defines the observed state of KafkaCluster
@@ -75,17 +110,117 @@ type KafkaClusterStatus struct {
 	RollingUpgrade           RollingUpgradeStatus     `json:"rollingUpgradeStatus,omitempty"`
 	AlertCount               int                      `json:"alertCount"`
 	ListenerStatuses         ListenerStatuses         `json:"listenerStatuses,omitempty"`
+	Conditions               []ClusterCondition       `json:"conditions,omitempty"`
+	KafkaVersion             KafkaVersionStatus       `json:"kafkaVersion,omitempty"`
+	KRaft                    KRaftStatus              `json:"kRaft,omitempty"`
+	Maintenance              MaintenanceStatus        `json:"maintenance,omitempty"`
+	TopicImport              TopicImportStatus        `json:"topicImport,omitempty"`
+}
+
+// TopicImportStatus holds the topics the topic import has to leave alone
+type TopicImportStatus struct {
+	// ReleasedTopics are the topics kept on the cluster when their KafkaTopic was deleted, they are not imported
+	// again while they exist
+	ReleasedTopics []string `json:"releasedTopics,omitempty"`
+}
+
+// MaintenanceStatus holds the tasks started in the maintenance windows
+type MaintenanceStatus struct {
+	// Runs are the latest tasks started in the maintenance windows, oldest first
+	Runs []MaintenanceRun `json:"runs,omitempty"`
+}
+
+// MaintenanceRun is a Cruise Control task started in a maintenance window
+type MaintenanceRun struct {
+	// Window is the name of the maintenance window
+	Window string `json:"window"`
+	// WindowStart is when the occurrence of the window the task was started in began
+	WindowStart metav1.Time `json:"windowStart"`
+	// Task is the task started in the window
+	Task MaintenanceTask `json:"task"`
+	// Reason is the imbalance the task was started for
+	Reason              string `json:"reason,omitempty"`
+	CruiseControlTaskId string `json:"cruiseControlTaskId,omitempty"`
+	// State is the state of the task, Running, Completed or Failed
+	State      MaintenanceRunState `json:"state"`
+	StartTime  metav1.Time         `json:"startTime"`
+	FinishTime *metav1.Time        `json:"finishTime,omitempty"`
+}
+
+// KRaftStatus holds the state of a cluster running in KRaft mode
+type KRaftStatus struct {
+	// ClusterID is the id the storage of the brokers is formatted with
+	ClusterID string `json:"clusterID,omitempty"`
+	// Voters are the ids of the controllers the quorum runs with. While a controller is added, the brokers learn
+	// about it before the voters, and while one is removed, the brokers forget it only after the voters did.
+	Voters []int32 `json:"voters,omitempty"`
+}
+
+// ClusterCondition describes an aspect of the state of a KafkaCluster
+type ClusterCondition struct {
+	Type   ClusterConditionType   `json:"type"`
+	Status metav1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the generation of the KafkaCluster the condition was set for
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
 }
 
 // RollingUpgradeStatus defines status of rolling upgrade
 type RollingUpgradeStatus struct {
 	LastSuccess string `json:"lastSuccess"`
 	ErrorCount  int    `json:"errorCount"`
+	// LastBrokerRestart is the time in RFC3339 format the last broker pod of the running rolling upgrade was deleted
+	// +optional
+	LastBrokerRestart string `json:"lastBrokerRestart,omitempty"`
+	// GateReason is why the rolling upgrade is held back before restarting the next broker
+	// +optional
+	GateReason string `json:"gateReason,omitempty"`
+}
+
+// KafkaVersionStatus describes the Kafka version of the brokers and the progress of its upgrade
+type KafkaVersionStatus struct {
+	// Version is the Kafka version the brokers were last upgraded to
+	Version string `json:"version,omitempty"`
+	// ProtocolVersion is the inter.broker.protocol.version and log.message.format.version of the brokers
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+	// TargetVersion is the Kafka version the brokers are being upgraded to
+	TargetVersion string `json:"targetVersion,omitempty"`
+	// UpgradePhase is empty when no upgrade is in progress
+	UpgradePhase KafkaVersionUpgradePhase `json:"upgradePhase,omitempty"`
 }
 
 // RollingUpgradeConfig defines the desired config of the RollingUpgrade
 type RollingUpgradeConfig struct {
 	FailureThreshold int `json:"failureThreshold"`
+	// If set to true, the next broker is not restarted while a partition has fewer in-sync replicas
+	// than the min.insync.replicas of its topic
+	// +optional
+	WaitForUnderMinISR bool `json:"waitForUnderMinISR,omitempty"`
+	// Minimum time to wait between the restarts of two brokers
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PauseBetweenBrokersSeconds int `json:"pauseBetweenBrokersSeconds,omitempty"`
+	// Maximum time a restarted broker has to come back healthy, the cluster is reported degraded
+	// when it is exceeded. Zero means no limit
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BrokerTimeoutSeconds int `json:"brokerTimeoutSeconds,omitempty"`
+	// Number of brokers restarted together. Only brokers of the same rack (broker.rack) are restarted together,
+	// as rack aware replica placement never puts two replicas of a partition into the same rack
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ConcurrentBrokerRestartsPerRack int `json:"concurrentBrokerRestartsPerRack,omitempty"`
+	// If set to true, the partition leadership is moved away from a broker before its restart and given back
+	// once it is in sync again. The leader elections are run by Cruise Control
+	// +optional
+	MoveLeadershipBeforeRestart bool `json:"moveLeadershipBeforeRestart,omitempty"`
+	// If set to true, the active controller is restarted only after every other broker got its new
+	// configuration, and no broker is restarted while the controller cannot be determined. Not supported
+	// in KRaft mode
+	// +optional
+	ControllerLast bool `json:"controllerLast,omitempty"`
 }
 
 // DisruptionBudget defines the configuration for PodDisruptionBudget
@@ -140,6 +275,10 @@ type BrokerConfig struct {
 	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
 	// SecurityContext allows to set security context for the kafka container
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+	// Roles are the KRaft process roles of the broker, the roles of its brokerConfigGroup are added to them.
+	// Only used in KRaft mode, broker when omitted
+	// +optional
+	Roles []ProcessRole `json:"roles,omitempty"`
 }
 
 type NetworkConfig struct {
@@ -179,12 +318,99 @@ type CruiseControlConfig struct {
 	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
 	// SecurityContext allows to set security context for the CruiseControl container
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+	// ScalingApproval makes the graceful upscales and downscales wait for the approval of their dry-run proposal
+	// +optional
+	ScalingApproval *ScalingApprovalConfig `json:"scalingApproval,omitempty"`
+	// MaintenanceWindows are the recurring periods the operator may rebalance the cluster in
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// NodeCapacity derives the capacity of the brokers missing from their config from the nodes their pods run on
+	// +optional
+	NodeCapacity *NodeCapacityConfig `json:"nodeCapacity,omitempty"`
+}
+
+// NodeCapacityConfig describes which capacities of the brokers Cruise Control gets from the nodes of the brokers
+type NodeCapacityConfig struct {
+	// AllocatableCPU sets the CPU capacity of the brokers without CPU limits and requests to the allocatable CPU of their node
+	// +optional
+	AllocatableCPU bool `json:"allocatableCPU,omitempty"`
+	// InstanceTypeNetworkConfigs are the network capacities of the brokers without NetworkConfig by the
+	// node.kubernetes.io/instance-type label of their node
+	// +optional
+	InstanceTypeNetworkConfigs map[string]NetworkConfig `json:"instanceTypeNetworkConfigs,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period the operator may run the rebalancing tasks of Cruise Control in, the tasks
+// are started when the imbalance of the cluster reported by Cruise Control exceeds the thresholds
+type MaintenanceWindow struct {
+	// Name identifies the window in the runs recorded in the status
+	Name string `json:"name"`
+	// Schedule is the cron expression of the start of the window in UTC, e.g. "0 2 * * SAT", the time zone can be
+	// set with a CRON_TZ= prefix
+	Schedule string `json:"schedule"`
+	// DurationMinutes is how long the window lasts after its start, the tasks started in the window run to completion
+	// +kubebuilder:validation:Minimum=1
+	DurationMinutes int32 `json:"durationMinutes"`
+	// Tasks are the tasks the operator may start in the window, each at most once per occurrence
+	// +kubebuilder:validation:MinItems=1
+	Tasks []MaintenanceTask `json:"tasks"`
+	// +optional
+	Thresholds ImbalanceThresholds `json:"thresholds,omitempty"`
+}
+
+// ImbalanceThresholds defines when the cluster is imbalanced enough for the maintenance tasks to run
+type ImbalanceThresholds struct {
+	// DiskUsageDeviationPercent is how many percentage points the disk usage of a broker may deviate from the
+	// average of the brokers before the cluster is rebalanced, defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DiskUsageDeviationPercent int32 `json:"diskUsageDeviationPercent,omitempty"`
+	// VolumeUsageDeviationPercent is how many percentage points the disk usage of the volumes of a broker may differ
+	// before its disks are rebalanced, defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	VolumeUsageDeviationPercent int32 `json:"volumeUsageDeviationPercent,omitempty"`
+	// LeaderCountDeviationPercent is how many percent the number of partitions led by a broker may deviate from the
+	// average of the brokers before the preferred leaders are elected, defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	LeaderCountDeviationPercent int32 `json:"leaderCountDeviationPercent,omitempty"`
+}
+
+// ScalingApprovalConfig holds the configuration of the approval of the graceful upscales and downscales
+type ScalingApprovalConfig struct {
+	// EstimatedReplicationRateMBps is the rate the data is expected to be moved with, used to estimate
+	// the duration of the proposals, defaults to 50
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	EstimatedReplicationRateMBps int32 `json:"estimatedReplicationRateMBps,omitempty"`
 }
 
 // CruiseControlTaskSpec specifies the configuration of the CC Tasks
 type CruiseControlTaskSpec struct {
 	// RetryDurationMinutes describes the amount of time the Operator waits for the task
 	RetryDurationMinutes int `json:"RetryDurationMinutes"`
+	// ReplicationThrottle is the upper bound in bytes per second of the bandwidth the replicas are moved with
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReplicationThrottle *int64 `json:"replicationThrottle,omitempty"`
+	// ConcurrentPartitionMovementsPerBroker is the number of partition replicas moved to or from a broker at once
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentPartitionMovementsPerBroker *int32 `json:"concurrentPartitionMovementsPerBroker,omitempty"`
+	// ConcurrentLeaderMovements is the number of partition leaderships moved at once
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentLeaderMovements *int32 `json:"concurrentLeaderMovements,omitempty"`
+	// ExcludedTopics is a regular expression matching the topics whose replicas are not moved
+	// +optional
+	ExcludedTopics string `json:"excludedTopics,omitempty"`
+	// Goals are the goals the replicas are moved for instead of the default goals of Cruise Control
+	// +optional
+	Goals []string `json:"goals,omitempty"`
+	// SkipHardGoalCheck allows the Goals to leave out hard goals of Cruise Control
+	// +optional
+	SkipHardGoalCheck bool `json:"skipHardGoalCheck,omitempty"`
 }
 
 // TopicConfig holds info for topic configuration regarding partitions and replicationFactor
@@ -251,6 +477,7 @@ type ListenersConfig struct {
 	ExternalListeners  []ExternalListenerConfig `json:"externalListeners,omitempty"`
 	InternalListeners  []InternalListenerConfig `json:"internalListeners"`
 	SSLSecrets         *SSLSecrets              `json:"sslSecrets,omitempty"`
+	SASLConfig         *SASLConfig              `json:"saslConfig,omitempty"`
 	ServiceAnnotations map[string]string        `json:"serviceAnnotations,omitempty"`
 }
 
@@ -300,6 +527,14 @@ type SSLSecrets struct {
 	PKIBackend PKIBackend `json:"pkiBackend,omitempty"`
 }
 
+// SASLConfig defines the SCRAM configuration used by the sasl_plaintext and sasl_ssl listeners
+type SASLConfig struct {
+	// AdminSecretName is the name of the secret holding the "username" and "password" of the SCRAM super user.
+	// The brokers use it for inter-broker and cruise control metrics reporter traffic, and the operator
+	// uses it to manage the cluster when the inter-broker listener is a SASL listener.
+	AdminSecretName string `json:"adminSecretName"`
+}
+
 // TODO (tinyzimmer): The above are all optional now in one way or another.
 // Would be another good use-case for a pre-admission hook
 // E.g. TLSSecretName and JKSPasswordName are only required if Create is false
@@ -325,6 +560,34 @@ type AlertManagerConfig struct {
 	UpScaleLimit int `json:"upScaleLimit,omitempty"`
 }
 
+// TopicImportConfig defines which unmanaged topics get a generated KafkaTopic
+type TopicImportConfig struct {
+	// Namespace the generated KafkaTopics are created in, defaults to the namespace of the KafkaCluster
+	Namespace string `json:"namespace,omitempty"`
+	// ExcludedTopics are regular expressions matching the names of topics that are not imported,
+	// internal topics starting with two underscores are never imported
+	ExcludedTopics []string `json:"excludedTopics,omitempty"`
+	// DeletionPolicy of the generated KafkaTopics, Retain when omitted so deleting a generated KafkaTopic
+	// keeps the topic the operator did not create
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// TopicPolicy defines the constraints KafkaTopics have to satisfy. KafkaTopics created by the operator
+// for the cluster itself, like the Cruise Control metrics topic, are exempt.
+type TopicPolicy struct {
+	// NameRegex is a regular expression the names of new topics have to match
+	NameRegex string `json:"nameRegex,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MinPartitions int32 `json:"minPartitions,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MaxPartitions int32 `json:"maxPartitions,omitempty"`
+	// MinInSyncReplicas is the lowest min.insync.replicas a topic may override the broker default with
+	// +kubebuilder:validation:Minimum=0
+	MinInSyncReplicas int32 `json:"minInSyncReplicas,omitempty"`
+	// AllowedCleanupPolicies lists the cleanup.policy values topics may set
+	AllowedCleanupPolicies []string `json:"allowedCleanupPolicies,omitempty"`
+}
+
 // ExternalListenerConfig defines the external listener config for Kafka
 type ExternalListenerConfig struct {
 	CommonListenerSpec   `json:",inline"`
@@ -365,11 +628,25 @@ type InternalListenerConfig struct {
 
 // CommonListenerSpec defines the common building block for Listener type
 type CommonListenerSpec struct {
+	// Type is the security protocol of the listener: plaintext, ssl, sasl_plaintext or sasl_ssl.
+	// The sasl_* listeners authenticate clients with SCRAM-SHA-512 and require listenersConfig.saslConfig.
 	Type          string `json:"type"`
 	Name          string `json:"name"`
 	ContainerPort int32  `json:"containerPort"`
 }
 
+// IsSASL returns true if the listener authenticates its clients over SASL
+func (c CommonListenerSpec) IsSASL() bool {
+	listenerType := strings.ToLower(c.Type)
+	return listenerType == ListenerTypeSASLPlaintext || listenerType == ListenerTypeSASLSSL
+}
+
+// IsSSL returns true if the listener traffic is encrypted with TLS
+func (c CommonListenerSpec) IsSSL() bool {
+	listenerType := strings.ToLower(c.Type)
+	return listenerType == ListenerTypeSSL || listenerType == ListenerTypeSASLSSL
+}
+
 // ListenerStatuses holds information about the statuses of the configured listeners.
 // The internal and external listeners are stored in separate maps, and each listener can be looked up by name.
 type ListenerStatuses struct {
@@ -393,6 +670,8 @@ type ListenerStatus struct {
 // +kubebuilder:printcolumn:JSONPath=".status.rollingUpgradeStatus.lastSuccess",name="Last successful upgrade",type="string"
 // +kubebuilder:printcolumn:JSONPath=".status.rollingUpgradeStatus.errorCount",name="Upgrade error count",type="string"
 // +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name="Age",type="date"
+// +kubebuilder:webhook:failurePolicy="fail",name="kafkaclusters.kafka.banzaicloud.io",path="/validate",mutating=false,resources={"kafkaclusters"},verbs={"create","update"},groups={"kafka.banzaicloud.io"},versions={"v1beta1"}
+// +kubebuilder:webhook:failurePolicy="fail",name="mkafkaclusters.kafka.banzaicloud.io",path="/mutate",mutating=true,resources={"kafkaclusters"},verbs={"create","update"},groups={"kafka.banzaicloud.io"},versions={"v1beta1"}
 
 // KafkaCluster is the Schema for the kafkaclusters API
 type KafkaCluster struct {
@@ -449,6 +728,19 @@ func (iIConfig *IstioIngressConfig) GetReplicas() int32 {
 	return iIConfig.Replicas
 }
 
+// IsPaused returns true if the whole reconciliation or the given subsystem of the cluster is paused
+func (kSpec *KafkaClusterSpec) IsPaused(subsystem PausableSubsystem) bool {
+	if kSpec.Paused {
+		return true
+	}
+	for _, paused := range kSpec.PausedSubsystems {
+		if paused == subsystem {
+			return true
+		}
+	}
+	return false
+}
+
 // GetIngressController returns the default Envoy ingress controller if not specified otherwise
 func (kSpec *KafkaClusterSpec) GetIngressController() string {
 	if kSpec.IngressController == "" {
@@ -465,6 +757,19 @@ func (kSpec *KafkaClusterSpec) GetKubernetesClusterDomain() string {
 	return kSpec.KubernetesClusterDomain
 }
 
+// IsKRaft returns true if the cluster runs without ZooKeeper
+func (kSpec *KafkaClusterSpec) IsKRaft() bool {
+	return kSpec.KRaftConfig != nil
+}
+
+// GetControllerListenerPort returns the default 29093 controller listener port if not specified otherwise
+func (kConfig *KRaftConfig) GetControllerListenerPort() int32 {
+	if kConfig.ControllerListenerPort == 0 {
+		return 29093
+	}
+	return kConfig.ControllerListenerPort
+}
+
 // GetZkPath returns the default "/" ZkPath if not specified otherwise
 func (kSpec *KafkaClusterSpec) GetZkPath() string {
 	const prefix = "/"
@@ -477,6 +782,14 @@ func (kSpec *KafkaClusterSpec) GetZkPath() string {
 	}
 }
 
+// GetDeletionPolicy returns the deletion policy of the KafkaTopics generated for the unmanaged topics
+func (tConfig *TopicImportConfig) GetDeletionPolicy() DeletionPolicy {
+	if tConfig.DeletionPolicy != "" {
+		return tConfig.DeletionPolicy
+	}
+	return DeletionPolicyRetain
+}
+
 // GetClusterImage returns the default container image for Kafka Cluster
 func (kSpec *KafkaClusterSpec) GetClusterImage() string {
 	if kSpec.ClusterImage != "" {
@@ -492,6 +805,43 @@ func (cTaskSpec *CruiseControlTaskSpec) GetDurationMinutes() float64 {
 	return float64(cTaskSpec.RetryDurationMinutes)
 }
 
+// IsScalingApprovalRequired returns true if the graceful upscales and downscales wait for an approval
+func (cConfig *CruiseControlConfig) IsScalingApprovalRequired() bool {
+	return cConfig.ScalingApproval != nil
+}
+
+// GetDiskUsageDeviationPercent returns the disk usage deviation of a broker the cluster is rebalanced above
+func (t *ImbalanceThresholds) GetDiskUsageDeviationPercent() float64 {
+	if t.DiskUsageDeviationPercent == 0 {
+		return 10
+	}
+	return float64(t.DiskUsageDeviationPercent)
+}
+
+// GetVolumeUsageDeviationPercent returns the disk usage difference of the volumes of a broker its disks are rebalanced above
+func (t *ImbalanceThresholds) GetVolumeUsageDeviationPercent() float64 {
+	if t.VolumeUsageDeviationPercent == 0 {
+		return 10
+	}
+	return float64(t.VolumeUsageDeviationPercent)
+}
+
+// GetLeaderCountDeviationPercent returns the leader count deviation of a broker the preferred leaders are elected above
+func (t *ImbalanceThresholds) GetLeaderCountDeviationPercent() float64 {
+	if t.LeaderCountDeviationPercent == 0 {
+		return 10
+	}
+	return float64(t.LeaderCountDeviationPercent)
+}
+
+// GetEstimatedReplicationRateMBps returns the rate the duration of the scaling proposals is estimated with
+func (sConfig *ScalingApprovalConfig) GetEstimatedReplicationRateMBps() int32 {
+	if sConfig == nil || sConfig.EstimatedReplicationRateMBps == 0 {
+		return 50
+	}
+	return sConfig.EstimatedReplicationRateMBps
+}
+
 //GetLoadBalancerSourceRanges returns LoadBalancerSourceRanges to use for Envoy generated LoadBalancer
 func (eConfig *EnvoyConfig) GetLoadBalancerSourceRanges() []string {
 	return eConfig.LoadBalancerSourceRanges
@@ -640,6 +990,19 @@ func (bConfig *BrokerConfig) GetResources() *corev1.ResourceRequirements {
 	}
 }
 
+// HasRole returns true if the broker has the given KRaft process role, the brokers without roles have the broker role
+func (bConfig *BrokerConfig) HasRole(role ProcessRole) bool {
+	if bConfig == nil || len(bConfig.Roles) == 0 {
+		return role == ProcessRoleBroker
+	}
+	for _, r := range bConfig.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // GetKafkaHeapOpts returns the broker specific Heap settings
 func (bConfig *BrokerConfig) GetKafkaHeapOpts() string {
 	if bConfig.KafkaHeapOpts != "" {
@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 // Copyright © 2019 Banzai Cloud
@@ -128,6 +129,11 @@ func (in *BrokerConfig) DeepCopyInto(out *BrokerConfig) {
 		*out = new(v1.SecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]ProcessRole, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerConfig.
@@ -144,6 +150,7 @@ func (in *BrokerConfig) DeepCopy() *BrokerConfig {
 func (in *BrokerState) DeepCopyInto(out *BrokerState) {
 	*out = *in
 	in.GracefulActionState.DeepCopyInto(&out.GracefulActionState)
+	in.LeadershipState.DeepCopyInto(&out.LeadershipState)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerState.
@@ -156,6 +163,22 @@ func (in *BrokerState) DeepCopy() *BrokerState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCondition.
+func (in *ClusterCondition) DeepCopy() *ClusterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonListenerSpec) DeepCopyInto(out *CommonListenerSpec) {
 	*out = *in
@@ -174,7 +197,7 @@ func (in *CommonListenerSpec) DeepCopy() *CommonListenerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CruiseControlConfig) DeepCopyInto(out *CruiseControlConfig) {
 	*out = *in
-	out.CruiseControlTaskSpec = in.CruiseControlTaskSpec
+	in.CruiseControlTaskSpec.DeepCopyInto(&out.CruiseControlTaskSpec)
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = new(v1.ResourceRequirements)
@@ -242,6 +265,23 @@ func (in *CruiseControlConfig) DeepCopyInto(out *CruiseControlConfig) {
 		*out = new(v1.SecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ScalingApproval != nil {
+		in, out := &in.ScalingApproval, &out.ScalingApproval
+		*out = new(ScalingApprovalConfig)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeCapacity != nil {
+		in, out := &in.NodeCapacity, &out.NodeCapacity
+		*out = new(NodeCapacityConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlConfig.
@@ -257,6 +297,26 @@ func (in *CruiseControlConfig) DeepCopy() *CruiseControlConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CruiseControlTaskSpec) DeepCopyInto(out *CruiseControlTaskSpec) {
 	*out = *in
+	if in.ReplicationThrottle != nil {
+		in, out := &in.ReplicationThrottle, &out.ReplicationThrottle
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ConcurrentPartitionMovementsPerBroker != nil {
+		in, out := &in.ConcurrentPartitionMovementsPerBroker, &out.ConcurrentPartitionMovementsPerBroker
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConcurrentLeaderMovements != nil {
+		in, out := &in.ConcurrentLeaderMovements, &out.ConcurrentLeaderMovements
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Goals != nil {
+		in, out := &in.Goals, &out.Goals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlTaskSpec.
@@ -363,6 +423,26 @@ func (in *ExternalListenerConfig) DeepCopy() *ExternalListenerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GracefulActionProposal) DeepCopyInto(out *GracefulActionProposal) {
+	*out = *in
+	if in.BrokerIDs != nil {
+		in, out := &in.BrokerIDs, &out.BrokerIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GracefulActionProposal.
+func (in *GracefulActionProposal) DeepCopy() *GracefulActionProposal {
+	if in == nil {
+		return nil
+	}
+	out := new(GracefulActionProposal)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GracefulActionState) DeepCopyInto(out *GracefulActionState) {
 	*out = *in
@@ -373,6 +453,11 @@ func (in *GracefulActionState) DeepCopyInto(out *GracefulActionState) {
 			(*out)[key] = val
 		}
 	}
+	if in.Proposal != nil {
+		in, out := &in.Proposal, &out.Proposal
+		*out = new(GracefulActionProposal)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GracefulActionState.
@@ -385,6 +470,21 @@ func (in *GracefulActionState) DeepCopy() *GracefulActionState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImbalanceThresholds) DeepCopyInto(out *ImbalanceThresholds) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImbalanceThresholds.
+func (in *ImbalanceThresholds) DeepCopy() *ImbalanceThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(ImbalanceThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InternalListenerConfig) DeepCopyInto(out *InternalListenerConfig) {
 	*out = *in
@@ -454,6 +554,41 @@ func (in *IstioIngressConfig) DeepCopy() *IstioIngressConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KRaftConfig) DeepCopyInto(out *KRaftConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KRaftConfig.
+func (in *KRaftConfig) DeepCopy() *KRaftConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KRaftConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KRaftStatus) DeepCopyInto(out *KRaftStatus) {
+	*out = *in
+	if in.Voters != nil {
+		in, out := &in.Voters, &out.Voters
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KRaftStatus.
+func (in *KRaftStatus) DeepCopy() *KRaftStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KRaftStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaCluster) DeepCopyInto(out *KafkaCluster) {
 	*out = *in
@@ -560,6 +695,26 @@ func (in *KafkaClusterSpec) DeepCopyInto(out *KafkaClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TopicImportConfig != nil {
+		in, out := &in.TopicImportConfig, &out.TopicImportConfig
+		*out = new(TopicImportConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopicPolicy != nil {
+		in, out := &in.TopicPolicy, &out.TopicPolicy
+		*out = new(TopicPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PausedSubsystems != nil {
+		in, out := &in.PausedSubsystems, &out.PausedSubsystems
+		*out = make([]PausableSubsystem, len(*in))
+		copy(*out, *in)
+	}
+	if in.KRaftConfig != nil {
+		in, out := &in.KRaftConfig, &out.KRaftConfig
+		*out = new(KRaftConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaClusterSpec.
@@ -584,6 +739,17 @@ func (in *KafkaClusterStatus) DeepCopyInto(out *KafkaClusterStatus) {
 	}
 	out.RollingUpgrade = in.RollingUpgrade
 	in.ListenerStatuses.DeepCopyInto(&out.ListenerStatuses)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ClusterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.KafkaVersion = in.KafkaVersion
+	in.KRaft.DeepCopyInto(&out.KRaft)
+	in.Maintenance.DeepCopyInto(&out.Maintenance)
+	in.TopicImport.DeepCopyInto(&out.TopicImport)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaClusterStatus.
@@ -596,6 +762,51 @@ func (in *KafkaClusterStatus) DeepCopy() *KafkaClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaVersionStatus) DeepCopyInto(out *KafkaVersionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaVersionStatus.
+func (in *KafkaVersionStatus) DeepCopy() *KafkaVersionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaVersionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeadershipState) DeepCopyInto(out *LeadershipState) {
+	*out = *in
+	if in.DemotedPartitions != nil {
+		in, out := &in.DemotedPartitions, &out.DemotedPartitions
+		*out = make(map[string][]int32, len(*in))
+		for key, val := range *in {
+			var outVal []int32
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]int32, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeadershipState.
+func (in *LeadershipState) DeepCopy() *LeadershipState {
+	if in == nil {
+		return nil
+	}
+	out := new(LeadershipState)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ListenerStatus) DeepCopyInto(out *ListenerStatus) {
 	*out = *in
@@ -695,6 +906,11 @@ func (in *ListenersConfig) DeepCopyInto(out *ListenersConfig) {
 		*out = new(SSLSecrets)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SASLConfig != nil {
+		in, out := &in.SASLConfig, &out.SASLConfig
+		*out = new(SASLConfig)
+		**out = **in
+	}
 	if in.ServiceAnnotations != nil {
 		in, out := &in.ServiceAnnotations, &out.ServiceAnnotations
 		*out = make(map[string]string, len(*in))
@@ -714,6 +930,70 @@ func (in *ListenersConfig) DeepCopy() *ListenersConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceRun) DeepCopyInto(out *MaintenanceRun) {
+	*out = *in
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceRun.
+func (in *MaintenanceRun) DeepCopy() *MaintenanceRun {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceStatus) DeepCopyInto(out *MaintenanceStatus) {
+	*out = *in
+	if in.Runs != nil {
+		in, out := &in.Runs, &out.Runs
+		*out = make([]MaintenanceRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceStatus.
+func (in *MaintenanceStatus) DeepCopy() *MaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]MaintenanceTask, len(*in))
+		copy(*out, *in)
+	}
+	out.Thresholds = in.Thresholds
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonitoringConfig) DeepCopyInto(out *MonitoringConfig) {
 	*out = *in
@@ -744,6 +1024,28 @@ func (in *NetworkConfig) DeepCopy() *NetworkConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCapacityConfig) DeepCopyInto(out *NodeCapacityConfig) {
+	*out = *in
+	if in.InstanceTypeNetworkConfigs != nil {
+		in, out := &in.InstanceTypeNetworkConfigs, &out.InstanceTypeNetworkConfigs
+		*out = make(map[string]NetworkConfig, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCapacityConfig.
+func (in *NodeCapacityConfig) DeepCopy() *NodeCapacityConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCapacityConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RackAwareness) DeepCopyInto(out *RackAwareness) {
 	*out = *in
@@ -794,6 +1096,21 @@ func (in *RollingUpgradeStatus) DeepCopy() *RollingUpgradeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SASLConfig) DeepCopyInto(out *SASLConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SASLConfig.
+func (in *SASLConfig) DeepCopy() *SASLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SASLConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSLSecrets) DeepCopyInto(out *SSLSecrets) {
 	*out = *in
@@ -814,6 +1131,21 @@ func (in *SSLSecrets) DeepCopy() *SSLSecrets {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingApprovalConfig) DeepCopyInto(out *ScalingApprovalConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingApprovalConfig.
+func (in *ScalingApprovalConfig) DeepCopy() *ScalingApprovalConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingApprovalConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 	*out = *in
@@ -849,6 +1181,66 @@ func (in *TopicConfig) DeepCopy() *TopicConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicImportConfig) DeepCopyInto(out *TopicImportConfig) {
+	*out = *in
+	if in.ExcludedTopics != nil {
+		in, out := &in.ExcludedTopics, &out.ExcludedTopics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicImportConfig.
+func (in *TopicImportConfig) DeepCopy() *TopicImportConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicImportConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicImportStatus) DeepCopyInto(out *TopicImportStatus) {
+	*out = *in
+	if in.ReleasedTopics != nil {
+		in, out := &in.ReleasedTopics, &out.ReleasedTopics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicImportStatus.
+func (in *TopicImportStatus) DeepCopy() *TopicImportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicImportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicPolicy) DeepCopyInto(out *TopicPolicy) {
+	*out = *in
+	if in.AllowedCleanupPolicies != nil {
+		in, out := &in.AllowedCleanupPolicies, &out.AllowedCleanupPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicPolicy.
+func (in *TopicPolicy) DeepCopy() *TopicPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultConfig) DeepCopyInto(out *VaultConfig) {
 	*out = *in
@@ -22,7 +22,12 @@ import (
 )
 
 func UseSSL(cluster *v1beta1.KafkaCluster) bool {
-	return cluster.Spec.ListenersConfig.InternalListeners[determineInternalListenerForInnerCom(cluster.Spec.ListenersConfig.InternalListeners)].Type != "plaintext"
+	return cluster.Spec.ListenersConfig.InternalListeners[determineInternalListenerForInnerCom(cluster.Spec.ListenersConfig.InternalListeners)].IsSSL()
+}
+
+// UseSASL returns true if the listener used for inner broker communication authenticates over SASL/SCRAM
+func UseSASL(cluster *v1beta1.KafkaCluster) bool {
+	return cluster.Spec.ListenersConfig.InternalListeners[determineInternalListenerForInnerCom(cluster.Spec.ListenersConfig.InternalListeners)].IsSASL()
 }
 
 func determineInternalListenerForInnerCom(internalListeners []v1beta1.InternalListenerConfig) int {
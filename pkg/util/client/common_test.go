@@ -65,3 +65,36 @@ func TestGenerateKafkaAddress(t *testing.T) {
 		t.Error("Expected kafka address:", expected, "Got:", generatedAllBroker)
 	}
 }
+
+func TestUseSSLAndSASL(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			ListenersConfig: v1beta1.ListenersConfig{
+				InternalListeners: []v1beta1.InternalListenerConfig{
+					{
+						CommonListenerSpec: v1beta1.CommonListenerSpec{Type: "plaintext"},
+					},
+					{
+						CommonListenerSpec:              v1beta1.CommonListenerSpec{Type: "sasl_ssl"},
+						UsedForInnerBrokerCommunication: true,
+					},
+				},
+			},
+		},
+	}
+
+	if !UseSSL(cluster) {
+		t.Error("Expected ssl for the inner broker listener")
+	}
+	if !UseSASL(cluster) {
+		t.Error("Expected sasl for the inner broker listener")
+	}
+
+	cluster.Spec.ListenersConfig.InternalListeners[1].Type = "sasl_plaintext"
+	if UseSSL(cluster) {
+		t.Error("Expected no ssl for the inner broker listener")
+	}
+	if !UseSASL(cluster) {
+		t.Error("Expected sasl for the inner broker listener")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cruisecontrol
+
+import (
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+// ParseMaintenanceSchedule parses the cron expression of a maintenance window, in UTC unless it sets its time zone
+func ParseMaintenanceSchedule(schedule string) (cron.Schedule, error) {
+	if !strings.HasPrefix(schedule, "CRON_TZ=") && !strings.HasPrefix(schedule, "TZ=") {
+		schedule = "CRON_TZ=UTC " + schedule
+	}
+	return cron.ParseStandard(schedule)
+}
+
+// MaintenanceWindowAt returns whether the window is open at the given time along with the start of its open
+// occurrence, and when the window closes or opens next
+func MaintenanceWindowAt(window v1beta1.MaintenanceWindow, now time.Time) (bool, time.Time, time.Time, error) {
+	schedule, err := ParseMaintenanceSchedule(window.Schedule)
+	if err != nil {
+		return false, time.Time{}, time.Time{}, err
+	}
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+	// the first start after the beginning of the duration is the one of the open occurrence, if any
+	start := schedule.Next(now.Add(-duration))
+	if start.After(now) {
+		return false, time.Time{}, start, nil
+	}
+	return true, start, start.Add(duration), nil
+}
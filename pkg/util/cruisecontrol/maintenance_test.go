@@ -0,0 +1,59 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cruisecontrol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+func TestMaintenanceWindowAt(t *testing.T) {
+	// every Saturday from 02:00 to 04:00 UTC
+	window := v1beta1.MaintenanceWindow{Name: "weekend", Schedule: "0 2 * * SAT", DurationMinutes: 120}
+	saturday := time.Date(2020, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		now          time.Time
+		open         bool
+		start        time.Time
+		closesOrOpen time.Time
+	}{
+		{now: saturday.Add(time.Hour), open: false, closesOrOpen: saturday.Add(2 * time.Hour)},
+		{now: saturday.Add(2 * time.Hour), open: true, start: saturday.Add(2 * time.Hour), closesOrOpen: saturday.Add(4 * time.Hour)},
+		{now: saturday.Add(3 * time.Hour), open: true, start: saturday.Add(2 * time.Hour), closesOrOpen: saturday.Add(4 * time.Hour)},
+		{now: saturday.Add(4 * time.Hour), open: false, closesOrOpen: saturday.Add(7*24*time.Hour + 2*time.Hour)},
+	}
+	for _, testCase := range testCases {
+		open, start, next, err := MaintenanceWindowAt(window, testCase.now)
+		if err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		if open != testCase.open || !start.Equal(testCase.start) || !next.Equal(testCase.closesOrOpen) {
+			t.Error("At", testCase.now, "expected", testCase.open, testCase.start, testCase.closesOrOpen, "got:", open, start, next)
+		}
+	}
+
+	window.Schedule = "CRON_TZ=Europe/Berlin 0 2 * * SAT"
+	if _, _, next, err := MaintenanceWindowAt(window, saturday.Add(-3*time.Hour)); err != nil || !next.Equal(saturday) {
+		t.Error("Expected the window to open at 02:00 summer time in Berlin, got:", next, err)
+	}
+
+	window.Schedule = "every saturday"
+	if _, _, _, err := MaintenanceWindowAt(window, saturday); err == nil {
+		t.Error("Expected an error for an invalid schedule")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Shopify/sarama"
 	"github.com/go-logr/logr"
 
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
@@ -33,6 +34,9 @@ const (
 	// property name in the ConfigMap's Data field for the broker configuration
 	ConfigPropertyName            = "broker-config"
 	securityProtocolMapConfigName = "listener.security.protocol.map"
+
+	// BrokerNodeLabel marks the pods serving the clients in KRaft mode, the pods of the controllers do not have it
+	BrokerNodeLabel = "brokerNode"
 )
 
 // PerBrokerConfigs configurations will not trigger rolling upgrade when updated
@@ -56,6 +60,9 @@ func LabelsForKafka(name string) map[string]string {
 // commonAclString is the raw representation of an ACL allowing Describe on a Topic
 var commonAclString = "User:%s,Topic,%s,%s,Describe,Allow,*"
 
+// describeConfigsAclString is the raw representation of an ACL allowing DescribeConfigs on a Topic
+var describeConfigsAclString = "User:%s,Topic,%s,%s,DescribeConfigs,Allow,*"
+
 // createAclString is the raw representation of an ACL allowing Create on a Topic
 var createAclString = "User:%s,Topic,%s,%s,Create,Allow,*"
 
@@ -66,7 +73,23 @@ var writeAclString = "User:%s,Topic,%s,%s,Write,Allow,*"
 var readAclString = "User:%s,Topic,%s,%s,Read,Allow,*"
 
 // readGroupAclString is the raw representation of an ACL allowing Read on ConsumerGroups
-var readGroupAclString = "User:%s,Group,LITERAL,*,Read,Allow,*"
+var readGroupAclString = "User:%s,Group,%s,%s,Read,Allow,*"
+
+// describeTransactionalIDAclString is the raw representation of an ACL allowing Describe on a TransactionalID
+var describeTransactionalIDAclString = "User:%s,TransactionalID,%s,%s,Describe,Allow,*"
+
+// writeTransactionalIDAclString is the raw representation of an ACL allowing Write on a TransactionalID
+var writeTransactionalIDAclString = "User:%s,TransactionalID,%s,%s,Write,Allow,*"
+
+// clusterAclString is the raw representation of an ACL allowing an operation on the Cluster
+var clusterAclString = "User:%s,Cluster,LITERAL,kafka-cluster,%s,Allow,*"
+
+// ACLToString converts an ACL binding to the same raw string representation GrantsToACLStrings uses
+func ACLToString(resource sarama.Resource, acl *sarama.Acl) string {
+	return fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s", acl.Principal, resource.ResourceType.String(),
+		strings.ToUpper(resource.ResourcePatternType.String()), resource.ResourceName,
+		acl.Operation.String(), acl.PermissionType.String(), acl.Host)
+}
 
 // GrantsToACLStrings converts a user DN and a list of topic grants to raw strings
 // for a CR status
@@ -78,17 +101,17 @@ func GrantsToACLStrings(dn string, grants []v1alpha1.UserTopicGrant) []string {
 		}
 		patternType := strings.ToUpper(string(x.PatternType))
 		cmn := fmt.Sprintf(commonAclString, dn, patternType, x.TopicName)
-		if !util.StringSliceContains(acls, cmn) {
-			acls = append(acls, cmn)
+		describeConfigsAcl := fmt.Sprintf(describeConfigsAclString, dn, patternType, x.TopicName)
+		for _, y := range []string{cmn, describeConfigsAcl} {
+			if !util.StringSliceContains(acls, y) {
+				acls = append(acls, y)
+			}
 		}
 		switch x.AccessType {
 		case v1alpha1.KafkaAccessTypeRead:
 			readAcl := fmt.Sprintf(readAclString, dn, patternType, x.TopicName)
-			readGroupAcl := fmt.Sprintf(readGroupAclString, dn)
-			for _, y := range []string{readAcl, readGroupAcl} {
-				if !util.StringSliceContains(acls, y) {
-					acls = append(acls, y)
-				}
+			if !util.StringSliceContains(acls, readAcl) {
+				acls = append(acls, readAcl)
 			}
 		case v1alpha1.KafkaAccessTypeWrite:
 			createAcl := fmt.Sprintf(createAclString, dn, patternType, x.TopicName)
@@ -103,6 +126,36 @@ func GrantsToACLStrings(dn string, grants []v1alpha1.UserTopicGrant) []string {
 	return acls
 }
 
+// UserACLStrings converts a user DN and every grant of the user spec to raw strings
+// for a CR status
+func UserACLStrings(dn string, spec *v1alpha1.KafkaUserSpec) []string {
+	acls := GrantsToACLStrings(dn, spec.TopicGrants)
+	appendACL := func(acl string) {
+		if !util.StringSliceContains(acls, acl) {
+			acls = append(acls, acl)
+		}
+	}
+	for _, x := range spec.GetGroupGrants() {
+		appendACL(fmt.Sprintf(readGroupAclString, dn, aclPatternTypeString(x.PatternType), x.GroupName))
+	}
+	for _, x := range spec.TransactionalIDGrants {
+		patternType := aclPatternTypeString(x.PatternType)
+		appendACL(fmt.Sprintf(describeTransactionalIDAclString, dn, patternType, x.TransactionalID))
+		appendACL(fmt.Sprintf(writeTransactionalIDAclString, dn, patternType, x.TransactionalID))
+	}
+	for _, x := range spec.ClusterOperations {
+		appendACL(fmt.Sprintf(clusterAclString, dn, x))
+	}
+	return acls
+}
+
+func aclPatternTypeString(patternType v1alpha1.KafkaPatternType) string {
+	if patternType == "" {
+		patternType = v1alpha1.KafkaPatternTypeDefault
+	}
+	return strings.ToUpper(string(patternType))
+}
+
 func ShouldRefreshOnlyPerBrokerConfigs(currentConfigs, desiredConfigs map[string]string, log logr.Logger) bool {
 	touchedConfigs := collectTouchedConfigs(currentConfigs, desiredConfigs, log)
 
@@ -18,8 +18,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/Shopify/sarama"
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/pkg/util"
 )
 
@@ -198,3 +200,73 @@ key5=value5`,
 		}
 	}
 }
+
+func TestUserACLStringsMatchACLToString(t *testing.T) {
+	grants := []v1alpha1.UserTopicGrant{
+		{
+			TopicName:  "test-topic",
+			AccessType: v1alpha1.KafkaAccessTypeRead,
+		},
+	}
+	expected := []string{
+		"User:CN=test-user,Topic,LITERAL,test-topic,Describe,Allow,*",
+		"User:CN=test-user,Topic,LITERAL,test-topic,DescribeConfigs,Allow,*",
+		"User:CN=test-user,Topic,LITERAL,test-topic,Read,Allow,*",
+		"User:CN=test-user,Group,LITERAL,*,Read,Allow,*",
+	}
+	acls := UserACLStrings("CN=test-user", &v1alpha1.KafkaUserSpec{TopicGrants: grants})
+	if !reflect.DeepEqual(acls, expected) {
+		t.Error("Expected:", expected, "Got:", acls)
+	}
+
+	aclString := ACLToString(sarama.Resource{
+		ResourceType:        sarama.AclResourceTopic,
+		ResourceName:        "test-topic",
+		ResourcePatternType: sarama.AclPatternLiteral,
+	}, &sarama.Acl{
+		Principal:      "User:CN=test-user",
+		Host:           "*",
+		Operation:      sarama.AclOperationDescribeConfigs,
+		PermissionType: sarama.AclPermissionAllow,
+	})
+	if !util.StringSliceContains(acls, aclString) {
+		t.Error("Expected", aclString, "to be part of", acls)
+	}
+}
+
+func TestUserACLStrings(t *testing.T) {
+	spec := &v1alpha1.KafkaUserSpec{
+		TopicGrants: []v1alpha1.UserTopicGrant{
+			{
+				TopicName:  "test-topic",
+				AccessType: v1alpha1.KafkaAccessTypeRead,
+			},
+		},
+		GroupGrants: []v1alpha1.UserGroupGrant{
+			{
+				GroupName:   "test-group",
+				PatternType: v1alpha1.KafkaPatternTypePrefixed,
+			},
+		},
+		TransactionalIDGrants: []v1alpha1.UserTransactionalIDGrant{
+			{
+				TransactionalID: "test-tx",
+			},
+		},
+		ClusterOperations: []v1alpha1.KafkaClusterOperation{
+			v1alpha1.KafkaClusterOperationIdempotentWrite,
+		},
+	}
+	expected := []string{
+		"User:CN=test-user,Topic,LITERAL,test-topic,Describe,Allow,*",
+		"User:CN=test-user,Topic,LITERAL,test-topic,DescribeConfigs,Allow,*",
+		"User:CN=test-user,Topic,LITERAL,test-topic,Read,Allow,*",
+		"User:CN=test-user,Group,PREFIXED,test-group,Read,Allow,*",
+		"User:CN=test-user,TransactionalID,LITERAL,test-tx,Describe,Allow,*",
+		"User:CN=test-user,TransactionalID,LITERAL,test-tx,Write,Allow,*",
+		"User:CN=test-user,Cluster,LITERAL,kafka-cluster,IdempotentWrite,Allow,*",
+	}
+	if acls := UserACLStrings("CN=test-user", spec); !reflect.DeepEqual(acls, expected) {
+		t.Error("Expected:", expected, "Got:", acls)
+	}
+}
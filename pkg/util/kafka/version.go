@@ -0,0 +1,87 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+const (
+	// InterBrokerProtocolVersionConfigName is the broker config pinning the protocol the brokers talk to each other
+	InterBrokerProtocolVersionConfigName = "inter.broker.protocol.version"
+	// LogMessageFormatVersionConfigName is the broker config pinning the format of the messages appended to the logs
+	LogMessageFormatVersionConfigName = "log.message.format.version"
+)
+
+// ProtocolVersion returns the inter.broker.protocol.version of a Kafka version, e.g. 2.6 for 2.6.1
+// and 0.11.0 for 0.11.0.3
+func ProtocolVersion(kafkaVersion string) (string, error) {
+	parts, err := parseVersion(kafkaVersion)
+	if err != nil {
+		return "", err
+	}
+	length := 2
+	if parts[0] == 0 {
+		length = 3
+	}
+	if len(parts) < length {
+		return "", errors.Errorf("kafka version %s has too few parts", kafkaVersion)
+	}
+	return strings.Join(strings.Split(kafkaVersion, ".")[:length], "."), nil
+}
+
+// CompareVersions returns -1, 0 or 1 if the first dot separated version is lower, equal or higher than the second,
+// the missing parts are taken as 0
+func CompareVersions(a, b string) (int, error) {
+	aParts, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bParts, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		switch {
+		case aPart < bPart:
+			return -1, nil
+		case aPart > bPart:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(version string) ([]int, error) {
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, errors.Errorf("invalid version %s", version)
+		}
+		parts = append(parts, number)
+	}
+	return parts, nil
+}
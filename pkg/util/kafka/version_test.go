@@ -0,0 +1,59 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import "testing"
+
+func TestProtocolVersion(t *testing.T) {
+	testCases := map[string]string{
+		"2.6.1":    "2.6",
+		"2.7":      "2.7",
+		"0.11.0.3": "0.11.0",
+	}
+	for kafkaVersion, expected := range testCases {
+		protocolVersion, err := ProtocolVersion(kafkaVersion)
+		if err != nil {
+			t.Error("Expected no error for", kafkaVersion, "got:", err)
+		} else if protocolVersion != expected {
+			t.Error("Expected", expected, "for", kafkaVersion, "got:", protocolVersion)
+		}
+	}
+
+	for _, kafkaVersion := range []string{"", "2", "0.11", "2.x.0"} {
+		if _, err := ProtocolVersion(kafkaVersion); err == nil {
+			t.Error("Expected error for", kafkaVersion, "got nil")
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"2.6", "2.6.0", 0},
+		{"2.6.1", "2.6", 1},
+		{"2.6", "2.10", -1},
+		{"0.11.0", "1.0", -1},
+	}
+	for _, testCase := range testCases {
+		result, err := CompareVersions(testCase.a, testCase.b)
+		if err != nil {
+			t.Error("Expected no error comparing", testCase.a, "and", testCase.b, "got:", err)
+		} else if result != testCase.expected {
+			t.Error("Expected", testCase.expected, "comparing", testCase.a, "and", testCase.b, "got:", result)
+		}
+	}
+}
@@ -120,7 +120,7 @@ func ConvertStringToInt32(s string) int32 {
 func IsSSLEnabledForInternalCommunication(l []v1beta1.InternalListenerConfig) (enabled bool) {
 
 	for _, listener := range l {
-		if strings.ToLower(listener.Type) == "ssl" {
+		if listener.IsSSL() {
 			enabled = true
 			break
 		}
@@ -128,6 +128,21 @@ func IsSSLEnabledForInternalCommunication(l []v1beta1.InternalListenerConfig) (e
 	return enabled
 }
 
+// IsSASLEnabled checks if any of the internal or external listeners authenticates its clients over SASL
+func IsSASLEnabled(l v1beta1.ListenersConfig) bool {
+	for _, listener := range l.InternalListeners {
+		if listener.IsSASL() {
+			return true
+		}
+	}
+	for _, listener := range l.ExternalListeners {
+		if listener.IsSASL() {
+			return true
+		}
+	}
+	return false
+}
+
 // ConvertMapStringToMapStringPointer converts a simple map[string]string to map[string]*string
 func ConvertMapStringToMapStringPointer(inputMap map[string]string) map[string]*string {
 
@@ -510,6 +510,32 @@ func TestIsSSLEnabledForInternalCommunication(t *testing.T) {
 	if IsSSLEnabledForInternalCommunication(lconfig) {
 		t.Error("Expected ssl disabled for internal communication, got enabled")
 	}
+	lconfig[0].Type = "sasl_ssl"
+	if !IsSSLEnabledForInternalCommunication(lconfig) {
+		t.Error("Expected ssl enabled for internal communication, got disabled")
+	}
+}
+
+func TestIsSASLEnabled(t *testing.T) {
+	lconfig := v1beta1.ListenersConfig{
+		InternalListeners: []v1beta1.InternalListenerConfig{
+			{
+				CommonListenerSpec: v1beta1.CommonListenerSpec{Type: "ssl"},
+			},
+		},
+		ExternalListeners: []v1beta1.ExternalListenerConfig{
+			{
+				CommonListenerSpec: v1beta1.CommonListenerSpec{Type: "sasl_ssl"},
+			},
+		},
+	}
+	if !IsSASLEnabled(lconfig) {
+		t.Error("Expected sasl enabled, got disabled")
+	}
+	lconfig.ExternalListeners[0].Type = "plaintext"
+	if IsSASLEnabled(lconfig) {
+		t.Error("Expected sasl disabled, got enabled")
+	}
 }
 
 func TestStringSliceContains(t *testing.T) {
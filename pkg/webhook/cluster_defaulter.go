@@ -0,0 +1,88 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gomodules.xyz/jsonpatch/v2"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+// defaultKafkaCluster responds with a JSON patch filling in the defaults of the raw cluster
+func (s *webhookServer) defaultKafkaCluster(raw []byte) *admissionv1beta1.AdmissionResponse {
+	var cluster v1beta1.KafkaCluster
+	if err := json.Unmarshal(raw, &cluster); err != nil {
+		log.Error(err, "Could not unmarshal raw object")
+		return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
+	}
+	log.Info(fmt.Sprintf("Setting the defaults of kafka cluster %s", cluster.Name))
+
+	setKafkaClusterDefaults(&cluster.Spec)
+
+	defaulted, err := json.Marshal(&cluster)
+	if err != nil {
+		log.Error(err, "Could not marshal defaulted object")
+		return notAllowed(err.Error(), metav1.StatusReasonInternalError)
+	}
+	patches, err := jsonpatch.CreatePatch(raw, defaulted)
+	if err != nil {
+		log.Error(err, "Could not create patch for defaulted object")
+		return notAllowed(err.Error(), metav1.StatusReasonInternalError)
+	}
+	if len(patches) == 0 {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+	patch, err := json.Marshal(patches)
+	if err != nil {
+		log.Error(err, "Could not marshal patch for defaulted object")
+		return notAllowed(err.Error(), metav1.StatusReasonInternalError)
+	}
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+// setKafkaClusterDefaults sets the values the operator falls back to when a field is omitted, so the stored
+// spec shows what the cluster actually runs with
+func setKafkaClusterDefaults(spec *v1beta1.KafkaClusterSpec) {
+	spec.IngressController = spec.GetIngressController()
+	spec.KubernetesClusterDomain = spec.GetKubernetesClusterDomain()
+	spec.ZKPath = spec.GetZkPath()
+
+	// A zero threshold stops the rolling upgrade before the first broker is restarted
+	if spec.RollingUpgradeConfig.FailureThreshold == 0 {
+		spec.RollingUpgradeConfig.FailureThreshold = 1
+	}
+	taskSpec := &spec.CruiseControlConfig.CruiseControlTaskSpec
+	taskSpec.RetryDurationMinutes = int(taskSpec.GetDurationMinutes())
+	spec.EnvoyConfig.Replicas = spec.EnvoyConfig.GetReplicas()
+	spec.IstioIngressConfig.Replicas = spec.IstioIngressConfig.GetReplicas()
+
+	for i := range spec.ListenersConfig.ExternalListeners {
+		listener := &spec.ListenersConfig.ExternalListeners[i]
+		listener.AccessMethod = listener.GetAccessMethod()
+	}
+}
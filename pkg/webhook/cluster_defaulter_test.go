@@ -0,0 +1,89 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gomodules.xyz/jsonpatch/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+func TestSetKafkaClusterDefaults(t *testing.T) {
+	spec := &newMockValidCluster().Spec
+	spec.ZKPath = "kafka"
+	spec.EnvoyConfig.Replicas = 3
+	spec.ListenersConfig.ExternalListeners = []v1beta1.ExternalListenerConfig{
+		{CommonListenerSpec: v1beta1.CommonListenerSpec{Name: "external"}},
+		{CommonListenerSpec: v1beta1.CommonListenerSpec{Name: "nodeport"}, AccessMethod: corev1.ServiceTypeNodePort},
+	}
+
+	setKafkaClusterDefaults(spec)
+
+	if spec.IngressController != "envoy" || spec.KubernetesClusterDomain != "cluster.local" || spec.ZKPath != "/kafka" {
+		t.Error("Unexpected defaults:", spec.IngressController, spec.KubernetesClusterDomain, spec.ZKPath)
+	}
+	if spec.RollingUpgradeConfig.FailureThreshold != 1 || spec.CruiseControlConfig.CruiseControlTaskSpec.RetryDurationMinutes != 5 {
+		t.Error("Unexpected defaults:", spec.RollingUpgradeConfig, spec.CruiseControlConfig.CruiseControlTaskSpec)
+	}
+	if spec.EnvoyConfig.Replicas != 3 || spec.IstioIngressConfig.Replicas != 1 {
+		t.Error("Unexpected replicas:", spec.EnvoyConfig.Replicas, spec.IstioIngressConfig.Replicas)
+	}
+	if spec.ListenersConfig.ExternalListeners[0].AccessMethod != corev1.ServiceTypeLoadBalancer ||
+		spec.ListenersConfig.ExternalListeners[1].AccessMethod != corev1.ServiceTypeNodePort {
+		t.Error("Unexpected access methods:", spec.ListenersConfig.ExternalListeners)
+	}
+}
+
+func TestDefaultKafkaCluster(t *testing.T) {
+	server := newMockServer()
+
+	if res := server.defaultKafkaCluster([]byte("some data")); res.Allowed {
+		t.Error("Expected not allowed due to invalid object, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonBadRequest {
+		t.Error("Expected bad request, got:", res.Result)
+	}
+
+	raw, _ := json.Marshal(newMockValidCluster())
+	res := server.defaultKafkaCluster(raw)
+	if !res.Allowed {
+		t.Fatal("Expected allowed, got:", res.Result)
+	}
+	var patches []jsonpatch.Operation
+	if err := json.Unmarshal(res.Patch, &patches); err != nil {
+		t.Fatal("Expected JSON patch, got:", err)
+	}
+	found := false
+	for _, patch := range patches {
+		if patch.Path == "/spec/ingressController" && patch.Value == "envoy" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected patch to set the default ingress controller, got:", patches)
+	}
+
+	// a cluster with every default set needs no patch
+	cluster := newMockValidCluster()
+	setKafkaClusterDefaults(&cluster.Spec)
+	raw, _ = json.Marshal(cluster)
+	if res := server.defaultKafkaCluster(raw); !res.Allowed || len(res.Patch) != 0 {
+		t.Error("Expected allowed without patch, got:", string(res.Patch))
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+	ccutils "github.com/banzaicloud/kafka-operator/pkg/util/cruisecontrol"
+	"github.com/banzaicloud/kafka-operator/pkg/util/kafka"
+)
+
+// brokerMetricsPort is the port of the JMX exporter in the broker containers
+const brokerMetricsPort = 9020
+
+// validateKafkaCluster checks the spec of the cluster, and on update the changes compared to the old cluster
+func (s *webhookServer) validateKafkaCluster(cluster, oldCluster *v1beta1.KafkaCluster) *admissionv1beta1.AdmissionResponse {
+	log.Info(fmt.Sprintf("Doing pre-admission validation of kafka cluster %s", cluster.Name))
+
+	// Finalizer, label and annotation updates of the operator must go through regardless of the spec
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) ||
+		(oldCluster != nil && reflect.DeepEqual(cluster.Spec, oldCluster.Spec)) {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	if msg := checkClusterBrokers(&cluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s has invalid brokers: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if msg := checkClusterListeners(&cluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s has invalid listeners: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if msg := checkClusterMetadataQuorum(&cluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s has no valid metadata quorum: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if msg := checkMaintenanceWindows(&cluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s has invalid maintenance windows: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if msg := checkTopicPolicyNameRegex(&cluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s has an invalid topic policy: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if oldCluster == nil {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	if msg := checkRemovedListeners(&cluster.Spec, &oldCluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s update removes listeners: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonForbidden)
+	}
+
+	if msg := checkStorageShrink(&cluster.Spec, &oldCluster.Spec); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s update shrinks broker storage: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonForbidden)
+	}
+
+	if msg := checkKafkaVersionDowngrade(&cluster.Spec, &oldCluster.Status); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s update downgrades Kafka: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonForbidden)
+	}
+
+	if msg := checkKafkaVersionDeclaration(&cluster.Spec, &oldCluster.Spec, &oldCluster.Status); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s update upgrades an undeclared Kafka version: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonForbidden)
+	}
+
+	if msg := checkControllerQuorumChange(&cluster.Spec, &oldCluster.Spec, &oldCluster.Status); msg != "" {
+		log.Info(fmt.Sprintf("Cluster %s update changes the controller quorum: %s", cluster.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonForbidden)
+	}
+
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+}
+
+// checkClusterBrokers returns the first broker with a duplicate id or an unknown config group
+func checkClusterBrokers(spec *v1beta1.KafkaClusterSpec) string {
+	ids := make(map[int32]struct{}, len(spec.Brokers))
+	for _, broker := range spec.Brokers {
+		if _, ok := ids[broker.Id]; ok {
+			return fmt.Sprintf("broker id %d is used by more than one broker", broker.Id)
+		}
+		ids[broker.Id] = struct{}{}
+
+		if broker.BrokerConfigGroup == "" {
+			continue
+		}
+		if _, ok := spec.BrokerConfigGroups[broker.BrokerConfigGroup]; !ok {
+			return fmt.Sprintf("broker %d references the brokerConfigGroup '%s' which does not exist", broker.Id, broker.BrokerConfigGroup)
+		}
+	}
+	return ""
+}
+
+// checkMaintenanceWindows returns the first maintenance window without a unique name or a valid schedule
+func checkMaintenanceWindows(spec *v1beta1.KafkaClusterSpec) string {
+	names := make(map[string]struct{})
+	for _, window := range spec.CruiseControlConfig.MaintenanceWindows {
+		if window.Name == "" {
+			return "maintenance windows must have a name"
+		}
+		if _, ok := names[window.Name]; ok {
+			return fmt.Sprintf("maintenance window name '%s' is used by more than one window", window.Name)
+		}
+		names[window.Name] = struct{}{}
+		if _, err := ccutils.ParseMaintenanceSchedule(window.Schedule); err != nil {
+			return fmt.Sprintf("maintenance window '%s' has an invalid schedule: %s", window.Name, err)
+		}
+	}
+	return ""
+}
+
+// checkTopicPolicyNameRegex returns why the name regex of the topic policy can not be compiled, as it would reject
+// every new topic of the cluster
+func checkTopicPolicyNameRegex(spec *v1beta1.KafkaClusterSpec) string {
+	if spec.TopicPolicy == nil || spec.TopicPolicy.NameRegex == "" {
+		return ""
+	}
+	if _, err := regexp.Compile(spec.TopicPolicy.NameRegex); err != nil {
+		return fmt.Sprintf("topic policy name regex '%s' is invalid: %s", spec.TopicPolicy.NameRegex, err)
+	}
+	return ""
+}
+
+// checkClusterListeners returns why the brokers could not start with the configured listeners
+func checkClusterListeners(spec *v1beta1.KafkaClusterSpec) string {
+	names := make(map[string]struct{})
+	ports := map[int32]string{brokerMetricsPort: "the metrics exporter"}
+	if spec.IsKRaft() {
+		ports[spec.KRaftConfig.GetControllerListenerPort()] = "the KRaft controller listener"
+	}
+	for _, envVar := range spec.Envs {
+		if envVar.Name != "JMX_PORT" {
+			continue
+		}
+		if port, err := strconv.ParseInt(envVar.Value, 10, 32); err == nil {
+			ports[int32(port)] = "JMX"
+		}
+	}
+
+	checkListener := func(listener v1beta1.CommonListenerSpec) string {
+		if _, ok := names[listener.Name]; ok {
+			return fmt.Sprintf("listener name '%s' is used by more than one listener", listener.Name)
+		}
+		names[listener.Name] = struct{}{}
+		if user, ok := ports[listener.ContainerPort]; ok {
+			return fmt.Sprintf("container port %d of listener '%s' is already used by %s", listener.ContainerPort, listener.Name, user)
+		}
+		ports[listener.ContainerPort] = fmt.Sprintf("listener '%s'", listener.Name)
+		return ""
+	}
+
+	var innerListeners, controllerListeners int
+	for _, listener := range spec.ListenersConfig.InternalListeners {
+		if msg := checkListener(listener.CommonListenerSpec); msg != "" {
+			return msg
+		}
+		if listener.UsedForInnerBrokerCommunication {
+			innerListeners++
+		}
+		if listener.UsedForControllerCommunication {
+			controllerListeners++
+		}
+	}
+	for _, listener := range spec.ListenersConfig.ExternalListeners {
+		if msg := checkListener(listener.CommonListenerSpec); msg != "" {
+			return msg
+		}
+	}
+
+	if innerListeners != 1 {
+		return fmt.Sprintf("exactly one internal listener must be used for inner broker communication, found %d", innerListeners)
+	}
+	if controllerListeners > 1 {
+		return fmt.Sprintf("at most one internal listener can be used for controller communication, found %d", controllerListeners)
+	}
+	return ""
+}
+
+// checkRemovedListeners returns the first listener of the old spec missing from the new one
+func checkRemovedListeners(spec, oldSpec *v1beta1.KafkaClusterSpec) string {
+	names := make(map[string]struct{})
+	for _, listener := range spec.ListenersConfig.InternalListeners {
+		names[listener.Name] = struct{}{}
+	}
+	for _, listener := range spec.ListenersConfig.ExternalListeners {
+		names[listener.Name] = struct{}{}
+	}
+
+	for _, listener := range oldSpec.ListenersConfig.InternalListeners {
+		if _, ok := names[listener.Name]; !ok {
+			return fmt.Sprintf("internal listener '%s' can not be removed", listener.Name)
+		}
+	}
+	for _, listener := range oldSpec.ListenersConfig.ExternalListeners {
+		if _, ok := names[listener.Name]; !ok {
+			return fmt.Sprintf("external listener '%s' can not be removed", listener.Name)
+		}
+	}
+	return ""
+}
+
+// checkStorageShrink returns the first storage of a kept broker that requests less than before, as persistent
+// volume claims can only be expanded
+func checkStorageShrink(spec, oldSpec *v1beta1.KafkaClusterSpec) string {
+	oldBrokers := make(map[int32]v1beta1.Broker, len(oldSpec.Brokers))
+	for _, broker := range oldSpec.Brokers {
+		oldBrokers[broker.Id] = broker
+	}
+
+	for _, broker := range spec.Brokers {
+		oldBroker, ok := oldBrokers[broker.Id]
+		if !ok {
+			continue
+		}
+		brokerConfig, err := util.GetBrokerConfig(broker, *spec)
+		if err != nil || brokerConfig == nil {
+			continue
+		}
+		oldBrokerConfig, err := util.GetBrokerConfig(oldBroker, *oldSpec)
+		if err != nil || oldBrokerConfig == nil {
+			continue
+		}
+
+		oldStorages := make(map[string]v1beta1.StorageConfig, len(oldBrokerConfig.StorageConfigs))
+		for _, storage := range oldBrokerConfig.StorageConfigs {
+			oldStorages[storage.MountPath] = storage
+		}
+		for _, storage := range brokerConfig.StorageConfigs {
+			oldStorage, ok := oldStorages[storage.MountPath]
+			if !ok || storage.PvcSpec == nil || oldStorage.PvcSpec == nil {
+				continue
+			}
+			size := storage.PvcSpec.Resources.Requests.Storage()
+			oldSize := oldStorage.PvcSpec.Resources.Requests.Storage()
+			if size.Cmp(*oldSize) < 0 {
+				return fmt.Sprintf("storage of broker %d mounted at '%s' can not be decreased from %s to %s",
+					broker.Id, storage.MountPath, oldSize.String(), size.String())
+			}
+		}
+	}
+	return ""
+}
+
+// checkKafkaVersionDowngrade returns why the brokers could not run the declared Kafka version, as they can not be
+// downgraded below the protocol version they already use
+func checkKafkaVersionDowngrade(spec *v1beta1.KafkaClusterSpec, oldStatus *v1beta1.KafkaClusterStatus) string {
+	protocolVersion := oldStatus.KafkaVersion.ProtocolVersion
+	if spec.KafkaVersion == "" || protocolVersion == "" {
+		return ""
+	}
+	desiredProtocolVersion, err := kafka.ProtocolVersion(spec.KafkaVersion)
+	if err != nil {
+		return err.Error()
+	}
+	if cmp, err := kafka.CompareVersions(desiredProtocolVersion, protocolVersion); err == nil && cmp < 0 {
+		return fmt.Sprintf("kafkaVersion %s can not be used by the brokers already using the protocol version %s",
+			spec.KafkaVersion, protocolVersion)
+	}
+	return ""
+}
+
+// checkKafkaVersionDeclaration returns the first kept broker whose image changes together with the first declaration
+// of the Kafka version, as the brokers would be pinned to the protocol of the new version without running the old
+// one first
+func checkKafkaVersionDeclaration(spec, oldSpec *v1beta1.KafkaClusterSpec, oldStatus *v1beta1.KafkaClusterStatus) string {
+	if spec.KafkaVersion == "" || oldStatus.KafkaVersion.Version != "" {
+		return ""
+	}
+	oldBrokers := make(map[int32]v1beta1.Broker, len(oldSpec.Brokers))
+	for _, broker := range oldSpec.Brokers {
+		oldBrokers[broker.Id] = broker
+	}
+
+	for _, broker := range spec.Brokers {
+		oldBroker, ok := oldBrokers[broker.Id]
+		if !ok {
+			continue
+		}
+		brokerConfig, err := util.GetBrokerConfig(broker, *spec)
+		if err != nil || brokerConfig == nil {
+			continue
+		}
+		oldBrokerConfig, err := util.GetBrokerConfig(oldBroker, *oldSpec)
+		if err != nil || oldBrokerConfig == nil {
+			continue
+		}
+		if util.GetBrokerImage(brokerConfig, spec.GetClusterImage()) != util.GetBrokerImage(oldBrokerConfig, oldSpec.GetClusterImage()) {
+			return fmt.Sprintf("kafkaVersion %s must first be declared at the version the brokers run, before the image of broker %d changes",
+				spec.KafkaVersion, broker.Id)
+		}
+	}
+	return ""
+}
+
+// checkClusterMetadataQuorum returns why the brokers could not store the metadata of the cluster, either in ZooKeeper
+// or in KRaft mode in a quorum of controllers
+func checkClusterMetadataQuorum(spec *v1beta1.KafkaClusterSpec) string {
+	if !spec.IsKRaft() {
+		if len(spec.ZKAddresses) == 0 {
+			return "zkAddresses must be set unless the cluster runs in KRaft mode"
+		}
+		return ""
+	}
+
+	var brokers, controllers int
+	for _, broker := range spec.Brokers {
+		brokerConfig, err := util.GetBrokerConfig(broker, *spec)
+		if err != nil {
+			return fmt.Sprintf("could not get the config of broker %d: %s", broker.Id, err)
+		}
+		if brokerConfig.HasRole(v1beta1.ProcessRoleBroker) {
+			brokers++
+		}
+		if brokerConfig.HasRole(v1beta1.ProcessRoleController) {
+			controllers++
+		}
+	}
+	if controllers == 0 {
+		return "at least one broker must have the controller role in KRaft mode"
+	}
+	if brokers == 0 {
+		return "at least one broker must have the broker role in KRaft mode"
+	}
+	// the brokers report an arbitrary broker as the controller in KRaft mode, not the active controller of the quorum
+	if spec.RollingUpgradeConfig.ControllerLast {
+		return "rollingUpgradeConfig.controllerLast is not supported in KRaft mode"
+	}
+	return ""
+}
+
+// checkControllerQuorumChange returns the first change of the controller quorum the brokers can not follow. The
+// voters of the quorum are changed one at a time, and the roles of the existing brokers are kept.
+func checkControllerQuorumChange(spec, oldSpec *v1beta1.KafkaClusterSpec, oldStatus *v1beta1.KafkaClusterStatus) string {
+	if spec.IsKRaft() != oldSpec.IsKRaft() {
+		return "kRaftConfig can not be added to or removed from an existing cluster"
+	}
+	if !spec.IsKRaft() {
+		return ""
+	}
+	if !reflect.DeepEqual(spec.KRaftConfig, oldSpec.KRaftConfig) {
+		return "kRaftConfig can not be changed"
+	}
+
+	roles := func(broker v1beta1.Broker, spec *v1beta1.KafkaClusterSpec) map[v1beta1.ProcessRole]bool {
+		brokerConfig, err := util.GetBrokerConfig(broker, *spec)
+		if err != nil {
+			return nil
+		}
+		return map[v1beta1.ProcessRole]bool{
+			v1beta1.ProcessRoleBroker:     brokerConfig.HasRole(v1beta1.ProcessRoleBroker),
+			v1beta1.ProcessRoleController: brokerConfig.HasRole(v1beta1.ProcessRoleController),
+		}
+	}
+	controllers := func(spec *v1beta1.KafkaClusterSpec) map[int32]bool {
+		ids := make(map[int32]bool)
+		for _, broker := range spec.Brokers {
+			if roles(broker, spec)[v1beta1.ProcessRoleController] {
+				ids[broker.Id] = true
+			}
+		}
+		return ids
+	}
+
+	oldBrokers := make(map[int32]v1beta1.Broker, len(oldSpec.Brokers))
+	for _, broker := range oldSpec.Brokers {
+		oldBrokers[broker.Id] = broker
+	}
+	for _, broker := range spec.Brokers {
+		if oldBroker, ok := oldBrokers[broker.Id]; ok && !reflect.DeepEqual(roles(broker, spec), roles(oldBroker, oldSpec)) {
+			return fmt.Sprintf("roles of broker %d can not be changed, add a new broker with the controller role "+
+				"and remove the old one instead", broker.Id)
+		}
+	}
+
+	desired, oldDesired := controllers(spec), controllers(oldSpec)
+	if reflect.DeepEqual(desired, oldDesired) {
+		return ""
+	}
+	voters := oldDesired
+	if len(oldStatus.KRaft.Voters) > 0 {
+		voters = make(map[int32]bool, len(oldStatus.KRaft.Voters))
+		for _, id := range oldStatus.KRaft.Voters {
+			voters[id] = true
+		}
+	}
+	if !reflect.DeepEqual(voters, oldDesired) {
+		return "the controller quorum can not be changed while the previous change is in progress"
+	}
+	changed := 0
+	for id := range desired {
+		if !voters[id] {
+			changed++
+		}
+	}
+	for id := range voters {
+		if !desired[id] {
+			changed++
+		}
+	}
+	if changed > 1 {
+		return "only one broker with the controller role can be added to or removed from the controller quorum at a time"
+	}
+	return ""
+}
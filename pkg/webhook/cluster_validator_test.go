@@ -0,0 +1,365 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+func newMockValidCluster() *v1beta1.KafkaCluster {
+	cluster := newMockCluster()
+	cluster.Spec.ZKAddresses = []string{"zookeeper-client.zookeeper:2181"}
+	cluster.Spec.ListenersConfig.InternalListeners = []v1beta1.InternalListenerConfig{
+		{
+			CommonListenerSpec:              v1beta1.CommonListenerSpec{Type: "plaintext", Name: "internal", ContainerPort: 29092},
+			UsedForInnerBrokerCommunication: true,
+		},
+		{
+			CommonListenerSpec:             v1beta1.CommonListenerSpec{Type: "plaintext", Name: "controller", ContainerPort: 29093},
+			UsedForControllerCommunication: true,
+		},
+	}
+	cluster.Spec.BrokerConfigGroups = map[string]v1beta1.BrokerConfig{
+		"default": {StorageConfigs: []v1beta1.StorageConfig{newMockStorage("/kafka-logs", "10Gi")}},
+	}
+	cluster.Spec.Brokers = []v1beta1.Broker{
+		{Id: 0, BrokerConfigGroup: "default"},
+		{Id: 1, BrokerConfigGroup: "default"},
+	}
+	return cluster
+}
+
+func newMockStorage(mountPath, size string) v1beta1.StorageConfig {
+	return v1beta1.StorageConfig{
+		MountPath: mountPath,
+		PvcSpec: &corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+}
+
+func TestValidateCluster(t *testing.T) {
+	server := newMockServer()
+	cluster := newMockValidCluster()
+
+	if res := server.validateKafkaCluster(cluster, nil); !res.Allowed {
+		t.Error("Expected allowed due to no issues, got:", res.Result)
+	}
+
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{Id: 1})
+	if res := server.validateKafkaCluster(cluster, nil); res.Allowed {
+		t.Error("Expected not allowed due to duplicate broker id, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonInvalid {
+		t.Error("Expected invalid status reason, got:", res.Result)
+	}
+
+	cluster.Spec.Brokers[2] = v1beta1.Broker{Id: 2, BrokerConfigGroup: "missing"}
+	if res := server.validateKafkaCluster(cluster, nil); res.Allowed {
+		t.Error("Expected not allowed due to unknown broker config group, got allowed")
+	}
+
+	// clusters being deleted are let through to remove their finalizers
+	now := metav1.Now()
+	cluster.SetDeletionTimestamp(&now)
+	if res := server.validateKafkaCluster(cluster, nil); !res.Allowed {
+		t.Error("Expected allowed due to cluster marked for deletion, got:", res.Result)
+	}
+	cluster.SetDeletionTimestamp(nil)
+
+	// metadata only updates are let through even if the spec is invalid already
+	old := cluster.DeepCopy()
+	cluster.Finalizers = []string{"finalizer.kafkaclusters.kafka.banzaicloud.io"}
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to unchanged spec, got:", res.Result)
+	}
+}
+
+func TestCheckClusterListeners(t *testing.T) {
+	spec := &newMockValidCluster().Spec
+	if msg := checkClusterListeners(spec); msg != "" {
+		t.Error("Expected valid listeners, got:", msg)
+	}
+
+	spec.ListenersConfig.InternalListeners[1].UsedForInnerBrokerCommunication = true
+	if msg := checkClusterListeners(spec); msg == "" {
+		t.Error("Expected error for two inner broker listeners")
+	}
+	spec.ListenersConfig.InternalListeners[1].UsedForInnerBrokerCommunication = false
+
+	spec.ListenersConfig.ExternalListeners = []v1beta1.ExternalListenerConfig{
+		{CommonListenerSpec: v1beta1.CommonListenerSpec{Type: "plaintext", Name: "external", ContainerPort: 29092}},
+	}
+	if msg := checkClusterListeners(spec); msg == "" {
+		t.Error("Expected error for clashing container ports")
+	}
+
+	spec.ListenersConfig.ExternalListeners[0].ContainerPort = brokerMetricsPort
+	if msg := checkClusterListeners(spec); msg == "" {
+		t.Error("Expected error for listener on the metrics port")
+	}
+
+	spec.ListenersConfig.ExternalListeners[0].ContainerPort = 9094
+	spec.Envs = []corev1.EnvVar{{Name: "JMX_PORT", Value: "9094"}}
+	if msg := checkClusterListeners(spec); msg == "" {
+		t.Error("Expected error for listener on the JMX port")
+	}
+
+	spec.Envs = nil
+	spec.ListenersConfig.ExternalListeners[0].Name = "internal"
+	if msg := checkClusterListeners(spec); msg == "" {
+		t.Error("Expected error for duplicate listener names")
+	}
+}
+
+func TestValidateClusterUpdate(t *testing.T) {
+	server := newMockServer()
+	old := newMockValidCluster()
+
+	cluster := old.DeepCopy()
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{
+		Id:           2,
+		BrokerConfig: &v1beta1.BrokerConfig{StorageConfigs: []v1beta1.StorageConfig{newMockStorage("/kafka-logs", "5Gi")}},
+	})
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to new broker with smaller storage, got:", res.Result)
+	}
+
+	cluster = old.DeepCopy()
+	cluster.Spec.BrokerConfigGroups["default"] = v1beta1.BrokerConfig{
+		StorageConfigs: []v1beta1.StorageConfig{newMockStorage("/kafka-logs", "20Gi")},
+	}
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to expanded storage, got:", res.Result)
+	}
+
+	cluster.Spec.BrokerConfigGroups["default"] = v1beta1.BrokerConfig{
+		StorageConfigs: []v1beta1.StorageConfig{newMockStorage("/kafka-logs", "5Gi")},
+	}
+	if res := server.validateKafkaCluster(cluster, old); res.Allowed {
+		t.Error("Expected not allowed due to shrunk storage, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonForbidden {
+		t.Error("Expected forbidden status reason, got:", res.Result)
+	}
+
+	cluster = old.DeepCopy()
+	cluster.Spec.ListenersConfig.InternalListeners = cluster.Spec.ListenersConfig.InternalListeners[:1]
+	if res := server.validateKafkaCluster(cluster, old); res.Allowed {
+		t.Error("Expected not allowed due to removed listener, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonForbidden {
+		t.Error("Expected forbidden status reason, got:", res.Result)
+	}
+
+	old.Status.KafkaVersion = v1beta1.KafkaVersionStatus{Version: "2.6.0", ProtocolVersion: "2.6"}
+	cluster = old.DeepCopy()
+	cluster.Spec.KafkaVersion = "2.6.1"
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to the same protocol version, got:", res.Result)
+	}
+
+	cluster.Spec.KafkaVersion = "2.5.1"
+	if res := server.validateKafkaCluster(cluster, old); res.Allowed {
+		t.Error("Expected not allowed due to downgrade below the protocol version, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonForbidden {
+		t.Error("Expected forbidden status reason, got:", res.Result)
+	}
+
+	old.Status.KafkaVersion = v1beta1.KafkaVersionStatus{}
+	cluster = old.DeepCopy()
+	cluster.Spec.KafkaVersion = "2.6.0"
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to the version declared without an image change, got:", res.Result)
+	}
+
+	cluster.Spec.KafkaVersion = "2.7.0"
+	cluster.Spec.ClusterImage = "ghcr.io/banzaicloud/kafka:2.13-2.7.0-bzc.1"
+	if res := server.validateKafkaCluster(cluster, old); res.Allowed {
+		t.Error("Expected not allowed due to the image changed together with the first version declaration, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonForbidden {
+		t.Error("Expected forbidden status reason, got:", res.Result)
+	}
+}
+
+func newMockKRaftCluster() *v1beta1.KafkaCluster {
+	cluster := newMockValidCluster()
+	cluster.Spec.ZKAddresses = nil
+	cluster.Spec.KRaftConfig = &v1beta1.KRaftConfig{ControllerListenerPort: 29094}
+	cluster.Spec.BrokerConfigGroups["controller"] = v1beta1.BrokerConfig{
+		Roles:          []v1beta1.ProcessRole{v1beta1.ProcessRoleController},
+		StorageConfigs: []v1beta1.StorageConfig{newMockStorage("/kafka-logs", "1Gi")},
+	}
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers,
+		v1beta1.Broker{Id: 100, BrokerConfigGroup: "controller"},
+		v1beta1.Broker{Id: 101, BrokerConfigGroup: "controller"},
+	)
+	return cluster
+}
+
+func TestCheckClusterMetadataQuorum(t *testing.T) {
+	spec := &newMockValidCluster().Spec
+	spec.ZKAddresses = nil
+	if msg := checkClusterMetadataQuorum(spec); msg == "" {
+		t.Error("Expected error for missing zkAddresses")
+	}
+
+	spec = &newMockKRaftCluster().Spec
+	spec.RollingUpgradeConfig.ControllerLast = true
+	if msg := checkClusterMetadataQuorum(spec); msg == "" {
+		t.Error("Expected error for controllerLast in KRaft mode")
+	}
+
+	spec.RollingUpgradeConfig.ControllerLast = false
+	if msg := checkClusterMetadataQuorum(spec); msg != "" {
+		t.Error("Expected valid KRaft cluster, got:", msg)
+	}
+	if msg := checkClusterListeners(spec); msg != "" {
+		t.Error("Expected valid listeners, got:", msg)
+	}
+
+	spec.KRaftConfig.ControllerListenerPort = 29092
+	if msg := checkClusterListeners(spec); msg == "" {
+		t.Error("Expected error for listener on the controller listener port")
+	}
+
+	spec.Brokers = spec.Brokers[:2]
+	if msg := checkClusterMetadataQuorum(spec); msg == "" {
+		t.Error("Expected error for KRaft cluster without controllers")
+	}
+
+	spec.Brokers = []v1beta1.Broker{{Id: 100, BrokerConfigGroup: "controller"}}
+	if msg := checkClusterMetadataQuorum(spec); msg == "" {
+		t.Error("Expected error for KRaft cluster without brokers")
+	}
+}
+
+func TestCheckMaintenanceWindows(t *testing.T) {
+	spec := &newMockValidCluster().Spec
+	spec.CruiseControlConfig.MaintenanceWindows = []v1beta1.MaintenanceWindow{
+		{Name: "weekend", Schedule: "0 2 * * SAT", DurationMinutes: 120},
+		{Name: "nightly", Schedule: "CRON_TZ=Europe/Berlin 30 1 * * *", DurationMinutes: 60},
+	}
+	if msg := checkMaintenanceWindows(spec); msg != "" {
+		t.Error("Expected valid maintenance windows, got:", msg)
+	}
+
+	spec.CruiseControlConfig.MaintenanceWindows[1].Name = "weekend"
+	if msg := checkMaintenanceWindows(spec); msg == "" {
+		t.Error("Expected error for duplicate maintenance window names")
+	}
+
+	spec.CruiseControlConfig.MaintenanceWindows[1].Name = "nightly"
+	spec.CruiseControlConfig.MaintenanceWindows[1].Schedule = "every night"
+	if msg := checkMaintenanceWindows(spec); msg == "" {
+		t.Error("Expected error for invalid schedule")
+	}
+}
+
+func TestValidateClusterTopicPolicy(t *testing.T) {
+	server := newMockServer()
+	cluster := newMockValidCluster()
+
+	cluster.Spec.TopicPolicy = &v1beta1.TopicPolicy{NameRegex: "^team-[a-z]+-"}
+	if res := server.validateKafkaCluster(cluster, nil); !res.Allowed {
+		t.Error("Expected allowed due to valid name regex, got:", res.Result)
+	}
+
+	cluster.Spec.TopicPolicy.NameRegex = "^team-(["
+	if res := server.validateKafkaCluster(cluster, nil); res.Allowed {
+		t.Error("Expected not allowed due to invalid name regex, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonInvalid {
+		t.Error("Expected invalid status reason, got:", res.Result)
+	}
+}
+
+func TestValidateKRaftClusterUpdate(t *testing.T) {
+	server := newMockServer()
+	old := newMockKRaftCluster()
+
+	cluster := old.DeepCopy()
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{Id: 2, BrokerConfigGroup: "default"})
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to new broker without the controller role, got:", res.Result)
+	}
+
+	cluster = old.DeepCopy()
+	cluster.Spec.Brokers = old.Spec.Brokers[1:]
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to removed broker without the controller role, got:", res.Result)
+	}
+
+	cluster = old.DeepCopy()
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{Id: 102, BrokerConfigGroup: "controller"})
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to one added controller, got:", res.Result)
+	}
+
+	cluster = old.DeepCopy()
+	cluster.Spec.Brokers = cluster.Spec.Brokers[:3]
+	if res := server.validateKafkaCluster(cluster, old); !res.Allowed {
+		t.Error("Expected allowed due to one removed controller, got:", res.Result)
+	}
+
+	// the voters still run with the previous quorum
+	inProgress := old.DeepCopy()
+	inProgress.Spec.Brokers = append(inProgress.Spec.Brokers, v1beta1.Broker{Id: 102, BrokerConfigGroup: "controller"})
+	inProgress.Status.KRaft.Voters = []int32{100, 101}
+	cluster = inProgress.DeepCopy()
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{Id: 2, BrokerConfigGroup: "default"})
+	if res := server.validateKafkaCluster(cluster, inProgress); !res.Allowed {
+		t.Error("Expected allowed due to unchanged quorum during a quorum change, got:", res.Result)
+	}
+	cluster = inProgress.DeepCopy()
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{Id: 103, BrokerConfigGroup: "controller"})
+	if res := server.validateKafkaCluster(cluster, inProgress); res.Allowed {
+		t.Error("Expected not allowed due to quorum change in progress, got allowed")
+	}
+
+	testCases := map[string]func(cluster *v1beta1.KafkaCluster){
+		"two added controllers": func(cluster *v1beta1.KafkaCluster) {
+			cluster.Spec.Brokers = append(cluster.Spec.Brokers,
+				v1beta1.Broker{Id: 102, BrokerConfigGroup: "controller"},
+				v1beta1.Broker{Id: 103, BrokerConfigGroup: "controller"})
+		},
+		"replaced controller": func(cluster *v1beta1.KafkaCluster) {
+			cluster.Spec.Brokers = append(cluster.Spec.Brokers[:3], v1beta1.Broker{Id: 102, BrokerConfigGroup: "controller"})
+		},
+		"changed roles": func(cluster *v1beta1.KafkaCluster) {
+			cluster.Spec.Brokers[0].BrokerConfigGroup = "controller"
+		},
+		"changed kRaftConfig": func(cluster *v1beta1.KafkaCluster) {
+			cluster.Spec.KRaftConfig.ControllerListenerPort = 29095
+		},
+		"removed kRaftConfig": func(cluster *v1beta1.KafkaCluster) {
+			cluster.Spec.KRaftConfig = nil
+			cluster.Spec.ZKAddresses = []string{"zookeeper-client.zookeeper:2181"}
+		},
+	}
+	for name, update := range testCases {
+		cluster = old.DeepCopy()
+		update(cluster)
+		if res := server.validateKafkaCluster(cluster, old); res.Allowed {
+			t.Error("Expected not allowed due to", name, "got allowed")
+		} else if res.Result.Reason != metav1.StatusReasonForbidden {
+			t.Error("Expected forbidden status reason for", name, "got:", res.Result)
+		}
+	}
+}
@@ -35,3 +35,10 @@ func IsInvalidReplicationFactor(err error) bool {
 	}
 	return false
 }
+
+func IsTopicPolicyViolation(err error) bool {
+	if apierrors.IsForbidden(err) && strings.Contains(err.Error(), topicPolicyViolationErrMsg) {
+		return true
+	}
+	return false
+}
@@ -15,9 +15,11 @@
 package webhook
 
 import (
+	"errors"
 	"testing"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestIsAdmissionConnectionError(t *testing.T) {
@@ -55,3 +57,21 @@ func TestIsInvalidReplicationFactor(t *testing.T) {
 		t.Error("Expected is invalid replication error to be false, got true")
 	}
 }
+
+func TestIsTopicPolicyViolation(t *testing.T) {
+	err := apierrors.NewForbidden(schema.GroupResource{}, "test-topic", errors.New(topicPolicyViolationErrMsg))
+
+	if !IsTopicPolicyViolation(err) {
+		t.Error("Expected is topic policy violation to be true, got false")
+	}
+
+	err = apierrors.NewForbidden(schema.GroupResource{}, "test-topic", errors.New("some other reason"))
+	if IsTopicPolicyViolation(err) {
+		t.Error("Expected is topic policy violation to be false, got true")
+	}
+
+	err = apierrors.NewBadRequest(topicPolicyViolationErrMsg)
+	if IsTopicPolicyViolation(err) {
+		t.Error("Expected is topic policy violation to be false, got true")
+	}
+}
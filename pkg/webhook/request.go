@@ -23,12 +23,15 @@ import (
 	"reflect"
 
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
-	kafkaTopic = reflect.TypeOf(v1alpha1.KafkaTopic{}).Name()
+	kafkaTopic   = reflect.TypeOf(v1alpha1.KafkaTopic{}).Name()
+	kafkaUser    = reflect.TypeOf(v1alpha1.KafkaUser{}).Name()
+	kafkaCluster = reflect.TypeOf(v1beta1.KafkaCluster{}).Name()
 )
 
 func (s *webhookServer) validate(ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
@@ -45,7 +48,63 @@ func (s *webhookServer) validate(ar *admissionv1beta1.AdmissionReview) *admissio
 			log.Error(err, "Could not unmarshal raw object")
 			return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
 		}
-		return s.validateKafkaTopic(&topic)
+		var oldTopic *v1alpha1.KafkaTopic
+		if req.Operation == admissionv1beta1.Update && len(req.OldObject.Raw) > 0 {
+			oldTopic = &v1alpha1.KafkaTopic{}
+			if err := json.Unmarshal(req.OldObject.Raw, oldTopic); err != nil {
+				log.Error(err, "Could not unmarshal raw old object")
+				return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
+			}
+		}
+		return s.validateKafkaTopic(&topic, oldTopic)
+
+	case kafkaUser:
+		var user v1alpha1.KafkaUser
+		if err := json.Unmarshal(req.Object.Raw, &user); err != nil {
+			log.Error(err, "Could not unmarshal raw object")
+			return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
+		}
+		var oldUser *v1alpha1.KafkaUser
+		if req.Operation == admissionv1beta1.Update && len(req.OldObject.Raw) > 0 {
+			oldUser = &v1alpha1.KafkaUser{}
+			if err := json.Unmarshal(req.OldObject.Raw, oldUser); err != nil {
+				log.Error(err, "Could not unmarshal raw old object")
+				return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
+			}
+		}
+		return s.validateKafkaUser(&user, oldUser)
+
+	case kafkaCluster:
+		var cluster v1beta1.KafkaCluster
+		if err := json.Unmarshal(req.Object.Raw, &cluster); err != nil {
+			log.Error(err, "Could not unmarshal raw object")
+			return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
+		}
+		var oldCluster *v1beta1.KafkaCluster
+		if req.Operation == admissionv1beta1.Update && len(req.OldObject.Raw) > 0 {
+			oldCluster = &v1beta1.KafkaCluster{}
+			if err := json.Unmarshal(req.OldObject.Raw, oldCluster); err != nil {
+				log.Error(err, "Could not unmarshal raw old object")
+				return notAllowed(err.Error(), metav1.StatusReasonBadRequest)
+			}
+		}
+		return s.validateKafkaCluster(&cluster, oldCluster)
+
+	default:
+		return notAllowed(fmt.Sprintf("Unexpected resource kind: %s", req.Kind.Kind), metav1.StatusReasonBadRequest)
+	}
+}
+
+func (s *webhookServer) mutate(ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	req := ar.Request
+
+	log.Info(fmt.Sprintf("Mutating AdmissionReview for Kind=%v, Namespace=%v Name=%v UID=%v patchOperation=%v UserInfo=%v",
+		req.Kind, req.Namespace, req.Name, req.UID, req.Operation, req.UserInfo))
+
+	switch req.Kind.Kind {
+
+	case kafkaCluster:
+		return s.defaultKafkaCluster(req.Object.Raw)
 
 	default:
 		return notAllowed(fmt.Sprintf("Unexpected resource kind: %s", req.Kind.Kind), metav1.StatusReasonBadRequest)
@@ -53,6 +112,15 @@ func (s *webhookServer) validate(ar *admissionv1beta1.AdmissionReview) *admissio
 }
 
 func (s *webhookServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.admit(w, r, s.validate)
+}
+
+func (s *webhookServer) serveMutate(w http.ResponseWriter, r *http.Request) {
+	s.admit(w, r, s.mutate)
+}
+
+// admit decodes the AdmissionReview of the request and writes back the response of the review func
+func (s *webhookServer) admit(w http.ResponseWriter, r *http.Request, review func(*admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse) {
 	defer r.Body.Close()
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -82,7 +150,7 @@ func (s *webhookServer) serve(w http.ResponseWriter, r *http.Request) {
 		log.Error(err, "Can't decode body")
 		admissionResponse = notAllowed(err.Error(), metav1.StatusReasonBadRequest)
 	} else {
-		admissionResponse = s.validate(&ar)
+		admissionResponse = review(&ar)
 	}
 
 	admissionReview := admissionv1beta1.AdmissionReview{}
@@ -91,6 +91,42 @@ func TestValidate(t *testing.T) {
 	} else if res.Result.Reason != metav1.StatusReasonNotFound {
 		t.Error("Expected not found for no cluster, got:", res.Result.Reason)
 	}
+
+	req.Request.Kind.Kind = kafkaUser
+
+	if res := server.validate(req); res.Allowed {
+		t.Error("Expected not allowed, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonNotFound {
+		t.Error("Expected not found for no cluster, got:", res.Result.Reason)
+	}
+
+	req.Request.Kind.Kind = kafkaCluster
+	req.Request.Object.Raw, _ = json.Marshal(newMockCluster())
+
+	if res := server.validate(req); res.Allowed {
+		t.Error("Expected not allowed, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonInvalid {
+		t.Error("Expected invalid cluster without listeners, got:", res.Result.Reason)
+	}
+}
+
+func TestMutate(t *testing.T) {
+	server := newMockServer()
+
+	req := newAdmissionReview()
+	if res := server.mutate(req); res.Allowed {
+		t.Error("Expected denied request for unknown resource type, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonBadRequest {
+		t.Error("Expected bad request, got:", res.Result.Reason)
+	}
+
+	req.Request.Kind.Kind = kafkaCluster
+	req.Request.Object.Raw, _ = json.Marshal(newMockCluster())
+	if res := server.mutate(req); !res.Allowed {
+		t.Error("Expected allowed, got:", res.Result)
+	} else if res.PatchType == nil || len(res.Patch) == 0 {
+		t.Error("Expected JSON patch with the defaults, got none")
+	}
 }
 
 func TestServe(t *testing.T) {
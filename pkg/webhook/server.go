@@ -52,6 +52,7 @@ func newWebhookServerMux(client client.Client, scheme *runtime.Scheme) *http.Ser
 	mux := http.NewServeMux()
 	webhookServer := newWebHookServer(client, scheme)
 	mux.HandleFunc("/validate", webhookServer.serve)
+	mux.HandleFunc("/mutate", webhookServer.serveMutate)
 	return mux
 }
 
@@ -61,5 +62,6 @@ func SetupServerHandlers(mgr ctrl.Manager, certDir string) {
 	server.CertDir = certDir
 	mux := newWebhookServerMux(mgr.GetClient(), mgr.GetScheme())
 	server.Register("/validate", mux)
+	server.Register("/mutate", mux)
 	return
 }
@@ -61,4 +61,8 @@ func TestNewServerMux(t *testing.T) {
 	if _, pattern := mux.Handler(req); pattern == "" {
 		t.Error("Expected mux to handle /validate, got 404")
 	}
+	req, _ = http.NewRequest("POST", "/mutate", &buf)
+	if _, pattern := mux.Handler(req); pattern == "" {
+		t.Error("Expected mux to handle /mutate, got 404")
+	}
 }
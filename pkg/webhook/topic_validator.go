@@ -17,11 +17,17 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	banzaicloudv1alpha1 "github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	banzaicloudv1beta1 "github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,11 +37,22 @@ import (
 const (
 	cantConnectErrorMsg            = "Failed to connect to kafka cluster"
 	invalidReplicationFactorErrMsg = "Replication factor is larger than the number of nodes in the kafka cluster"
+	invalidTopicConfigErrMsg       = "Invalid topic configuration"
+	topicPolicyViolationErrMsg     = "Topic violates the topic policy of the kafka cluster"
 )
 
-func (s *webhookServer) validateKafkaTopic(topic *v1alpha1.KafkaTopic) (res *admissionv1beta1.AdmissionResponse) {
+func (s *webhookServer) validateKafkaTopic(topic, oldTopic *v1alpha1.KafkaTopic) (res *admissionv1beta1.AdmissionResponse) {
 	log.Info(fmt.Sprintf("Doing pre-admission validation of kafka topic %s", topic.Spec.Name))
 
+	// Finalizer, label and status updates of the controller must go through, even when the topic violates a topic
+	// policy added after it was created
+	if k8sutil.IsMarkedForDeletion(topic.ObjectMeta) ||
+		(oldTopic != nil && reflect.DeepEqual(topic.Spec, oldTopic.Spec)) {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
 	// Get the referenced kafkacluster
 	clusterNamespace := topic.Spec.ClusterRef.Namespace
 	if clusterNamespace == "" {
@@ -91,16 +108,33 @@ func (s *webhookServer) validateKafkaTopic(topic *v1alpha1.KafkaTopic) (res *adm
 		// Check if this is the correct CR for this topic
 		topicCR := &banzaicloudv1alpha1.KafkaTopic{}
 		if err := s.client.Get(context.TODO(), types.NamespacedName{Name: topic.Name, Namespace: topic.Namespace}, topicCR); err != nil {
-			if apierrors.IsNotFound(err) {
+			if !apierrors.IsNotFound(err) {
+				log.Error(err, "API failure while running topic validation")
+				return notAllowed("API failure while validating topic, please try again", metav1.StatusReasonServiceUnavailable)
+			}
+			if topic.GetAnnotations()[banzaicloudv1alpha1.TopicAdoptAnnotation] != "true" {
 				// User is trying to overwrite an existing topic - bad user
 				log.Info("User attempted to create topic with name that already exists in the kafka cluster")
 				return notAllowed(
-					fmt.Sprintf("Topic '%s' already exists on kafka cluster '%s'", topic.Spec.Name, topic.Spec.ClusterRef.Name),
+					fmt.Sprintf("Topic '%s' already exists on kafka cluster '%s', set the '%s' annotation to adopt it",
+						topic.Spec.Name, topic.Spec.ClusterRef.Name, banzaicloudv1alpha1.TopicAdoptAnnotation),
+					metav1.StatusReasonAlreadyExists,
+				)
+			}
+			// an adopted topic must not be managed by another KafkaTopic already
+			owner, err := s.findTopicOwner(topic, cluster)
+			if err != nil {
+				log.Error(err, "API failure while running topic validation")
+				return notAllowed("API failure while validating topic, please try again", metav1.StatusReasonServiceUnavailable)
+			}
+			if owner != nil {
+				log.Info("User attempted to adopt a topic that is already managed by another KafkaTopic")
+				return notAllowed(
+					fmt.Sprintf("Topic '%s' is already managed by KafkaTopic '%s' in the namespace '%s'", topic.Spec.Name, owner.Name, owner.Namespace),
 					metav1.StatusReasonAlreadyExists,
 				)
 			}
-			log.Error(err, "API failure while running topic validation")
-			return notAllowed("API failure while validating topic, please try again", metav1.StatusReasonServiceUnavailable)
+			log.Info(fmt.Sprintf("Adopting existing topic %s", topic.Spec.Name))
 		}
 
 		// make sure the user isn't trying to decrease partition count
@@ -109,23 +143,103 @@ func (s *webhookServer) validateKafkaTopic(topic *v1alpha1.KafkaTopic) (res *adm
 			return notAllowed("Kafka does not support decreasing partition count on an existing topic", metav1.StatusReasonInvalid)
 		}
 
-		// check if the user is trying to change the replication factor
+		// replication factor changes are applied by the controller with a partition reassignment
 		if existing.ReplicationFactor != int16(topic.Spec.ReplicationFactor) {
-			log.Info(fmt.Sprintf("Spec is requesting replication factor change from %v to %v, rejecting", existing.ReplicationFactor, topic.Spec.ReplicationFactor))
-			return notAllowed("Kafka does not support changing the replication factor on an existing topic", metav1.StatusReasonInvalid)
+			log.Info(fmt.Sprintf("Spec is requesting replication factor change from %v to %v", existing.ReplicationFactor, topic.Spec.ReplicationFactor))
 		}
+	}
 
-		// the topic does not exist
-	} else {
-		// check if requesting a replication factor larger than the broker size
-		if int(topic.Spec.ReplicationFactor) > broker.NumBrokers() {
-			log.Info(fmt.Sprintf("Spec is requesting replication factor of %v, larger than cluster size of %v", topic.Spec.ReplicationFactor, broker.NumBrokers()))
-			return notAllowed(invalidReplicationFactorErrMsg, metav1.StatusReasonBadRequest)
+	// check if requesting a replication factor larger than the broker size
+	if int(topic.Spec.ReplicationFactor) > broker.NumBrokers() {
+		log.Info(fmt.Sprintf("Spec is requesting replication factor of %v, larger than cluster size of %v", topic.Spec.ReplicationFactor, broker.NumBrokers()))
+		return notAllowed(invalidReplicationFactorErrMsg, metav1.StatusReasonBadRequest)
+	}
+
+	// enforce the topic policy of the cluster, the topics the operator creates for the cluster itself are exempt
+	if policy := cluster.Spec.TopicPolicy; policy != nil && !metav1.IsControlledBy(topic, cluster) {
+		if violation := checkTopicPolicy(topic, policy, existing == nil); violation != "" {
+			log.Info(fmt.Sprintf("Topic %s violates the topic policy of the cluster: %s", topic.Spec.Name, violation))
+			return notAllowed(fmt.Sprintf("%s: %s", topicPolicyViolationErrMsg, violation), metav1.StatusReasonForbidden)
 		}
 	}
 
+	// let the brokers validate the topic configuration without applying it
+	config := util.MapStringStringPointer(topic.Spec.Config)
+	if existing == nil {
+		err = broker.ValidateTopic(&kafkaclient.CreateTopicOptions{
+			Name:              topic.Spec.Name,
+			Partitions:        topic.Spec.Partitions,
+			ReplicationFactor: int16(topic.Spec.ReplicationFactor),
+			Config:            config,
+		})
+	} else {
+		err = broker.ValidateTopicConfig(topic.Spec.Name, config)
+	}
+	if err != nil {
+		log.Info(fmt.Sprintf("Topic %s was rejected by the kafka cluster", topic.Spec.Name), "error", err.Error())
+		return notAllowed(fmt.Sprintf("%s: %s", invalidTopicConfigErrMsg, err.Error()), metav1.StatusReasonInvalid)
+	}
+
 	// everything looks a-okay
 	return &admissionv1beta1.AdmissionResponse{
 		Allowed: true,
 	}
 }
+
+// findTopicOwner returns the KafkaTopic other than the given one that manages the same topic of the cluster
+func (s *webhookServer) findTopicOwner(topic *v1alpha1.KafkaTopic, cluster *banzaicloudv1beta1.KafkaCluster) (*v1alpha1.KafkaTopic, error) {
+	topics := &v1alpha1.KafkaTopicList{}
+	if err := s.client.List(context.TODO(), topics); err != nil {
+		return nil, err
+	}
+	for i, other := range topics.Items {
+		if other.Name == topic.Name && other.Namespace == topic.Namespace {
+			continue
+		}
+		otherClusterNamespace := other.Spec.ClusterRef.Namespace
+		if otherClusterNamespace == "" {
+			otherClusterNamespace = other.Namespace
+		}
+		if other.Spec.Name == topic.Spec.Name && other.Spec.ClusterRef.Name == cluster.Name && otherClusterNamespace == cluster.Namespace {
+			return &topics.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// checkTopicPolicy returns why the topic violates the policy, the name is only checked for new topics
+// so that existing topics can still be adopted
+func checkTopicPolicy(topic *v1alpha1.KafkaTopic, policy *banzaicloudv1beta1.TopicPolicy, newTopic bool) string {
+	if newTopic && policy.NameRegex != "" {
+		re, err := regexp.Compile(policy.NameRegex)
+		if err != nil {
+			return fmt.Sprintf("the name regex '%s' of the policy is invalid", policy.NameRegex)
+		}
+		if !re.MatchString(topic.Spec.Name) {
+			return fmt.Sprintf("the name does not match '%s'", policy.NameRegex)
+		}
+	}
+	if policy.MinPartitions > 0 && topic.Spec.Partitions < policy.MinPartitions {
+		return fmt.Sprintf("at least %d partitions are required", policy.MinPartitions)
+	}
+	if policy.MaxPartitions > 0 && topic.Spec.Partitions > policy.MaxPartitions {
+		return fmt.Sprintf("at most %d partitions are allowed", policy.MaxPartitions)
+	}
+	if value, ok := topic.Spec.Config["min.insync.replicas"]; ok && policy.MinInSyncReplicas > 0 {
+		minInSyncReplicas, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Sprintf("min.insync.replicas '%s' is not a number", value)
+		}
+		if int32(minInSyncReplicas) < policy.MinInSyncReplicas {
+			return fmt.Sprintf("min.insync.replicas has to be at least %d", policy.MinInSyncReplicas)
+		}
+	}
+	if value, ok := topic.Spec.Config["cleanup.policy"]; ok && len(policy.AllowedCleanupPolicies) > 0 {
+		for _, cleanupPolicy := range strings.Split(value, ",") {
+			if !util.StringSliceContains(policy.AllowedCleanupPolicies, strings.TrimSpace(cleanupPolicy)) {
+				return fmt.Sprintf("cleanup.policy '%s' is not one of %v", cleanupPolicy, policy.AllowedCleanupPolicies)
+			}
+		}
+	}
+	return ""
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
 	"github.com/banzaicloud/kafka-operator/api/v1beta1"
 	"github.com/banzaicloud/kafka-operator/pkg/kafkaclient"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
 )
 
 func newMockCluster() *v1beta1.KafkaCluster {
@@ -65,7 +66,7 @@ func TestValidateTopic(t *testing.T) {
 	topic := newMockTopic()
 
 	// Test non-existent kafka cluster
-	res := server.validateKafkaTopic(topic)
+	res := server.validateKafkaTopic(topic, nil)
 	if res.Result.Reason != metav1.StatusReasonNotFound {
 		t.Error("Expected not found cluster, got:", res.Result)
 	}
@@ -73,7 +74,7 @@ func TestValidateTopic(t *testing.T) {
 	// test topic marked for deletion
 	now := metav1.Now()
 	topic.SetDeletionTimestamp(&now)
-	if res = server.validateKafkaTopic(topic); !res.Allowed {
+	if res = server.validateKafkaTopic(topic, nil); !res.Allowed {
 		t.Error("Expected allowed due to topic marked for deletion, got:", res.Result)
 	}
 	// remove deletion timestamp
@@ -82,7 +83,7 @@ func TestValidateTopic(t *testing.T) {
 	// test cluster marked for deletion
 	cluster.SetDeletionTimestamp(&now)
 	server.client.Create(context.TODO(), cluster)
-	if res = server.validateKafkaTopic(topic); !res.Allowed {
+	if res = server.validateKafkaTopic(topic, nil); !res.Allowed {
 		t.Error("Expected allowed due to cluster marked for deletion, got:", res.Result)
 	}
 
@@ -91,7 +92,7 @@ func TestValidateTopic(t *testing.T) {
 	server.client.Update(context.TODO(), cluster)
 
 	// test no rejection reasons
-	if res = server.validateKafkaTopic(topic); !res.Allowed {
+	if res = server.validateKafkaTopic(topic, nil); !res.Allowed {
 		t.Error("Expected allowed due to no issues, got:", res.Result)
 	}
 
@@ -99,7 +100,7 @@ func TestValidateTopic(t *testing.T) {
 
 	// Replication factor larger than num brokers
 	topic.Spec.ReplicationFactor = 2
-	if res = server.validateKafkaTopic(topic); res.Allowed {
+	if res = server.validateKafkaTopic(topic, nil); res.Allowed {
 		t.Error("Expected not allowed due to replication factor larger than num brokers, got allowed")
 	} else if res.Result.Reason != metav1.StatusReasonBadRequest {
 		t.Error("Expected bad request, got:", res.Result.Reason)
@@ -113,29 +114,144 @@ func TestValidateTopic(t *testing.T) {
 		t.Error("creation of topic should have been successful")
 	}
 	topic.Name = "test-topic"
-	if res = server.validateKafkaTopic(topic); res.Allowed {
+	if res = server.validateKafkaTopic(topic, nil); res.Allowed {
 		t.Error("Expected not allowed due to existing topic with same name, got allowed")
 	} else if res.Result.Reason != metav1.StatusReasonAlreadyExists {
 		t.Error("Expected not allowed for reason already exists, got:", res.Result)
 	}
 
+	// Adopting the existing topic
+	topic.SetAnnotations(map[string]string{v1alpha1.TopicAdoptAnnotation: "true"})
+	if res = server.validateKafkaTopic(topic, nil); !res.Allowed {
+		t.Error("Expected allowed due to adoption of existing topic, got:", res.Result)
+	}
+
+	// Adopting a topic that another KafkaTopic manages
+	owner := newMockTopic()
+	owner.Name = "test-topic-owner"
+	server.client.Create(context.TODO(), owner)
+	if res = server.validateKafkaTopic(topic, nil); res.Allowed {
+		t.Error("Expected not allowed due to topic managed by another KafkaTopic, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonAlreadyExists {
+		t.Error("Expected not allowed for reason already exists, got:", res.Result)
+	}
+	server.client.Delete(context.TODO(), owner)
+	topic.SetAnnotations(nil)
+
 	// Add topic and test existing topic reason
 	server.client.Create(context.TODO(), topic)
 
 	// partition decrease attempt
 	topic.Spec.Partitions = 1
-	if res = server.validateKafkaTopic(topic); res.Allowed {
+	if res = server.validateKafkaTopic(topic, nil); res.Allowed {
 		t.Error("Expected not allowed due to partition decrease, got allowed")
 	} else if res.Result.Reason != metav1.StatusReasonInvalid {
 		t.Error("Expected invalid status reason, got:", res.Result)
 	}
 
-	// replication factor change attempt
+	// replication factor change larger than num brokers
 	topic.Spec.Partitions = 2
 	topic.Spec.ReplicationFactor = 2
-	if res = server.validateKafkaTopic(topic); res.Allowed {
-		t.Error("Expected not allowed due to replication factor change, got allowed")
+	if res = server.validateKafkaTopic(topic, nil); res.Allowed {
+		t.Error("Expected not allowed due to replication factor larger than num brokers, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonBadRequest {
+		t.Error("Expected bad request, got:", res.Result.Reason)
+	}
+}
+
+func TestValidateTopicConfig(t *testing.T) {
+	cluster := newMockCluster()
+	server, _ := newMockServerForTopicValidator(cluster)
+	server.client.Create(context.TODO(), cluster)
+	topic := newMockTopic()
+
+	topic.Spec.Config = map[string]string{"retention.ms": "1000"}
+	if res := server.validateKafkaTopic(topic, nil); !res.Allowed {
+		t.Error("Expected allowed due to valid topic config, got:", res.Result)
+	}
+
+	topic.Spec.Config = map[string]string{"retention": "1000"}
+	if res := server.validateKafkaTopic(topic, nil); res.Allowed {
+		t.Error("Expected not allowed due to unknown topic config, got allowed")
 	} else if res.Result.Reason != metav1.StatusReasonInvalid {
 		t.Error("Expected invalid status reason, got:", res.Result)
 	}
 }
+
+func TestValidateTopicPolicy(t *testing.T) {
+	cluster := newMockCluster()
+	cluster.Spec.TopicPolicy = &v1beta1.TopicPolicy{
+		NameRegex:              "^test-",
+		MinPartitions:          2,
+		MaxPartitions:          4,
+		MinInSyncReplicas:      2,
+		AllowedCleanupPolicies: []string{"delete", "compact"},
+	}
+	cluster.UID = "test-cluster-uid"
+	server, broker := newMockServerForTopicValidator(cluster)
+	server.client.Create(context.TODO(), cluster)
+	topic := newMockTopic()
+
+	topic.Spec.Config = map[string]string{"cleanup.policy": "compact,delete", "min.insync.replicas": "2"}
+	if res := server.validateKafkaTopic(topic, nil); !res.Allowed {
+		t.Error("Expected allowed due to no policy violations, got:", res.Result)
+	}
+
+	violations := map[string]func(*v1alpha1.KafkaTopic){
+		"name":                func(topic *v1alpha1.KafkaTopic) { topic.Spec.Name = "other-topic" },
+		"min partitions":      func(topic *v1alpha1.KafkaTopic) { topic.Spec.Partitions = 1 },
+		"max partitions":      func(topic *v1alpha1.KafkaTopic) { topic.Spec.Partitions = 5 },
+		"min.insync.replicas": func(topic *v1alpha1.KafkaTopic) { topic.Spec.Config["min.insync.replicas"] = "1" },
+		"cleanup.policy":      func(topic *v1alpha1.KafkaTopic) { topic.Spec.Config["cleanup.policy"] = "compact,retain" },
+	}
+	for name, violate := range violations {
+		topic := newMockTopic()
+		topic.Spec.Config = map[string]string{}
+		violate(topic)
+		if res := server.validateKafkaTopic(topic, nil); res.Allowed {
+			t.Errorf("Expected not allowed due to %s policy, got allowed", name)
+		} else if res.Result.Reason != metav1.StatusReasonForbidden {
+			t.Errorf("Expected forbidden status reason for %s policy, got: %v", name, res.Result)
+		}
+	}
+
+	// the name of an existing topic is not checked so it can be adopted
+	if err := broker.CreateTopic(&kafkaclient.CreateTopicOptions{Name: "other-topic", ReplicationFactor: 1, Partitions: 2}); err != nil {
+		t.Error("creation of topic should have been successful")
+	}
+	topic = newMockTopic()
+	topic.Spec.Name = "other-topic"
+	topic.SetAnnotations(map[string]string{v1alpha1.TopicAdoptAnnotation: "true"})
+	if res := server.validateKafkaTopic(topic, nil); !res.Allowed {
+		t.Error("Expected allowed due to adoption of existing topic, got:", res.Result)
+	}
+
+	// topics of the cluster itself are exempt
+	topic = newMockTopic()
+	topic.Spec.Name = "__CruiseControlMetrics"
+	topic.Spec.Partitions = 12
+	topic.SetOwnerReferences([]metav1.OwnerReference{{Name: cluster.Name, UID: cluster.UID, Controller: util.BoolPointer(true)}})
+	if res := server.validateKafkaTopic(topic, nil); !res.Allowed {
+		t.Error("Expected allowed due to topic of the cluster, got:", res.Result)
+	}
+
+	// the controller can still update and release topics created before the policy
+	topic = newMockTopic()
+	topic.Spec.Partitions = 1
+	old := topic.DeepCopy()
+	topic.Finalizers = []string{"finalizer.kafkatopics.kafka.banzaicloud.io"}
+	if res := server.validateKafkaTopic(topic, old); !res.Allowed {
+		t.Error("Expected allowed due to unchanged spec, got:", res.Result)
+	}
+	now := metav1.Now()
+	topic.SetDeletionTimestamp(&now)
+	topic.Finalizers = nil
+	if res := server.validateKafkaTopic(topic, old); !res.Allowed {
+		t.Error("Expected allowed due to topic marked for deletion, got:", res.Result)
+	}
+	topic.SetDeletionTimestamp(nil)
+	topic.Spec.Partitions = 5
+	if res := server.validateKafkaTopic(topic, old); res.Allowed {
+		t.Error("Expected not allowed due to spec change violating the policy, got allowed")
+	}
+}
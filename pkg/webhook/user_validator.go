@@ -0,0 +1,267 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+	"github.com/banzaicloud/kafka-operator/pkg/k8sutil"
+	"github.com/banzaicloud/kafka-operator/pkg/util"
+)
+
+func (s *webhookServer) validateKafkaUser(user, oldUser *v1alpha1.KafkaUser) *admissionv1beta1.AdmissionResponse {
+	log.Info(fmt.Sprintf("Doing pre-admission validation of kafka user %s", user.Name))
+
+	// Let the finalizers be removed even when the cluster is gone already, and let finalizer, label and status
+	// updates of the controller through for users created before a check was added
+	if k8sutil.IsMarkedForDeletion(user.ObjectMeta) ||
+		(oldUser != nil && reflect.DeepEqual(user.Spec, oldUser.Spec)) {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	// Check if the cluster being referenced actually exists
+	clusterNamespace := user.Spec.ClusterRef.Namespace
+	if clusterNamespace == "" {
+		clusterNamespace = user.Namespace
+	}
+	cluster, err := k8sutil.LookupKafkaCluster(s.client, user.Spec.ClusterRef.Name, clusterNamespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Referenced kafka cluster does not exist")
+			return notAllowed(
+				fmt.Sprintf("KafkaCluster '%s' in the namespace '%s' does not exist", user.Spec.ClusterRef.Name, clusterNamespace),
+				metav1.StatusReasonNotFound,
+			)
+		}
+		log.Error(err, "API failure while running user validation")
+		return notAllowed("API failure while validating user, please try again", metav1.StatusReasonServiceUnavailable)
+	}
+
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		log.Info("Cluster is going down for deletion, assuming a delete user request")
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	if msg := checkUserAuthentication(user, cluster); msg != "" {
+		log.Info(fmt.Sprintf("User %s can not authenticate to the cluster: %s", user.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if msg := checkUserGrants(&user.Spec); msg != "" {
+		log.Info(fmt.Sprintf("User %s requests invalid grants: %s", user.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+
+	if !userHasSecret(user) {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	vault := userPKIBackend(user, cluster) == v1beta1.PKIBackendVault
+	if msg := checkUserSecretName(user.Spec.SecretName, vault); msg != "" {
+		log.Info(fmt.Sprintf("User %s has an invalid secret name: %s", user.Name, msg))
+		return notAllowed(msg, metav1.StatusReasonInvalid)
+	}
+	owner, err := s.findSecretOwner(user, vault)
+	if err != nil {
+		log.Error(err, "API failure while running user validation")
+		return notAllowed("API failure while validating user, please try again", metav1.StatusReasonServiceUnavailable)
+	}
+	if owner != nil {
+		log.Info(fmt.Sprintf("User %s requests the secret of another user", user.Name))
+		return notAllowed(
+			fmt.Sprintf("Secret '%s' is already used by KafkaUser '%s' in the namespace '%s'", user.Spec.SecretName, owner.Name, owner.Namespace),
+			metav1.StatusReasonAlreadyExists,
+		)
+	}
+
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+}
+
+// checkUserAuthentication returns why the user could not authenticate to the cluster
+func checkUserAuthentication(user *v1alpha1.KafkaUser, cluster *v1beta1.KafkaCluster) string {
+	if user.Spec.GetAuthenticationType() == v1alpha1.UserAuthenticationSCRAMSHA512 {
+		if user.Spec.PKIBackendSpec != nil {
+			return "PKI backend can not be set for users authenticating with scram-sha-512"
+		}
+		if !util.IsSASLEnabled(cluster.Spec.ListenersConfig) {
+			return fmt.Sprintf("KafkaCluster '%s' has no sasl listener for scram-sha-512 authentication", cluster.Name)
+		}
+		return ""
+	}
+	if !user.Spec.GetIfCertShouldBeCreated() {
+		return ""
+	}
+
+	backendSpec := user.Spec.PKIBackendSpec
+	if backendSpec == nil {
+		if cluster.Spec.ListenersConfig.SSLSecrets == nil {
+			return fmt.Sprintf("KafkaCluster '%s' has no SSL enabled, set a PKI backend to create the user certificate", cluster.Name)
+		}
+		return ""
+	}
+	switch v1beta1.PKIBackend(backendSpec.PKIBackend) {
+	case v1beta1.PKIBackendCertManager:
+		if backendSpec.IssuerRef == nil {
+			return "an issuer reference is required for the cert-manager PKI backend"
+		}
+	case v1beta1.PKIBackendVault:
+		if cluster.Spec.VaultConfig.AuthRole == "" || cluster.Spec.VaultConfig.PKIPath == "" {
+			return fmt.Sprintf("KafkaCluster '%s' has no vault configuration for the vault PKI backend", cluster.Name)
+		}
+	default:
+		return fmt.Sprintf("unknown PKI backend '%s'", backendSpec.PKIBackend)
+	}
+	return ""
+}
+
+// checkUserGrants returns the first grant Kafka can not create an ACL for
+func checkUserGrants(spec *v1alpha1.KafkaUserSpec) string {
+	for _, grant := range spec.TopicGrants {
+		if grant.AccessType != v1alpha1.KafkaAccessTypeRead && grant.AccessType != v1alpha1.KafkaAccessTypeWrite {
+			return fmt.Sprintf("invalid access type '%s' for topic '%s'", grant.AccessType, grant.TopicName)
+		}
+		if msg := checkGrantPatternType(grant.PatternType); msg != "" {
+			return fmt.Sprintf("%s for topic '%s'", msg, grant.TopicName)
+		}
+	}
+	for _, grant := range spec.GroupGrants {
+		if msg := checkGrantPatternType(grant.PatternType); msg != "" {
+			return fmt.Sprintf("%s for group '%s'", msg, grant.GroupName)
+		}
+	}
+	for _, grant := range spec.TransactionalIDGrants {
+		if msg := checkGrantPatternType(grant.PatternType); msg != "" {
+			return fmt.Sprintf("%s for transactional id '%s'", msg, grant.TransactionalID)
+		}
+	}
+	return ""
+}
+
+// checkGrantPatternType rejects the pattern types that can only be used to filter ACLs
+func checkGrantPatternType(patternType v1alpha1.KafkaPatternType) string {
+	switch patternType {
+	case "", v1alpha1.KafkaPatternTypeLiteral, v1alpha1.KafkaPatternTypePrefixed:
+		return ""
+	case v1alpha1.KafkaPatternTypeAny, v1alpha1.KafkaPatternTypeMatch:
+		return fmt.Sprintf("pattern type '%s' can only be used to filter ACLs, use literal or prefixed", patternType)
+	default:
+		return fmt.Sprintf("invalid pattern type '%s'", patternType)
+	}
+}
+
+// userHasSecret returns whether the operator writes certificates or credentials to the secret of the user
+func userHasSecret(user *v1alpha1.KafkaUser) bool {
+	return user.Spec.GetAuthenticationType() == v1alpha1.UserAuthenticationSCRAMSHA512 || user.Spec.GetIfCertShouldBeCreated()
+}
+
+// userPKIBackend returns the PKI backend issuing the certificate of the user
+func userPKIBackend(user *v1alpha1.KafkaUser, cluster *v1beta1.KafkaCluster) v1beta1.PKIBackend {
+	if user.Spec.GetAuthenticationType() == v1alpha1.UserAuthenticationSCRAMSHA512 {
+		return ""
+	}
+	if user.Spec.PKIBackendSpec != nil {
+		return v1beta1.PKIBackend(user.Spec.PKIBackendSpec.PKIBackend)
+	}
+	if cluster.Spec.ListenersConfig.SSLSecrets != nil {
+		return cluster.Spec.ListenersConfig.SSLSecrets.PKIBackend
+	}
+	return ""
+}
+
+// checkUserSecretName validates the secret name as a vault path or as the name of a Kubernetes secret
+func checkUserSecretName(name string, vault bool) string {
+	if !vault {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Sprintf("invalid secret name '%s': %s", name, strings.Join(errs, ", "))
+		}
+		return ""
+	}
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Sprintf("invalid vault secret path '%s'", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" {
+			return fmt.Sprintf("invalid vault secret path '%s': empty path segment", name)
+		}
+	}
+	return ""
+}
+
+// findSecretOwner returns another KafkaUser writing to the same secret. Kubernetes secrets are namespaced
+// while vault paths are shared by every user of the vault PKI backend.
+func (s *webhookServer) findSecretOwner(user *v1alpha1.KafkaUser, vault bool) (*v1alpha1.KafkaUser, error) {
+	users := &v1alpha1.KafkaUserList{}
+	if err := s.client.List(context.TODO(), users); err != nil {
+		return nil, err
+	}
+	for i, other := range users.Items {
+		if other.Name == user.Name && other.Namespace == user.Namespace {
+			continue
+		}
+		if !userHasSecret(&other) || k8sutil.IsMarkedForDeletion(other.ObjectMeta) {
+			continue
+		}
+		if !vault {
+			if other.Namespace == user.Namespace && other.Spec.SecretName == user.Spec.SecretName {
+				return &users.Items[i], nil
+			}
+			continue
+		}
+		if vaultSecretPath(other.Spec.SecretName) != vaultSecretPath(user.Spec.SecretName) {
+			continue
+		}
+		otherClusterNamespace := other.Spec.ClusterRef.Namespace
+		if otherClusterNamespace == "" {
+			otherClusterNamespace = other.Namespace
+		}
+		otherCluster, err := k8sutil.LookupKafkaCluster(s.client, other.Spec.ClusterRef.Name, otherClusterNamespace)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if userPKIBackend(&other, otherCluster) == v1beta1.PKIBackendVault {
+			return &users.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// vaultSecretPath mirrors how the vault PKI backend places secrets given only by name under secret/
+func vaultSecretPath(name string) string {
+	if !strings.Contains(name, "/") {
+		return "secret/" + name
+	}
+	return name
+}
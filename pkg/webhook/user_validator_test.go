@@ -0,0 +1,168 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/kafka-operator/api/v1alpha1"
+	"github.com/banzaicloud/kafka-operator/api/v1beta1"
+)
+
+func newMockUser() *v1alpha1.KafkaUser {
+	return &v1alpha1.KafkaUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-user", Namespace: "test-namespace"},
+		Spec: v1alpha1.KafkaUserSpec{
+			SecretName: "test-user-secret",
+			ClusterRef: v1alpha1.ClusterReference{
+				Name:      "test-cluster",
+				Namespace: "test-namespace",
+			},
+			TopicGrants: []v1alpha1.UserTopicGrant{
+				{TopicName: "test-topic", AccessType: v1alpha1.KafkaAccessTypeRead},
+			},
+		},
+	}
+}
+
+func TestValidateUser(t *testing.T) {
+	cluster := newMockCluster()
+	server, _ := newMockServerForTopicValidator(cluster)
+	user := newMockUser()
+
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to missing cluster, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonNotFound {
+		t.Error("Expected not found cluster, got:", res.Result)
+	}
+
+	// users marked for deletion are let through to remove their finalizers
+	now := metav1.Now()
+	user.SetDeletionTimestamp(&now)
+	if res := server.validateKafkaUser(user, nil); !res.Allowed {
+		t.Error("Expected allowed due to user marked for deletion, got:", res.Result)
+	}
+	user.SetDeletionTimestamp(nil)
+
+	// updates leaving the spec untouched are let through as well
+	oldUser := user.DeepCopy()
+	user.Labels = map[string]string{"kafka_cr": "test-cluster"}
+	if res := server.validateKafkaUser(user, oldUser); !res.Allowed {
+		t.Error("Expected allowed due to unchanged spec, got:", res.Result)
+	}
+	user.Spec.TopicGrants[0].AccessType = v1alpha1.KafkaAccessTypeWrite
+	if res := server.validateKafkaUser(user, oldUser); res.Allowed {
+		t.Error("Expected not allowed due to changed spec with missing cluster, got allowed")
+	}
+	user.Spec = oldUser.Spec
+
+	// plaintext cluster without a PKI backend for the user
+	server.client.Create(context.TODO(), cluster)
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to cluster without SSL, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonInvalid {
+		t.Error("Expected invalid status reason, got:", res.Result)
+	}
+
+	cluster.Spec.ListenersConfig.SSLSecrets = &v1beta1.SSLSecrets{TLSSecretName: "test-tls"}
+	server.client.Update(context.TODO(), cluster)
+	if res := server.validateKafkaUser(user, nil); !res.Allowed {
+		t.Error("Expected allowed due to no issues, got:", res.Result)
+	}
+
+	// grants with pattern types that can only filter ACLs
+	user.Spec.GroupGrants = []v1alpha1.UserGroupGrant{{GroupName: "test-group", PatternType: v1alpha1.KafkaPatternTypeMatch}}
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to match pattern type, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonInvalid {
+		t.Error("Expected invalid status reason, got:", res.Result)
+	}
+	user.Spec.GroupGrants[0].PatternType = v1alpha1.KafkaPatternTypePrefixed
+
+	// secret name collision with another user in the same namespace
+	other := newMockUser()
+	other.Name = "other-user"
+	server.client.Create(context.TODO(), other)
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to secret used by another user, got allowed")
+	} else if res.Result.Reason != metav1.StatusReasonAlreadyExists {
+		t.Error("Expected already exists status reason, got:", res.Result)
+	}
+	other.Namespace = "other-namespace"
+	server.client.Delete(context.TODO(), &v1alpha1.KafkaUser{ObjectMeta: metav1.ObjectMeta{Name: "other-user", Namespace: "test-namespace"}})
+	server.client.Create(context.TODO(), other)
+	if res := server.validateKafkaUser(user, nil); !res.Allowed {
+		t.Error("Expected allowed due to secret in another namespace, got:", res.Result)
+	}
+}
+
+func TestValidateUserPKIBackend(t *testing.T) {
+	cluster := newMockCluster()
+	server, _ := newMockServerForTopicValidator(cluster)
+	server.client.Create(context.TODO(), cluster)
+	user := newMockUser()
+
+	user.Spec.PKIBackendSpec = &v1alpha1.PKIBackendSpec{PKIBackend: string(v1beta1.PKIBackendCertManager)}
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to missing issuer, got allowed")
+	}
+	user.Spec.PKIBackendSpec.IssuerRef = &cmmeta.ObjectReference{Name: "test-issuer"}
+	if res := server.validateKafkaUser(user, nil); !res.Allowed {
+		t.Error("Expected allowed due to user issuer, got:", res.Result)
+	}
+
+	user.Spec.PKIBackendSpec = &v1alpha1.PKIBackendSpec{PKIBackend: string(v1beta1.PKIBackendVault)}
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to cluster without vault config, got allowed")
+	}
+	cluster.Spec.VaultConfig = v1beta1.VaultConfig{AuthRole: "kafka", PKIPath: "pki_kafka", IssuePath: "pki_kafka/issue/operator", UserStore: "secret/users"}
+	server.client.Update(context.TODO(), cluster)
+	if res := server.validateKafkaUser(user, nil); !res.Allowed {
+		t.Error("Expected allowed due to cluster with vault config, got:", res.Result)
+	}
+
+	user.Spec.SecretName = "secret//test-user"
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to invalid vault path, got allowed")
+	}
+
+	// SCRAM users have no certificate
+	user.Spec.SecretName = "test-user-secret"
+	user.Spec.AuthenticationType = v1alpha1.UserAuthenticationSCRAMSHA512
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to PKI backend for scram user, got allowed")
+	}
+	user.Spec.PKIBackendSpec = nil
+	if res := server.validateKafkaUser(user, nil); res.Allowed {
+		t.Error("Expected not allowed due to cluster without sasl listener, got allowed")
+	}
+}
+
+func TestCheckUserSecretName(t *testing.T) {
+	for name, vault := range map[string]bool{"test-secret": false, "secret/users/test": true, "test": true} {
+		if msg := checkUserSecretName(name, vault); msg != "" {
+			t.Errorf("Expected %q to be valid, got: %s", name, msg)
+		}
+	}
+	for name, vault := range map[string]bool{"Test_Secret": false, "secret/users/": true, "": true} {
+		if msg := checkUserSecretName(name, vault); msg == "" {
+			t.Errorf("Expected %q to be invalid", name)
+		}
+	}
+}